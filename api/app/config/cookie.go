@@ -0,0 +1,61 @@
+package config
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// クッキーの属性
+type CookieConfig struct {
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// 環境変数からクッキーの属性を取得する
+// COOKIE_SAMESITEはstrict、lax、noneのいずれか（未設定の場合はlax）
+// SameSite=NoneのクッキーはSecureでないとブラウザに拒否されるため、常にSecureにする
+func GetCookieConfig() CookieConfig {
+	cookieConfig := CookieConfig{
+		Domain:   os.Getenv("FRONTEND_DOMAIN"),
+		Secure:   os.Getenv("COOKIE_SECURE") == "true",
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	switch strings.ToLower(os.Getenv("COOKIE_SAMESITE")) {
+	case "strict":
+		cookieConfig.SameSite = http.SameSiteStrictMode
+	case "none":
+		cookieConfig.SameSite = http.SameSiteNoneMode
+		cookieConfig.Secure = true
+	}
+
+	return cookieConfig
+}
+
+// 設定したSameSite、Secure属性でクッキーをセットする
+// maxAgeが負の場合はクッキーを削除する
+func SetCookie(c *gin.Context, name string, value string, maxAge int, path string, httpOnly bool) {
+	setCookie(c, GetCookieConfig(), name, value, maxAge, path, httpOnly)
+}
+
+// IdPからのリダイレクトなど、他のサイトからの遷移で送信する必要のあるクッキーをセットする
+// SameSite=Strictのクッキーは他のサイトからの遷移では送信されないため、Laxにする
+func SetCrossSiteRedirectCookie(c *gin.Context, name string, value string, maxAge int, path string, httpOnly bool) {
+	cookieConfig := GetCookieConfig()
+	if cookieConfig.SameSite == http.SameSiteStrictMode {
+		cookieConfig.SameSite = http.SameSiteLaxMode
+	}
+	setCookie(c, cookieConfig, name, value, maxAge, path, httpOnly)
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func setCookie(c *gin.Context, cookieConfig CookieConfig, name string, value string, maxAge int, path string, httpOnly bool) {
+	c.SetSameSite(cookieConfig.SameSite)
+	c.SetCookie(name, value, maxAge, path, cookieConfig.Domain, cookieConfig.Secure, httpOnly)
+}
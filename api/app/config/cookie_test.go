@@ -0,0 +1,75 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// テストの間だけクッキーの属性の環境変数を設定する
+func withCookieEnv(t *testing.T, sameSite string, secure string) {
+	originalSameSite := os.Getenv("COOKIE_SAMESITE")
+	originalSecure := os.Getenv("COOKIE_SECURE")
+	os.Setenv("COOKIE_SAMESITE", sameSite)
+	os.Setenv("COOKIE_SECURE", secure)
+	t.Cleanup(func() {
+		os.Setenv("COOKIE_SAMESITE", originalSameSite)
+		os.Setenv("COOKIE_SECURE", originalSecure)
+	})
+}
+
+func TestGetCookieConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		sameSite string
+		secure   string
+		expected CookieConfig
+	}{
+		{"未設定", "", "", CookieConfig{SameSite: http.SameSiteLaxMode}},
+		{"Strict", "strict", "true", CookieConfig{SameSite: http.SameSiteStrictMode, Secure: true}},
+		{"None_常にSecure", "None", "", CookieConfig{SameSite: http.SameSiteNoneMode, Secure: true}},
+		{"不正な値", "invalid", "false", CookieConfig{SameSite: http.SameSiteLaxMode}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCookieEnv(t, tt.sameSite, tt.secure)
+
+			cookieConfig := GetCookieConfig()
+			assert.Equal(t, tt.expected.SameSite, cookieConfig.SameSite)
+			assert.Equal(t, tt.expected.Secure, cookieConfig.Secure)
+		})
+	}
+}
+
+func TestSetCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	withCookieEnv(t, "strict", "true")
+
+	r := gin.New()
+	r.GET("/cookie", func(c *gin.Context) {
+		SetCookie(c, "session", "value", 60, "/", true)
+		SetCrossSiteRedirectCookie(c, "state", "value", 60, "/callback", true)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/cookie", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	assert.Equal(t, http.SameSiteStrictMode, cookies["session"].SameSite)
+	assert.True(t, cookies["session"].Secure)
+	assert.True(t, cookies["session"].HttpOnly)
+
+	// 他のサイトからのリダイレクトで送信するクッキーはLaxにする
+	assert.Equal(t, http.SameSiteLaxMode, cookies["state"].SameSite)
+	assert.True(t, cookies["state"].Secure)
+}
@@ -31,6 +31,7 @@ func CorsSetting(r *gin.Engine) {
 			"Content-Length",
 			"Accept-Encoding",
 			"Authorization",
+			"X-CSRF-Token",
 		},
 		// cookieなどの情報を必要とするかどうか
 		AllowCredentials: true,
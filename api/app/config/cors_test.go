@@ -30,7 +30,7 @@ func TestCorsSetting(t *testing.T) {
 	// レスポンスのCORSヘッダーをチェック
 	assert.Equal(t, "http://localhost:3000", rec.Header().Get("Access-Control-Allow-Origin"))
 	assert.Equal(t, "POST,GET,PUT,DELETE,OPTIONS", rec.Header().Get("Access-Control-Allow-Methods"))
-	assert.Equal(t, "Access-Control-Allow-Credentials,Access-Control-Allow-Headers,Content-Type,Content-Length,Accept-Encoding,Authorization", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "Access-Control-Allow-Credentials,Access-Control-Allow-Headers,Content-Type,Content-Length,Accept-Encoding,Authorization,X-Csrf-Token", rec.Header().Get("Access-Control-Allow-Headers"))
 	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
 	assert.Equal(t, "86400", rec.Header().Get("Access-Control-Max-Age"))
 
@@ -2,8 +2,56 @@ package constant
 
 const (
 	JWT_TOKEN_NAME = "access-token"
-	JWT_TOKEN_LIFETIME = "24"
-	COOKIE_MAX_AGE = 3600
+	JWT_ISSUER = "look-back-api" // 環境変数JWT_ISSUERで上書きできる
+	ACCESS_TOKEN_LIFETIME_MINUTES = 15
+	REFRESH_TOKEN_NAME = "refresh-token"
+	REFRESH_TOKEN_LIFETIME_DAYS = 14
+	COOKIE_MAX_AGE = 60 * 60 * 24 * REFRESH_TOKEN_LIFETIME_DAYS
+	SESSION_LAST_SEEN_INTERVAL_SECONDS = 60
 	GUEST_LOGIN = "guest_login"
 	TEST_DSN= "alicend:password@tcp(database:3306)/loolback_development?charset=utf8mb4&parseTime=True&loc=Local"
-)
\ No newline at end of file
+	PASSWORD_HASH_MEMORY = 19456 // KiB
+	PASSWORD_HASH_ITERATIONS = 2
+	PASSWORD_HASH_PARALLELISM = 1
+	PASSWORD_RESET_TOKEN_LIFETIME_MINUTES = 15
+	EMAIL_CHANGE_TOKEN_LIFETIME_HOURS = 1
+	RATE_LIMIT_WINDOW_MINUTES = 15
+	LOGIN_RATE_LIMIT_PER_IP = 30
+	EMAIL_RATE_LIMIT_PER_IP = 10
+	EMAIL_RATE_LIMIT_PER_ACCOUNT = 3
+	LOGIN_MAX_FAILURES = 5
+	LOGIN_LOCKOUT_BASE_MINUTES = 1
+	LOGIN_LOCKOUT_MAX_MINUTES = 60
+	TOTP_ISSUER = "Look Back Calendar"
+	TOTP_PERIOD_SECONDS = 30
+	TOTP_DIGITS = 6
+	TOTP_SKEW_STEPS = 1
+	MFA_CHALLENGE_TOKEN_LIFETIME_MINUTES = 5
+	RECOVERY_CODE_COUNT = 10
+	PERSONAL_ACCESS_TOKEN_PREFIX = "lbpat_"
+	AUTH_USER_ID_KEY = "auth_user_id" // 認証済みユーザーのIDを保持するコンテキストのキー
+	AUTH_TOKEN_SCOPES_KEY = "auth_token_scopes" // アクセストークンで認証した場合のスコープを保持するコンテキストのキー
+	CSRF_TOKEN_NAME = "csrf-token"
+	CSRF_HEADER_NAME = "X-CSRF-Token"
+	OIDC_STATE_COOKIE_NAME = "oidc-state"
+	OIDC_STATE_LIFETIME_MINUTES = 10
+	OIDC_HTTP_TIMEOUT_SECONDS = 10
+	OIDC_CLOCK_SKEW_SECONDS = 60
+	AUDIT_LOG_RETENTION_DAYS = 365 // 0の場合は削除しない
+	AUDIT_LOG_PURGE_INTERVAL_HOURS = 24
+	AUDIT_LOG_DEFAULT_PAGE_SIZE = 50
+	SUBTASK_COMPLETION_REQUIRE = "require" // すべてのサブタスクが完了していなければ親タスクをLook Backに移動できない
+	SUBTASK_COMPLETION_CASCADE = "cascade" // 親タスクをLook Backに移動するとサブタスクもあわせて移動する
+	SUBTASK_COMPLETION_POLICY = SUBTASK_COMPLETION_REQUIRE // 環境変数SUBTASK_COMPLETION_POLICYで上書きできる
+	RECURRING_TASK_INTERVAL_MINUTES = 60
+	RECURRING_TASK_LOOKAHEAD_DAYS = 0
+	TRASH_RETENTION_DAYS = 30 // 0の場合は削除しない
+	TRASH_PURGE_INTERVAL_HOURS = 24
+)
+
+// WIP制限を超えた場合の扱い
+const (
+	WIP_LIMIT_REJECT = "reject" // WIP制限を超えるタスクの作成・変更を拒否する
+	WIP_LIMIT_WARN = "warn" // WIP制限を超えてもタスクを作成・変更し、警告を返す
+	WIP_LIMIT_POLICY = WIP_LIMIT_REJECT // 環境変数WIP_LIMIT_POLICYで上書きできる
+)
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+)
+
+// ログイン中のユーザーのユーザーグループの監査ログを検索する
+func (handler *Handler) GetAuditLogsHandler(c *gin.Context) {
+	var auditLogQuery models.AuditLogQuery
+	if err := c.ShouldBindQuery(&auditLogQuery); err != nil {
+		log.Printf("Invalid query: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
+	auditLogs, total, err := models.FetchAuditLogs(handler.DB, userGroupID, auditLogQuery)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	page, perPage := auditLogQuery.Page, auditLogQuery.PerPage
+	if page == 0 {
+		page = 1
+	}
+	if perPage == 0 {
+		perPage = constant.AUDIT_LOG_DEFAULT_PAGE_SIZE
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"audit_logs": auditLogs,
+		"total":      total,
+		"page":       page,
+		"per_page":   perPage,
+	})
+}
+
+// ログイン中のユーザーのユーザーグループの監査ログが改ざんされていないか検証する
+func (handler *Handler) VerifyAuditLogsHandler(c *gin.Context) {
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
+	verification, err := models.VerifyAuditLogs(handler.DB, userGroupID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"verification": verification,
+	})
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// 監査ログを記録する（失敗しても操作は継続する）
+// ユーザーグループが未指定の場合は、対象のユーザーまたは操作したユーザーのユーザーグループに記録する
+func (handler *Handler) recordAuditLog(c *gin.Context, auditLog models.AuditLog) {
+	if auditLog.UserGroupID == 0 {
+		userID := auditLog.TargetUserID
+		if userID == 0 {
+			userID = auditLog.ActorID
+		}
+		if userID != 0 {
+			userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+			if err != nil {
+				log.Printf("Failed to fetch user group for audit log: %v", err)
+			}
+			auditLog.UserGroupID = userGroupID
+		}
+	}
+	auditLog.IPAddress = c.ClientIP()
+	auditLog.UserAgent = c.Request.UserAgent()
+
+	if err := models.CreateAuditLog(handler.DB, &auditLog); err != nil {
+		log.Printf("Failed to record audit log %s: %v", auditLog.Action, err)
+		return
+	}
+
+	if err := handler.AuditLogSink.Write(auditLog); err != nil {
+		log.Printf("Failed to write audit log to file: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestAuditLogHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	sinkPath := filepath.Join(t.TempDir(), "audit.log")
+	handler := &Handler{DB: db, AuditLogSink: utils.NewAuditLogSink(sinkPath)}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.POST("/login", handler.LoginHandler)
+	r.GET("/audit-logs", handler.GetAuditLogsHandler)
+	r.GET("/audit-logs/verify", handler.VerifyAuditLogsHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	if err := db.Create(&userGroup).Error; err != nil {
+		t.Fatalf("failed to create user group: %v", err)
+	}
+	hashedPassword, _ := utils.HashPassword("testPassword123")
+	user := &models.User{
+		Name:        "Test User",
+		Password:    hashedPassword,
+		Email:       "audit@example.com",
+		UserGroupID: userGroup.ID,
+		Role:        models.RoleAdmin,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	session, _, _ := models.CreateSession(db, user.ID, "TestAgent", "127.0.0.1")
+	tokenString, _ := utils.GenerateSessionToken(user.ID, session.TokenID)
+	newRequest := func(method string, url string, body []byte) *http.Request {
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "TestAgent")
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+
+	t.Run("ログインの成功と失敗を記録", func(t *testing.T) {
+		for _, password := range []string{"wrongPassword123", "testPassword123"} {
+			body, _ := json.Marshal(map[string]string{"email": user.Email, "password": password})
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, newRequest(http.MethodPost, "/login", body))
+		}
+
+		// ファイルにも1行に1件のJSONで出力される
+		content, err := os.ReadFile(sinkPath)
+		if err != nil {
+			t.Fatalf("failed to read audit log file: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if len(lines) != 2 || !strings.Contains(lines[0], models.AuditActionLoginFailed) || !strings.Contains(lines[1], models.AuditActionLoginSucceeded) {
+			t.Errorf("Unexpected audit log file: %v", string(content))
+		}
+	})
+
+	t.Run("検索", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, "/audit-logs?action=login.failed&per_page=10", nil))
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+
+		var response struct {
+			AuditLogs []models.AuditLogResponse `json:"audit_logs"`
+			Total     int64                     `json:"total"`
+			Page      int                       `json:"page"`
+			PerPage   int                       `json:"per_page"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if response.Total != 1 || response.Page != 1 || response.PerPage != 10 {
+			t.Errorf("Unexpected response: %v", resp.Body.String())
+		}
+		if len(response.AuditLogs) != 1 || response.AuditLogs[0].TargetUserID != user.ID || response.AuditLogs[0].UserAgent != "TestAgent" {
+			t.Errorf("Unexpected audit logs: %v", response.AuditLogs)
+		}
+	})
+
+	t.Run("失敗_不正な検索条件", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, "/audit-logs?per_page=1000", nil))
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("検証", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, "/audit-logs/verify", nil))
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+		}
+
+		var response struct {
+			Verification models.AuditLogVerification `json:"verification"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if !response.Verification.Valid || response.Verification.Checked != 2 {
+			t.Errorf("Unexpected verification: %v", resp.Body.String())
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Where("user_group_id = ?", userGroup.ID).Delete(&models.AuditLog{})
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Session{})
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
@@ -11,7 +11,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/resendlabs/resend-go"
 
+	"github.com/alicend/LookBack/app/config"
 	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/middleware"
 	"github.com/alicend/LookBack/app/models"
 	"github.com/alicend/LookBack/app/utils"
 )
@@ -34,13 +36,20 @@ func (handler *Handler) SendSignUpEmailHandler(c *gin.Context) {
 		return
 	}
 
+	// 同じメールアドレスへの送信回数を制限
+	if !handler.allowEmailTo(c, userPreSignUpInput.Email) {
+		return
+	}
+
 	// メールアドレスが既に使用されていないか確認
+	// 登録済みかどうかを推測されないように、登録済みの場合もメールを送らずに成功を返す
 	_, err := models.FindUserByEmail(handler.DB, userPreSignUpInput.Email)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	} else if err == nil {
-		respondWithErrAndMsg(c, http.StatusBadRequest, "", "他のユーザーが使用しているので別のメールアドレスを入力してください")
+		log.Printf("Sign up requested for registered email")
+		c.JSON(http.StatusOK, gin.H{})
 		return
 	}
 
@@ -64,13 +73,41 @@ func (handler *Handler) SendInviteEmailHandler(c *gin.Context) {
 		return
 	}
 
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
+	// ログイン中のユーザーのユーザーグループにのみ招待できる
+	if userInviteInput.UserGroupID != userGroupID {
+		log.Printf("User %d cannot invite to user group %d", userID, userInviteInput.UserGroupID)
+		respondWithAuthorizationError(c, models.ErrForbidden)
+		return
+	}
+
+	// 同じメールアドレスへの送信回数を制限
+	if !handler.allowEmailTo(c, userInviteInput.Email) {
+		return
+	}
+
 	// メールアドレスが既に使用されていないか確認
-	_, err := models.FindUserByEmail(handler.DB, userInviteInput.Email)
+	// 登録済みかどうかを推測されないように、登録済みの場合もメールを送らずに成功を返す
+	_, err = models.FindUserByEmail(handler.DB, userInviteInput.Email)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	} else if err == nil {
-		respondWithErrAndMsg(c, http.StatusBadRequest, "", "他のユーザーが使用しているので別のメールアドレスを入力してください")
+		log.Printf("Invite requested for registered email")
+		c.JSON(http.StatusOK, gin.H{})
 		return
 	}
 
@@ -80,6 +117,13 @@ func (handler *Handler) SendInviteEmailHandler(c *gin.Context) {
 		return
 	}
 
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:      models.AuditActionInvitationSent,
+		ActorID:     userID,
+		UserGroupID: userGroupID,
+		Detail:      userInviteInput.Email,
+	})
+
 	// 生成したトークンをJSONレスポンスとして返す
 	c.JSON(http.StatusOK, gin.H{})
 }
@@ -93,6 +137,14 @@ func (handler *Handler) SignUpHandler(c *gin.Context) {
 		return
 	}
 
+	// 登録用メールのトークンを検証してメールアドレスを取得
+	email, err := utils.ParseEmailToken(signUpInput.EmailToken)
+	if err != nil {
+		log.Printf("Invalid email token: %v", err)
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "登録用のリンクが無効か、有効期限が切れています")
+		return
+	}
+
 	newUserGroup := &models.UserGroup{
 		UserGroup:   signUpInput.UserGroup,
 	}
@@ -106,8 +158,9 @@ func (handler *Handler) SignUpHandler(c *gin.Context) {
 	newUser := &models.User{
 		Name:        signUpInput.Name,
 		Password:    signUpInput.Password,
-		Email:       signUpInput.Email,
+		Email:       email,
 		UserGroupID: userGroupID,
+		Role:        models.RoleOwner, // ユーザーグループを作成したユーザーがオーナーになる
 	}
 
 	user, err := newUser.CreateUser(handler.DB)
@@ -131,11 +184,34 @@ func (handler *Handler) InviteSignUpHandler(c *gin.Context) {
 		return
 	}
 
+	// 招待メールのトークンを検証してメールアドレスとユーザーグループを取得
+	email, err := utils.ParseEmailToken(inviteSignUpInput.EmailToken)
+	if err != nil {
+		log.Printf("Invalid email token: %v", err)
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "招待用のリンクが無効か、有効期限が切れています")
+		return
+	}
+
+	userGroupIDClaims, err := utils.ParseUserGroupIDToken(inviteSignUpInput.UserGroupToken)
+	if err != nil {
+		log.Printf("Invalid user group token: %v", err)
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "招待用のリンクが無効か、有効期限が切れています")
+		return
+	}
+
+	// 別のメールアドレス宛ての招待のユーザーグループには登録できない
+	if userGroupIDClaims.Email != email {
+		log.Printf("User group token was issued for another email")
+		respondWithErrAndMsg(c, http.StatusBadRequest, "email does not match invitation", "招待用のリンクが無効か、有効期限が切れています")
+		return
+	}
+
 	newUser := &models.User{
 		Name:        inviteSignUpInput.Name,
 		Password:    inviteSignUpInput.Password,
-		Email:       inviteSignUpInput.Email,
-		UserGroupID: inviteSignUpInput.UserGroup,
+		Email:       email,
+		UserGroupID: userGroupIDClaims.UserGroupID,
+		Role:        models.RoleMember,
 	}
 
 	user, err := newUser.CreateUser(handler.DB)
@@ -158,35 +234,85 @@ func (handler *Handler) LoginHandler(c *gin.Context) {
 		return
 	}
 
+	// ロックアウト中のアカウントはパスワードを検証せずに拒否する
+	accountKey := utils.RateLimitKey("login", loginInput.Email)
+	if lockedFor := handler.Limiter.LockedFor(accountKey); lockedFor > 0 {
+		log.Printf("Login attempt for locked out account from %s", c.ClientIP())
+		handler.recordAuditLog(c, models.AuditLog{Action: models.AuditActionLoginFailed, Detail: "locked out"})
+		middleware.RespondTooManyRequests(c, lockedFor)
+		return
+	}
+
 	// ユーザを取得
+	// 登録済みかどうかを推測されないように、存在しないユーザーとパスワード不一致は同じ応答にする
 	user, err := models.FindUserByEmail(handler.DB, loginInput.Email)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-    respondWithErrAndMsg(c, http.StatusNotFound, err.Error(), "存在しないユーザです")
-    return
+		utils.CompareDummyPassword(loginInput.Password)
+		handler.recordLoginFailure(c, accountKey)
+		handler.recordAuditLog(c, models.AuditLog{Action: models.AuditActionLoginFailed, Detail: "unknown user"})
+		respondWithErrAndMsg(c, http.StatusUnauthorized, "invalid credentials", "メールアドレスまたはパスワードが違います")
+		return
 	} else if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
-	} 
+	}
 
-  // 入力されたパスワードとIDから取得したパスワードが等しいかを検証
+	// 入力されたパスワードとIDから取得したパスワードが等しいかを検証
 	if !user.VerifyPassword(loginInput.Password) {
 		log.Printf("パスワードが違います")
-		respondWithError(c, http.StatusUnauthorized, "パスワードが違います")
+		handler.recordLoginFailure(c, accountKey)
+		handler.recordAuditLog(c, models.AuditLog{
+			Action:       models.AuditActionLoginFailed,
+			TargetUserID: user.ID,
+			UserGroupID:  user.UserGroupID,
+			Detail:       "password",
+		})
+		respondWithErrAndMsg(c, http.StatusUnauthorized, "invalid credentials", "メールアドレスまたはパスワードが違います")
 		return
 	}
+	handler.Limiter.Reset(accountKey)
 
-	// クッキーにJWT(中身はユーザID)をセットする
-	token, err := utils.GenerateSessionToken(user.ID)
+	// 旧形式のハッシュであれば新しい形式で保存し直す（失敗してもログインは継続）
+	if err := user.RehashPasswordIfNeeded(handler.DB, loginInput.Password); err != nil {
+		log.Printf("Failed to rehash password: %v", err)
+	}
+
+	// 二要素認証が有効な場合は、認証コードの検証が済むまでセッションを作成しない
+	twoFactorEnabled, err := models.IsTwoFactorEnabled(handler.DB, user.ID)
 	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if twoFactorEnabled {
+		challengeToken, err := utils.GenerateMFAChallengeToken(user.ID)
+		if err != nil {
+			respondWithError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":    true,
+			"challenge_token": challengeToken,
+		})
+		return
+	}
+
+	// セッションを作成し、クッキーにアクセストークンとリフレッシュトークンをセットする
+	if err := handler.startSession(c, user.ID); err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// JWTでセッション管理する
-	c.SetCookie(constant.JWT_TOKEN_NAME, token, constant.COOKIE_MAX_AGE, "/", os.Getenv("FRONTEND_DOMAIN"), false, true)
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:       models.AuditActionLoginSucceeded,
+		ActorID:      user.ID,
+		TargetUserID: user.ID,
+		UserGroupID:  user.UserGroupID,
+		Detail:       "password",
+	})
 	
 	// ゲストログインでないことをクッキーに登録
-	c.SetCookie(constant.GUEST_LOGIN, "false", constant.COOKIE_MAX_AGE, "/", os.Getenv("FRONTEND_DOMAIN"), false, false)
+	config.SetCookie(c, constant.GUEST_LOGIN, "false", constant.COOKIE_MAX_AGE, "/", false)
 
 	c.JSON(http.StatusOK, gin.H{})
 }
@@ -206,30 +332,73 @@ func (handler *Handler) GuestLoginHandler(c *gin.Context) {
 		return
 	}
 
-	// クッキーにJWT(中身はユーザID)をセットする
-	token, err := utils.GenerateSessionToken(user.ID)
-	if err != nil {
+	// セッションを作成し、クッキーにアクセストークンとリフレッシュトークンをセットする
+	if err := handler.startSession(c, user.ID); err != nil {
 		respondWithErrAndMsg(c, http.StatusInternalServerError, err.Error(), "ゲストログインに失敗しました")
 		return
 	}
 
-	// JWTでセッション管理する
-	c.SetCookie(constant.JWT_TOKEN_NAME, token, constant.COOKIE_MAX_AGE, "/", os.Getenv("FRONTEND_DOMAIN"), false, true)
-
 	// ゲストログインであることをクッキーに登録
-	c.SetCookie(constant.GUEST_LOGIN, "true", constant.COOKIE_MAX_AGE, "/", os.Getenv("FRONTEND_DOMAIN"), false, false)
+	config.SetCookie(c, constant.GUEST_LOGIN, "true", constant.COOKIE_MAX_AGE, "/", false)
 	
 	c.JSON(http.StatusOK, gin.H{
 		"user": user,
 	})
 }
 
+func (handler *Handler) RefreshSessionHandler(c *gin.Context) {
+	refreshToken, err := c.Cookie(constant.REFRESH_TOKEN_NAME)
+	if err != nil {
+		log.Printf("Error retrieving cookie: %v", err)
+		respondWithError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// リフレッシュトークンを差し替え、新しいアクセストークンを発行する
+	session, newRefreshToken, err := models.RotateSession(handler.DB, refreshToken, c.Request.UserAgent(), c.ClientIP())
+	if errors.Is(err, models.ErrInvalidRefreshToken) {
+		clearSessionCookies(c)
+		respondWithErrAndMsg(c, http.StatusUnauthorized, err.Error(), err.Error())
+		return
+	} else if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	accessToken, err := utils.GenerateSessionToken(session.UserID, session.TokenID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	setSessionCookies(c, accessToken, newRefreshToken)
+
+	c.JSON(http.StatusOK, gin.H{})
+}
+
 func (handler *Handler) LogoutHandler(c *gin.Context) {
+	// サーバー側のセッションを失効させる（失敗してもクッキーは削除する）
+	if refreshToken, err := c.Cookie(constant.REFRESH_TOKEN_NAME); err == nil {
+		if err := models.RevokeSessionByRefreshToken(handler.DB, refreshToken); err != nil {
+			log.Printf("Failed to revoke session: %v", err)
+		}
+	}
+
+	// アクセストークンからユーザーを特定できる場合のみ記録する
+	if userID, err := extractUserID(c); err == nil {
+		handler.recordAuditLog(c, models.AuditLog{
+			Action:       models.AuditActionLogout,
+			ActorID:      userID,
+			TargetUserID: userID,
+		})
+	}
+
 	// クッキーの値を削除
-	c.SetCookie(constant.JWT_TOKEN_NAME, "", -1, "/", os.Getenv("FRONTEND_DOMAIN"), false, true)
-	c.SetCookie(constant.GUEST_LOGIN, "", -1, "/", os.Getenv("FRONTEND_DOMAIN"), false, true)
+	clearSessionCookies(c)
 
-	c.JSON(http.StatusOK, gin.H{})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Successfully logged out",
+	})
 }
 
 
@@ -250,6 +419,88 @@ func respondWithErrAndMsg(c *gin.Context, status int, err string, msg string) {
 	})
 }
 
+// セッションを作成し、アクセストークンとリフレッシュトークンをクッキーにセットする
+func (handler *Handler) startSession(c *gin.Context, userID uint) error {
+	session, refreshToken, err := models.CreateSession(handler.DB, userID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := utils.GenerateSessionToken(userID, session.TokenID)
+	if err != nil {
+		return err
+	}
+
+	setSessionCookies(c, accessToken, refreshToken)
+
+	return nil
+}
+
+func setSessionCookies(c *gin.Context, accessToken string, refreshToken string) {
+	config.SetCookie(c, constant.JWT_TOKEN_NAME, accessToken, constant.ACCESS_TOKEN_LIFETIME_MINUTES*60, "/", true)
+	// リフレッシュトークンは認証APIにのみ送信する
+	config.SetCookie(c, constant.REFRESH_TOKEN_NAME, refreshToken, constant.COOKIE_MAX_AGE, "/api/auth", true)
+}
+
+func clearSessionCookies(c *gin.Context) {
+	config.SetCookie(c, constant.JWT_TOKEN_NAME, "", -1, "/", true)
+	config.SetCookie(c, constant.REFRESH_TOKEN_NAME, "", -1, "/api/auth", true)
+	config.SetCookie(c, constant.GUEST_LOGIN, "", -1, "/", true)
+}
+
+// 同じメールアドレスへのメール送信回数が上限に達していれば429を返す
+func (handler *Handler) allowEmailTo(c *gin.Context, email string) bool {
+	if handler.Limiter == nil {
+		return true
+	}
+
+	allowed, retryAfter := handler.Limiter.Allow(utils.RateLimitKey("email", email), handler.Limiter.Config.EmailPerAccount)
+	if !allowed {
+		log.Printf("Email rate limit exceeded from %s", c.ClientIP())
+		middleware.RespondTooManyRequests(c, retryAfter)
+		return false
+	}
+
+	return true
+}
+
+// ログインの失敗を記録し、上限に達したアカウントをロックアウトする
+func (handler *Handler) recordLoginFailure(c *gin.Context, accountKey string) {
+	if handler.Limiter == nil {
+		return
+	}
+
+	lockedFor := handler.Limiter.RecordFailure(accountKey, handler.Limiter.Config.LoginLockout)
+	if lockedFor > 0 {
+		log.Printf("Account locked out for %v after repeated login failures from %s", lockedFor, c.ClientIP())
+		log.Printf("ログインの失敗が続いたためアカウントをロックしました")
+	}
+}
+
+// 認可エラーの種類に応じたステータスコードでレスポンスを返す
+func respondWithAuthorizationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		respondWithErrAndMsg(c, http.StatusNotFound, err.Error(), err.Error())
+	case errors.Is(err, models.ErrForbidden):
+		respondWithErrAndMsg(c, http.StatusForbidden, err.Error(), err.Error())
+	case errors.Is(err, models.ErrInvalidTaskCategory), errors.Is(err, models.ErrInvalidTaskResponsible), errors.Is(err, models.ErrInvalidCommentParent),
+		errors.Is(err, models.ErrInvalidSubtask), errors.Is(err, models.ErrInvalidSubtaskOrder),
+		errors.Is(err, models.ErrInvalidDependency), errors.Is(err, models.ErrInvalidRRule), errors.Is(err, models.ErrInvalidOccurrence),
+		errors.Is(err, models.ErrInvalidTimeEntry), errors.Is(err, models.ErrInvalidTaskStatus), errors.Is(err, models.ErrInvalidWorkflowTransition),
+		errors.Is(err, models.ErrInvalidTaskMove), errors.Is(err, models.ErrInvalidTaskLabel), errors.Is(err, models.ErrInvalidLabelMerge):
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), err.Error())
+	case errors.Is(err, models.ErrIncompleteSubtasks), errors.Is(err, models.ErrDependencyCycle),
+		errors.Is(err, models.ErrTimerAlreadyRunning), errors.Is(err, models.ErrTimerNotRunning),
+		errors.Is(err, models.ErrRestoreConflict), errors.Is(err, models.ErrRestoreDuplicateCategory),
+		errors.Is(err, models.ErrStatusTransitionNotAllowed), errors.Is(err, models.ErrWorkflowStatusInUse),
+		errors.Is(err, models.ErrWorkflowStatusRequired), errors.Is(err, models.ErrDuplicateLabel):
+		respondWithErrAndMsg(c, http.StatusConflict, err.Error(), err.Error())
+	default:
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
 func (p *ProductionMailSender) SendSignUpMail(email string) error {
 	client := resend.NewClient(os.Getenv("RESEND_TOKEN"))
 
@@ -297,7 +548,7 @@ func (p *ProductionMailSender) SendInviteMail(userInviteInput UserInviteInput) e
 		log.Printf("EmailToken generation failed: %v", err)
 		return err
 	}
-	userGroupIDToken, err := utils.GenerateUserGroupIDToken(userInviteInput.UserGroupID)
+	userGroupIDToken, err := utils.GenerateUserGroupIDToken(userInviteInput.UserGroupID, userInviteInput.Email)
 	if err != nil {
 		log.Printf("UserGroupIDToken generation failed: %v", err)
 		return err
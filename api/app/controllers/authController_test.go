@@ -2,21 +2,28 @@ package controllers
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 
 	"github.com/alicend/LookBack/app/constant"
 	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
 )
 
 func (m *MockMailSender) SendSignUpMail(email string) error {
@@ -26,6 +33,14 @@ func (m *MockMailSender) SendInviteMail(userInviteInput UserInviteInput) error {
 	return m.MockSendInviteMail(userInviteInput)
 }
 
+func mustHashPassword(t *testing.T, password string) string {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	return hashedPassword
+}
+
 func TestSendSignUpEmailHandler(t *testing.T) {
 	// SQLMock のセットアップ
 	sqlDB, mock, err := sqlmock.New()
@@ -92,6 +107,61 @@ func TestSendSignUpEmailHandler(t *testing.T) {
 			t.Errorf("Error: %v", resp.Body.String())
 		}
 	})
+
+	mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
+		WithArgs("registered@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "registered@example.com"))
+
+	t.Run("登録済みのメールアドレス", func(t *testing.T) {
+		mailSent := false
+		mockMailSender.MockSendSignUpMail = func(email string) error {
+			mailSent = true
+			return nil
+		}
+
+		user := UserPreSignUpInput{Email: "registered@example.com"}
+		body, _ := json.Marshal(user)
+		req, _ := http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(body))
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		// 登録済みかどうかを推測されないように成功を返す
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if mailSent {
+			t.Errorf("Sign up mail should not be sent to a registered email")
+		}
+	})
+
+	t.Run("失敗_送信回数の上限", func(t *testing.T) {
+		handler.Limiter = utils.NewRateLimiter(utils.RateLimitConfig{
+			EmailPerAccount: utils.RateLimit{Max: 1, Window: time.Hour},
+		})
+		defer func() { handler.Limiter = nil }()
+
+		mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
+			WithArgs("limited@example.com").
+			WillReturnError(gorm.ErrRecordNotFound)
+		mockMailSender.MockSendSignUpMail = func(email string) error {
+			return nil
+		}
+
+		codes := []int{}
+		for i := 0; i < 2; i++ {
+			body, _ := json.Marshal(UserPreSignUpInput{Email: "limited@example.com"})
+			req, _ := http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(body))
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+			codes = append(codes, resp.Code)
+		}
+
+		if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+			t.Errorf("Expected HTTP 200 then 429, got: %v", codes)
+		}
+	})
 }
 
 func TestSendInviteEmailHandler(t *testing.T) {
@@ -117,21 +187,34 @@ func TestSendInviteEmailHandler(t *testing.T) {
 	r := gin.Default()
 	r.POST("/invite", handler.SendInviteEmailHandler)
 
-	mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
-		WithArgs("test@example.com").
-		WillReturnError(gorm.ErrRecordNotFound)
+	tokenString, _ := utils.GenerateSessionToken(1, "test_session_id")
+	newRequest := func(userInviteInput UserInviteInput) *http.Request {
+		body, _ := json.Marshal(userInviteInput)
+		req, _ := http.NewRequest(http.MethodPost, "/invite", bytes.NewBuffer(body))
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+	expectUserGroupQuery := func() {
+		mock.ExpectQuery("SELECT user_groups.id FROM `user_groups` JOIN users").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	}
 
 	t.Run("成功", func(t *testing.T) {
+		expectUserGroupQuery()
+		mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
+			WithArgs("test@example.com").
+			WillReturnError(gorm.ErrRecordNotFound)
+
 		mockMailSender.MockSendInviteMail = func(inviteInput UserInviteInput) error {
 			return nil
 		}
 
-		user := UserInviteInput{Email: "test@example.com", UserGroupID: 1}
-		body, _ := json.Marshal(user)
-		req, _ := http.NewRequest(http.MethodPost, "/invite", bytes.NewBuffer(body))
 		resp := httptest.NewRecorder()
-
-		r.ServeHTTP(resp, req)
+		r.ServeHTTP(resp, newRequest(UserInviteInput{Email: "test@example.com", UserGroupID: 1}))
 
 		if resp.Code != http.StatusOK {
 			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
@@ -139,27 +222,49 @@ func TestSendInviteEmailHandler(t *testing.T) {
 		}
 	})
 
-	mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
-		WithArgs("test@example.com").
-		WillReturnError(gorm.ErrRecordNotFound)
-
 	t.Run("メール送信失敗", func(t *testing.T) {
+		expectUserGroupQuery()
+		mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
+			WithArgs("test@example.com").
+			WillReturnError(gorm.ErrRecordNotFound)
+
 		mockMailSender.MockSendInviteMail = func(inviteInput UserInviteInput) error {
 			return errors.New("mail send error")
 		}
 
-		user := UserInviteInput{Email: "test@example.com", UserGroupID: 1}
-		body, _ := json.Marshal(user)
-		req, _ := http.NewRequest(http.MethodPost, "/invite", bytes.NewBuffer(body))
 		resp := httptest.NewRecorder()
-
-		r.ServeHTTP(resp, req)
+		r.ServeHTTP(resp, newRequest(UserInviteInput{Email: "test@example.com", UserGroupID: 1}))
 
 		if resp.Code != http.StatusInternalServerError {
 			t.Errorf("Expected HTTP 500 Internal Server Error, got: %v", resp.Code)
 			t.Errorf("Error: %v", resp.Body.String())
 		}
 	})
+
+	t.Run("失敗_他のユーザーグループへの招待", func(t *testing.T) {
+		expectUserGroupQuery()
+
+		mailSent := false
+		mockMailSender.MockSendInviteMail = func(inviteInput UserInviteInput) error {
+			mailSent = true
+			return nil
+		}
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(UserInviteInput{Email: "test@example.com", UserGroupID: 2}))
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if mailSent {
+			t.Errorf("Invite mail should not be sent")
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
 }
 
 
@@ -186,28 +291,33 @@ func TestSignUpHandler(t *testing.T) {
 	r := gin.Default()
 	r.POST("/signup", handler.SignUpHandler)
 
+	emailToken, _ := utils.GenerateEmailToken("test@example.com")
+
 	t.Run("成功", func(t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectExec("INSERT INTO `user_groups`").
-			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "TestGroup").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "TestGroup", sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
 		mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
 			WithArgs("test@example.com").
 			WillReturnError(gorm.ErrRecordNotFound)
+		mock.ExpectQuery("SELECT (.+) FROM `users` WHERE email = (.+) AND deleted_at IS NOT NULL").
+			WithArgs("test@example.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
 
 		mock.ExpectBegin()
 		mock.ExpectExec("INSERT INTO `users`").
-			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "TestUser", sqlmock.AnyArg(), "test@example.com", sqlmock.AnyArg()).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "TestUser", sqlmock.AnyArg(), "test@example.com", sqlmock.AnyArg(), models.RoleOwner, sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
 		user := models.UserSignUpInput{
-			Name:      "TestUser",
-			Password:  "password",
-			Email:     "test@example.com",
-			UserGroup: "TestGroup",
+			Name:       "TestUser",
+			Password:   "password",
+			EmailToken: emailToken,
+			UserGroup:  "TestGroup",
 		}
 		body, _ := json.Marshal(user)
 		req, _ := http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(body))
@@ -227,10 +337,55 @@ func TestSignUpHandler(t *testing.T) {
 			WillReturnError(errors.New("Insert failed"))
 
 		user := models.UserSignUpInput{
-			Name:      "TestUser",
-			Password:  "password",
-			Email:     "test@example.com",
-			UserGroup: "TestGroup",
+			Name:       "TestUser",
+			Password:   "password",
+			EmailToken: emailToken,
+			UserGroup:  "TestGroup",
+		}
+		body, _ := json.Marshal(user)
+		req, _ := http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(body))
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_改ざんされたトークン", func(t *testing.T) {
+		// 署名部分を改ざんしたトークン
+		user := models.UserSignUpInput{
+			Name:       "TestUser",
+			Password:   "password",
+			EmailToken: emailToken + "tampered",
+			UserGroup:  "TestGroup",
+		}
+		body, _ := json.Marshal(user)
+		req, _ := http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(body))
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_有効期限切れのトークン", func(t *testing.T) {
+		expiredToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"email": "test@example.com",
+			"exp":   time.Now().Add(-time.Hour).Unix(),
+		})
+		expiredTokenString, _ := expiredToken.SignedString([]byte(os.Getenv("EMAIL_SECRET_KEY")))
+
+		user := models.UserSignUpInput{
+			Name:       "TestUser",
+			Password:   "password",
+			EmailToken: expiredTokenString,
+			UserGroup:  "TestGroup",
 		}
 		body, _ := json.Marshal(user)
 		req, _ := http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(body))
@@ -266,22 +421,28 @@ func TestInviteSignUpHandler(t *testing.T) {
 	r := gin.Default()
 	r.POST("/invite_signup", handler.InviteSignUpHandler)
 
+	emailToken, _ := utils.GenerateEmailToken("test@example.com")
+	userGroupToken, _ := utils.GenerateUserGroupIDToken(1, "test@example.com")
+
 	t.Run("成功", func(t *testing.T) {
 		mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
 			WithArgs("test@example.com").
 			WillReturnError(gorm.ErrRecordNotFound)
+		mock.ExpectQuery("SELECT (.+) FROM `users` WHERE email = (.+) AND deleted_at IS NOT NULL").
+			WithArgs("test@example.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
 
 		mock.ExpectBegin()
 		mock.ExpectExec("INSERT INTO `users`").
-			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "TestUser", sqlmock.AnyArg(), "test@example.com", sqlmock.AnyArg()).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "TestUser", sqlmock.AnyArg(), "test@example.com", sqlmock.AnyArg(), models.RoleMember, sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
 		user := models.UserInviteSignUpInput{
-			Name:      "TestUser",
-			Password:  "password",
-			Email:     "test@example.com",
-			UserGroup: 1,
+			Name:           "TestUser",
+			Password:       "password",
+			EmailToken:     emailToken,
+			UserGroupToken: userGroupToken,
 		}
 		body, _ := json.Marshal(user)
 		req, _ := http.NewRequest(http.MethodPost, "/invite_signup", bytes.NewBuffer(body))
@@ -299,18 +460,87 @@ func TestInviteSignUpHandler(t *testing.T) {
 		mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
 			WithArgs("test@example.com").
 			WillReturnError(gorm.ErrRecordNotFound)
+		mock.ExpectQuery("SELECT (.+) FROM `users` WHERE email = (.+) AND deleted_at IS NOT NULL").
+			WithArgs("test@example.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
 
 		mock.ExpectBegin()
 		mock.ExpectExec("INSERT INTO `users`").
-		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "TestUser", sqlmock.AnyArg(), "test@example.com", sqlmock.AnyArg()).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "TestUser", sqlmock.AnyArg(), "test@example.com", sqlmock.AnyArg(), models.RoleMember, sqlmock.AnyArg()).
 			WillReturnError(errors.New("Insert failed"))
 		mock.ExpectCommit()
 
 		user := models.UserInviteSignUpInput{
-			Name:      "TestUser",
-			Password:  "password",
-			Email:     "test@example.com",
-			UserGroup: 1,
+			Name:           "TestUser",
+			Password:       "password",
+			EmailToken:     emailToken,
+			UserGroupToken: userGroupToken,
+		}
+		body, _ := json.Marshal(user)
+		req, _ := http.NewRequest(http.MethodPost, "/invite_signup", bytes.NewBuffer(body))
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_ユーザーグループのトークンの取り違え", func(t *testing.T) {
+		// メールアドレス用のトークンはユーザーグループのトークンとして使えない
+		user := models.UserInviteSignUpInput{
+			Name:           "TestUser",
+			Password:       "password",
+			EmailToken:     emailToken,
+			UserGroupToken: emailToken,
+		}
+		body, _ := json.Marshal(user)
+		req, _ := http.NewRequest(http.MethodPost, "/invite_signup", bytes.NewBuffer(body))
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_別のメールアドレス宛ての招待", func(t *testing.T) {
+		// 他のメールアドレス宛ての招待のユーザーグループには登録できない
+		otherUserGroupToken, _ := utils.GenerateUserGroupIDToken(2, "other@example.com")
+		user := models.UserInviteSignUpInput{
+			Name:           "TestUser",
+			Password:       "password",
+			EmailToken:     emailToken,
+			UserGroupToken: otherUserGroupToken,
+		}
+		body, _ := json.Marshal(user)
+		req, _ := http.NewRequest(http.MethodPost, "/invite_signup", bytes.NewBuffer(body))
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest || !strings.Contains(resp.Body.String(), "email does not match invitation") {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_偽造されたユーザーグループのトークン", func(t *testing.T) {
+		forgedToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_group_id": 2,
+			"exp":           time.Now().Add(time.Hour).Unix(),
+		})
+		forgedTokenString, _ := forgedToken.SignedString([]byte("forged_secret_key"))
+
+		user := models.UserInviteSignUpInput{
+			Name:           "TestUser",
+			Password:       "password",
+			EmailToken:     emailToken,
+			UserGroupToken: forgedTokenString,
 		}
 		body, _ := json.Marshal(user)
 		req, _ := http.NewRequest(http.MethodPost, "/invite_signup", bytes.NewBuffer(body))
@@ -354,7 +584,7 @@ func TestLoginHandler(t *testing.T) {
 	user := models.User{
 		Model:    gorm.Model{ID: 1},
 		Name:     "TestUser",
-		Password: models.Encrypt("password"),
+		Password: mustHashPassword(t, "password"),
 		Email:    "test@example.com",
 		UserGroupID: 1,
 	}
@@ -366,6 +596,13 @@ func TestLoginHandler(t *testing.T) {
 		mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
 			WithArgs(user.Email).
 			WillReturnRows(rows)
+		expectTwoFactorDisabled(mock, user.ID)
+
+		// セッションの作成
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `sessions`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		// リクエストボディ
 		loginInput := models.UserLoginInput{
@@ -383,6 +620,100 @@ func TestLoginHandler(t *testing.T) {
 			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
 			t.Errorf("Error: %v", resp.Body.String())
 		}
+
+		// アクセストークンとリフレッシュトークンがクッキーにセットされる
+		cookieNames := map[string]bool{}
+		for _, cookie := range resp.Result().Cookies() {
+			if cookie.Value != "" {
+				cookieNames[cookie.Name] = true
+			}
+		}
+		if !cookieNames[constant.JWT_TOKEN_NAME] || !cookieNames[constant.REFRESH_TOKEN_NAME] {
+			t.Errorf("Expected access and refresh token cookies, got: %v", resp.Result().Cookies())
+		}
+	})
+
+	t.Run("成功_旧形式のハッシュを再ハッシュ", func(t *testing.T) {
+		legacyHash := fmt.Sprintf("%x", sha256.Sum256([]byte("password")))
+		rows := sqlmock.NewRows([]string{"id", "email", "password"}).
+			AddRow(user.ID, user.Email, legacyHash)
+		mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
+			WithArgs(user.Email).
+			WillReturnRows(rows)
+
+		// 新しい形式のハッシュで更新される
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `users` SET `password`=(.+) WHERE id = ?").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), user.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectTwoFactorDisabled(mock, user.ID)
+
+		// セッションの作成
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `sessions`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		loginInput := models.UserLoginInput{
+			Email:    user.Email,
+			Password: "password",
+		}
+		body, _ := json.Marshal(loginInput)
+		req, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Password was not rehashed: %v", err)
+		}
+	})
+
+	t.Run("成功_二要素認証が必要", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "email", "password"}).
+			AddRow(user.ID, user.Email, user.Password)
+		mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
+			WithArgs(user.Email).
+			WillReturnRows(rows)
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `two_factor_auths`").
+			WithArgs(user.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		loginInput := models.UserLoginInput{
+			Email:    user.Email,
+			Password: "password",
+		}
+		body, _ := json.Marshal(loginInput)
+		req, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+
+		// 認証コードの検証が済むまでセッションは作成しない
+		if len(resp.Result().Cookies()) != 0 {
+			t.Errorf("Expected no cookies, got: %v", resp.Result().Cookies())
+		}
+		var response map[string]interface{}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if response["mfa_required"] != true {
+			t.Errorf("Expected mfa_required, got: %v", response)
+		}
+		challengeToken, _ := response["challenge_token"].(string)
+		if userID, err := utils.ParseMFAChallengeToken(challengeToken); err != nil || userID != user.ID {
+			t.Errorf("Expected a valid challenge token, got: %v", err)
+		}
 	})
 
 	t.Run("失敗_存在しないユーザ", func(t *testing.T) {
@@ -401,8 +732,9 @@ func TestLoginHandler(t *testing.T) {
 
 		r.ServeHTTP(resp, req)
 
-		if resp.Code != http.StatusNotFound {
-			t.Errorf("Expected HTTP 404 Not Found, got: %v", resp.Code)
+		// 登録済みかどうかを推測されないようにパスワード不一致と同じ応答にする
+		if resp.Code != http.StatusUnauthorized {
+			t.Errorf("Expected HTTP 401 Unauthorized, got: %v", resp.Code)
 			t.Errorf("Error: %v", resp.Body.String())
 		}
 	})
@@ -430,6 +762,160 @@ func TestLoginHandler(t *testing.T) {
 			t.Errorf("Error: %v", resp.Body.String())
 		}
 	})
+
+	t.Run("失敗_ロックアウト", func(t *testing.T) {
+		handler.Limiter = utils.NewRateLimiter(utils.RateLimitConfig{
+			LoginLockout: utils.LockoutPolicy{
+				MaxFailures:  2,
+				BaseDuration: time.Minute,
+				MaxDuration:  time.Hour,
+			},
+		})
+		defer func() { handler.Limiter = nil }()
+
+		login := func(password string) *httptest.ResponseRecorder {
+			loginInput := models.UserLoginInput{
+				Email:    user.Email,
+				Password: password,
+			}
+			body, _ := json.Marshal(loginInput)
+			req, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+			return resp
+		}
+
+		for i := 0; i < 2; i++ {
+			mock.ExpectQuery("SELECT (.+) FROM (.+) WHERE email = ?").
+				WithArgs(user.Email).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "email", "password"}).AddRow(user.ID, user.Email, user.Password))
+
+			if resp := login("wrongPassword"); resp.Code != http.StatusUnauthorized {
+				t.Errorf("Expected HTTP 401 Unauthorized, got: %v", resp.Code)
+			}
+		}
+
+		// ロック中は正しいパスワードでもログインできない
+		resp := login("password")
+		if resp.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected HTTP 429 Too Many Requests, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if resp.Header().Get("Retry-After") == "" {
+			t.Errorf("Expected Retry-After header")
+		}
+	})
+}
+
+func expectTwoFactorDisabled(mock sqlmock.Sqlmock, userID uint) {
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `two_factor_auths`").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+}
+
+func TestRefreshSessionHandler(t *testing.T) {
+	// SQLMock のセットアップ
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	handler := &Handler{
+		DB: gormDB,
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.POST("/refresh", handler.RefreshSessionHandler)
+
+	refreshToken := "test_session_id.test_secret"
+	sessionColumns := []string{"id", "user_id", "token_id", "refresh_token_hash", "last_seen_at", "expires_at", "revoked_at"}
+
+	t.Run("成功", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `sessions` WHERE token_id = (.+) FOR UPDATE").
+			WithArgs("test_session_id").
+			WillReturnRows(sqlmock.NewRows(sessionColumns).
+				AddRow(1, 1, "test_session_id", utils.HashToken(refreshToken), time.Now(), time.Now().Add(time.Hour), nil))
+		mock.ExpectExec("UPDATE `sessions` SET (.+) WHERE `sessions`.`deleted_at` IS NULL AND `id` = ?").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		req, _ := http.NewRequest(http.MethodPost, "/refresh", nil)
+		req.AddCookie(&http.Cookie{
+			Name:  constant.REFRESH_TOKEN_NAME,
+			Value: refreshToken,
+		})
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Refresh token was not rotated: %v", err)
+		}
+
+		// 新しいリフレッシュトークンが発行される
+		for _, cookie := range resp.Result().Cookies() {
+			if cookie.Name == constant.REFRESH_TOKEN_NAME && (cookie.Value == "" || cookie.Value == refreshToken) {
+				t.Errorf("Expected a new refresh token, got: %s", cookie.Value)
+			}
+		}
+	})
+
+	t.Run("失敗_差し替え済みのトークンの再利用", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `sessions` WHERE token_id = (.+) FOR UPDATE").
+			WithArgs("test_session_id").
+			WillReturnRows(sqlmock.NewRows(sessionColumns).
+				AddRow(1, 1, "test_session_id", utils.HashToken("test_session_id.rotated_secret"), time.Now(), time.Now().Add(time.Hour), nil))
+		mock.ExpectRollback()
+
+		// 盗用の可能性があるためセッションを失効させる
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `sessions` SET `revoked_at`=(.+) WHERE id = (.+) AND revoked_at IS NULL").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		req, _ := http.NewRequest(http.MethodPost, "/refresh", nil)
+		req.AddCookie(&http.Cookie{
+			Name:  constant.REFRESH_TOKEN_NAME,
+			Value: refreshToken,
+		})
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusUnauthorized {
+			t.Errorf("Expected HTTP 401 Unauthorized, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Session was not revoked: %v", err)
+		}
+	})
+
+	t.Run("失敗_リフレッシュトークンなし", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/refresh", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusUnauthorized {
+			t.Errorf("Expected HTTP 401 Unauthorized, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+	})
 }
 
 func TestGuestLoginHandler(t *testing.T) {
@@ -473,10 +959,38 @@ func TestGuestLoginHandler(t *testing.T) {
 			WithArgs(0).
 			WillReturnRows(rows)
 	
+		// 取得したUser IDsに紐づくTaskのコメントを削除するクエリ
+		mock.ExpectExec("DELETE FROM `comments` WHERE task_id IN \\(SELECT `id` FROM `tasks` WHERE creator IN \\(\\?\\) OR responsible IN \\(\\?\\)\\)").
+			WithArgs(1, 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
 		// 取得したUser IDsに紐づくTaskを削除するクエリ
 		mock.ExpectExec("DELETE FROM `tasks` WHERE creator IN \\(\\?\\) OR responsible IN \\(\\?\\)").
 		WithArgs(1, 1).
 		WillReturnResult(sqlmock.NewResult(0, 1))	
+
+		// 取得したUser IDsに紐づくセッションや各種トークンを削除するクエリ
+		mock.ExpectExec("DELETE FROM `sessions` WHERE user_id IN \\(\\?\\)").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `password_reset_tokens` WHERE user_id IN \\(\\?\\)").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM `email_change_requests` WHERE user_id IN \\(\\?\\)").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM `two_factor_auths` WHERE user_id IN \\(\\?\\)").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM `recovery_codes` WHERE user_id IN \\(\\?\\)").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM `personal_access_tokens` WHERE user_id IN \\(\\?\\)").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM `oidc_identities` WHERE user_id IN \\(\\?\\)").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
 	
 		// UserGroupIDが0であるCategoryを削除するクエリ
 		mock.ExpectExec("DELETE FROM (.+) WHERE user_group_id = ?").
@@ -488,6 +1002,11 @@ func TestGuestLoginHandler(t *testing.T) {
 			WithArgs(0).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 	
+		// UserGroupIDが0であるゴミ箱の削除操作を削除するクエリ
+		mock.ExpectExec("DELETE FROM `deletions` WHERE user_group_id = ?").
+			WithArgs(0).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	
 		// 最後にIDが0であるUserGroupを削除するクエリ
 		mock.ExpectExec("DELETE FROM (.+) WHERE id = ?").
 			WithArgs(0).
@@ -517,6 +1036,11 @@ func TestGuestLoginHandler(t *testing.T) {
 		// コミット
 		mock.ExpectCommit()
 
+		// セッションの作成
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `sessions`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		req, _ := http.NewRequest(http.MethodGet, "/login/guest", nil)
 		resp := httptest.NewRecorder()
@@ -572,4 +1096,20 @@ func TestLogoutHandler(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("クッキーの属性を設定", func(t *testing.T) {
+		originalSameSite := os.Getenv("COOKIE_SAMESITE")
+		os.Setenv("COOKIE_SAMESITE", "none")
+		defer os.Setenv("COOKIE_SAMESITE", originalSameSite)
+
+		req, _ := http.NewRequest(http.MethodGet, "/logout", nil)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		for _, cookie := range resp.Result().Cookies() {
+			if cookie.SameSite != http.SameSiteNoneMode || !cookie.Secure {
+				t.Errorf("Expected cookie %s to be SameSite=None; Secure, got: %v", cookie.Name, cookie.String())
+			}
+		}
+	})
 }
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/utils"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/constant"
+)
+
+// 他のユーザーグループのタスク・カテゴリー・ユーザーグループを操作できないことを確認する
+func TestCrossUserGroupAccess(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.POST("/tasks", handler.CreateTaskHandler)
+	r.PUT("/tasks/:id", handler.UpdateTaskHandler)
+	r.PUT("/tasks/:id/to-completed", handler.UpdateTaskToMoveToCompletedHandler)
+	r.DELETE("/tasks/:id", handler.DeleteTaskHandler)
+	r.PUT("/categories/:id", handler.UpdateCategoryHandler)
+	r.DELETE("/categories/:id", handler.DeleteCategoryHandler)
+	r.PUT("/user-groups/:id", handler.UpdateUserGroupHandler)
+	r.DELETE("/user-groups/:id", handler.DeleteUserGroupHandler)
+	r.PUT("/users/me/user-group", handler.UpdateCurrentUserGroupHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	if err := db.Create(&userGroup).Error; err != nil {
+		t.Fatalf("failed to create user group: %v", err)
+	}
+	otherUserGroup := &models.UserGroup{UserGroup: "Other UserGroup"}
+	if err := db.Create(&otherUserGroup).Error; err != nil {
+		t.Fatalf("failed to create user group: %v", err)
+	}
+
+	user := &models.User{
+		Name:        "Test User",
+		Password:    "testPassword123",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	otherUser := &models.User{
+		Name:        "Other User",
+		Password:    "testPassword123",
+		Email:       "other@example.com",
+		UserGroupID: otherUserGroup.ID,
+	}
+	if err := db.Create(&otherUser).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	category := &models.Category{Category: "Test Category", UserGroupID: userGroup.ID}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatalf("failed to create category: %v", err)
+	}
+	otherCategory := &models.Category{Category: "Other Category", UserGroupID: otherUserGroup.ID}
+	if err := db.Create(&otherCategory).Error; err != nil {
+		t.Fatalf("failed to create category: %v", err)
+	}
+
+	task := &models.Task{
+		Task:        "Test Task",
+		Description: "This is a test task",
+		Creator:     user.ID,
+		CategoryID:  category.ID,
+		Status:      1,
+		Responsible: user.ID,
+		Estimate:    ptrToUint(5),
+		StartDate:   ptrToTime(time.Now()),
+	}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	otherTask := &models.Task{
+		Task:        "Other Task",
+		Description: "This is a test task",
+		Creator:     otherUser.ID,
+		CategoryID:  otherCategory.ID,
+		Status:      1,
+		Responsible: otherUser.ID,
+		Estimate:    ptrToUint(5),
+		StartDate:   ptrToTime(time.Now()),
+	}
+	if err := db.Create(&otherTask).Error; err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+
+	tokenString, _ := utils.GenerateSessionToken(uint(user.ID), "test_session_id")
+	// 他のユーザー宛てに送られた招待
+	otherInvitationToken, _ := utils.GenerateUserGroupIDToken(otherUserGroup.ID, otherUser.Email)
+
+	taskInput := func(categoryID uint, responsible uint) models.TaskInput {
+		return models.TaskInput{
+			Task:        "Updated Task",
+			Description: "Updated Description",
+			CategoryID:  categoryID,
+			Status:      2,
+			Responsible: responsible,
+			Estimate:    ptrToUint(5),
+			StartDate:   "2023-01-01T00:00:00Z",
+		}
+	}
+	categoryInput := models.CategoryInput{Category: "Updated Category"}
+	userGroupInput := models.UserGroupInput{UserGroup: "Updated UserGroup"}
+
+	// 存在しないID
+	missingID := otherTask.ID + otherCategory.ID + otherUserGroup.ID + 1000
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     interface{}
+		expected int
+	}{
+		{"タスク更新_他のグループのタスク", http.MethodPut, fmt.Sprintf("/tasks/%d", otherTask.ID), taskInput(category.ID, user.ID), http.StatusForbidden},
+		{"タスク更新_存在しないタスク", http.MethodPut, fmt.Sprintf("/tasks/%d", missingID), taskInput(category.ID, user.ID), http.StatusNotFound},
+		{"タスク更新_他のグループのカテゴリー", http.MethodPut, fmt.Sprintf("/tasks/%d", task.ID), taskInput(otherCategory.ID, user.ID), http.StatusBadRequest},
+		{"タスク更新_他のグループの担当者", http.MethodPut, fmt.Sprintf("/tasks/%d", task.ID), taskInput(category.ID, otherUser.ID), http.StatusBadRequest},
+		{"タスク完了_他のグループのタスク", http.MethodPut, fmt.Sprintf("/tasks/%d/to-completed", otherTask.ID), taskInput(category.ID, user.ID), http.StatusForbidden},
+		{"タスク削除_他のグループのタスク", http.MethodDelete, fmt.Sprintf("/tasks/%d", otherTask.ID), nil, http.StatusForbidden},
+		{"タスク削除_存在しないタスク", http.MethodDelete, fmt.Sprintf("/tasks/%d", missingID), nil, http.StatusNotFound},
+		{"タスク作成_他のグループのカテゴリー", http.MethodPost, "/tasks", taskInput(otherCategory.ID, user.ID), http.StatusBadRequest},
+		{"タスク作成_他のグループの担当者", http.MethodPost, "/tasks", taskInput(category.ID, otherUser.ID), http.StatusBadRequest},
+		{"カテゴリー更新_他のグループのカテゴリー", http.MethodPut, fmt.Sprintf("/categories/%d", otherCategory.ID), categoryInput, http.StatusForbidden},
+		{"カテゴリー更新_存在しないカテゴリー", http.MethodPut, fmt.Sprintf("/categories/%d", missingID), categoryInput, http.StatusNotFound},
+		{"カテゴリー削除_他のグループのカテゴリー", http.MethodDelete, fmt.Sprintf("/categories/%d", otherCategory.ID), nil, http.StatusForbidden},
+		{"ユーザーグループ更新_他のグループ", http.MethodPut, fmt.Sprintf("/user-groups/%d", otherUserGroup.ID), userGroupInput, http.StatusForbidden},
+		{"ユーザーグループ更新_存在しないグループ", http.MethodPut, fmt.Sprintf("/user-groups/%d", missingID), userGroupInput, http.StatusNotFound},
+		{"ユーザーグループ削除_他のグループ", http.MethodDelete, fmt.Sprintf("/user-groups/%d", otherUserGroup.ID), nil, http.StatusForbidden},
+		{"ユーザーグループ移動_招待されていないグループ", http.MethodPut, "/users/me/user-group", map[string]interface{}{"user_group_id": otherUserGroup.ID}, http.StatusBadRequest},
+		{"ユーザーグループ移動_不正な招待トークン", http.MethodPut, "/users/me/user-group", models.UserGroupUpdateInput{UserGroupToken: "invalid"}, http.StatusBadRequest},
+		{"ユーザーグループ移動_別のメールアドレス宛ての招待", http.MethodPut, "/users/me/user-group", models.UserGroupUpdateInput{UserGroupToken: otherInvitationToken}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			if tt.body != nil {
+				body, _ = json.Marshal(tt.body)
+			}
+			req, _ := http.NewRequest(tt.method, tt.url, bytes.NewBuffer(body))
+			resp := httptest.NewRecorder()
+
+			req.AddCookie(&http.Cookie{
+				Name:  constant.JWT_TOKEN_NAME,
+				Value: tokenString,
+			})
+
+			r.ServeHTTP(resp, req)
+
+			if resp.Code != tt.expected {
+				t.Errorf("Expected HTTP %d, got: %v", tt.expected, resp.Code)
+				t.Errorf("Error: %v", resp.Body.String())
+			}
+		})
+	}
+
+	// 他のグループのデータが変更・削除されていないことを確認
+	var checkTask models.Task
+	if err := db.First(&checkTask, otherTask.ID).Error; err != nil {
+		t.Errorf("Other group's task should not be deleted: %v", err)
+	} else if checkTask.Task != "Other Task" || checkTask.Status != 1 {
+		t.Errorf("Other group's task should not be updated: %v", checkTask)
+	}
+
+	var checkCategory models.Category
+	if err := db.First(&checkCategory, otherCategory.ID).Error; err != nil {
+		t.Errorf("Other group's category should not be deleted: %v", err)
+	} else if checkCategory.Category != "Other Category" {
+		t.Errorf("Other group's category should not be updated: %v", checkCategory)
+	}
+
+	var checkUserGroup models.UserGroup
+	if err := db.First(&checkUserGroup, otherUserGroup.ID).Error; err != nil {
+		t.Errorf("Other user group should not be deleted: %v", err)
+	} else if checkUserGroup.UserGroup != "Other UserGroup" {
+		t.Errorf("Other user group should not be updated: %v", checkUserGroup)
+	}
+
+	var checkUser models.User
+	if err := db.First(&checkUser, user.ID).Error; err != nil || checkUser.UserGroupID != userGroup.ID {
+		t.Errorf("User should not join other user group without invitation: %v", checkUser)
+	}
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Delete(&task)
+	db.Unscoped().Delete(&otherTask)
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&otherCategory)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&userGroup)
+	db.Unscoped().Delete(&otherUserGroup)
+}
@@ -91,6 +91,20 @@ func (handler *Handler) UpdateCategoryHandler(c *gin.Context) {
 		Category: updateCategoryInput.Category,
 	}
 	
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
 	// URLからtaskのidを取得
 	id, err := getIdFromURLTail(c)
 	if err != nil {
@@ -98,16 +112,16 @@ func (handler *Handler) UpdateCategoryHandler(c *gin.Context) {
 		return
 	}
 
-	err = updateCategory.UpdateCategory(handler.DB, id)
+	// 更新対象のカテゴリーがユーザーグループのものか確認
+	err = models.AuthorizeCategory(handler.DB, id, userGroupID)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, err.Error())
+		respondWithAuthorizationError(c, err)
 		return
 	}
 
-	// Cookie内のjwtからUSER_IDを取得
-	userID, err := extractUserID(c)
+	err = updateCategory.UpdateCategory(handler.DB, id)
 	if err != nil {
-		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 	
@@ -119,7 +133,7 @@ func (handler *Handler) UpdateCategoryHandler(c *gin.Context) {
 		return
 	}
 
-	tasks, err := models.FetchTaskBoardTasks(handler.DB, userID)
+	tasks, err := models.FetchTaskBoardTasks(handler.DB, userID, models.TaskQuery{})
 	if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
@@ -133,6 +147,20 @@ func (handler *Handler) UpdateCategoryHandler(c *gin.Context) {
 
 func (handler *Handler) DeleteCategoryHandler(c *gin.Context) {
 
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
 	// URLからtaskのidを取得
 	id, err := getIdFromURLTail(c)
 	if err != nil {
@@ -140,18 +168,18 @@ func (handler *Handler) DeleteCategoryHandler(c *gin.Context) {
 		return
 	}
 
-	deleteCategory := &models.Category{}
-
-	err = deleteCategory.DeleteCategoryAndRelatedTasks(handler.DB, id)
+	// 削除対象のカテゴリーがユーザーグループのものか確認
+	err = models.AuthorizeCategory(handler.DB, id, userGroupID)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, err.Error())
+		respondWithAuthorizationError(c, err)
 		return
 	}
 
-	// Cookie内のjwtからUSER_IDを取得
-	userID, err := extractUserID(c)
+	deleteCategory := &models.Category{}
+
+	err = deleteCategory.DeleteCategoryAndRelatedTasks(handler.DB, id, userID)
 	if err != nil {
-		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -161,7 +189,7 @@ func (handler *Handler) DeleteCategoryHandler(c *gin.Context) {
 		return
 	}
 
-	tasks, err := models.FetchTaskBoardTasks(handler.DB, userID)
+	tasks, err := models.FetchTaskBoardTasks(handler.DB, userID, models.TaskQuery{})
 	if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
@@ -53,7 +53,7 @@ func TestCreateCategoryHandler(t *testing.T) {
 		if err := db.Create(&user).Error; err != nil {
 			t.Fatalf("failed to create user: %v", err)
 		}
-		tokenString, _ := utils.GenerateSessionToken(uint(user.ID))
+		tokenString, _ := utils.GenerateSessionToken(uint(user.ID), "test_session_id")
 
 		categoryInput := models.CategoryInput{Category: "New Category"}
 		body, _ := json.Marshal(categoryInput)
@@ -93,7 +93,7 @@ func TestGetCategoryHandler(t *testing.T) {
 		DB: db,
 	}
 
-	tokenString, _ := utils.GenerateSessionToken(uint(1))
+	tokenString, _ := utils.GenerateSessionToken(uint(1), "test_session_id")
 
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
@@ -160,7 +160,7 @@ func TestUpdateCategoryHandler(t *testing.T) {
 		DB: db,
 	}
 
-	tokenString, _ := utils.GenerateSessionToken(uint(1))
+	tokenString, _ := utils.GenerateSessionToken(uint(1), "test_session_id")
 
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
@@ -232,7 +232,7 @@ func TestDeleteCategoryHandler(t *testing.T) {
 		DB: db,
 	}
 
-	tokenString, _ := utils.GenerateSessionToken(uint(1))
+	tokenString, _ := utils.GenerateSessionToken(uint(1), "test_session_id")
 
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
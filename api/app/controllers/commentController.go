@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/models"
+)
+
+func (handler *Handler) GetCommentsHandler(c *gin.Context) {
+	_, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	comments, err := models.FetchComments(handler.DB, taskID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": comments,
+	})
+}
+
+func (handler *Handler) CreateCommentHandler(c *gin.Context) {
+	var commentInput models.CommentInput
+	if err := c.ShouldBindJSON(&commentInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	err := models.CreateComment(handler.DB, taskID, userID, commentInput)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithComments(c, taskID)
+}
+
+func (handler *Handler) UpdateCommentHandler(c *gin.Context) {
+	var commentUpdateInput models.CommentUpdateInput
+	if err := c.ShouldBindJSON(&commentUpdateInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	// URLからcommentのidを取得
+	commentID, err := getIdFromParam(c, "commentId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	err = models.UpdateComment(handler.DB, taskID, commentID, userID, commentUpdateInput.Body)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithComments(c, taskID)
+}
+
+func (handler *Handler) DeleteCommentHandler(c *gin.Context) {
+	userID, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	// URLからcommentのidを取得
+	commentID, err := getIdFromParam(c, "commentId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	err = models.DeleteComment(handler.DB, taskID, commentID, userID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithComments(c, taskID)
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// URLのタスクがログイン中のユーザーのユーザーグループのものか確認する
+func (handler *Handler) authorizeTaskFromParam(c *gin.Context) (uint, int, bool) {
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return 0, 0, false
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return 0, 0, false
+	}
+
+	// URLからtaskのidを取得
+	taskID, err := getIdFromParam(c, "taskId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return 0, 0, false
+	}
+
+	if err := models.AuthorizeTask(handler.DB, taskID, userGroupID); err != nil {
+		respondWithAuthorizationError(c, err)
+		return 0, 0, false
+	}
+
+	return userID, taskID, true
+}
+
+func (handler *Handler) respondWithComments(c *gin.Context, taskID int) {
+	comments, err := models.FetchComments(handler.DB, taskID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": comments,
+	})
+}
+
+func getIdFromParam(c *gin.Context, name string) (int, error) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		log.Printf("URLのIDのフォーマットが不正です")
+		log.Printf("Invalid id format: %v", err)
+		return id, err
+	}
+
+	return id, nil
+}
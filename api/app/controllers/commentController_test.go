@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestCommentHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/tasks/:taskId/comments", handler.GetCommentsHandler)
+	r.POST("/tasks/:taskId/comments", handler.CreateCommentHandler)
+	r.PUT("/tasks/:taskId/comments/:commentId", handler.UpdateCommentHandler)
+	r.DELETE("/tasks/:taskId/comments/:commentId", handler.DeleteCommentHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	db.Create(&userGroup)
+	otherUserGroup := &models.UserGroup{UserGroup: "Other UserGroup"}
+	db.Create(&otherUserGroup)
+	user := &models.User{Name: "Test User", Password: "testPassword123", Email: "test@example.com", UserGroupID: userGroup.ID, Role: models.RoleMember}
+	db.Create(&user)
+	otherUser := &models.User{Name: "Other User", Password: "testPassword123", Email: "other@example.com", UserGroupID: otherUserGroup.ID, Role: models.RoleOwner}
+	db.Create(&otherUser)
+	category := &models.Category{Category: "Test Category", UserGroupID: userGroup.ID}
+	db.Create(&category)
+	task := &models.Task{
+		Task:        "Sample Task",
+		Description: "This is a test task",
+		Creator:     user.ID,
+		CategoryID:  category.ID,
+		Status:      1,
+		Responsible: user.ID,
+		Estimate:    ptrToUint(5),
+		StartDate:   ptrToTime(time.Now()),
+	}
+	db.Create(&task)
+
+	newRequest := func(userID uint, method string, url string, body []byte) *http.Request {
+		tokenString, _ := utils.GenerateSessionToken(userID, "test_session_id")
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+	commentsURL := fmt.Sprintf("/tasks/%d/comments", task.ID)
+
+	var response struct {
+		Comments []models.CommentResponse `json:"comments"`
+	}
+
+	t.Run("投稿", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{"body": "Looks good"})
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPost, commentsURL, body))
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if len(response.Comments) != 1 || response.Comments[0].Author != user.ID {
+			t.Errorf("Unexpected comments: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("返信", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{"body": "Thanks", "parent_id": response.Comments[0].ID})
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPost, commentsURL, body))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Comments[0].Replies) != 1 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_存在しない返信先", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{"body": "Thanks", "parent_id": 999999})
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPost, commentsURL, body))
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("編集", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{"body": "Looks great"})
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPut, fmt.Sprintf("%s/%d", commentsURL, response.Comments[0].ID), body))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || response.Comments[0].Body != "Looks great" || response.Comments[0].EditedAt == "" {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_他のユーザーグループのタスク", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(otherUser.ID, http.MethodGet, commentsURL, nil))
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+		}
+
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(otherUser.ID, http.MethodDelete, fmt.Sprintf("%s/%d", commentsURL, response.Comments[0].ID), nil))
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodDelete, fmt.Sprintf("%s/%d", commentsURL, response.Comments[0].ID), nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || !response.Comments[0].Deleted {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Where("task_id = ?", task.ID).Delete(&models.Comment{})
+	db.Unscoped().Delete(&task)
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&userGroup)
+	db.Unscoped().Delete(&otherUserGroup)
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/config"
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+// CSRFトークンを発行し、クッキーにセットする
+// フロントエンドはクッキーかレスポンスのトークンをX-CSRF-Tokenヘッダーに付けて送信する
+// 複数のタブで使えるよう、発行済みのトークンがあればそのまま返す
+func (handler *Handler) GetCSRFTokenHandler(c *gin.Context) {
+	csrfToken, err := c.Cookie(constant.CSRF_TOKEN_NAME)
+	if err != nil || !csrfTokenPattern.MatchString(csrfToken) {
+		csrfToken, err = utils.GenerateRandomString(32)
+		if err != nil {
+			respondWithError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	// フロントエンドから読み取れるようにHttpOnlyにしない
+	config.SetCookie(c, constant.CSRF_TOKEN_NAME, csrfToken, constant.COOKIE_MAX_AGE, "/", false)
+
+	c.JSON(http.StatusOK, gin.H{
+		"csrf_token": csrfToken,
+	})
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+var csrfTokenPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestGetCSRFTokenHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+
+	handler := &Handler{}
+	r.GET("/csrf", handler.GetCSRFTokenHandler)
+
+	var issuedToken string
+
+	t.Run("発行", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/csrf", nil)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+		}
+
+		var response struct {
+			CSRFToken string `json:"csrf_token"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		issuedToken = response.CSRFToken
+
+		// フロントエンドから読み取れるクッキーにセットされる
+		found := false
+		for _, cookie := range resp.Result().Cookies() {
+			if cookie.Name == constant.CSRF_TOKEN_NAME {
+				found = true
+				if cookie.Value != issuedToken {
+					t.Errorf("Expected cookie to match response token")
+				}
+				if cookie.HttpOnly {
+					t.Errorf("CSRF cookie should not be HttpOnly")
+				}
+			}
+		}
+		if !found || len(issuedToken) != 64 {
+			t.Errorf("Expected CSRF token, got: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("発行済みのトークンを再利用", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/csrf", nil)
+		req.AddCookie(&http.Cookie{Name: constant.CSRF_TOKEN_NAME, Value: issuedToken})
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		var response struct {
+			CSRFToken string `json:"csrf_token"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if response.CSRFToken != issuedToken {
+			t.Errorf("Expected token to be reused, got: %v", response.CSRFToken)
+		}
+	})
+
+	t.Run("不正なトークンは発行し直す", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/csrf", nil)
+		req.AddCookie(&http.Cookie{Name: constant.CSRF_TOKEN_NAME, Value: "attacker-chosen"})
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		var response struct {
+			CSRFToken string `json:"csrf_token"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if response.CSRFToken == "attacker-chosen" || len(response.CSRFToken) != 64 {
+			t.Errorf("Expected new token, got: %v", response.CSRFToken)
+		}
+	})
+}
@@ -2,13 +2,16 @@ package controllers
 
 import (
 	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/utils"
 )
 
 type MailSender interface {
 	SendSignUpMail(email string) error
 	SendInviteMail(userInviteInput UserInviteInput) error
-	SendUpdateEmailMail(email string) error
-	SendUpdatePasswordMail(email string) error
+	SendUpdateEmailMail(email string, confirmToken string) error
+	SendEmailChangeNoticeMail(oldEmail string, newEmail string, cancelToken string) error
+	SendUpdatePasswordMail(email string, resetToken string) error
 }
 
 type ProductionMailSender struct{}
@@ -16,11 +19,16 @@ type ProductionMailSender struct{}
 type MockMailSender struct {
 	MockSendSignUpMail func(email string) error
 	MockSendInviteMail func(userInviteInput UserInviteInput) error
-	MockSendUpdateEmailMail func(email string) error
-	MockSendUpdatePasswordMail func(email string) error
+	MockSendUpdateEmailMail func(email string, confirmToken string) error
+	MockSendEmailChangeNoticeMail func(oldEmail string, newEmail string, cancelToken string) error
+	MockSendUpdatePasswordMail func(email string, resetToken string) error
 }
 
 type Handler struct {
 	DB *gorm.DB
 	MailSender MailSender
+	Limiter *utils.RateLimiter // nilの場合はレート制限をしない
+	OIDC *utils.OIDCProvider // nilの場合はOIDCでのログインを無効にする
+	AuditLogSink *utils.AuditLogSink // nilの場合は監査ログをファイルに出力しない
+	TaskConfig utils.TaskConfig
 }
\ No newline at end of file
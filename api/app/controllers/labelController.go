@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/models"
+)
+
+func (handler *Handler) GetLabelsHandler(c *gin.Context) {
+	userGroupID, ok := handler.extractUserGroupID(c)
+	if !ok {
+		return
+	}
+
+	handler.respondWithLabels(c, userGroupID)
+}
+
+func (handler *Handler) CreateLabelHandler(c *gin.Context) {
+	var labelInput models.LabelInput
+	if err := c.ShouldBindJSON(&labelInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userGroupID, ok := handler.extractUserGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.CreateLabel(handler.DB, userGroupID, labelInput); err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithLabels(c, userGroupID)
+}
+
+func (handler *Handler) UpdateLabelHandler(c *gin.Context) {
+	var labelInput models.LabelInput
+	if err := c.ShouldBindJSON(&labelInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// URLからラベルのidを取得
+	labelID, err := getIdFromParam(c, "labelId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	userGroupID, ok := handler.extractUserGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.UpdateLabel(handler.DB, userGroupID, labelID, labelInput); err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithLabels(c, userGroupID)
+}
+
+// ラベルを削除し、タスクからラベルを外す
+func (handler *Handler) DeleteLabelHandler(c *gin.Context) {
+	// URLからラベルのidを取得
+	labelID, err := getIdFromParam(c, "labelId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	userGroupID, ok := handler.extractUserGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DeleteLabel(handler.DB, userGroupID, labelID); err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithLabels(c, userGroupID)
+}
+
+// URLのラベルをTargetのラベルに統合する
+func (handler *Handler) MergeLabelHandler(c *gin.Context) {
+	var labelMergeInput models.LabelMergeInput
+	if err := c.ShouldBindJSON(&labelMergeInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// URLからラベルのidを取得
+	labelID, err := getIdFromParam(c, "labelId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	userGroupID, ok := handler.extractUserGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.MergeLabel(handler.DB, userGroupID, labelID, labelMergeInput.Target); err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithLabels(c, userGroupID)
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func (handler *Handler) respondWithLabels(c *gin.Context, userGroupID uint) {
+	labels, err := models.FetchLabels(handler.DB, userGroupID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"labels": labels,
+	})
+}
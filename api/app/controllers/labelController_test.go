@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestLabelHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/labels", handler.GetLabelsHandler)
+	r.POST("/labels", handler.CreateLabelHandler)
+	r.PUT("/labels/:labelId", handler.UpdateLabelHandler)
+	r.DELETE("/labels/:labelId", handler.DeleteLabelHandler)
+	r.POST("/labels/:labelId/merge", handler.MergeLabelHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	db.Create(&userGroup)
+	user := &models.User{Name: "Test User", Password: "testPassword123", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(&user)
+
+	newRequest := func(method string, url string, body interface{}) *http.Request {
+		tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+
+	var response struct {
+		Labels []models.LabelResponse `json:"labels"`
+	}
+
+	t.Run("作成", func(t *testing.T) {
+		for _, input := range []models.LabelInput{{Name: "バグ", Color: "#F44336"}, {Name: "不具合", Color: "#E91E63"}} {
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, newRequest(http.MethodPost, "/labels", input))
+
+			json.Unmarshal(resp.Body.Bytes(), &response)
+			if resp.Code != http.StatusOK {
+				t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+			}
+		}
+		if len(response.Labels) != 2 {
+			t.Errorf("Expected 2 labels, got: %v", response.Labels)
+		}
+	})
+
+	t.Run("失敗_重複した名前", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, "/labels", models.LabelInput{Name: "バグ", Color: "#000000"}))
+
+		if resp.Code != http.StatusConflict {
+			t.Errorf("Expected HTTP 409 Conflict, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("失敗_不正な色", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, "/labels", models.LabelInput{Name: "改善", Color: "green"}))
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("統合", func(t *testing.T) {
+		// 名前順で「不具合」を「バグ」に統合する
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, fmt.Sprintf("/labels/%d/merge", response.Labels[1].ID), models.LabelMergeInput{Target: response.Labels[0].ID}))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Labels) != 1 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodDelete, fmt.Sprintf("/labels/%d", response.Labels[0].ID), nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Labels) != 0 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Where("user_group_id = ?", userGroup.ID).Delete(&models.Label{})
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
@@ -0,0 +1,13 @@
+package controllers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/alicend/LookBack/app/testutil"
+)
+
+func TestMain(m *testing.M) {
+	testutil.SetSigningKeys()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/config"
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+// IdPの認可エンドポイントにリダイレクトする
+// state、nonce、PKCEのコードベリファイアはコールバックまでクッキーに保存する
+func (handler *Handler) OIDCLoginHandler(c *gin.Context) {
+	if handler.OIDC == nil {
+		respondWithError(c, http.StatusNotFound, "OIDC login is disabled")
+		return
+	}
+
+	stateClaims, err := newOIDCStateClaims()
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	stateToken, err := utils.GenerateOIDCStateToken(stateClaims)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	authURL, err := handler.OIDC.AuthCodeURL(c.Request.Context(), stateClaims.State, stateClaims.Nonce, stateClaims.CodeVerifier)
+	if err != nil {
+		log.Printf("Failed to build authorization URL: %v", err)
+		respondWithErrAndMsg(c, http.StatusBadGateway, err.Error(), "IdPに接続できませんでした")
+		return
+	}
+
+	// IdPからリダイレクトされた時に送信されるよう、SameSite=Strictにはしない
+	config.SetCrossSiteRedirectCookie(c, constant.OIDC_STATE_COOKIE_NAME, stateToken, constant.OIDC_STATE_LIFETIME_MINUTES*60, "/api/auth/oidc", true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// IdPからのコールバックで認可コードをIDトークンと交換し、セッションを作成する
+func (handler *Handler) OIDCCallbackHandler(c *gin.Context) {
+	if handler.OIDC == nil {
+		respondWithError(c, http.StatusNotFound, "OIDC login is disabled")
+		return
+	}
+
+	stateToken, err := c.Cookie(constant.OIDC_STATE_COOKIE_NAME)
+	// stateは一度しか使えないよう、検証の結果にかかわらず削除する
+	config.SetCrossSiteRedirectCookie(c, constant.OIDC_STATE_COOKIE_NAME, "", -1, "/api/auth/oidc", true)
+	if err != nil {
+		log.Printf("Error retrieving cookie: %v", err)
+		respondWithErrAndMsg(c, http.StatusBadRequest, "missing state", "ログインの有効期限が切れました。もう一度ログインしてください")
+		return
+	}
+
+	stateClaims, err := utils.ParseOIDCStateToken(stateToken)
+	if err != nil {
+		log.Printf("Invalid state token: %v", err)
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "ログインの有効期限が切れました。もう一度ログインしてください")
+		return
+	}
+
+	// 別のブラウザで開始した認可リクエストの結果を受け付けない(CSRF対策)
+	if subtle.ConstantTimeCompare([]byte(c.Query("state")), []byte(stateClaims.State)) != 1 {
+		log.Printf("OIDC state mismatch from %s", c.ClientIP())
+		respondWithErrAndMsg(c, http.StatusBadRequest, "state mismatch", "ログインの有効期限が切れました。もう一度ログインしてください")
+		return
+	}
+
+	if idpError := c.Query("error"); idpError != "" {
+		log.Printf("IdP returned error: %s %s", idpError, c.Query("error_description"))
+		respondWithErrAndMsg(c, http.StatusUnauthorized, idpError, "IdPでの認証に失敗しました")
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		respondWithErrAndMsg(c, http.StatusBadRequest, "missing code", "IdPでの認証に失敗しました")
+		return
+	}
+
+	claims, err := handler.OIDC.Exchange(c.Request.Context(), code, stateClaims.CodeVerifier, stateClaims.Nonce)
+	if err != nil {
+		log.Printf("Failed to exchange authorization code: %v", err)
+		respondWithErrAndMsg(c, http.StatusUnauthorized, err.Error(), "IdPでの認証に失敗しました")
+		return
+	}
+
+	// 未登録のユーザーは、クレームに対応するユーザーグループがある場合のみ作成する
+	provisioning := models.OIDCProvisioning{}
+	if handler.OIDC.Config.JITProvisioning {
+		provisioning.UserGroupID = handler.OIDC.MapUserGroup(claims)
+	}
+
+	user, err := models.FindOrLinkOIDCUser(handler.DB, claims, provisioning)
+	if errors.Is(err, models.ErrOIDCEmailNotVerified) || errors.Is(err, models.ErrOIDCUserNotFound) {
+		handler.recordAuditLog(c, models.AuditLog{Action: models.AuditActionLoginFailed, Detail: "oidc"})
+		respondWithErrAndMsg(c, http.StatusForbidden, err.Error(), err.Error())
+		return
+	} else if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// 多要素認証はIdPに任せるため、二要素認証のチャレンジは行わない
+	if err := handler.startSession(c, user.ID); err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:       models.AuditActionLoginSucceeded,
+		ActorID:      user.ID,
+		TargetUserID: user.ID,
+		UserGroupID:  user.UserGroupID,
+		Detail:       "oidc",
+	})
+
+	// ゲストログインでないことをクッキーに登録
+	config.SetCookie(c, constant.GUEST_LOGIN, "false", constant.COOKIE_MAX_AGE, "/", false)
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("%s/task-board", os.Getenv("FRONTEND_ORIGIN")))
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func newOIDCStateClaims() (utils.OIDCStateClaims, error) {
+	state, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return utils.OIDCStateClaims{}, err
+	}
+	nonce, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return utils.OIDCStateClaims{}, err
+	}
+	codeVerifier, err := utils.GeneratePKCEVerifier()
+	if err != nil {
+		return utils.OIDCStateClaims{}, err
+	}
+
+	return utils.OIDCStateClaims{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+	}, nil
+}
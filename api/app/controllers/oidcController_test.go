@@ -0,0 +1,319 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestOIDCHandlers(t *testing.T) {
+	// SQLMock のセットアップ
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	defer sqlDB.Close()
+
+	gormDB, _ := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	// テスト用のIdP
+	idp := utils.NewMockOIDCServer("look-back")
+	defer idp.Close()
+
+	config := idp.Config("http://localhost/oidc/callback")
+	config.GroupClaim = "groups"
+	config.GroupMapping = map[string]uint{"engineering": 2}
+	handler := &Handler{
+		DB:   gormDB,
+		OIDC: utils.NewOIDCProvider(config),
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/oidc/login", handler.OIDCLoginHandler)
+	r.GET("/oidc/callback", handler.OIDCCallbackHandler)
+
+	// ログインを開始し、IdPでログインした後のコールバックのリクエストを返す
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	loginWithIdP := func(t *testing.T, claims jwt.MapClaims) *http.Request {
+		idp.Claims = claims
+
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oidc/login", nil)
+		r.ServeHTTP(resp, req)
+		if resp.Code != http.StatusFound {
+			t.Fatalf("Expected HTTP 302 Found, got: %v", resp.Code)
+		}
+
+		idpResp, err := client.Get(resp.Header().Get("Location"))
+		if err != nil {
+			t.Fatalf("Failed to authorize: %v", err)
+		}
+		callbackURL, _ := url.Parse(idpResp.Header.Get("Location"))
+
+		callbackReq, _ := http.NewRequest(http.MethodGet, callbackURL.RequestURI(), nil)
+		for _, cookie := range resp.Result().Cookies() {
+			callbackReq.AddCookie(cookie)
+		}
+		return callbackReq
+	}
+
+	expectSessionCookies := func(t *testing.T, resp *httptest.ResponseRecorder) {
+		if resp.Code != http.StatusFound {
+			t.Errorf("Expected HTTP 302 Found, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if !strings.HasSuffix(resp.Header().Get("Location"), "/task-board") {
+			t.Errorf("Expected redirect to task board, got: %v", resp.Header().Get("Location"))
+		}
+
+		cookieNames := map[string]bool{}
+		for _, cookie := range resp.Result().Cookies() {
+			if cookie.Value != "" {
+				cookieNames[cookie.Name] = true
+			}
+		}
+		if !cookieNames[constant.JWT_TOKEN_NAME] || !cookieNames[constant.REFRESH_TOKEN_NAME] {
+			t.Errorf("Expected session cookies, got: %v", cookieNames)
+		}
+	}
+
+	t.Run("ログイン開始", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oidc/login", nil)
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusFound {
+			t.Errorf("Expected HTTP 302 Found, got: %v", resp.Code)
+		}
+
+		location, _ := url.Parse(resp.Header().Get("Location"))
+		if location.Query().Get("code_challenge_method") != "S256" || location.Query().Get("code_challenge") == "" {
+			t.Errorf("Expected PKCE parameters, got: %v", location)
+		}
+
+		hasStateCookie := false
+		for _, cookie := range resp.Result().Cookies() {
+			if cookie.Name == constant.OIDC_STATE_COOKIE_NAME && cookie.HttpOnly {
+				hasStateCookie = true
+			}
+		}
+		if !hasStateCookie {
+			t.Errorf("Expected state cookie")
+		}
+	})
+
+	t.Run("成功_連携済み", func(t *testing.T) {
+		req := loginWithIdP(t, jwt.MapClaims{"sub": "user-1", "email": "test@example.com", "email_verified": true})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `oidc_identities` WHERE \\(issuer = \\? AND subject = \\?\\)").
+			WithArgs(idp.URL, "user-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "issuer", "subject"}).AddRow(1, 1, idp.URL, "user-1"))
+		mock.ExpectQuery("SELECT (.+) FROM `users` WHERE id = ?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "test@example.com"))
+		mock.ExpectCommit()
+
+		// セッションの作成
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `sessions`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		expectSessionCookies(t, resp)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("There were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("成功_メールアドレスで既存のユーザーと紐付け", func(t *testing.T) {
+		req := loginWithIdP(t, jwt.MapClaims{"sub": "user-1", "email": "test@example.com", "email_verified": true})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `oidc_identities` WHERE \\(issuer = \\? AND subject = \\?\\)").
+			WithArgs(idp.URL, "user-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery("SELECT (.+) FROM `users` WHERE email = ?").
+			WithArgs("test@example.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "test@example.com"))
+		mock.ExpectExec("DELETE FROM `oidc_identities` WHERE (.+) AND deleted_at IS NOT NULL").
+			WithArgs(idp.URL, "user-1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO `oidc_identities`").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1, idp.URL, "user-1", nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		// セッションの作成
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `sessions`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		expectSessionCookies(t, resp)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("There were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("成功_初回ログイン時にユーザーを作成", func(t *testing.T) {
+		handler.OIDC.Config.JITProvisioning = true
+		defer func() { handler.OIDC.Config.JITProvisioning = false }()
+
+		req := loginWithIdP(t, jwt.MapClaims{"sub": "user-2", "email": "new@example.com", "email_verified": true, "name": "New User", "groups": []string{"engineering"}})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `oidc_identities` WHERE \\(issuer = \\? AND subject = \\?\\)").
+			WithArgs(idp.URL, "user-2").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery("SELECT (.+) FROM `users` WHERE email = ?").
+			WithArgs("new@example.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		// クレームに対応するユーザーグループにメンバーとして作成する
+		mock.ExpectQuery("SELECT (.+) FROM `user_groups` WHERE id = ?").
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_group"}).AddRow(2, "Engineering"))
+		mock.ExpectQuery("SELECT (.+) FROM `users` WHERE email = ?").
+			WithArgs("new@example.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery("SELECT (.+) FROM `users` WHERE email = (.+) AND deleted_at IS NOT NULL").
+			WithArgs("new@example.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec("INSERT INTO `users`").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "New User", sqlmock.AnyArg(), "new@example.com", 2, "member", nil).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec("DELETE FROM `oidc_identities` WHERE (.+) AND deleted_at IS NOT NULL").
+			WithArgs(idp.URL, "user-2").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO `oidc_identities`").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 2, idp.URL, "user-2", nil).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		// セッションの作成
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `sessions`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		expectSessionCookies(t, resp)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("There were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("失敗_未登録のユーザー", func(t *testing.T) {
+		req := loginWithIdP(t, jwt.MapClaims{"sub": "user-2", "email": "new@example.com", "email_verified": true, "groups": []string{"engineering"}})
+
+		// ユーザーの作成が無効な場合は作成しない
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `oidc_identities` WHERE \\(issuer = \\? AND subject = \\?\\)").
+			WithArgs(idp.URL, "user-2").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery("SELECT (.+) FROM `users` WHERE email = ?").
+			WithArgs("new@example.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("There were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("失敗_メールアドレスが未確認", func(t *testing.T) {
+		req := loginWithIdP(t, jwt.MapClaims{"sub": "user-3", "email": "test@example.com", "email_verified": false})
+
+		// 未確認のメールアドレスで既存のユーザーと紐付けない
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `oidc_identities` WHERE \\(issuer = \\? AND subject = \\?\\)").
+			WithArgs(idp.URL, "user-3").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("There were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("失敗_stateが異なる", func(t *testing.T) {
+		req := loginWithIdP(t, jwt.MapClaims{"sub": "user-1", "email": "test@example.com", "email_verified": true})
+		query := req.URL.Query()
+		query.Set("state", "forged")
+		req.URL.RawQuery = query.Encode()
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_stateのクッキーがない", func(t *testing.T) {
+		req := loginWithIdP(t, jwt.MapClaims{"sub": "user-1", "email": "test@example.com", "email_verified": true})
+		req.Header.Del("Cookie")
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_OIDCが無効", func(t *testing.T) {
+		disabledHandler := &Handler{DB: gormDB}
+		disabledRouter := gin.Default()
+		disabledRouter.GET("/oidc/login", disabledHandler.OIDCLoginHandler)
+
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oidc/login", nil)
+		disabledRouter.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusNotFound {
+			t.Errorf("Expected HTTP 404 Not Found, got: %v", resp.Code)
+		}
+	})
+}
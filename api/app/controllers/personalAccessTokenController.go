@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/models"
+)
+
+func (handler *Handler) GetPersonalAccessTokensHandler(c *gin.Context) {
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	personalAccessTokens, err := models.FetchPersonalAccessTokens(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"personal_access_tokens": personalAccessTokens,
+	})
+}
+
+func (handler *Handler) CreatePersonalAccessTokenHandler(c *gin.Context) {
+	var personalAccessTokenInput models.PersonalAccessTokenInput
+	if err := c.ShouldBindJSON(&personalAccessTokenInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	personalAccessToken, tokenString, err := models.CreatePersonalAccessToken(handler.DB, userID, personalAccessTokenInput)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// トークンを表示するのはこの時だけ
+	c.JSON(http.StatusOK, gin.H{
+		"token":                 tokenString,
+		"personal_access_token": personalAccessToken,
+	})
+}
+
+func (handler *Handler) DeletePersonalAccessTokenHandler(c *gin.Context) {
+	// URLからトークンのidを取得
+	tokenID, err := getIdFromURLTail(c)
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	err = models.DeletePersonalAccessToken(handler.DB, userID, tokenID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{})
+}
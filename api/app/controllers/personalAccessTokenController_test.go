@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestPersonalAccessTokenHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/tokens", handler.GetPersonalAccessTokensHandler)
+	r.POST("/tokens", handler.CreatePersonalAccessTokenHandler)
+	r.DELETE("/tokens/:tokenId", handler.DeletePersonalAccessTokenHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	if err := db.Create(&userGroup).Error; err != nil {
+		t.Fatalf("failed to create user group: %v", err)
+	}
+	user := &models.User{
+		Name:        "Test User",
+		Password:    "testPassword123",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	session, _, _ := models.CreateSession(db, user.ID, "TestAgent", "127.0.0.1")
+	tokenString, _ := utils.GenerateSessionToken(user.ID, session.TokenID)
+	newRequest := func(method string, url string, body []byte) *http.Request {
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+
+	var created struct {
+		Token               string                             `json:"token"`
+		PersonalAccessToken models.PersonalAccessTokenResponse `json:"personal_access_token"`
+	}
+
+	t.Run("失敗_不正なスコープ", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{
+			"name":   "CI",
+			"scopes": []string{"users:manage"},
+		})
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, "/tokens", body))
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("発行", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{
+			"name":            "CI",
+			"scopes":          []string{models.ScopeReadTasks},
+			"expires_in_days": 30,
+		})
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, "/tokens", body))
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+
+		json.Unmarshal(resp.Body.Bytes(), &created)
+		if created.Token == "" {
+			t.Errorf("Expected token in response")
+		}
+	})
+
+	t.Run("一覧取得", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, "/tokens", nil))
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if bytes.Contains(resp.Body.Bytes(), []byte(created.Token)) {
+			t.Errorf("Token should not be listed")
+		}
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodDelete, fmt.Sprintf("/tokens/%d", created.PersonalAccessToken.ID), nil))
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if _, err := models.FindActivePersonalAccessToken(db, created.Token); err == nil {
+			t.Errorf("Token should be deleted")
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&models.PersonalAccessToken{})
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Session{})
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/models"
+)
+
+func (handler *Handler) GetRecurringTasksHandler(c *gin.Context) {
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	handler.respondWithRecurringTasks(c, userID)
+}
+
+func (handler *Handler) CreateRecurringTaskHandler(c *gin.Context) {
+	var recurringTaskInput models.RecurringTaskInput
+	if err := c.ShouldBindJSON(&recurringTaskInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
+	// カテゴリーと担当者が同じユーザーグループのものか確認
+	err = models.ValidateTaskReferences(handler.DB, recurringTaskInput.CategoryID, recurringTaskInput.Responsible, userGroupID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	startDate, err := parseDate(recurringTaskInput.StartDate)
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "開始日のフォーマットが不正です")
+		return
+	}
+
+	newRecurringTask := &models.RecurringTask{
+		Task:        recurringTaskInput.Task,
+		Description: recurringTaskInput.Description,
+		Creator:     userID,
+		CategoryID:  recurringTaskInput.CategoryID,
+		Responsible: recurringTaskInput.Responsible,
+		Estimate:    *recurringTaskInput.Estimate,
+		RRule:       recurringTaskInput.RRule,
+		StartDate:   startDate,
+	}
+
+	err = newRecurringTask.CreateRecurringTask(handler.DB, handler.TaskConfig.RecurringTaskHorizon(time.Now()))
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithRecurringTasks(c, userID)
+}
+
+// 繰り返しタスクを編集する（作成済みのタスクは変更しない）
+func (handler *Handler) UpdateRecurringTaskHandler(c *gin.Context) {
+	var recurringTaskInput models.RecurringTaskInput
+	if err := c.ShouldBindJSON(&recurringTaskInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, userGroupID, id, ok := handler.authorizeRecurringTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	// 変更後のカテゴリーと担当者が同じユーザーグループのものか確認
+	err := models.ValidateTaskReferences(handler.DB, recurringTaskInput.CategoryID, recurringTaskInput.Responsible, userGroupID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	startDate, err := parseDate(recurringTaskInput.StartDate)
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "開始日のフォーマットが不正です")
+		return
+	}
+
+	updateRecurringTask := &models.RecurringTask{
+		Task:        recurringTaskInput.Task,
+		Description: recurringTaskInput.Description,
+		CategoryID:  recurringTaskInput.CategoryID,
+		Responsible: recurringTaskInput.Responsible,
+		Estimate:    *recurringTaskInput.Estimate,
+		RRule:       recurringTaskInput.RRule,
+		StartDate:   startDate,
+	}
+
+	err = updateRecurringTask.UpdateRecurringTask(handler.DB, id, handler.TaskConfig.RecurringTaskHorizon(time.Now()))
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithRecurringTasks(c, userID)
+}
+
+func (handler *Handler) StopRecurringTaskHandler(c *gin.Context) {
+	userID, _, id, ok := handler.authorizeRecurringTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	if err := models.StopRecurringTask(handler.DB, id); err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	handler.respondWithRecurringTasks(c, userID)
+}
+
+// まだタスクを作成していない予定日を1回だけスキップする
+func (handler *Handler) SkipRecurringTaskOccurrenceHandler(c *gin.Context) {
+	var recurringTaskSkipInput models.RecurringTaskSkipInput
+	if err := c.ShouldBindJSON(&recurringTaskSkipInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _, id, ok := handler.authorizeRecurringTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	date, err := parseDate(recurringTaskSkipInput.Date)
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "日付のフォーマットが不正です")
+		return
+	}
+
+	err = models.SkipRecurringTaskOccurrence(handler.DB, id, date)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithRecurringTasks(c, userID)
+}
+
+func (handler *Handler) DeleteRecurringTaskHandler(c *gin.Context) {
+	userID, _, id, ok := handler.authorizeRecurringTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DeleteRecurringTask(handler.DB, id); err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	handler.respondWithRecurringTasks(c, userID)
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// URLの繰り返しタスクがログイン中のユーザーのユーザーグループのものか確認する
+func (handler *Handler) authorizeRecurringTaskFromParam(c *gin.Context) (uint, uint, int, bool) {
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return 0, 0, 0, false
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return 0, 0, 0, false
+	}
+
+	// URLからrecurringTaskのidを取得
+	id, err := getIdFromParam(c, "recurringTaskId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return 0, 0, 0, false
+	}
+
+	if err := models.AuthorizeRecurringTask(handler.DB, id, userGroupID); err != nil {
+		respondWithAuthorizationError(c, err)
+		return 0, 0, 0, false
+	}
+
+	return userID, userGroupID, id, true
+}
+
+func (handler *Handler) respondWithRecurringTasks(c *gin.Context, userID uint) {
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
+	recurringTasks, err := models.FetchRecurringTasks(handler.DB, userGroupID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recurring_tasks": recurringTasks,
+	})
+}
+
+// 日付をstring型からtime.Time型に変換する（時刻付きと日付のみの両方に対応）
+func parseDate(value string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02T15:04:05Z07:00", value)
+	if err != nil {
+		date, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			log.Printf("Invalid date format: %v", err)
+			return date, err
+		}
+	}
+
+	return date, nil
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestRecurringTaskHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/recurring-tasks", handler.GetRecurringTasksHandler)
+	r.POST("/recurring-tasks", handler.CreateRecurringTaskHandler)
+	r.PUT("/recurring-tasks/:recurringTaskId", handler.UpdateRecurringTaskHandler)
+	r.PUT("/recurring-tasks/:recurringTaskId/stop", handler.StopRecurringTaskHandler)
+	r.POST("/recurring-tasks/:recurringTaskId/skips", handler.SkipRecurringTaskOccurrenceHandler)
+	r.DELETE("/recurring-tasks/:recurringTaskId", handler.DeleteRecurringTaskHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	db.Create(&userGroup)
+	otherUserGroup := &models.UserGroup{UserGroup: "Other UserGroup"}
+	db.Create(&otherUserGroup)
+	user := &models.User{Name: "Test User", Password: "testPassword123", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(&user)
+	otherUser := &models.User{Name: "Other User", Password: "testPassword123", Email: "other@example.com", UserGroupID: otherUserGroup.ID}
+	db.Create(&otherUser)
+	category := &models.Category{Category: "Test Category", UserGroupID: userGroup.ID}
+	db.Create(&category)
+
+	newRequest := func(userID uint, method string, url string, body interface{}) *http.Request {
+		tokenString, _ := utils.GenerateSessionToken(userID, "test_session_id")
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+
+	today := time.Now().Format("2006-01-02")
+	recurringTaskInput := models.RecurringTaskInput{
+		Task:        "Weekly Chore",
+		Description: "This is a test task",
+		StartDate:   today,
+		Estimate:    ptrToUint(1),
+		Responsible: user.ID,
+		CategoryID:  category.ID,
+		RRule:       "FREQ=DAILY;INTERVAL=7",
+	}
+
+	var response struct {
+		RecurringTasks []models.RecurringTaskResponse `json:"recurring_tasks"`
+	}
+
+	t.Run("作成", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPost, "/recurring-tasks", recurringTaskInput))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.RecurringTasks) != 1 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+
+		// 今日が予定日のタスクを作成する
+		var count int64
+		db.Model(&models.Task{}).Where("recurring_task_id = ?", response.RecurringTasks[0].ID).Count(&count)
+		if count != 1 {
+			t.Errorf("Expected 1 generated task, got: %v", count)
+		}
+	})
+
+	t.Run("失敗_不正な繰り返しルール", func(t *testing.T) {
+		input := recurringTaskInput
+		input.RRule = "FREQ=HOURLY"
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPost, "/recurring-tasks", input))
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("失敗_他のユーザーグループの繰り返しタスク", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(otherUser.ID, http.MethodPut, fmt.Sprintf("/recurring-tasks/%d/stop", response.RecurringTasks[0].ID), nil))
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("スキップ", func(t *testing.T) {
+		nextOccurrence := response.RecurringTasks[0].NextOccurrence
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPost, fmt.Sprintf("/recurring-tasks/%d/skips", response.RecurringTasks[0].ID), models.RecurringTaskSkipInput{Date: nextOccurrence}))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.RecurringTasks[0].SkippedDates) != 1 || response.RecurringTasks[0].NextOccurrence == nextOccurrence {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+
+		// 作成済みの予定日
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPost, fmt.Sprintf("/recurring-tasks/%d/skips", response.RecurringTasks[0].ID), models.RecurringTaskSkipInput{Date: today}))
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("編集と停止", func(t *testing.T) {
+		input := recurringTaskInput
+		input.Task = "Monthly Chore"
+		input.RRule = "FREQ=MONTHLY;BYMONTHDAY=1"
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPut, fmt.Sprintf("/recurring-tasks/%d", response.RecurringTasks[0].ID), input))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || response.RecurringTasks[0].Task != "Monthly Chore" {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPut, fmt.Sprintf("/recurring-tasks/%d/stop", response.RecurringTasks[0].ID), nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || !response.RecurringTasks[0].Stopped || response.RecurringTasks[0].NextOccurrence != "" {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodDelete, fmt.Sprintf("/recurring-tasks/%d", response.RecurringTasks[0].ID), nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.RecurringTasks) != 0 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Where("category_id = ?", category.ID).Delete(&models.Task{})
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&userGroup)
+	db.Unscoped().Delete(&otherUserGroup)
+}
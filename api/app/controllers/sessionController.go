@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func (handler *Handler) GetCurrentUserSessionsHandler(c *gin.Context) {
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// 現在のセッションを判別するためにトークンIDを取得
+	sessionID, err := extractSessionID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract session ID")
+		return
+	}
+
+	sessions, err := models.FetchSessions(handler.DB, userID, sessionID)
+	if err != nil {
+		log.Printf("セッションの取得に失敗しました")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions" : sessions,  // sessionsをレスポンスとして返す
+	})
+}
+
+func (handler *Handler) RevokeSessionHandler(c *gin.Context) {
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// URLからsessionのidを取得
+	id, err := getIdFromURLTail(c)
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	err = models.RevokeSession(handler.DB, userID, id)
+	if errors.Is(err, models.ErrNotFound) {
+		respondWithAuthorizationError(c, err)
+		return
+	} else if err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sessionID, err := extractSessionID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract session ID")
+		return
+	}
+
+	sessions, err := models.FetchSessions(handler.DB, userID, sessionID)
+	if err != nil {
+		log.Printf("セッションの取得に失敗しました")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions" : sessions,  // sessionsをレスポンスとして返す
+	})
+}
+
+func (handler *Handler) RevokeAllSessionsHandler(c *gin.Context) {
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// このブラウザのセッションも含めてすべて失効させる
+	err = models.RevokeAllSessions(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	clearSessionCookies(c)
+
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func extractSessionID(c *gin.Context) (string, error) {
+	tokenString, err := c.Cookie(constant.JWT_TOKEN_NAME)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := utils.ParseSessionToken(tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.GetSessionIDFromToken(token)
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"fmt"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestSessionHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/sessions", handler.GetCurrentUserSessionsHandler)
+	r.DELETE("/sessions", handler.RevokeAllSessionsHandler)
+	r.DELETE("/sessions/:id", handler.RevokeSessionHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	if err := db.Create(&userGroup).Error; err != nil {
+		t.Fatalf("failed to create user group: %v", err)
+	}
+	user := &models.User{
+		Name:        "Test User",
+		Password:    "testPassword123",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	otherUser := &models.User{
+		Name:        "Other User",
+		Password:    "testPassword123",
+		Email:       "other@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	if err := db.Create(&otherUser).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	currentSession, _, _ := models.CreateSession(db, user.ID, "CurrentAgent", "127.0.0.1")
+	anotherSession, _, _ := models.CreateSession(db, user.ID, "AnotherAgent", "127.0.0.1")
+	otherSession, _, _ := models.CreateSession(db, otherUser.ID, "OtherAgent", "127.0.0.1")
+
+	tokenString, _ := utils.GenerateSessionToken(user.ID, currentSession.TokenID)
+	newRequest := func(method string, url string) *http.Request {
+		req, _ := http.NewRequest(method, url, nil)
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+
+	t.Run("一覧取得", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, "/sessions"))
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+
+		var response struct {
+			Sessions []models.SessionResponse `json:"sessions"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if len(response.Sessions) != 2 {
+			t.Errorf("Expected 2 sessions, got: %v", len(response.Sessions))
+		}
+	})
+
+	t.Run("失敗_他のユーザーのセッションの失効", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodDelete, fmt.Sprintf("/sessions/%d", otherSession.ID)))
+
+		if resp.Code != http.StatusNotFound {
+			t.Errorf("Expected HTTP 404 Not Found, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if _, err := models.FindActiveSession(db, otherSession.TokenID); err != nil {
+			t.Errorf("Other user's session should not be revoked: %v", err)
+		}
+	})
+
+	t.Run("1件失効", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodDelete, fmt.Sprintf("/sessions/%d", anotherSession.ID)))
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if _, err := models.FindActiveSession(db, anotherSession.TokenID); err == nil {
+			t.Errorf("Session should be revoked")
+		}
+	})
+
+	t.Run("すべて失効", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodDelete, "/sessions"))
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if _, err := models.FindActiveSession(db, currentSession.TokenID); err == nil {
+			t.Errorf("Current session should be revoked")
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Where("user_id IN ?", []uint{user.ID, otherUser.ID}).Delete(&models.Session{})
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/models"
+)
+
+func (handler *Handler) GetSubtasksHandler(c *gin.Context) {
+	_, parentID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	handler.respondWithSubtasks(c, parentID)
+}
+
+func (handler *Handler) AddSubtaskHandler(c *gin.Context) {
+	var subtaskInput models.SubtaskInput
+	if err := c.ShouldBindJSON(&subtaskInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, parentID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
+	// サブタスクにするタスクもユーザーグループのものか確認
+	err = models.AuthorizeTask(handler.DB, int(subtaskInput.SubtaskID), userGroupID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	err = models.AddSubtask(handler.DB, parentID, int(subtaskInput.SubtaskID))
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithSubtasks(c, parentID)
+}
+
+func (handler *Handler) ReorderSubtasksHandler(c *gin.Context) {
+	var subtaskOrderInput models.SubtaskOrderInput
+	if err := c.ShouldBindJSON(&subtaskOrderInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, parentID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	// 親タスクのサブタスク以外が指定された場合はエラーになる
+	err := models.ReorderSubtasks(handler.DB, parentID, subtaskOrderInput.SubtaskIDs)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithSubtasks(c, parentID)
+}
+
+func (handler *Handler) DetachSubtaskHandler(c *gin.Context) {
+	_, parentID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	// URLからsubtaskのidを取得
+	subtaskID, err := getIdFromParam(c, "subtaskId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	err = models.DetachSubtask(handler.DB, parentID, subtaskID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithSubtasks(c, parentID)
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func (handler *Handler) respondWithSubtasks(c *gin.Context, parentID int) {
+	subtasks, err := models.FetchSubtasks(handler.DB, parentID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"subtasks": subtasks,
+	})
+}
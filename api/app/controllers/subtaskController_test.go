@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestSubtaskHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+	cascadeHandler := &Handler{DB: db, TaskConfig: utils.TaskConfig{CascadeSubtaskCompletion: true}}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/tasks/:taskId/subtasks", handler.GetSubtasksHandler)
+	r.POST("/tasks/:taskId/subtasks", handler.AddSubtaskHandler)
+	r.PUT("/tasks/:taskId/subtasks/order", handler.ReorderSubtasksHandler)
+	r.DELETE("/tasks/:taskId/subtasks/:subtaskId", handler.DetachSubtaskHandler)
+	r.PUT("/tasks/:taskId/to-completed", handler.UpdateTaskToMoveToCompletedHandler)
+	r.PUT("/cascade/tasks/:taskId/to-completed", cascadeHandler.UpdateTaskToMoveToCompletedHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	db.Create(&userGroup)
+	otherUserGroup := &models.UserGroup{UserGroup: "Other UserGroup"}
+	db.Create(&otherUserGroup)
+	user := &models.User{Name: "Test User", Password: "testPassword123", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(&user)
+	otherUser := &models.User{Name: "Other User", Password: "testPassword123", Email: "other@example.com", UserGroupID: otherUserGroup.ID}
+	db.Create(&otherUser)
+	category := &models.Category{Category: "Test Category", UserGroupID: userGroup.ID}
+	db.Create(&category)
+	otherCategory := &models.Category{Category: "Other Category", UserGroupID: otherUserGroup.ID}
+	db.Create(&otherCategory)
+	newTask := func(categoryID uint, responsible uint) *models.Task {
+		task := &models.Task{
+			Task:        "Sample Task",
+			Description: "This is a test task",
+			Creator:     responsible,
+			CategoryID:  categoryID,
+			Status:      1,
+			Responsible: responsible,
+			Estimate:    ptrToUint(5),
+			StartDate:   ptrToTime(time.Now()),
+		}
+		db.Create(&task)
+		return task
+	}
+	parent := newTask(category.ID, user.ID)
+	first := newTask(category.ID, user.ID)
+	second := newTask(category.ID, user.ID)
+	otherTask := newTask(otherCategory.ID, otherUser.ID)
+
+	newRequest := func(method string, url string, body interface{}) *http.Request {
+		tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+	subtasksURL := fmt.Sprintf("/tasks/%d/subtasks", parent.ID)
+
+	var response struct {
+		Subtasks []models.TaskResponse `json:"subtasks"`
+	}
+
+	t.Run("追加", func(t *testing.T) {
+		for _, subtask := range []*models.Task{first, second} {
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, newRequest(http.MethodPost, subtasksURL, models.SubtaskInput{SubtaskID: subtask.ID}))
+
+			if resp.Code != http.StatusOK {
+				t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+				t.Errorf("Error: %v", resp.Body.String())
+			}
+		}
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, subtasksURL, nil))
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if len(response.Subtasks) != 2 || response.Subtasks[0].ID != first.ID {
+			t.Errorf("Unexpected subtasks: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_他のユーザーグループのタスク", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, subtasksURL, models.SubtaskInput{SubtaskID: otherTask.ID}))
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("並び替え", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPut, subtasksURL+"/order", models.SubtaskOrderInput{SubtaskIDs: []uint{second.ID}}))
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		}
+
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPut, subtasksURL+"/order", models.SubtaskOrderInput{SubtaskIDs: []uint{second.ID, first.ID}}))
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || response.Subtasks[0].ID != second.ID {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("Look Backへの移動", func(t *testing.T) {
+		input := models.TaskInput{
+			Task:        "Sample Task",
+			Description: "This is a test task",
+			CategoryID:  category.ID,
+			Status:      4,
+			Responsible: user.ID,
+			Estimate:    ptrToUint(5),
+			StartDate:   "2023-01-01T00:00:00Z",
+		}
+
+		// 完了していないサブタスクがある場合は移動できない
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPut, fmt.Sprintf("/tasks/%d/to-completed", parent.ID), input))
+		if resp.Code != http.StatusConflict {
+			t.Errorf("Expected HTTP 409 Conflict, got: %v", resp.Code)
+		}
+
+		// 設定によってはサブタスクもあわせて移動する
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPut, fmt.Sprintf("/cascade/tasks/%d/to-completed", parent.ID), input))
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+		}
+		var subtask models.Task
+		db.First(&subtask, first.ID)
+		if subtask.Status != 4 {
+			t.Errorf("Expected subtask status to be 4, got: %v", subtask.Status)
+		}
+	})
+
+	t.Run("切り離し", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodDelete, fmt.Sprintf("%s/%d", subtasksURL, first.ID), nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Subtasks) != 1 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+
+		// 切り離し済みのタスク
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodDelete, fmt.Sprintf("%s/%d", subtasksURL, first.ID), nil))
+		if resp.Code != http.StatusNotFound {
+			t.Errorf("Expected HTTP 404 Not Found, got: %v", resp.Code)
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Delete(&first)
+	db.Unscoped().Delete(&second)
+	db.Unscoped().Delete(&parent)
+	db.Unscoped().Delete(&otherTask)
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&otherCategory)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&userGroup)
+	db.Unscoped().Delete(&otherUserGroup)
+}
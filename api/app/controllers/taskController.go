@@ -34,6 +34,40 @@ func (handler *Handler) CreateTaskHandler(c *gin.Context) {
 		return
 	}
 
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
+	// カテゴリーと担当者が同じユーザーグループのものか確認
+	err = models.ValidateTaskReferences(handler.DB, createTaskInput.CategoryID, createTaskInput.Responsible, userGroupID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	// ステータスがユーザーグループに存在するか確認
+	err = models.ValidateTaskStatus(handler.DB, createTaskInput.Status, userGroupID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	// ラベルが同じユーザーグループのものか確認
+	err = models.ValidateTaskLabels(handler.DB, createTaskInput.LabelIDs, userGroupID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	// WIP制限を超える場合は設定に従って拒否または警告する
+	wipWarnings, ok := handler.checkWipLimits(c, userGroupID, 0, createTaskInput.Status, createTaskInput.Responsible)
+	if !ok {
+		return
+	}
+
 	// StartDateをstring型から*time.Time型に変換
 	layout := "2006-01-02T15:04:05Z07:00"
 	startDate, err := time.Parse(layout, createTaskInput.StartDate)
@@ -52,6 +86,7 @@ func (handler *Handler) CreateTaskHandler(c *gin.Context) {
 		Responsible: createTaskInput.Responsible,
 		Estimate:    createTaskInput.Estimate,
 		StartDate:   &startDate,
+		LabelIDs:    createTaskInput.LabelIDs,
 	}
 
 	err = newTask.CreateTask(handler.DB)
@@ -60,15 +95,7 @@ func (handler *Handler) CreateTaskHandler(c *gin.Context) {
 		return
 	}
 
-	tasks, err := models.FetchTaskBoardTasks(handler.DB, userID)
-	if err != nil {
-		respondWithError(c, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"tasks"   : tasks,
-	})
+	handler.respondWithTaskBoard(c, userID, userGroupID, wipWarnings)
 }
 
 func (handler *Handler) GetTaskBoardTasksHandler(c *gin.Context) {
@@ -80,19 +107,26 @@ func (handler *Handler) GetTaskBoardTasksHandler(c *gin.Context) {
 		return
 	}
 
-	tasks, err := models.FetchTaskBoardTasks(handler.DB, userID)
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, err.Error())
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
 		return
 	}
-	
-	c.JSON(http.StatusOK, gin.H{
-		"tasks"   : tasks,  // tasksをレスポンスとして返す
-	})
+
+	handler.respondWithTaskBoard(c, userID, userGroupID, []models.WipLimitViolation{})
 }
 
 func (handler *Handler) GetLookBackTasksHandler(c *gin.Context) {
-	
+	// ラベルで絞り込む
+	var taskQuery models.TaskQuery
+	if err := c.ShouldBindQuery(&taskQuery); err != nil {
+		log.Printf("Invalid query: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Cookie内のjwtからUSER_IDを取得
 	userID, err := extractUserID(c)
 	if err != nil {
@@ -100,7 +134,7 @@ func (handler *Handler) GetLookBackTasksHandler(c *gin.Context) {
 		return
 	}
 
-	tasks, err := models.FetchLookBackTasks(handler.DB, userID)
+	tasks, err := models.FetchLookBackTasks(handler.DB, userID, taskQuery)
 	if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
@@ -142,6 +176,21 @@ func (handler *Handler) UpdateTaskHandler(c *gin.Context) {
 		Responsible: updateTaskInput.Responsible,
 		Estimate:    updateTaskInput.Estimate,
 		StartDate:   &startDate,
+		LabelIDs:    updateTaskInput.LabelIDs,
+	}
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
 	}
 
 	// URLからtaskのidを取得
@@ -151,28 +200,41 @@ func (handler *Handler) UpdateTaskHandler(c *gin.Context) {
 		return
 	}
 
-	err = updateTask.UpdateTask(handler.DB, id)
+	// 更新対象のタスクがユーザーグループのものか確認
+	err = models.AuthorizeTask(handler.DB, id, userGroupID)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, err.Error())
+		respondWithAuthorizationError(c, err)
 		return
 	}
 
-	// Cookie内のjwtからUSER_IDを取得
-	userID, err := extractUserID(c)
+	// 変更後のカテゴリーと担当者が同じユーザーグループのものか確認
+	err = models.ValidateTaskReferences(handler.DB, updateTask.CategoryID, updateTask.Responsible, userGroupID)
 	if err != nil {
-		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		respondWithAuthorizationError(c, err)
 		return
 	}
 
-	tasks, err := models.FetchTaskBoardTasks(handler.DB, userID)
+	// ラベルが同じユーザーグループのものか確認
+	err = models.ValidateTaskLabels(handler.DB, updateTask.LabelIDs, userGroupID)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, err.Error())
+		respondWithAuthorizationError(c, err)
 		return
 	}
-	
-	c.JSON(http.StatusOK, gin.H{
-		"tasks"   : tasks,  // tasksをレスポンスとして返す
-	})
+
+	// WIP制限を超える場合は設定に従って拒否または警告する
+	wipWarnings, ok := handler.checkWipLimits(c, userGroupID, id, updateTask.Status, updateTask.Responsible)
+	if !ok {
+		return
+	}
+
+	// ステータスの遷移はユーザーグループの設定に、サブタスクの扱いは設定に従う
+	err = updateTask.UpdateTask(handler.DB, id, handler.TaskConfig.CascadeSubtaskCompletion, userID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTaskBoard(c, userID, userGroupID, wipWarnings)
 }
 
 func (handler *Handler) UpdateTaskToMoveToCompletedHandler(c *gin.Context) {
@@ -184,8 +246,18 @@ func (handler *Handler) UpdateTaskToMoveToCompletedHandler(c *gin.Context) {
 		return
 	}
 	
-	updateTask := &models.Task{
-		Status:      updateTaskInput.Status,
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
 	}
 
 	// URLからtaskのidを取得
@@ -195,20 +267,33 @@ func (handler *Handler) UpdateTaskToMoveToCompletedHandler(c *gin.Context) {
 		return
 	}
 
-	err = updateTask.UpdateTask(handler.DB, id)
+	// 更新対象のタスクがユーザーグループのものか確認
+	err = models.AuthorizeTask(handler.DB, id, userGroupID)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, err.Error())
+		respondWithAuthorizationError(c, err)
 		return
 	}
 
-	// Cookie内のjwtからUSER_IDを取得
-	userID, err := extractUserID(c)
+	responsible, err := models.FetchTaskResponsible(handler.DB, id)
 	if err != nil {
-		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		respondWithAuthorizationError(c, err)
 		return
 	}
 
-	tasks, err := models.FetchLookBackTasks(handler.DB, userID)
+	// WIP制限を超える場合は設定に従って拒否または警告する
+	wipWarnings, ok := handler.checkWipLimits(c, userGroupID, id, updateTaskInput.Status, responsible)
+	if !ok {
+		return
+	}
+
+	// サブタスクの扱いは設定に従う
+	err = models.UpdateTaskStatus(handler.DB, id, updateTaskInput.Status, handler.TaskConfig.CascadeSubtaskCompletion, userID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	tasks, err := models.FetchLookBackTasks(handler.DB, userID, models.TaskQuery{})
 	if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
@@ -216,11 +301,26 @@ func (handler *Handler) UpdateTaskToMoveToCompletedHandler(c *gin.Context) {
 	
 	c.JSON(http.StatusOK, gin.H{
 		"tasks"   : tasks,  // tasksをレスポンスとして返す
+		"wip_warnings": wipWarnings,
 	})
 }
 
 func (handler *Handler) DeleteTaskHandler(c *gin.Context) {
 
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
 	// URLからtaskのidを取得
 	id, err := getIdFromURLTail(c)
 	if err != nil {
@@ -228,22 +328,22 @@ func (handler *Handler) DeleteTaskHandler(c *gin.Context) {
 		return
 	}
 
-	deleteTask := &models.Task{}
-
-	err = deleteTask.DeleteTask(handler.DB, id)
+	// 削除対象のタスクがユーザーグループのものか確認
+	err = models.AuthorizeTask(handler.DB, id, userGroupID)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, err.Error())
+		respondWithAuthorizationError(c, err)
 		return
 	}
 
-	// Cookie内のjwtからUSER_IDを取得
-	userID, err := extractUserID(c)
+	deleteTask := &models.Task{}
+
+	err = deleteTask.DeleteTask(handler.DB, id, userID)
 	if err != nil {
-		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	tasks, err := models.FetchTaskBoardTasks(handler.DB, userID)
+	tasks, err := models.FetchTaskBoardTasks(handler.DB, userID, models.TaskQuery{})
 	if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
@@ -258,12 +358,20 @@ func (handler *Handler) DeleteTaskHandler(c *gin.Context) {
 // 以下はプライベート関数
 // ==================================================================
 func extractUserID(c *gin.Context) (uint, error) {
+	// AuthMiddlewareで認証済みの場合（パーソナルアクセストークンを含む）
+	if userID := c.GetUint(constant.AUTH_USER_ID_KEY); userID != 0 {
+		return userID, nil
+	}
+
 	tokenString, err := c.Cookie(constant.JWT_TOKEN_NAME)
 	if err != nil {
 		return 0, err
 	}
 
-	token, _ := utils.ParseSessionToken(tokenString)
+	token, err := utils.ParseSessionToken(tokenString)
+	if err != nil {
+		return 0, err
+	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
 		return 0, errors.New("failed to parse claims")
@@ -311,4 +419,54 @@ func getIdFromSecondLastPartOfURL(c *gin.Context) (int, error) {
 	}
 
 	return id, nil
-}
\ No newline at end of file
+}
+
+// WIP制限を超える場合、設定に従って409を返すか、超えたWIP制限を警告として返す
+func (handler *Handler) checkWipLimits(c *gin.Context, userGroupID uint, taskID int, status uint, responsible uint) ([]models.WipLimitViolation, bool) {
+	violations, err := models.CheckWipLimits(handler.DB, userGroupID, taskID, status, responsible)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return nil, false
+	}
+
+	if len(violations) > 0 && handler.TaskConfig.RejectOverWipLimit {
+		log.Printf("WIP制限を超えるためタスクを作成・変更しません")
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        models.ErrWipLimitExceeded.Error(),
+			"message":      models.ErrWipLimitExceeded.Error(),
+			"wip_warnings": violations,
+		})
+		return nil, false
+	}
+
+	return violations, true
+}
+
+// タスクボードのタスクとWIP制限に対する件数を返す
+// タスクはクエリのラベルで絞り込む（WIP制限に対する件数は絞り込まない）
+func (handler *Handler) respondWithTaskBoard(c *gin.Context, userID uint, userGroupID uint, wipWarnings []models.WipLimitViolation) {
+	var taskQuery models.TaskQuery
+	if err := c.ShouldBindQuery(&taskQuery); err != nil {
+		log.Printf("Invalid query: %v", err)
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tasks, err := models.FetchTaskBoardTasks(handler.DB, userID, taskQuery)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wipCounts, err := models.FetchWipCounts(handler.DB, userGroupID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tasks":        tasks, // tasksをレスポンスとして返す
+		"wip":          wipCounts,
+		"wip_warnings": wipWarnings,
+	})
+}
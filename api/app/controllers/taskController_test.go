@@ -58,7 +58,7 @@ func TestCreateTaskHandler(t *testing.T) {
 		if err := db.Create(&category).Error; err != nil {
 			t.Fatalf("failed to create category: %v", err)
 		}
-		tokenString, _ := utils.GenerateSessionToken(uint(user.ID))
+		tokenString, _ := utils.GenerateSessionToken(uint(user.ID), "test_session_id")
 
 		estimateValue := uint(5)
 		taskInput := models.TaskInput{
@@ -150,7 +150,7 @@ func TestGetTaskBoardTasksHandler(t *testing.T) {
 		if err := db.Create(&task).Error; err != nil {
 			t.Fatalf("failed to create task: %v", err)
 		}
-		tokenString, _ := utils.GenerateSessionToken(uint(user.ID))
+		tokenString, _ := utils.GenerateSessionToken(uint(user.ID), "test_session_id")
 
 		req, _ := http.NewRequest(http.MethodGet, "/tasks", nil)
 		resp := httptest.NewRecorder()
@@ -230,7 +230,7 @@ func TestGetLookBackTasksHandler(t *testing.T) {
 		if err := db.Create(&task).Error; err != nil {
 			t.Fatalf("failed to create task: %v", err)
 		}
-		tokenString, _ := utils.GenerateSessionToken(uint(user.ID))
+		tokenString, _ := utils.GenerateSessionToken(uint(user.ID), "test_session_id")
 
 		req, _ := http.NewRequest(http.MethodGet, "/lookback-tasks", nil)
 		resp := httptest.NewRecorder()
@@ -316,7 +316,7 @@ func TestUpdateTaskHandler(t *testing.T) {
 		if err := db.Create(&task).Error; err != nil {
 			t.Fatalf("failed to create task: %v", err)
 		}
-		tokenString, _ := utils.GenerateSessionToken(uint(user1.ID))
+		tokenString, _ := utils.GenerateSessionToken(uint(user1.ID), "test_session_id")
 
 		updateTaskInput := models.TaskInput{
 			Task:        "Updated Task",
@@ -404,7 +404,7 @@ func TestUpdateTaskToMoveToCompletedHandler(t *testing.T) {
 		if err := db.Create(&task).Error; err != nil {
 			t.Fatalf("failed to create task: %v", err)
 		}
-		tokenString, _ := utils.GenerateSessionToken(uint(user.ID))
+		tokenString, _ := utils.GenerateSessionToken(uint(user.ID), "test_session_id")
 
 		updateTaskInput := models.TaskInput{
 			Task:        "Updated Task",
@@ -500,7 +500,7 @@ func TestDeleteTaskHandler(t *testing.T) {
 		if err := db.Create(&task).Error; err != nil {
 			t.Fatalf("failed to create task: %v", err)
 		}
-		tokenString, _ := utils.GenerateSessionToken(uint(user.ID))
+		tokenString, _ := utils.GenerateSessionToken(uint(user.ID), "test_session_id")
 
 		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/tasks/%d", task.ID), nil)
 		resp := httptest.NewRecorder()
@@ -539,4 +539,115 @@ func ptrToUint(u uint) *uint {
 
 func ptrToTime(t time.Time) *time.Time {
 	return &t
-}
\ No newline at end of file
+}
+func TestWipLimitHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	rejectHandler := &Handler{DB: db, TaskConfig: utils.TaskConfig{RejectOverWipLimit: true}}
+	warnHandler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.POST("/reject/tasks", rejectHandler.CreateTaskHandler)
+	r.POST("/warn/tasks", warnHandler.CreateTaskHandler)
+	r.PUT("/reject/tasks/:taskId/to-completed", rejectHandler.UpdateTaskToMoveToCompletedHandler)
+	r.GET("/tasks/task-board", warnHandler.GetTaskBoardTasksHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	db.Create(&userGroup)
+	user := &models.User{Name: "Test User", Password: "testPassword123", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(&user)
+	category := &models.Category{Category: "Test Category", UserGroupID: userGroup.ID}
+	db.Create(&category)
+	task := &models.Task{Task: "In Progress", Description: "Test Description", Creator: user.ID, CategoryID: category.ID, Status: 2, Responsible: user.ID, Estimate: ptrToUint(5), StartDate: ptrToTime(time.Now())}
+	db.Create(&task)
+
+	// 担当者ごとの進行中のタスクは1件まで
+	models.UpdateWorkflowStatus(db, userGroup.ID, 2, models.WorkflowStatusInput{Name: "進行中", Order: 2, Color: "#2196F3", Kind: models.WorkflowStatusKindActive, AssigneeWipLimit: 1})
+
+	newRequest := func(method string, url string, body interface{}) *http.Request {
+		tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+	taskInput := models.TaskInput{
+		Task:        "Test Task",
+		Description: "Test Description",
+		CategoryID:  category.ID,
+		Status:      2,
+		Responsible: user.ID,
+		Estimate:    ptrToUint(5),
+		StartDate:   "2023-01-01T00:00:00Z",
+	}
+
+	var response struct {
+		Wip         []models.WipCountResponse  `json:"wip"`
+		WipWarnings []models.WipLimitViolation `json:"wip_warnings"`
+	}
+
+	t.Run("拒否", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, "/reject/tasks", taskInput))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusConflict || len(response.WipWarnings) != 1 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("拒否_ステータスの変更", func(t *testing.T) {
+		todoTask := &models.Task{Task: "Todo", Description: "Test Description", Creator: user.ID, CategoryID: category.ID, Status: 1, Responsible: user.ID, Estimate: ptrToUint(5), StartDate: ptrToTime(time.Now())}
+		db.Create(&todoTask)
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPut, fmt.Sprintf("/reject/tasks/%d/to-completed", todoTask.ID), taskInput))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusConflict || len(response.WipWarnings) != 1 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+
+		// ステータスは変更されない
+		var unchanged models.Task
+		db.First(&unchanged, todoTask.ID)
+		if unchanged.Status != 1 {
+			t.Errorf("Expected status 1, got: %v", unchanged.Status)
+		}
+	})
+
+	t.Run("警告", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, "/warn/tasks", taskInput))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.WipWarnings) != 1 || response.WipWarnings[0].Count != 2 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("タスクボードの件数", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, "/tasks/task-board", nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Wip) != 1 || response.Wip[0].Assignees[0].Count != 2 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Where("user_group_id = ?", userGroup.ID).Delete(&models.WorkflowStatus{})
+	db.Unscoped().Where("category_id = ?", category.ID).Delete(&models.Task{})
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/models"
+)
+
+func (handler *Handler) GetTaskDependenciesHandler(c *gin.Context) {
+	_, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	handler.respondWithTaskDependencies(c, taskID)
+}
+
+// URLのタスクが指定したタスクに依存する関係を追加する
+func (handler *Handler) AddBlockerHandler(c *gin.Context) {
+	userID, taskID, blockerID, ok := handler.bindTaskDependency(c)
+	if !ok {
+		return
+	}
+
+	if !handler.authorizeDependencyTask(c, userID, blockerID) {
+		return
+	}
+
+	err := models.CreateTaskDependency(handler.DB, uint(taskID), uint(blockerID))
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTaskDependencies(c, taskID)
+}
+
+func (handler *Handler) RemoveBlockerHandler(c *gin.Context) {
+	_, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	// URLから依存先のタスクのidを取得
+	blockerID, err := getIdFromParam(c, "blockerId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	err = models.DeleteTaskDependency(handler.DB, uint(taskID), uint(blockerID))
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTaskDependencies(c, taskID)
+}
+
+// 指定したタスクがURLのタスクに依存する関係を追加する
+func (handler *Handler) AddBlockedTaskHandler(c *gin.Context) {
+	userID, taskID, blockedID, ok := handler.bindTaskDependency(c)
+	if !ok {
+		return
+	}
+
+	if !handler.authorizeDependencyTask(c, userID, blockedID) {
+		return
+	}
+
+	err := models.CreateTaskDependency(handler.DB, uint(blockedID), uint(taskID))
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTaskDependencies(c, taskID)
+}
+
+func (handler *Handler) RemoveBlockedTaskHandler(c *gin.Context) {
+	_, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	// URLから依存元のタスクのidを取得
+	blockedID, err := getIdFromParam(c, "blockedId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	err = models.DeleteTaskDependency(handler.DB, uint(blockedID), uint(taskID))
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTaskDependencies(c, taskID)
+}
+
+func (handler *Handler) GetCategoryDependencyGraphHandler(c *gin.Context) {
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
+	// URLからcategoryのidを取得
+	categoryID, err := getIdFromParam(c, "categoryId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	// 取得対象のカテゴリーがユーザーグループのものか確認
+	err = models.AuthorizeCategory(handler.DB, categoryID, userGroupID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	graph, err := models.FetchCategoryDependencyGraph(handler.DB, categoryID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"graph": graph,
+	})
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// リクエストボディから依存関係の相手のタスクを取得し、URLのタスクの権限を確認する
+func (handler *Handler) bindTaskDependency(c *gin.Context) (uint, int, int, bool) {
+	var taskDependencyInput models.TaskDependencyInput
+	if err := c.ShouldBindJSON(&taskDependencyInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return 0, 0, 0, false
+	}
+
+	userID, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	return userID, taskID, int(taskDependencyInput.TaskID), true
+}
+
+// 依存関係の相手のタスクもログイン中のユーザーのユーザーグループのものか確認する
+func (handler *Handler) authorizeDependencyTask(c *gin.Context, userID uint, taskID int) bool {
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return false
+	}
+
+	if err := models.AuthorizeTask(handler.DB, taskID, userGroupID); err != nil {
+		respondWithAuthorizationError(c, err)
+		return false
+	}
+
+	return true
+}
+
+func (handler *Handler) respondWithTaskDependencies(c *gin.Context, taskID int) {
+	dependencies, err := models.FetchTaskDependencies(handler.DB, taskID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dependencies": dependencies,
+	})
+}
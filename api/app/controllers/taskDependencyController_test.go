@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestTaskDependencyHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/tasks/:taskId/dependencies", handler.GetTaskDependenciesHandler)
+	r.POST("/tasks/:taskId/blocked-by", handler.AddBlockerHandler)
+	r.DELETE("/tasks/:taskId/blocked-by/:blockerId", handler.RemoveBlockerHandler)
+	r.POST("/tasks/:taskId/blocks", handler.AddBlockedTaskHandler)
+	r.DELETE("/tasks/:taskId/blocks/:blockedId", handler.RemoveBlockedTaskHandler)
+	r.GET("/categories/:categoryId/dependency-graph", handler.GetCategoryDependencyGraphHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	db.Create(&userGroup)
+	otherUserGroup := &models.UserGroup{UserGroup: "Other UserGroup"}
+	db.Create(&otherUserGroup)
+	user := &models.User{Name: "Test User", Password: "testPassword123", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(&user)
+	otherUser := &models.User{Name: "Other User", Password: "testPassword123", Email: "other@example.com", UserGroupID: otherUserGroup.ID}
+	db.Create(&otherUser)
+	category := &models.Category{Category: "Test Category", UserGroupID: userGroup.ID}
+	db.Create(&category)
+	otherCategory := &models.Category{Category: "Other Category", UserGroupID: otherUserGroup.ID}
+	db.Create(&otherCategory)
+	newTask := func(categoryID uint, responsible uint) *models.Task {
+		task := &models.Task{
+			Task:        "Sample Task",
+			Description: "This is a test task",
+			Creator:     responsible,
+			CategoryID:  categoryID,
+			Status:      1,
+			Responsible: responsible,
+			Estimate:    ptrToUint(5),
+			StartDate:   ptrToTime(time.Now()),
+		}
+		db.Create(&task)
+		return task
+	}
+	design := newTask(category.ID, user.ID)
+	build := newTask(category.ID, user.ID)
+	otherTask := newTask(otherCategory.ID, otherUser.ID)
+
+	newRequest := func(method string, url string, body interface{}) *http.Request {
+		tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+
+	var response struct {
+		Dependencies models.TaskDependenciesResponse `json:"dependencies"`
+	}
+
+	t.Run("追加", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, fmt.Sprintf("/tasks/%d/blocked-by", build.ID), models.TaskDependencyInput{TaskID: design.ID}))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Dependencies.BlockedBy) != 1 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, fmt.Sprintf("/tasks/%d/dependencies", design.ID), nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if len(response.Dependencies.Blocks) != 1 || response.Dependencies.Blocks[0].ID != build.ID {
+			t.Errorf("Unexpected dependencies: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_循環", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, fmt.Sprintf("/tasks/%d/blocks", build.ID), models.TaskDependencyInput{TaskID: design.ID}))
+
+		if resp.Code != http.StatusConflict {
+			t.Errorf("Expected HTTP 409 Conflict, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("失敗_他のユーザーグループのタスク", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, fmt.Sprintf("/tasks/%d/blocked-by", build.ID), models.TaskDependencyInput{TaskID: otherTask.ID}))
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+		}
+
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, fmt.Sprintf("/categories/%d/dependency-graph", otherCategory.ID), nil))
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("依存関係グラフ", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, fmt.Sprintf("/categories/%d/dependency-graph", category.ID), nil))
+
+		var graphResponse struct {
+			Graph models.DependencyGraphResponse `json:"graph"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &graphResponse)
+		if resp.Code != http.StatusOK || len(graphResponse.Graph.Nodes) != 2 || len(graphResponse.Graph.Edges) != 1 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodDelete, fmt.Sprintf("/tasks/%d/blocks/%d", design.ID, build.ID), nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Dependencies.Blocks) != 0 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodDelete, fmt.Sprintf("/tasks/%d/blocked-by/%d", build.ID, design.ID), nil))
+		if resp.Code != http.StatusNotFound {
+			t.Errorf("Expected HTTP 404 Not Found, got: %v", resp.Code)
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Delete(&design)
+	db.Unscoped().Delete(&build)
+	db.Unscoped().Delete(&otherTask)
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&otherCategory)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&userGroup)
+	db.Unscoped().Delete(&otherUserGroup)
+}
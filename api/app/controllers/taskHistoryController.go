@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/models"
+)
+
+func (handler *Handler) GetTaskHistoryHandler(c *gin.Context) {
+	_, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	history, err := models.FetchTaskHistory(handler.DB, taskID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"history": history,
+	})
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestGetTaskHistoryHandler(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/tasks/:taskId/history", handler.GetTaskHistoryHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	db.Create(&userGroup)
+	otherUserGroup := &models.UserGroup{UserGroup: "Other UserGroup"}
+	db.Create(&otherUserGroup)
+	user := &models.User{Name: "Test User", Password: "testPassword123", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(&user)
+	otherUser := &models.User{Name: "Other User", Password: "testPassword123", Email: "other@example.com", UserGroupID: otherUserGroup.ID}
+	db.Create(&otherUser)
+	category := &models.Category{Category: "Test Category", UserGroupID: userGroup.ID}
+	db.Create(&category)
+	task := &models.Task{
+		Task:        "Sample Task",
+		Description: "This is a test task",
+		Creator:     user.ID,
+		CategoryID:  category.ID,
+		Status:      1,
+		Responsible: user.ID,
+		Estimate:    ptrToUint(5),
+		StartDate:   ptrToTime(time.Now()),
+	}
+	task.CreateTask(db)
+	models.UpdateTaskStatus(db, int(task.ID), 2, false, user.ID)
+
+	newRequest := func(userID uint) *http.Request {
+		tokenString, _ := utils.GenerateSessionToken(userID, "test_session_id")
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%d/history", task.ID), nil)
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+
+	t.Run("取得", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID))
+
+		var response struct {
+			History []models.TaskHistoryResponse `json:"history"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.History) != 2 || response.History[1].Action != models.TaskHistoryActionStatus {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_他のユーザーグループのタスク", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(otherUser.ID))
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Where("task_id = ?", task.ID).Delete(&models.TaskHistory{})
+	db.Unscoped().Delete(&task)
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&userGroup)
+	db.Unscoped().Delete(&otherUserGroup)
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/models"
+)
+
+// タスクをタスクボードの列の前後のタスクの間に移動する
+func (handler *Handler) MoveTaskHandler(c *gin.Context) {
+	var moveTaskInput models.TaskMoveInput
+	if err := c.ShouldBindJSON(&moveTaskInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
+	responsible, err := models.FetchTaskResponsible(handler.DB, taskID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	// 別の列に移動する場合はWIP制限を超えるか確認する
+	wipWarnings, ok := handler.checkWipLimits(c, userGroupID, taskID, moveTaskInput.Status, responsible)
+	if !ok {
+		return
+	}
+
+	// サブタスクの扱いは設定に従う
+	err = models.MoveTask(handler.DB, taskID, moveTaskInput, handler.TaskConfig.CascadeSubtaskCompletion, userID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTaskBoard(c, userID, userGroupID, wipWarnings)
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestMoveTaskHandler(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db, TaskConfig: utils.GetTaskConfig()}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.PUT("/tasks/:taskId/move", handler.MoveTaskHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	db.Create(&userGroup)
+	user := &models.User{Name: "Test User", Password: "testPassword123", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(&user)
+	category := &models.Category{Category: "Test Category", UserGroupID: userGroup.ID}
+	db.Create(&category)
+	newTask := func(name string) *models.Task {
+		task := &models.Task{Task: name, Description: "This is a test task", Creator: user.ID, CategoryID: category.ID, Status: 1, Responsible: user.ID, Estimate: ptrToUint(5), StartDate: ptrToTime(time.Now())}
+		task.CreateTask(db)
+		return task
+	}
+	first := newTask("First")
+	second := newTask("Second")
+
+	newRequest := func(taskID uint, body models.TaskMoveInput) *http.Request {
+		tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/tasks/%d/move", taskID), bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+
+	t.Run("成功", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(second.ID, models.TaskMoveInput{Status: 1, After: &first.ID}))
+
+		var response struct {
+			Tasks []models.TaskResponse `json:"tasks"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Tasks) != 2 || response.Tasks[0].ID != second.ID {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_前後のタスクが正しくない", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(second.ID, models.TaskMoveInput{Status: 2, Before: &first.ID}))
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	for _, task := range []*models.Task{first, second} {
+		db.Where("task_id = ?", task.ID).Delete(&models.TaskHistory{})
+		db.Unscoped().Delete(task)
+	}
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/models"
+)
+
+func (handler *Handler) GetTimeEntriesHandler(c *gin.Context) {
+	_, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	handler.respondWithTimeEntries(c, taskID)
+}
+
+func (handler *Handler) StartTimerHandler(c *gin.Context) {
+	userID, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	err := models.StartTimer(handler.DB, taskID, userID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTimeEntries(c, taskID)
+}
+
+func (handler *Handler) StopTimerHandler(c *gin.Context) {
+	userID, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	err := models.StopTimer(handler.DB, taskID, userID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTimeEntries(c, taskID)
+}
+
+// 作業時間を手動で記録する
+func (handler *Handler) CreateTimeEntryHandler(c *gin.Context) {
+	startedAt, stoppedAt, ok := bindTimeEntry(c)
+	if !ok {
+		return
+	}
+
+	userID, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	err := models.CreateTimeEntry(handler.DB, taskID, userID, startedAt, stoppedAt)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTimeEntries(c, taskID)
+}
+
+func (handler *Handler) UpdateTimeEntryHandler(c *gin.Context) {
+	startedAt, stoppedAt, ok := bindTimeEntry(c)
+	if !ok {
+		return
+	}
+
+	userID, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	// URLからtimeEntryのidを取得
+	timeEntryID, err := getIdFromParam(c, "timeEntryId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	err = models.UpdateTimeEntry(handler.DB, taskID, timeEntryID, userID, startedAt, stoppedAt)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTimeEntries(c, taskID)
+}
+
+func (handler *Handler) DeleteTimeEntryHandler(c *gin.Context) {
+	userID, taskID, ok := handler.authorizeTaskFromParam(c)
+	if !ok {
+		return
+	}
+
+	// URLからtimeEntryのidを取得
+	timeEntryID, err := getIdFromParam(c, "timeEntryId")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	err = models.DeleteTimeEntry(handler.DB, taskID, timeEntryID, userID)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTimeEntries(c, taskID)
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// リクエストボディから作業の開始時刻と終了時刻を取得する
+func bindTimeEntry(c *gin.Context) (time.Time, time.Time, bool) {
+	var timeEntryInput models.TimeEntryInput
+	if err := c.ShouldBindJSON(&timeEntryInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return time.Time{}, time.Time{}, false
+	}
+
+	startedAt, err := time.Parse(time.RFC3339, timeEntryInput.StartedAt)
+	if err != nil {
+		log.Printf("Invalid date format: %v", err)
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "開始時刻のフォーマットが不正です")
+		return time.Time{}, time.Time{}, false
+	}
+
+	stoppedAt, err := time.Parse(time.RFC3339, timeEntryInput.StoppedAt)
+	if err != nil {
+		log.Printf("Invalid date format: %v", err)
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "終了時刻のフォーマットが不正です")
+		return time.Time{}, time.Time{}, false
+	}
+
+	return startedAt, stoppedAt, true
+}
+
+func (handler *Handler) respondWithTimeEntries(c *gin.Context, taskID int) {
+	timeEntries, err := models.FetchTimeEntries(handler.DB, taskID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"time_entries": timeEntries,
+	})
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestTimeEntryHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/tasks/:taskId/time-entries", handler.GetTimeEntriesHandler)
+	r.POST("/tasks/:taskId/time-entries", handler.CreateTimeEntryHandler)
+	r.PUT("/tasks/:taskId/time-entries/:timeEntryId", handler.UpdateTimeEntryHandler)
+	r.DELETE("/tasks/:taskId/time-entries/:timeEntryId", handler.DeleteTimeEntryHandler)
+	r.PUT("/tasks/:taskId/timer/start", handler.StartTimerHandler)
+	r.PUT("/tasks/:taskId/timer/stop", handler.StopTimerHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	db.Create(&userGroup)
+	otherUserGroup := &models.UserGroup{UserGroup: "Other UserGroup"}
+	db.Create(&otherUserGroup)
+	user := &models.User{Name: "Test User", Password: "testPassword123", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(&user)
+	otherUser := &models.User{Name: "Other User", Password: "testPassword123", Email: "other@example.com", UserGroupID: otherUserGroup.ID}
+	db.Create(&otherUser)
+	category := &models.Category{Category: "Test Category", UserGroupID: userGroup.ID}
+	db.Create(&category)
+	task := &models.Task{
+		Task:        "Sample Task",
+		Description: "This is a test task",
+		Creator:     user.ID,
+		CategoryID:  category.ID,
+		Status:      2,
+		Responsible: user.ID,
+		Estimate:    ptrToUint(5),
+		StartDate:   ptrToTime(time.Now()),
+	}
+	db.Create(&task)
+
+	newRequest := func(userID uint, method string, url string, body interface{}) *http.Request {
+		tokenString, _ := utils.GenerateSessionToken(userID, "test_session_id")
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+
+	var response struct {
+		TimeEntries []models.TimeEntryResponse `json:"time_entries"`
+	}
+
+	t.Run("タイマーの開始と終了", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPut, fmt.Sprintf("/tasks/%d/timer/start", task.ID), nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.TimeEntries) != 1 || !response.TimeEntries[0].Running {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+
+		// 計測中のタイマーがある場合は開始できない
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPut, fmt.Sprintf("/tasks/%d/timer/start", task.ID), nil))
+		if resp.Code != http.StatusConflict {
+			t.Errorf("Expected HTTP 409 Conflict, got: %v", resp.Code)
+		}
+
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPut, fmt.Sprintf("/tasks/%d/timer/stop", task.ID), nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || response.TimeEntries[0].Running {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("手動で記録", func(t *testing.T) {
+		startedAt := time.Now().Add(-3 * time.Hour)
+		input := models.TimeEntryInput{
+			StartedAt: startedAt.Format(time.RFC3339),
+			StoppedAt: startedAt.Add(30 * time.Minute).Format(time.RFC3339),
+		}
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPost, fmt.Sprintf("/tasks/%d/time-entries", task.ID), input))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.TimeEntries) != 2 || response.TimeEntries[0].Minutes != 30 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_終了時刻が開始時刻より前", func(t *testing.T) {
+		startedAt := time.Now().Add(-time.Hour)
+		input := models.TimeEntryInput{
+			StartedAt: startedAt.Format(time.RFC3339),
+			StoppedAt: startedAt.Add(-time.Minute).Format(time.RFC3339),
+		}
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodPut, fmt.Sprintf("/tasks/%d/time-entries/%d", task.ID, response.TimeEntries[0].ID), input))
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("失敗_他のユーザーグループのタスク", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(otherUser.ID, http.MethodPut, fmt.Sprintf("/tasks/%d/timer/start", task.ID), nil))
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(user.ID, http.MethodDelete, fmt.Sprintf("/tasks/%d/time-entries/%d", task.ID, response.TimeEntries[0].ID), nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.TimeEntries) != 1 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Delete(&task)
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&userGroup)
+	db.Unscoped().Delete(&otherUserGroup)
+}
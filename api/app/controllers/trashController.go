@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/middleware"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func (handler *Handler) GetTrashHandler(c *gin.Context) {
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	handler.respondWithTrash(c, userID)
+}
+
+func (handler *Handler) RestoreDeletionHandler(c *gin.Context) {
+	deletionID, err := getIdFromParam(c, "deletionId")
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// パーソナルアクセストークンの場合はトークンのスコープも確認する
+	var scopes []string
+	if value, ok := c.Get(constant.AUTH_TOKEN_SCOPES_KEY); ok {
+		scopes = append([]string{}, value.([]string)...)
+	}
+
+	// 復元できる役割とスコープかはゴミ箱のデータの種類ごとに確認する
+	if err := models.RestoreDeletion(handler.DB, deletionID, userID, scopes); err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithTrash(c, userID)
+}
+
+// ユーザーグループを削除したオーナーは自分もゴミ箱に移動しているため、ログインせずにメールアドレスとパスワードで復元する
+func (handler *Handler) RestoreDeletedUserGroupHandler(c *gin.Context) {
+	var loginInput models.UserLoginInput
+	if err := c.ShouldBind(&loginInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// ログインと同じロックアウトを使い、パスワードの総当たりに使われないようにする
+	accountKey := utils.RateLimitKey("login", loginInput.Email)
+	if lockedFor := handler.Limiter.LockedFor(accountKey); lockedFor > 0 {
+		log.Printf("User group restore attempt for locked out account from %s", c.ClientIP())
+		middleware.RespondTooManyRequests(c, lockedFor)
+		return
+	}
+
+	user, err := models.RestoreDeletedUserGroup(handler.DB, loginInput.Email, loginInput.Password)
+	if errors.Is(err, models.ErrInvalidCredentials) {
+		handler.recordLoginFailure(c, accountKey)
+		respondWithErrAndMsg(c, http.StatusUnauthorized, "invalid credentials", "メールアドレスまたはパスワードが違います")
+		return
+	} else if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+	handler.Limiter.Reset(accountKey)
+
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:      models.AuditActionUserGroupRestored,
+		ActorID:     user.ID,
+		UserGroupID: user.UserGroupID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func (handler *Handler) respondWithTrash(c *gin.Context, userID uint) {
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
+	deletions, err := models.FetchDeletions(handler.DB, userGroupID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trash": deletions,
+	})
+}
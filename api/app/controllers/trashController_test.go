@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestTrashHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/trash", handler.GetTrashHandler)
+	r.POST("/trash/:deletionId/restore", handler.RestoreDeletionHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	db.Create(&userGroup)
+	otherUserGroup := &models.UserGroup{UserGroup: "Other UserGroup"}
+	db.Create(&otherUserGroup)
+	user := &models.User{Name: "Test User", Password: "testPassword123", Email: "test@example.com", UserGroupID: userGroup.ID, Role: models.RoleAdmin}
+	db.Create(&user)
+	otherUser := &models.User{Name: "Other User", Password: "testPassword123", Email: "other@example.com", UserGroupID: otherUserGroup.ID, Role: models.RoleAdmin}
+	db.Create(&otherUser)
+	category := &models.Category{Category: "Test Category", UserGroupID: userGroup.ID}
+	db.Create(&category)
+	task := &models.Task{
+		Task:        "Sample Task",
+		Description: "This is a test task",
+		Creator:     user.ID,
+		CategoryID:  category.ID,
+		Status:      1,
+		Responsible: user.ID,
+		Estimate:    ptrToUint(5),
+		StartDate:   ptrToTime(time.Now()),
+	}
+	db.Create(&task)
+	task.DeleteTask(db, int(task.ID), user.ID)
+
+	newRequest := func(method string, path string, userID uint) *http.Request {
+		tokenString, _ := utils.GenerateSessionToken(userID, "test_session_id")
+		req, _ := http.NewRequest(method, path, nil)
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+
+	var deletionID uint
+	t.Run("取得", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, "/trash", user.ID))
+
+		var response struct {
+			Trash []models.DeletionResponse `json:"trash"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Trash) != 1 || response.Trash[0].ItemID != task.ID {
+			t.Fatalf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+		deletionID = response.Trash[0].ID
+	})
+
+	t.Run("失敗_他のユーザーグループのゴミ箱", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, fmt.Sprintf("/trash/%d/restore", deletionID), otherUser.ID))
+
+		if resp.Code != http.StatusNotFound {
+			t.Errorf("Expected HTTP 404 Not Found, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("失敗_読み取り専用のアクセストークン", func(t *testing.T) {
+		// AuthMiddlewareでパーソナルアクセストークンを認証した状態
+		tokenRouter := gin.New()
+		tokenRouter.Use(func(c *gin.Context) {
+			c.Set(constant.AUTH_USER_ID_KEY, user.ID)
+			c.Set(constant.AUTH_TOKEN_SCOPES_KEY, []string{models.ScopeReadTasks})
+		})
+		tokenRouter.POST("/trash/:deletionId/restore", handler.RestoreDeletionHandler)
+
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/trash/%d/restore", deletionID), nil)
+		tokenRouter.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("復元", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, fmt.Sprintf("/trash/%d/restore", deletionID), user.ID))
+
+		var response struct {
+			Trash []models.DeletionResponse `json:"trash"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Trash) != 0 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+		if err := db.First(&models.Task{}, task.ID).Error; err != nil {
+			t.Errorf("Task should be restored: %v", err)
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Where("task_id = ?", task.ID).Delete(&models.TaskHistory{})
+	db.Unscoped().Delete(&task)
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&userGroup)
+	db.Unscoped().Delete(&otherUserGroup)
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/config"
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/middleware"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func (handler *Handler) EnrollTwoFactorHandler(c *gin.Context) {
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	user, err := models.FindUserByID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	enrollment, err := models.StartTwoFactorEnrollment(handler.DB, user)
+	if errors.Is(err, models.ErrTwoFactorAlreadyEnabled) {
+		respondWithErrAndMsg(c, http.StatusConflict, err.Error(), err.Error())
+		return
+	} else if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (handler *Handler) ConfirmTwoFactorHandler(c *gin.Context) {
+	var twoFactorCodeInput models.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&twoFactorCodeInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	recoveryCodes, err := models.ConfirmTwoFactorEnrollment(handler.DB, userID, twoFactorCodeInput.Code)
+	if errors.Is(err, models.ErrInvalidTwoFactorCode) || errors.Is(err, models.ErrTwoFactorNotEnrolled) {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), err.Error())
+		return
+	} else if errors.Is(err, models.ErrTwoFactorAlreadyEnabled) {
+		respondWithErrAndMsg(c, http.StatusConflict, err.Error(), err.Error())
+		return
+	} else if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// リカバリーコードを表示するのはこの時だけ
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+func (handler *Handler) DisableTwoFactorHandler(c *gin.Context) {
+	var twoFactorDisableInput models.TwoFactorDisableInput
+	if err := c.ShouldBindJSON(&twoFactorDisableInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	// ユーザを取得
+	user, err := models.FindUserByID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 入力されたパスワードとIDから取得したパスワードが等しいかを検証
+	if !user.VerifyPassword(twoFactorDisableInput.Password) {
+		log.Printf("パスワードが違います")
+		respondWithError(c, http.StatusBadRequest, "パスワードが違います")
+		return
+	}
+
+	err = models.DisableTwoFactor(handler.DB, userID)
+	if errors.Is(err, models.ErrTwoFactorNotEnabled) {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), err.Error())
+		return
+	} else if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// ログイン時に発行したチャレンジトークンと認証コード（またはリカバリーコード）を検証してセッションを作成する
+func (handler *Handler) VerifyTwoFactorHandler(c *gin.Context) {
+	var twoFactorVerifyInput models.TwoFactorVerifyInput
+	if err := c.ShouldBindJSON(&twoFactorVerifyInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, err := utils.ParseMFAChallengeToken(twoFactorVerifyInput.ChallengeToken)
+	if err != nil {
+		log.Printf("Invalid challenge token: %v", err)
+		respondWithErrAndMsg(c, http.StatusUnauthorized, err.Error(), "有効期限が切れました。もう一度ログインしてください")
+		return
+	}
+
+	// 認証コードの総当たりを防ぐため、ログインと同じようにロックアウトする
+	accountKey := utils.RateLimitKey("mfa", fmt.Sprint(userID))
+	if lockedFor := handler.Limiter.LockedFor(accountKey); lockedFor > 0 {
+		log.Printf("Two factor attempt for locked out account from %s", c.ClientIP())
+		middleware.RespondTooManyRequests(c, lockedFor)
+		return
+	}
+
+	err = models.VerifyTwoFactorCode(handler.DB, userID, twoFactorVerifyInput.Code)
+	if errors.Is(err, models.ErrInvalidTwoFactorCode) || errors.Is(err, models.ErrTwoFactorNotEnabled) {
+		handler.recordLoginFailure(c, accountKey)
+		handler.recordAuditLog(c, models.AuditLog{
+			Action:       models.AuditActionLoginFailed,
+			TargetUserID: userID,
+			Detail:       "mfa",
+		})
+		respondWithErrAndMsg(c, http.StatusUnauthorized, err.Error(), models.ErrInvalidTwoFactorCode.Error())
+		return
+	} else if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	handler.Limiter.Reset(accountKey)
+
+	// セッションを作成し、クッキーにアクセストークンとリフレッシュトークンをセットする
+	if err := handler.startSession(c, userID); err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:       models.AuditActionLoginSucceeded,
+		ActorID:      userID,
+		TargetUserID: userID,
+		Detail:       "password+mfa",
+	})
+
+	// ゲストログインでないことをクッキーに登録
+	config.SetCookie(c, constant.GUEST_LOGIN, "false", constant.COOKIE_MAX_AGE, "/", false)
+
+	c.JSON(http.StatusOK, gin.H{})
+}
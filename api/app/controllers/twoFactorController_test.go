@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestVerifyTwoFactorHandler(t *testing.T) {
+	// SQLMock のセットアップ
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	handler := &Handler{
+		DB: gormDB,
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.POST("/mfa/verify", handler.VerifyTwoFactorHandler)
+
+	secret, _ := utils.GenerateTOTPSecret()
+	challengeToken, _ := utils.GenerateMFAChallengeToken(1)
+	twoFactorColumns := []string{"id", "user_id", "secret", "last_used_step", "enabled_at"}
+
+	verify := func(input models.TwoFactorVerifyInput) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(input)
+		req, _ := http.NewRequest(http.MethodPost, "/mfa/verify", bytes.NewBuffer(body))
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("成功_認証コード", func(t *testing.T) {
+		code, _ := utils.GenerateTOTPCode(secret, time.Now())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `two_factor_auths` WHERE (.+) FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(twoFactorColumns).AddRow(1, 1, secret, 0, time.Now()))
+		mock.ExpectExec("UPDATE `two_factor_auths` SET `last_used_step`").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// セッションの作成
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `sessions`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp := verify(models.TwoFactorVerifyInput{ChallengeToken: challengeToken, Code: code})
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+
+		cookieNames := map[string]bool{}
+		for _, cookie := range resp.Result().Cookies() {
+			if cookie.Value != "" {
+				cookieNames[cookie.Name] = true
+			}
+		}
+		if !cookieNames[constant.JWT_TOKEN_NAME] || !cookieNames[constant.REFRESH_TOKEN_NAME] {
+			t.Errorf("Expected access and refresh token cookies, got: %v", resp.Result().Cookies())
+		}
+	})
+
+	t.Run("失敗_使用済みの認証コード", func(t *testing.T) {
+		now := time.Now()
+		code, _ := utils.GenerateTOTPCode(secret, now)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `two_factor_auths` WHERE (.+) FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(twoFactorColumns).AddRow(1, 1, secret, now.Unix()/constant.TOTP_PERIOD_SECONDS+1, now))
+		mock.ExpectRollback()
+
+		resp := verify(models.TwoFactorVerifyInput{ChallengeToken: challengeToken, Code: code})
+
+		if resp.Code != http.StatusUnauthorized {
+			t.Errorf("Expected HTTP 401 Unauthorized, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+		if len(resp.Result().Cookies()) != 0 {
+			t.Errorf("Expected no cookies, got: %v", resp.Result().Cookies())
+		}
+	})
+
+	t.Run("成功_リカバリーコード", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `two_factor_auths` WHERE (.+) FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(twoFactorColumns).AddRow(1, 1, secret, 0, time.Now()))
+		mock.ExpectExec("UPDATE `recovery_codes` SET `used_at`").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1, utils.HashRecoveryCode("abcde-12345")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// セッションの作成
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `sessions`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp := verify(models.TwoFactorVerifyInput{ChallengeToken: challengeToken, Code: "abcde-12345"})
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_不正なチャレンジトークン", func(t *testing.T) {
+		resp := verify(models.TwoFactorVerifyInput{ChallengeToken: "invalid", Code: "123456"})
+
+		if resp.Code != http.StatusUnauthorized {
+			t.Errorf("Expected HTTP 401 Unauthorized, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
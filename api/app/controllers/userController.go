@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"errors"
 	"fmt"
+	"html"
 	"log"
 	"os"
 
@@ -79,16 +80,36 @@ func (handler *Handler) SendEmailUpdateEmailHandler(c *gin.Context) {
 		return
 	}
 
-	err = handler.MailSender.SendUpdateEmailMail(emailUpdateInput.NewEmail);
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	currentUser, err := models.FindUserByID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 変更申請を保存し、確認用と取り消し用のトークンを発行
+	confirmToken, cancelToken, err := models.CreateEmailChangeRequest(handler.DB, currentUser, emailUpdateInput.NewEmail)
 	if err != nil {
 		respondWithErrAndMsg(c, http.StatusInternalServerError, err.Error(), "メールの送信に失敗しました")
 		return
 	}
 
-	// Cookie内のjwtからUSER_IDを取得
-	userID, err := extractUserID(c)
+	err = handler.MailSender.SendUpdateEmailMail(emailUpdateInput.NewEmail, confirmToken);
 	if err != nil {
-		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		respondWithErrAndMsg(c, http.StatusInternalServerError, err.Error(), "メールの送信に失敗しました")
+		return
+	}
+
+	// 変更前のメールアドレスに取り消し用のリンクを通知
+	err = handler.MailSender.SendEmailChangeNoticeMail(currentUser.Email, emailUpdateInput.NewEmail, cancelToken);
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusInternalServerError, err.Error(), "メールの送信に失敗しました")
 		return
 	}
 
@@ -104,28 +125,14 @@ func (handler *Handler) SendEmailUpdateEmailHandler(c *gin.Context) {
 }
 
 func (handler *Handler) UpdateCurrentUserEmailHandler(c *gin.Context) {
-	var emailUpdateInput models.EmailUpdateInput
-	if err := c.ShouldBindJSON(&emailUpdateInput); err != nil {
+	var emailChangeTokenInput models.EmailChangeTokenInput
+	if err := c.ShouldBindJSON(&emailChangeTokenInput); err != nil {
 		log.Printf("Invalid request body: %v", err)
 		log.Printf("リクエスト内容が正しくありません")
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// メールアドレスが既に使用されていないか確認
-	_, err := models.FindUserByEmail(handler.DB, emailUpdateInput.NewEmail)
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			respondWithError(c, http.StatusBadRequest, err.Error())
-			return
-	} else if err == nil {
-		respondWithErrAndMsg(c, http.StatusBadRequest, "", "他のユーザーが使用しているので別のメールアドレスを入力してください")
-		return
-	}
-
-	updateUser := &models.User{
-		Email:     emailUpdateInput.NewEmail,
-	}
-
 	// Cookie内のjwtからUSER_IDを取得
 	userID, err := extractUserID(c)
 	if err != nil {
@@ -133,12 +140,22 @@ func (handler *Handler) UpdateCurrentUserEmailHandler(c *gin.Context) {
 		return
 	}
 
-	err = updateUser.UpdateEmail(handler.DB, userID)
-	if err != nil {
+	// トークンを検証してから申請されたメールアドレスに変更
+	err = models.ConfirmEmailChangeRequest(handler.DB, userID, emailChangeTokenInput.Token)
+	if errors.Is(err, models.ErrInvalidEmailChangeToken) {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), err.Error())
+		return
+	} else if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:       models.AuditActionEmailChanged,
+		ActorID:      userID,
+		TargetUserID: userID,
+	})
+
 	updatedUser, err := models.FindUserByIDWithoutPassword(handler.DB, userID)
 	if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
@@ -150,6 +167,27 @@ func (handler *Handler) UpdateCurrentUserEmailHandler(c *gin.Context) {
 	})
 }
 
+func (handler *Handler) CancelEmailUpdateHandler(c *gin.Context) {
+	var emailChangeTokenInput models.EmailChangeTokenInput
+	if err := c.ShouldBindJSON(&emailChangeTokenInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := models.CancelEmailChangeRequest(handler.DB, emailChangeTokenInput.Token)
+	if errors.Is(err, models.ErrInvalidEmailChangeToken) {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), err.Error())
+		return
+	} else if err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{})
+}
+
 func (handler *Handler) UpdateCurrentUsernameHandler(c *gin.Context) {
 	var usernameUpdateInput models.UsernameUpdateInput
 	if err := c.ShouldBindJSON(&usernameUpdateInput); err != nil {
@@ -212,12 +250,18 @@ func (handler *Handler) SendEmailResetPasswordHandler(c *gin.Context) {
 		return
 	}
 
+	// 同じメールアドレスへの送信回数を制限
+	if !handler.allowEmailTo(c, passwordResetInput.Email) {
+		return
+	}
+
 	// メールアドレスが登録済みか確認
-	_, err := models.FindUserByEmail(handler.DB, passwordResetInput.Email)
+	user, err := models.FindUserByEmail(handler.DB, passwordResetInput.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// 未登録の場合
-			respondWithErrAndMsg(c, http.StatusBadRequest, "", "入力したメールアドレスは未登録です")
+			// 未登録の場合も、登録済みかどうかを推測されないようにメールを送らずに成功を返す
+			log.Printf("Password reset requested for unregistered email")
+			c.JSON(http.StatusOK, gin.H{})
 			return
 		} else {
 			// データベースエラーの場合
@@ -226,8 +270,14 @@ func (handler *Handler) SendEmailResetPasswordHandler(c *gin.Context) {
 		}
 	}
 
-	// レコードが存在する場合
-	err = handler.MailSender.SendUpdatePasswordMail(passwordResetInput.Email)
+	// レコードが存在する場合、リセット用のトークンを発行
+	resetToken, err := models.IssuePasswordResetToken(handler.DB, user)
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusInternalServerError, err.Error(), "メールの送信に失敗しました")
+		return
+	}
+
+	err = handler.MailSender.SendUpdatePasswordMail(passwordResetInput.Email, resetToken)
 	if err != nil {
 		respondWithErrAndMsg(c, http.StatusInternalServerError, err.Error(), "メールの送信に失敗しました")
 		return
@@ -247,15 +297,22 @@ func (handler *Handler) ResetPasswordHandler(c *gin.Context) {
 
 	updateUser := &models.User{
 		Password: userPasswordResetInput.Password,
-		Email:    userPasswordResetInput.Email,
 	}
 
-	err := updateUser.ResetUserPassword(handler.DB)
-	if err != nil {
+	err := updateUser.ResetUserPassword(handler.DB, userPasswordResetInput.Token)
+	if errors.Is(err, models.ErrInvalidPasswordResetToken) {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), err.Error())
+		return
+	} else if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:       models.AuditActionPasswordReset,
+		TargetUserID: updateUser.ID,
+	})
+
 	c.JSON(http.StatusOK, gin.H{})
 }
 
@@ -299,6 +356,19 @@ func (handler *Handler) UpdateCurrentUserPasswordHandler(c *gin.Context) {
 		return
 	}
 
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:       models.AuditActionPasswordChanged,
+		ActorID:      userID,
+		TargetUserID: userID,
+		UserGroupID:  user.UserGroupID,
+	})
+
+	// すべてのセッションが失効したため、このブラウザのセッションを作り直す
+	if err := handler.startSession(c, userID); err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
 	updatedUser, err := models.FindUserByIDWithoutPassword(handler.DB, userID)
 	if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
@@ -326,16 +396,54 @@ func (handler *Handler) UpdateCurrentUserGroupHandler(c *gin.Context) {
 		return
 	}
 
+	// 招待されたユーザーグループにのみ移動できる
+	userGroupIDClaims, err := utils.ParseUserGroupIDToken(userGroupUpdateInput.UserGroupToken)
+	if err != nil {
+		log.Printf("Invalid user group token: %v", err)
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "招待用のリンクが無効か、有効期限が切れています")
+		return
+	}
+	newUserGroupID := userGroupIDClaims.UserGroupID
+
+	// 移動前のユーザーグループにも記録するため、変更前に取得しておく
+	currentUser, err := models.FindUserByID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+	oldUserGroupID := currentUser.UserGroupID
+
+	// 別のメールアドレス宛ての招待のユーザーグループには移動できない
+	if userGroupIDClaims.Email != currentUser.Email {
+		log.Printf("User group token was issued for another email")
+		respondWithErrAndMsg(c, http.StatusBadRequest, "email does not match invitation", "招待用のリンクが無効か、有効期限が切れています")
+		return
+	}
+
 	updateUser := &models.User{
-		UserGroupID: userGroupUpdateInput.NewUserGroupID,
+		UserGroupID: newUserGroupID,
 	}
 
 	err = updateUser.UpdateUserGroup(handler.DB, userID)
-	if err != nil {
+	if errors.Is(err, models.ErrOwnerMustTransfer) {
+		respondWithErrAndMsg(c, http.StatusConflict, err.Error(), err.Error())
+		return
+	} else if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	detail := fmt.Sprintf("user group %d -> %d", oldUserGroupID, newUserGroupID)
+	for _, userGroupID := range []uint{oldUserGroupID, newUserGroupID} {
+		handler.recordAuditLog(c, models.AuditLog{
+			Action:       models.AuditActionUserGroupChanged,
+			ActorID:      userID,
+			TargetUserID: userID,
+			UserGroupID:  userGroupID,
+			Detail:       detail,
+		})
+	}
+
 	updatedUser, err := models.FindUserByIDWithoutPassword(handler.DB, userID)
 	if err != nil {
 		respondWithError(c, http.StatusInternalServerError, err.Error())
@@ -358,30 +466,41 @@ func (handler *Handler) DeleteCurrentUserHandler(c *gin.Context) {
 		return
 	}
 
-	err = deleteUser.DeleteUserAndRelatedTasks(handler.DB, userID)
+	// 削除後はユーザーグループを取得できないため、削除前に取得しておく
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
 	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return
+	}
+
+	err = deleteUser.DeleteUserAndRelatedTasks(handler.DB, userID)
+	if errors.Is(err, models.ErrOwnerMustTransfer) {
+		respondWithErrAndMsg(c, http.StatusConflict, err.Error(), err.Error())
+		return
+	} else if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:       models.AuditActionUserDeleted,
+		ActorID:      userID,
+		TargetUserID: userID,
+		UserGroupID:  userGroupID,
+	})
+
 	// ログインセッションを削除
-	c.SetCookie(constant.JWT_TOKEN_NAME, "", -1, "/", os.Getenv("FRONTEND_DOMAIN"), false, true)
+	clearSessionCookies(c)
 	
 	c.JSON(http.StatusOK, gin.H{})
 }
 
-func (p *ProductionMailSender)SendUpdateEmailMail(email string) error {
+func (p *ProductionMailSender)SendUpdateEmailMail(email string, confirmToken string) error {
 
 	client := resend.NewClient(os.Getenv("RESEND_TOKEN"))
 
 	// URLを生成
-	// トークンを生成
-	emailToken, err := utils.GenerateEmailToken(email)
-	if err != nil {
-		log.Printf("Token generation failed: %v", err)
-		return err
-	}
-	registrationURL := fmt.Sprintf("%s/update/email?&email=%s", os.Getenv("FRONTEND_ORIGIN"), emailToken)
+	registrationURL := fmt.Sprintf("%s/update/email?&token=%s", os.Getenv("FRONTEND_ORIGIN"), confirmToken)
 
 	body := fmt.Sprintf(`
 		<p>メールアドレスの更新を完了するには、以下のリンクにアクセスしてください。</p>
@@ -407,23 +526,50 @@ func (p *ProductionMailSender)SendUpdateEmailMail(email string) error {
 	return nil
 }
 
-func (p *ProductionMailSender)SendUpdatePasswordMail(email string) error {
+func (p *ProductionMailSender)SendEmailChangeNoticeMail(oldEmail string, newEmail string, cancelToken string) error {
 
 	client := resend.NewClient(os.Getenv("RESEND_TOKEN"))
 
 	// URLを生成
-	// トークンを生成
-	emailToken, err := utils.GenerateEmailToken(email)
-	if err != nil {
-		log.Printf("Token generation failed: %v", err)
-		return err
-	}
-	registrationURL := fmt.Sprintf("%s/update/password?&email=%s", os.Getenv("FRONTEND_ORIGIN"), emailToken)
+	cancelURL := fmt.Sprintf("%s/update/email/cancel?&token=%s", os.Getenv("FRONTEND_ORIGIN"), cancelToken)
+
+	body := fmt.Sprintf(`
+		<p>アカウントのメールアドレスを %s に変更する申請がありました。</p>
+		<p>心当たりがない場合は、以下のリンクにアクセスして変更を取り消してください。</p>
+		<a href="%s">%s</a>
+	`, html.EscapeString(newEmail), cancelURL, cancelURL)
+
+	subject := "【Look Back Calendar】メールアドレス変更のお知らせ"
+
+    params := &resend.SendEmailRequest{
+        From:    "Look Back Calendar <update@lookback-calendar.com>",
+        To:      []string{oldEmail},
+        Html:    body,
+        Subject: subject,
+    }
+
+    sent, err := client.Emails.Send(params)
+    if err != nil {
+        log.Println(err.Error())
+        return err
+    }
+    fmt.Println(sent.Id)
+
+	return nil
+}
+
+func (p *ProductionMailSender)SendUpdatePasswordMail(email string, resetToken string) error {
+
+	client := resend.NewClient(os.Getenv("RESEND_TOKEN"))
+
+	// URLを生成
+	registrationURL := fmt.Sprintf("%s/update/password?&token=%s", os.Getenv("FRONTEND_ORIGIN"), resetToken)
 
 	body := fmt.Sprintf(`
 		<p>パスワードの更新を完了するには、以下のリンクにアクセスしてください。</p>
+		<p>リンクの有効期限は%d分で、一度だけ使用できます。</p>
 		<a href="%s">%s</a>
-	`, registrationURL, registrationURL)
+	`, constant.PASSWORD_RESET_TOKEN_LIFETIME_MINUTES, registrationURL, registrationURL)
 
 	subject := "【Look Back Calendar】パスワード更新のお願い"
 
@@ -6,8 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 
@@ -16,11 +18,14 @@ import (
 	"github.com/alicend/LookBack/app/utils"
 )
 
-func (m *MockMailSender) SendUpdateEmailMail(email string) error {
-	return m.MockSendUpdateEmailMail(email)
+func (m *MockMailSender) SendUpdateEmailMail(email string, confirmToken string) error {
+	return m.MockSendUpdateEmailMail(email, confirmToken)
 }
-func (m *MockMailSender) SendUpdatePasswordMail(email string) error {
-	return m.MockSendUpdatePasswordMail(email)
+func (m *MockMailSender) SendEmailChangeNoticeMail(oldEmail string, newEmail string, cancelToken string) error {
+	return m.MockSendEmailChangeNoticeMail(oldEmail, newEmail, cancelToken)
+}
+func (m *MockMailSender) SendUpdatePasswordMail(email string, resetToken string) error {
+	return m.MockSendUpdatePasswordMail(email, resetToken)
 }
 
 func TestGetUsersAllHandler(t *testing.T) {
@@ -34,7 +39,7 @@ func TestGetUsersAllHandler(t *testing.T) {
 		DB: db,
 	}
 
-	tokenString, _ := utils.GenerateSessionToken(uint(1))
+	tokenString, _ := utils.GenerateSessionToken(uint(1), "test_session_id")
 
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
@@ -126,7 +131,7 @@ func TestGetCurrentUserHandler(t *testing.T) {
 			t.Fatalf("failed to create user: %v", err)
 		}
 		// テストユーザーのセッショントークンを生成
-		tokenString, _ := utils.GenerateSessionToken(user.ID)
+		tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
 
 		req, _ := http.NewRequest(http.MethodGet, "/current-user", nil)
 		resp := httptest.NewRecorder()
@@ -196,9 +201,20 @@ func TestSendEmailUpdateEmailHandler(t *testing.T) {
 			t.Fatalf("failed to create user: %v", err)
 		}
 		// テストユーザーのセッショントークンを生成
-		tokenString, _ := utils.GenerateSessionToken(user.ID)
+		tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
 
-		mockMailSender.MockSendUpdateEmailMail = func(email string) error {
+		mockMailSender.MockSendUpdateEmailMail = func(email string, confirmToken string) error {
+			if email != "new-email@example.com" || confirmToken == "" {
+				t.Errorf("Confirmation mail should be sent to the new address with a token")
+			}
+			return nil
+		}
+		noticeSent := false
+		mockMailSender.MockSendEmailChangeNoticeMail = func(oldEmail string, newEmail string, cancelToken string) error {
+			noticeSent = true
+			if oldEmail != "test@example.com" || cancelToken == "" {
+				t.Errorf("Notice mail should be sent to the old address with a cancel token")
+			}
 			return nil
 		}
 
@@ -233,12 +249,18 @@ func TestSendEmailUpdateEmailHandler(t *testing.T) {
 			}
 
 			// レスポンスにuserが含まれていることを確認する
-			if _, ok := response["user"]; !ok {
-				t.Errorf("Response does not contain user")
+			// 確認前なのでメールアドレスはまだ変更されていない
+			currentUser, ok := response["user"].(map[string]interface{})
+			if !ok || currentUser["Email"] != "test@example.com" {
+				t.Errorf("Email should not be changed before confirmation")
 			}
 		}
+		if !noticeSent {
+			t.Errorf("Notice mail was not sent to the old address")
+		}
 
 		// 後処理: テスト用のデータを削除
+		db.Unscoped().Where("user_id = ?", user.ID).Delete(&models.EmailChangeRequest{})
 		db.Unscoped().Delete(&user)
 		db.Unscoped().Delete(&userGroup)
 	})
@@ -258,38 +280,35 @@ func TestUpdateCurrentUserEmailHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
 	r.PUT("/update-email", handler.UpdateCurrentUserEmailHandler)
+	r.PUT("/update-email/cancel", handler.CancelEmailUpdateHandler)
 
-	t.Run("成功", func(t *testing.T) {
-		// テストデータの作成
-		userGroup := &models.UserGroup{
-			UserGroup: "Test UserGroup",
-		}
-		if err := db.Create(&userGroup).Error; err != nil {
-			t.Fatalf("failed to create user group: %v", err)
-		}
+	// テストデータの作成
+	userGroup := &models.UserGroup{
+		UserGroup: "Test UserGroup",
+	}
+	if err := db.Create(&userGroup).Error; err != nil {
+		t.Fatalf("failed to create user group: %v", err)
+	}
 
-		user := &models.User{
-			Name:        "Test User",
-			Password:    "testPassword123",
-			Email:       "test@example.com",
-			UserGroupID: userGroup.ID,
-		}
-		if err := db.Create(&user).Error; err != nil {
-			t.Fatalf("failed to create user: %v", err)
-		}
-		// テストユーザーのセッショントークンを生成
-		tokenString, _ := utils.GenerateSessionToken(user.ID)
+	user := &models.User{
+		Name:        "Test User",
+		Password:    "testPassword123",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	// テストユーザーのセッショントークンを生成
+	tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
 
-		// 新しいメールアドレスのデータを作成
-		emailUpdateInput := models.EmailUpdateInput{
-			NewEmail: "newemail@example.com",
-		}
-		requestBody, err := json.Marshal(emailUpdateInput)
+	sendToken := func(path string, token string) *httptest.ResponseRecorder {
+		requestBody, err := json.Marshal(models.EmailChangeTokenInput{Token: token})
 		if err != nil {
 			t.Fatalf("failed to marshal request body: %v", err)
 		}
 
-		req, _ := http.NewRequest(http.MethodPut, "/update-email", bytes.NewBuffer(requestBody))
+		req, _ := http.NewRequest(http.MethodPut, path, bytes.NewBuffer(requestBody))
 		resp := httptest.NewRecorder()
 
 		req.Header.Set("Content-Type", "application/json")
@@ -299,6 +318,42 @@ func TestUpdateCurrentUserEmailHandler(t *testing.T) {
 		})
 
 		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("失敗 - 不正なトークン", func(t *testing.T) {
+		resp := sendToken("/update-email", "invalid_token")
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("失敗 - 取り消された申請", func(t *testing.T) {
+		confirmToken, cancelToken, err := models.CreateEmailChangeRequest(db, *user, "canceled@example.com")
+		if err != nil {
+			t.Fatalf("failed to create email change request: %v", err)
+		}
+
+		resp := sendToken("/update-email/cancel", cancelToken)
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+			t.Errorf("Error: %v", resp.Body.String())
+		}
+
+		resp = sendToken("/update-email", confirmToken)
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("成功", func(t *testing.T) {
+		confirmToken, _, err := models.CreateEmailChangeRequest(db, *user, "newemail@example.com")
+		if err != nil {
+			t.Fatalf("failed to create email change request: %v", err)
+		}
+
+		resp := sendToken("/update-email", confirmToken)
 
 		if resp.Code != http.StatusOK {
 			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
@@ -312,15 +367,22 @@ func TestUpdateCurrentUserEmailHandler(t *testing.T) {
 
 			// レスポンスに更新されたユーザーが含まれていることを確認する
 			updatedUser, ok := response["user"].(map[string]interface{})
-			if !ok || updatedUser["Email"] != emailUpdateInput.NewEmail {
+			if !ok || updatedUser["Email"] != "newemail@example.com" {
 				t.Errorf("Response does not contain updated user or email was not updated correctly")
 			}
 		}
 
-		// 後処理: テスト用のデータを削除
-		db.Unscoped().Delete(&user)
-		db.Unscoped().Delete(&userGroup)
+		// 同じトークンは再利用できない
+		resp = sendToken("/update-email", confirmToken)
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request for reused token, got: %v", resp.Code)
+		}
 	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&models.EmailChangeRequest{})
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
 }
 
 
@@ -369,7 +431,7 @@ func TestUpdateCurrentUsernameHandler(t *testing.T) {
 	}
 
 	// テストユーザーのセッショントークンを生成
-	tokenString, _ := utils.GenerateSessionToken(user.ID)
+	tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
 
 	t.Run("成功", func(t *testing.T) {		
 		// 新しいユーザー名のデータを作成
@@ -476,10 +538,13 @@ func TestSendEmailResetPasswordHandler(t *testing.T) {
 	if err := db.Create(&user).Error; err != nil {
 		t.Fatalf("failed to create user: %v", err)
 	}
-	tokenString, _ := utils.GenerateSessionToken(user.ID)
+	tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
 
 	t.Run("成功", func(t *testing.T) {
-		mockMailSender.MockSendUpdatePasswordMail = func(email string) error {
+		mockMailSender.MockSendUpdatePasswordMail = func(email string, resetToken string) error {
+			if resetToken == "" {
+				t.Errorf("Reset token should be passed to the mail sender")
+			}
 			return nil
 		}
 
@@ -503,8 +568,8 @@ func TestSendEmailResetPasswordHandler(t *testing.T) {
 		}
 	})
 
-	t.Run("失敗 - 存在しないメールアドレス", func(t *testing.T) {
-		mockMailSender.MockSendUpdatePasswordMail = func(email string) error {
+	t.Run("存在しないメールアドレス", func(t *testing.T) {
+		mockMailSender.MockSendUpdatePasswordMail = func(email string, resetToken string) error {
 			return nil
 		}
 
@@ -523,12 +588,14 @@ func TestSendEmailResetPasswordHandler(t *testing.T) {
 
 		r.ServeHTTP(resp, req)
 
-		if resp.Code != http.StatusBadRequest {
-			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		// 登録済みかどうかを推測されないように成功を返す
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
 		}
 	})
 
 	// 後処理: テスト用のデータを削除
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&models.PasswordResetToken{})
 	db.Unscoped().Delete(&user)
 	db.Unscoped().Delete(&userGroup)
 }
@@ -548,44 +615,128 @@ func TestResetPasswordHandler(t *testing.T) {
 	r := gin.Default()
 	r.POST("/reset-password", handler.ResetPasswordHandler)
 
-	t.Run("成功", func(t *testing.T) {
-		// テストデータの作成
-		userGroup := &models.UserGroup{
-			UserGroup: "Test UserGroup",
-		}
-		if err := db.Create(&userGroup).Error; err != nil {
-			t.Fatalf("failed to create user group: %v", err)
-		}
+	// テストデータの作成
+	userGroup := &models.UserGroup{
+		UserGroup: "Test UserGroup",
+	}
+	if err := db.Create(&userGroup).Error; err != nil {
+		t.Fatalf("failed to create user group: %v", err)
+	}
 
-		user := &models.User{
-			Name:        "Test User",
-			Password:    "oldPassword123",
-			Email:       "test@example.com",
-			UserGroupID: userGroup.ID,
-		}
-		if err := db.Create(&user).Error; err != nil {
-			t.Fatalf("failed to create user: %v", err)
-		}
+	user := &models.User{
+		Name:        "Test User",
+		Password:    mustHashPassword(t, "oldPassword123"),
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
 
+	resetPassword := func(token string) *httptest.ResponseRecorder {
 		passwordResetInput := models.UserPasswordResetInput{
-			Email:    "test@example.com",
+			Token:    token,
 			Password: "newPassword123",
 		}
 		requestBody, _ := json.Marshal(passwordResetInput)
 		req, _ := http.NewRequest(http.MethodPost, "/reset-password", bytes.NewBuffer(requestBody))
 		resp := httptest.NewRecorder()
-
 		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	fetchUser := func() models.User {
+		fetchedUser, err := models.FindUserByID(db, user.ID)
+		if err != nil {
+			t.Fatalf("failed to fetch user: %v", err)
+		}
+		return fetchedUser
+	}
+
+	t.Run("成功", func(t *testing.T) {
+		resetToken, err := models.IssuePasswordResetToken(db, fetchUser())
+		if err != nil {
+			t.Fatalf("failed to issue reset token: %v", err)
+		}
+
+		resp := resetPassword(resetToken)
 
 		if resp.Code != http.StatusOK {
 			t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
 			t.Errorf("Error: %v", resp.Body.String())
 		}
+		updatedUser := fetchUser()
+		if !updatedUser.VerifyPassword("newPassword123") {
+			t.Errorf("Password was not reset")
+		}
 
-		// 後処理: テスト用のデータを削除
-		db.Unscoped().Delete(&user)
-		db.Unscoped().Delete(&userGroup)
+		// 失敗 - 使用済みのトークンは再利用できない
+		resp = resetPassword(resetToken)
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request for reused token, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("失敗 - 新しいトークンの発行で失効した古いトークン", func(t *testing.T) {
+		oldToken, err := models.IssuePasswordResetToken(db, fetchUser())
+		if err != nil {
+			t.Fatalf("failed to issue reset token: %v", err)
+		}
+		if _, err := models.IssuePasswordResetToken(db, fetchUser()); err != nil {
+			t.Fatalf("failed to issue reset token: %v", err)
+		}
+
+		resp := resetPassword(oldToken)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request for revoked token, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("失敗 - 発行後にパスワードが変更された古いトークン", func(t *testing.T) {
+		oldToken, err := models.IssuePasswordResetToken(db, fetchUser())
+		if err != nil {
+			t.Fatalf("failed to issue reset token: %v", err)
+		}
+
+		// トークン発行後にパスワードを変更
+		updateUser := &models.User{Password: "changedPassword123"}
+		if err := updateUser.UpdateUserPassword(db, user.ID); err != nil {
+			t.Fatalf("failed to update password: %v", err)
+		}
+
+		resp := resetPassword(oldToken)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request for stale token, got: %v", resp.Code)
+		}
+		changedUser := fetchUser()
+		if !changedUser.VerifyPassword("changedPassword123") {
+			t.Errorf("Password should not be changed by a stale token")
+		}
+	})
+
+	t.Run("失敗 - 偽造されたトークン", func(t *testing.T) {
+		// 正しい鍵で署名されていないトークン
+		forgedToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": user.ID,
+			"jti":     "forged",
+			"pwd":     utils.PasswordFingerprint(fetchUser().Password),
+			"exp":     time.Now().Add(time.Minute).Unix(),
+		})
+		forgedTokenString, _ := forgedToken.SignedString([]byte("forged_secret_key"))
+
+		resp := resetPassword(forgedTokenString)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request for forged token, got: %v", resp.Code)
+		}
 	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&models.PasswordResetToken{})
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
 }
 
 func TestUpdateCurrentUserPasswordHandler(t *testing.T) {
@@ -613,7 +764,7 @@ func TestUpdateCurrentUserPasswordHandler(t *testing.T) {
 
 		user := &models.User{
 			Name:        "Test User",
-			Password:    models.Encrypt("oldPassword123"),
+			Password:    mustHashPassword(t, "oldPassword123"),
 			Email:       "test@example.com",
 			UserGroupID: userGroup.ID,
 		}
@@ -622,7 +773,7 @@ func TestUpdateCurrentUserPasswordHandler(t *testing.T) {
 		}
 
 		// テストユーザーのセッショントークンを生成
-		tokenString, _ := utils.GenerateSessionToken(user.ID)
+		tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
 
 		// パスワード更新リクエストを作成
 		passwordUpdateInput := models.UserPasswordUpdateInput{
@@ -687,7 +838,7 @@ func TestUpdateCurrentUserGroupHandler(t *testing.T) {
 
 		user := &models.User{
 			Name:        "Test User",
-			Password:    models.Encrypt("oldPassword123"),
+			Password:    mustHashPassword(t, "oldPassword123"),
 			Email:       "test@example.com",
 			UserGroupID: userGroup.ID,
 		}
@@ -696,11 +847,12 @@ func TestUpdateCurrentUserGroupHandler(t *testing.T) {
 		}
 
 		// テストユーザーのセッショントークンを生成
-		tokenString, _ := utils.GenerateSessionToken(user.ID)
+		tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
 
-		// ユーザーグループ更新リクエストを作成
+		// 招待メールのトークンでユーザーグループ更新リクエストを作成
+		userGroupToken, _ := utils.GenerateUserGroupIDToken(userGroup.ID, user.Email)
 		userGroupUpdateInput := models.UserGroupUpdateInput{
-			NewUserGroupID: userGroup.ID,
+			UserGroupToken: userGroupToken,
 		}
 		requestBody, _ := json.Marshal(userGroupUpdateInput)
 		req, _ := http.NewRequest(http.MethodPost, "/update-user-group", bytes.NewBuffer(requestBody))
@@ -773,7 +925,7 @@ func TestDeleteCurrentUserHandler(t *testing.T) {
 		}
 
 		// テストユーザーのセッショントークンを生成
-		tokenString, _ := utils.GenerateSessionToken(user.ID)
+		tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
 
         req, _ := http.NewRequest(http.MethodDelete, "/user", nil)
         resp := httptest.NewRecorder()
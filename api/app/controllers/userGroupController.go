@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"log"
 
@@ -75,12 +76,26 @@ func (handler *Handler) UpdateUserGroupHandler(c *gin.Context) {
 		return
 	}
 
+	// 更新対象がログイン中のユーザーのユーザーグループか確認
+	if !handler.authorizeUserGroup(c, userGroupID) {
+		return
+	}
+
 	err = updateUserGroup.UpdateUserGroup(handler.DB, userGroupID)
 	if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	// 更新したユーザーはユーザーグループのメンバーであることを確認済み
+	userID, _ := extractUserID(c)
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:      models.AuditActionUserGroupUpdated,
+		ActorID:     userID,
+		UserGroupID: uint(userGroupID),
+		Detail:      "name: " + updateUserGroupInput.UserGroup,
+	})
+
 	user_groups, err := models.FetchUserGroups(handler.DB)
 	if err != nil {
 		log.Printf("ユーザーグループの取得に失敗しました")
@@ -102,17 +117,146 @@ func (handler *Handler) DeleteUserGroupHandler(c *gin.Context) {
 		return
 	}
 
+	// 削除対象がログイン中のユーザーのユーザーグループか確認
+	if !handler.authorizeUserGroup(c, userGroupID) {
+		return
+	}
+
 	deleteUserGroup := &models.UserGroup{}
 
-	err = deleteUserGroup.DeleteUserGroupAndRelatedUsers(handler.DB, userGroupID)
+	// 削除後はログイン中のユーザーも削除されているため、削除前に取得しておく
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	err = deleteUserGroup.DeleteUserGroupAndRelatedUsers(handler.DB, userGroupID, userID)
 	if err != nil {
 		respondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:      models.AuditActionUserGroupDeleted,
+		ActorID:     userID,
+		UserGroupID: uint(userGroupID),
+	})
+
 	c.JSON(http.StatusOK, gin.H{})
 }
 
+func (handler *Handler) UpdateMemberRoleHandler(c *gin.Context) {
+	var roleUpdateInput models.RoleUpdateInput
+	if err := c.ShouldBindJSON(&roleUpdateInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// URLから役割を変更するユーザーのidを取得
+	targetUserID, err := getIdFromSecondLastPartOfURL(c)
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	err = models.ChangeUserRole(handler.DB, userID, uint(targetUserID), roleUpdateInput.Role)
+	if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:       models.AuditActionRoleChanged,
+		ActorID:      userID,
+		TargetUserID: uint(targetUserID),
+		Detail:       "role: " + roleUpdateInput.Role,
+	})
+
+	users, err := models.FindUsersAll(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users" : users,  // usersをレスポンスとして返す
+	})
+}
+
+func (handler *Handler) TransferOwnershipHandler(c *gin.Context) {
+	var ownershipTransferInput models.OwnershipTransferInput
+	if err := c.ShouldBindJSON(&ownershipTransferInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return
+	}
+
+	err = models.TransferOwnership(handler.DB, userID, ownershipTransferInput.UserID)
+	if errors.Is(err, models.ErrCannotTransferToSelf) {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), err.Error())
+		return
+	} else if err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.recordAuditLog(c, models.AuditLog{
+		Action:       models.AuditActionOwnershipTransferred,
+		ActorID:      userID,
+		TargetUserID: ownershipTransferInput.UserID,
+	})
+
+	users, err := models.FindUsersAll(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users" : users,  // usersをレスポンスとして返す
+	})
+}
+
 // ==================================================================
 // 以下はプライベート関数
 // ==================================================================
+func (handler *Handler) authorizeUserGroup(c *gin.Context, targetUserGroupID int) bool {
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return false
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return false
+	}
+
+	if err := models.AuthorizeUserGroup(handler.DB, targetUserGroupID, userGroupID); err != nil {
+		respondWithAuthorizationError(c, err)
+		return false
+	}
+
+	return true
+}
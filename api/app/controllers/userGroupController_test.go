@@ -14,7 +14,7 @@ import (
 
 	"github.com/alicend/LookBack/app/constant"
 	"github.com/alicend/LookBack/app/models"
-	// "github.com/alicend/LookBack/app/utils"
+	"github.com/alicend/LookBack/app/utils"
 )
 
 func TestCreateUserGroupHandler(t *testing.T) {
@@ -131,6 +131,17 @@ func TestUpdateUserGroupHandler(t *testing.T) {
 			t.Fatalf("failed to create user group: %v", err)
 		}
 
+        user := &models.User{
+            Name:        "Test User",
+            Password:    "testPassword123",
+            Email:       "test@example.com",
+            UserGroupID: userGroup.ID,
+        }
+        if err := db.Create(&user).Error; err != nil {
+            t.Fatalf("failed to create user: %v", err)
+        }
+        tokenString, _ := utils.GenerateSessionToken(uint(user.ID), "test_session_id")
+
         updateUserGroupInput := models.UserGroupInput{
             UserGroup: "Updated UserGroup",
         }
@@ -138,6 +149,11 @@ func TestUpdateUserGroupHandler(t *testing.T) {
         req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/user-groups/%d", userGroup.ID), bytes.NewBuffer(requestBody))
         resp := httptest.NewRecorder()
 
+        req.AddCookie(&http.Cookie{
+            Name:  constant.JWT_TOKEN_NAME,
+            Value: tokenString,
+        })
+
         r.ServeHTTP(resp, req)
 
         if resp.Code != http.StatusOK {
@@ -145,6 +161,7 @@ func TestUpdateUserGroupHandler(t *testing.T) {
         }
 
         // 後処理: テスト用のデータを削除
+        db.Unscoped().Delete(&user)
         db.Unscoped().Delete(&userGroup)
     })
 }
@@ -173,9 +190,26 @@ func TestDeleteUserGroupHandler(t *testing.T) {
             t.Fatalf("failed to create user group: %v", err)
         }
 
+        // ユーザーグループと一緒に削除される
+        user := &models.User{
+            Name:        "Test User",
+            Password:    "testPassword123",
+            Email:       "test@example.com",
+            UserGroupID: userGroup.ID,
+        }
+        if err := db.Create(&user).Error; err != nil {
+            t.Fatalf("failed to create user: %v", err)
+        }
+        tokenString, _ := utils.GenerateSessionToken(uint(user.ID), "test_session_id")
+
         req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/user-groups/%d", userGroup.ID), nil)
         resp := httptest.NewRecorder()
 
+        req.AddCookie(&http.Cookie{
+            Name:  constant.JWT_TOKEN_NAME,
+            Value: tokenString,
+        })
+
         r.ServeHTTP(resp, req)
 
         if resp.Code != http.StatusOK {
@@ -194,3 +228,102 @@ func TestDeleteUserGroupHandler(t *testing.T) {
         }
     })
 }
+
+func TestMemberRoleHandlers(t *testing.T) {
+    // テスト用MySQLデータベースに接続
+    db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+    if err != nil {
+        t.Fatalf("failed to connect to MySQL database: %v", err)
+    }
+
+    handler := &Handler{
+        DB: db,
+    }
+
+    gin.SetMode(gin.TestMode)
+    r := gin.Default()
+    r.PUT("/user-groups/members/:userId/role", handler.UpdateMemberRoleHandler)
+    r.PUT("/user-groups/owner", handler.TransferOwnershipHandler)
+
+    // テストデータの作成
+    userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+    if err := db.Create(&userGroup).Error; err != nil {
+        t.Fatalf("failed to create user group: %v", err)
+    }
+    owner := &models.User{Name: "Owner", Password: "testPassword123", Email: "owner@example.com", UserGroupID: userGroup.ID, Role: models.RoleOwner}
+    if err := db.Create(&owner).Error; err != nil {
+        t.Fatalf("failed to create user: %v", err)
+    }
+    member := &models.User{Name: "Member", Password: "testPassword123", Email: "member@example.com", UserGroupID: userGroup.ID, Role: models.RoleMember}
+    if err := db.Create(&member).Error; err != nil {
+        t.Fatalf("failed to create user: %v", err)
+    }
+
+    newRequest := func(method string, url string, userID uint, input interface{}) *http.Request {
+        body, _ := json.Marshal(input)
+        req, _ := http.NewRequest(method, url, bytes.NewBuffer(body))
+        tokenString, _ := utils.GenerateSessionToken(userID, "test_session_id")
+        req.AddCookie(&http.Cookie{
+            Name:  constant.JWT_TOKEN_NAME,
+            Value: tokenString,
+        })
+        return req
+    }
+
+    t.Run("役割の変更_成功", func(t *testing.T) {
+        resp := httptest.NewRecorder()
+        r.ServeHTTP(resp, newRequest(http.MethodPut, fmt.Sprintf("/user-groups/members/%d/role", member.ID), owner.ID, models.RoleUpdateInput{Role: models.RoleAdmin}))
+
+        if resp.Code != http.StatusOK {
+            t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+            t.Errorf("Error: %v", resp.Body.String())
+        }
+    })
+
+    t.Run("役割の変更_失敗_オーナーへの変更", func(t *testing.T) {
+        resp := httptest.NewRecorder()
+        r.ServeHTTP(resp, newRequest(http.MethodPut, fmt.Sprintf("/user-groups/members/%d/role", member.ID), owner.ID, models.RoleUpdateInput{Role: models.RoleOwner}))
+
+        if resp.Code != http.StatusBadRequest {
+            t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+        }
+    })
+
+    t.Run("役割の変更_失敗_上位の役割のメンバー", func(t *testing.T) {
+        resp := httptest.NewRecorder()
+        r.ServeHTTP(resp, newRequest(http.MethodPut, fmt.Sprintf("/user-groups/members/%d/role", owner.ID), member.ID, models.RoleUpdateInput{Role: models.RoleViewer}))
+
+        if resp.Code != http.StatusForbidden {
+            t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+        }
+    })
+
+    t.Run("オーナーの譲渡_成功", func(t *testing.T) {
+        resp := httptest.NewRecorder()
+        r.ServeHTTP(resp, newRequest(http.MethodPut, "/user-groups/owner", owner.ID, models.OwnershipTransferInput{UserID: member.ID}))
+
+        if resp.Code != http.StatusOK {
+            t.Errorf("Expected HTTP 200 OK, got: %v", resp.Code)
+            t.Errorf("Error: %v", resp.Body.String())
+        }
+
+        newOwner, _ := models.FindUserByID(db, member.ID)
+        if newOwner.Role != models.RoleOwner {
+            t.Errorf("Expected role %s, got: %s", models.RoleOwner, newOwner.Role)
+        }
+    })
+
+    t.Run("オーナーの譲渡_失敗_オーナー以外", func(t *testing.T) {
+        resp := httptest.NewRecorder()
+        r.ServeHTTP(resp, newRequest(http.MethodPut, "/user-groups/owner", owner.ID, models.OwnershipTransferInput{UserID: member.ID}))
+
+        if resp.Code != http.StatusForbidden {
+            t.Errorf("Expected HTTP 403 Forbidden, got: %v", resp.Code)
+        }
+    })
+
+    // 後処理: テスト用のデータを削除
+    db.Unscoped().Delete(&member)
+    db.Unscoped().Delete(&owner)
+    db.Unscoped().Delete(&userGroup)
+}
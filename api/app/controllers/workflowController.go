@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/models"
+)
+
+func (handler *Handler) GetWorkflowHandler(c *gin.Context) {
+	userGroupID, ok := handler.extractUserGroupID(c)
+	if !ok {
+		return
+	}
+
+	handler.respondWithWorkflow(c, userGroupID)
+}
+
+func (handler *Handler) CreateWorkflowStatusHandler(c *gin.Context) {
+	var workflowStatusInput models.WorkflowStatusInput
+	if err := c.ShouldBindJSON(&workflowStatusInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userGroupID, ok := handler.extractUserGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.CreateWorkflowStatus(handler.DB, userGroupID, workflowStatusInput); err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithWorkflow(c, userGroupID)
+}
+
+func (handler *Handler) UpdateWorkflowStatusHandler(c *gin.Context) {
+	var workflowStatusInput models.WorkflowStatusInput
+	if err := c.ShouldBindJSON(&workflowStatusInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// URLからステータスの番号を取得
+	status, err := getIdFromParam(c, "status")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	userGroupID, ok := handler.extractUserGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.UpdateWorkflowStatus(handler.DB, userGroupID, uint(status), workflowStatusInput); err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithWorkflow(c, userGroupID)
+}
+
+func (handler *Handler) DeleteWorkflowStatusHandler(c *gin.Context) {
+	// URLからステータスの番号を取得
+	status, err := getIdFromParam(c, "status")
+	if err != nil {
+		respondWithErrAndMsg(c, http.StatusBadRequest, err.Error(), "IDのフォーマットが不正です")
+		return
+	}
+
+	userGroupID, ok := handler.extractUserGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DeleteWorkflowStatus(handler.DB, userGroupID, uint(status)); err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithWorkflow(c, userGroupID)
+}
+
+func (handler *Handler) UpdateWorkflowTransitionsHandler(c *gin.Context) {
+	var workflowTransitionsInput models.WorkflowTransitionsInput
+	if err := c.ShouldBindJSON(&workflowTransitionsInput); err != nil {
+		log.Printf("Invalid request body: %v", err)
+		log.Printf("リクエスト内容が正しくありません")
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userGroupID, ok := handler.extractUserGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.UpdateWorkflowTransitions(handler.DB, userGroupID, workflowTransitionsInput.Transitions); err != nil {
+		respondWithAuthorizationError(c, err)
+		return
+	}
+
+	handler.respondWithWorkflow(c, userGroupID)
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// ログイン中のユーザーのユーザーグループのIDを取得する
+func (handler *Handler) extractUserGroupID(c *gin.Context) (uint, bool) {
+	// Cookie内のjwtからUSER_IDを取得
+	userID, err := extractUserID(c)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract user ID")
+		return 0, false
+	}
+
+	// USER_IDからUSER_GROUP_IDを取得
+	userGroupID, err := models.FetchUserGroupIDByUserID(handler.DB, userID)
+	if err != nil {
+		respondWithError(c, http.StatusUnauthorized, "Failed to extract userGroup ID")
+		return 0, false
+	}
+
+	return userGroupID, true
+}
+
+func (handler *Handler) respondWithWorkflow(c *gin.Context, userGroupID uint) {
+	workflow, err := models.FetchWorkflow(handler.DB, userGroupID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workflow": workflow,
+	})
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestWorkflowHandlers(t *testing.T) {
+	// テスト用のデータベース接続をセットアップ
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+	handler := &Handler{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/workflow", handler.GetWorkflowHandler)
+	r.POST("/workflow/statuses", handler.CreateWorkflowStatusHandler)
+	r.DELETE("/workflow/statuses/:status", handler.DeleteWorkflowStatusHandler)
+	r.PUT("/workflow/transitions", handler.UpdateWorkflowTransitionsHandler)
+
+	// テストデータの作成
+	userGroup := &models.UserGroup{UserGroup: "Test UserGroup"}
+	db.Create(&userGroup)
+	user := &models.User{Name: "Test User", Password: "testPassword123", Email: "test@example.com", UserGroupID: userGroup.ID, Role: models.RoleAdmin}
+	db.Create(&user)
+	category := &models.Category{Category: "Test Category", UserGroupID: userGroup.ID}
+	db.Create(&category)
+	task := &models.Task{
+		Task:        "Sample Task",
+		Description: "This is a test task",
+		Creator:     user.ID,
+		CategoryID:  category.ID,
+		Status:      2,
+		Responsible: user.ID,
+		Estimate:    ptrToUint(5),
+		StartDate:   ptrToTime(time.Now()),
+	}
+	db.Create(&task)
+
+	newRequest := func(method string, url string, body interface{}) *http.Request {
+		tokenString, _ := utils.GenerateSessionToken(user.ID, "test_session_id")
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{
+			Name:  constant.JWT_TOKEN_NAME,
+			Value: tokenString,
+		})
+		return req
+	}
+
+	var response struct {
+		Workflow models.WorkflowResponse `json:"workflow"`
+	}
+
+	t.Run("取得", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodGet, "/workflow", nil))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Workflow.Statuses) != 4 || response.Workflow.Statuses[3].Kind != models.WorkflowStatusKindArchived {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("追加", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, "/workflow/statuses", models.WorkflowStatusInput{Name: "レビュー", Order: 3, Color: "#FF9800", Kind: models.WorkflowStatusKindActive}))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Workflow.Statuses) != 5 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("失敗_不正な種類", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodPost, "/workflow/statuses", models.WorkflowStatusInput{Name: "Unknown", Color: "#FF9800", Kind: "unknown"}))
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400 Bad Request, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("失敗_タスクがあるステータスの削除", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, newRequest(http.MethodDelete, "/workflow/statuses/2", nil))
+
+		if resp.Code != http.StatusConflict {
+			t.Errorf("Expected HTTP 409 Conflict, got: %v", resp.Code)
+		}
+	})
+
+	t.Run("遷移の更新", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		transitions := models.WorkflowTransitionsInput{Transitions: []models.WorkflowTransitionInput{{From: 1, To: 2}}}
+		r.ServeHTTP(resp, newRequest(http.MethodPut, "/workflow/transitions", transitions))
+
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if resp.Code != http.StatusOK || len(response.Workflow.Transitions) != 1 {
+			t.Errorf("Unexpected response: %v %v", resp.Code, resp.Body.String())
+		}
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Where("user_group_id = ?", userGroup.ID).Delete(&models.WorkflowTransition{})
+	db.Where("user_group_id = ?", userGroup.ID).Delete(&models.WorkflowStatus{})
+	db.Unscoped().Delete(&task)
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
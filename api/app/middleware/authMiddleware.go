@@ -3,31 +3,115 @@ package middleware
 import (
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
 	"github.com/alicend/LookBack/app/utils"
 )
 
-func AuthMiddleware(c *gin.Context) {
-	// トークンが含まれているか確認
-	tokenString, err := c.Cookie(constant.JWT_TOKEN_NAME)
-	if err != nil {
-		log.Printf("Error retrieving cookie: %v", err)
-		log.Printf("認証情報が存在しません")
+// クッキーのアクセストークン、またはAuthorizationヘッダーのパーソナルアクセストークンで認証する
+func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// スクリプトなどからのリクエストはパーソナルアクセストークンで認証
+		if header := c.GetHeader("Authorization"); header != "" {
+			authenticatePersonalAccessToken(c, db, header)
+			return
+		}
+
+		// トークンが含まれているか確認
+		tokenString, err := c.Cookie(constant.JWT_TOKEN_NAME)
+		if err != nil {
+			log.Printf("Error retrieving cookie: %v", err)
+			log.Printf("認証情報が存在しません")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Unauthorized",
+			})
+			c.Abort()
+			return
+		}
+
+		// 正しいトークンか確認
+		token, err := utils.ParseSessionToken(tokenString)
+		if err != nil {
+			log.Printf("Error parsing token: %v", err)
+			log.Printf("認証情報が正しくありません")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Invalid token",
+			})
+			c.Abort()
+			return
+		}
+
+		// 失効していないセッションか確認
+		sessionID, err := utils.GetSessionIDFromToken(token)
+		if err != nil {
+			log.Printf("Error parsing session ID: %v", err)
+			log.Printf("認証情報が正しくありません")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Invalid token",
+			})
+			c.Abort()
+			return
+		}
+
+		session, err := models.FindActiveSession(db, sessionID)
+		if err != nil {
+			log.Printf("セッションが失効しています")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Session revoked",
+			})
+			c.Abort()
+			return
+		}
+
+		// 最終アクセス日時の更新に失敗してもリクエストは継続
+		if err := session.Touch(db, c.ClientIP()); err != nil {
+			log.Printf("Failed to update session: %v", err)
+		}
+
+		c.Set(constant.AUTH_USER_ID_KEY, session.UserID)
+		c.Next()
+	}
+}
+
+// パーソナルアクセストークンでの認証を拒否する
+// パスワードの変更やトークンの発行など、ブラウザからのみ許可する操作に使用する
+func SessionOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(constant.AUTH_TOKEN_SCOPES_KEY); ok {
+			log.Printf("アクセストークンでは操作できません")
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Forbidden",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func authenticatePersonalAccessToken(c *gin.Context, db *gorm.DB, header string) {
+	tokenString := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	if !strings.HasPrefix(header, "Bearer ") || tokenString == "" {
+		log.Printf("認証情報が正しくありません")
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "Unauthorized",
+			"message": "Invalid token",
 		})
 		c.Abort()
 		return
 	}
 
-	// 正しいトークンか確認
-	_, err = utils.ParseSessionToken(tokenString)
+	personalAccessToken, err := models.FindActivePersonalAccessToken(db, tokenString)
 	if err != nil {
-		log.Printf("Error parsing token: %v", err)
-		log.Printf("認証情報が正しくありません")
+		log.Printf("アクセストークンが無効です")
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"message": "Invalid token",
 		})
@@ -35,5 +119,12 @@ func AuthMiddleware(c *gin.Context) {
 		return
 	}
 
+	// 最終使用日時の更新に失敗してもリクエストは継続
+	if err := personalAccessToken.Touch(db); err != nil {
+		log.Printf("Failed to update personal access token: %v", err)
+	}
+
+	c.Set(constant.AUTH_USER_ID_KEY, personalAccessToken.UserID)
+	c.Set(constant.AUTH_TOKEN_SCOPES_KEY, personalAccessToken.ScopeList())
 	c.Next()
-}
\ No newline at end of file
+}
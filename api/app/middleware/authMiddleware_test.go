@@ -2,25 +2,58 @@ package middleware
 
 import (
 	"log"
+	"time"
 	"testing"
 	"net/http"
 	"net/http/httptest"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
 
 	"github.com/alicend/LookBack/app/constant"
 	"github.com/alicend/LookBack/app/utils"
 )
 
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+
+	return gormDB, mock
+}
+
+func sessionRows(lastSeenAt time.Time, revokedAt *time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "token_id", "last_seen_at", "expires_at", "revoked_at"}).
+		AddRow(1, 1, "test_session_id", lastSeenAt, time.Now().Add(time.Hour), revokedAt)
+}
+
 func TestAuthMiddleware_ValidToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
+	db, mock := setupMockDB(t)
 
 	// テスト用の正しいトークンを生成
-	validToken, _ := utils.GenerateSessionToken(1)
+	validToken, _ := utils.GenerateSessionToken(1, "test_session_id")
+
+	// 有効なセッション（最終アクセス日時が新しいので更新しない）
+	mock.ExpectQuery("SELECT (.+) FROM `sessions` WHERE token_id = ?").
+		WithArgs("test_session_id").
+		WillReturnRows(sessionRows(time.Now(), nil))
 
 	router := gin.New()
-	router.Use(AuthMiddleware)
+	router.Use(AuthMiddleware(db))
 
 	router.GET("/protected", func(c *gin.Context) {
 		c.String(http.StatusOK, "Protected endpoint")
@@ -37,16 +70,18 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "Protected endpoint")
+	assert.Nil(t, mock.ExpectationsWereMet())
 }
 
 func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
+	db, _ := setupMockDB(t)
 
 	// テスト用の不正なトークンを生成
 	invalidToken := "invalid_token"
 
 	router := gin.New()
-	router.Use(AuthMiddleware)
+	router.Use(AuthMiddleware(db))
 
 	router.GET("/protected", func(c *gin.Context) {
 		c.String(http.StatusOK, "Protected endpoint")
@@ -67,4 +102,163 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 	assert.Contains(t, w.Body.String(), "Invalid token")
-}
\ No newline at end of file
+}
+
+func TestAuthMiddleware_RevokedSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock := setupMockDB(t)
+
+	validToken, _ := utils.GenerateSessionToken(1, "test_session_id")
+
+	// 失効済みのセッション
+	revokedAt := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM `sessions` WHERE token_id = ?").
+		WithArgs("test_session_id").
+		WillReturnRows(sessionRows(time.Now(), &revokedAt))
+
+	router := gin.New()
+	router.Use(AuthMiddleware(db))
+
+	router.GET("/protected", func(c *gin.Context) {
+		c.String(http.StatusOK, "Protected endpoint")
+	})
+
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  constant.JWT_TOKEN_NAME,
+		Value: validToken,
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "Session revoked")
+}
+
+func TestAuthMiddleware_UpdatesLastSeen(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock := setupMockDB(t)
+
+	validToken, _ := utils.GenerateSessionToken(1, "test_session_id")
+
+	// 最終アクセス日時が古いセッションは更新される
+	mock.ExpectQuery("SELECT (.+) FROM `sessions` WHERE token_id = ?").
+		WithArgs("test_session_id").
+		WillReturnRows(sessionRows(time.Now().Add(-time.Hour), nil))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `sessions` SET (.+) WHERE id = ?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	router := gin.New()
+	router.Use(AuthMiddleware(db))
+
+	router.GET("/protected", func(c *gin.Context) {
+		c.String(http.StatusOK, "Protected endpoint")
+	})
+
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  constant.JWT_TOKEN_NAME,
+		Value: validToken,
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func personalAccessTokenRows(scopes string, expiresAt *time.Time, lastUsedAt *time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "name", "token_hash", "scopes", "expires_at", "last_used_at"}).
+		AddRow(1, 1, "CI", utils.HashToken("lbpat_test"), scopes, expiresAt, lastUsedAt)
+}
+
+func TestAuthMiddleware_PersonalAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock := setupMockDB(t)
+
+	// 有効なトークン（最終使用日時を更新する）
+	mock.ExpectQuery("SELECT (.+) FROM `personal_access_tokens` WHERE token_hash = ?").
+		WithArgs(utils.HashToken("lbpat_test")).
+		WillReturnRows(personalAccessTokenRows("tasks:read", nil, nil))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `personal_access_tokens` SET `last_used_at`").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	router := gin.New()
+	router.Use(AuthMiddleware(db))
+
+	router.GET("/protected", func(c *gin.Context) {
+		scopes, _ := c.Get(constant.AUTH_TOKEN_SCOPES_KEY)
+		assert.Equal(t, uint(1), c.GetUint(constant.AUTH_USER_ID_KEY))
+		assert.Equal(t, []string{"tasks:read"}, scopes)
+		c.String(http.StatusOK, "Protected endpoint")
+	})
+
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer lbpat_test")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthMiddleware_ExpiredPersonalAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock := setupMockDB(t)
+
+	expiredAt := time.Now().Add(-time.Hour)
+	mock.ExpectQuery("SELECT (.+) FROM `personal_access_tokens` WHERE token_hash = ?").
+		WithArgs(utils.HashToken("lbpat_test")).
+		WillReturnRows(personalAccessTokenRows("tasks:read", &expiredAt, nil))
+
+	router := gin.New()
+	router.Use(AuthMiddleware(db))
+
+	router.GET("/protected", func(c *gin.Context) {
+		c.String(http.StatusOK, "Protected endpoint")
+	})
+
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer lbpat_test")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestSessionOnlyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			c.Set(constant.AUTH_TOKEN_SCOPES_KEY, []string{"tasks:read"})
+		}
+		c.Next()
+	})
+	router.Use(SessionOnlyMiddleware())
+	router.GET("/protected", func(c *gin.Context) {
+		c.String(http.StatusOK, "Protected endpoint")
+	})
+
+	// アクセストークンでの認証は拒否する
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer lbpat_test")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// クッキーでの認証は許可する
+	req = httptest.NewRequest("GET", "/protected", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+// ダブルサブミットクッキー方式のCSRF対策
+// 状態を変更するリクエストでは、クッキーのトークンとヘッダーのトークンが一致することを確認する
+// 他のサイトからはクッキーの値を読めず、ヘッダーも付けられないため、偽造したリクエストを拒否できる
+// アクセストークン(Bearer)での認証はクッキーを使わないため対象外
+func CSRFMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if strings.HasPrefix(c.GetHeader("Authorization"), "Bearer ") {
+			c.Next()
+			return
+		}
+
+		cookieToken, err := c.Cookie(constant.CSRF_TOKEN_NAME)
+		headerToken := c.GetHeader(constant.CSRF_HEADER_NAME)
+		if err != nil || cookieToken == "" || subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) != 1 {
+			log.Printf("CSRF token mismatch: %s %s from %s", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "invalid csrf token",
+				"message": "ページを再読み込みしてから再度お試しください",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
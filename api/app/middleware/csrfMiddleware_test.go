@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(CSRFMiddleware())
+	router.GET("/tasks", func(c *gin.Context) {
+		c.String(http.StatusOK, "OK")
+	})
+	router.POST("/tasks", func(c *gin.Context) {
+		c.String(http.StatusOK, "OK")
+	})
+
+	tests := []struct {
+		name          string
+		method        string
+		cookieToken   string
+		headerToken   string
+		authorization string
+		expected      int
+	}{
+		{"参照は対象外", http.MethodGet, "", "", "", http.StatusOK},
+		{"成功_トークンが一致", http.MethodPost, "token", "token", "", http.StatusOK},
+		{"成功_アクセストークンでの認証", http.MethodPost, "", "", "Bearer lbpat_test", http.StatusOK},
+		{"失敗_トークンなし", http.MethodPost, "", "", "", http.StatusForbidden},
+		{"失敗_ヘッダーなし", http.MethodPost, "token", "", "", http.StatusForbidden},
+		{"失敗_クッキーなし", http.MethodPost, "", "token", "", http.StatusForbidden},
+		{"失敗_トークンが異なる", http.MethodPost, "token", "forged", "", http.StatusForbidden},
+		{"失敗_Bearer以外の認証", http.MethodPost, "", "", "Basic dXNlcjpwYXNz", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/tasks", nil)
+			if tt.cookieToken != "" {
+				req.AddCookie(&http.Cookie{Name: constant.CSRF_TOKEN_NAME, Value: tt.cookieToken})
+			}
+			if tt.headerToken != "" {
+				req.Header.Set(constant.CSRF_HEADER_NAME, tt.headerToken)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
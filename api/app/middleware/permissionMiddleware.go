@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+)
+
+// ユーザーグループ内の役割に操作が許可されているか確認する
+// パーソナルアクセストークンの場合は、トークンのスコープでも許可されている必要がある
+// AuthMiddlewareの後に使用する
+func PermissionMiddleware(db *gorm.DB, permission models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint(constant.AUTH_USER_ID_KEY)
+		if userID == 0 {
+			log.Printf("認証情報が存在しません")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Unauthorized",
+			})
+			c.Abort()
+			return
+		}
+
+		user, err := models.FindUserByID(db, userID)
+		if err != nil {
+			log.Printf("ユーザーの取得に失敗しました")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Unauthorized",
+			})
+			c.Abort()
+			return
+		}
+
+		if !models.HasPermission(user.Role, permission) {
+			log.Printf("User %d (%s) does not have permission %s", user.ID, user.Role, permission)
+			log.Printf("操作が許可されていません")
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Forbidden",
+			})
+			c.Abort()
+			return
+		}
+
+		if scopes, ok := c.Get(constant.AUTH_TOKEN_SCOPES_KEY); ok && !models.ScopesAllow(scopes.([]string), permission) {
+			log.Printf("Personal access token of user %d does not have scope for %s", user.ID, permission)
+			log.Printf("アクセストークンのスコープで許可されていません")
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Forbidden",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
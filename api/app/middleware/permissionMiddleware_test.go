@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/models"
+)
+
+func TestPermissionMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		role       string
+		permission models.Permission
+		expected   int
+	}{
+		{"閲覧者_タスクの取得", models.RoleViewer, models.PermissionReadTasks, http.StatusOK},
+		{"閲覧者_タスクの更新", models.RoleViewer, models.PermissionWriteTasks, http.StatusForbidden},
+		{"メンバー_タスクの更新", models.RoleMember, models.PermissionWriteTasks, http.StatusOK},
+		{"メンバー_招待", models.RoleMember, models.PermissionSendInvitations, http.StatusForbidden},
+		{"管理者_招待", models.RoleAdmin, models.PermissionSendInvitations, http.StatusOK},
+		{"管理者_ユーザーグループの削除", models.RoleAdmin, models.PermissionDeleteUserGroup, http.StatusForbidden},
+		{"オーナー_ユーザーグループの削除", models.RoleOwner, models.PermissionDeleteUserGroup, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+
+			mock.ExpectQuery("SELECT (.+) FROM `users` WHERE ID = ?").
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_group_id", "role"}).AddRow(1, 1, tt.role))
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set(constant.AUTH_USER_ID_KEY, uint(1))
+				c.Next()
+			})
+			router.Use(PermissionMiddleware(db, tt.permission))
+			router.GET("/protected", func(c *gin.Context) {
+				c.String(http.StatusOK, "Protected endpoint")
+			})
+
+			req := httptest.NewRequest("GET", "/protected", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPermissionMiddleware_Unauthenticated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, _ := setupMockDB(t)
+
+	router := gin.New()
+	router.Use(PermissionMiddleware(db, models.PermissionReadTasks))
+	router.GET("/protected", func(c *gin.Context) {
+		c.String(http.StatusOK, "Protected endpoint")
+	})
+
+	req := httptest.NewRequest("GET", "/protected", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestPermissionMiddleware_PersonalAccessTokenScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		scopes     []string
+		permission models.Permission
+		expected   int
+	}{
+		{"タスクの参照_タスクの取得", []string{models.ScopeReadTasks}, models.PermissionReadTasks, http.StatusOK},
+		{"タスクの参照_タスクの更新", []string{models.ScopeReadTasks}, models.PermissionWriteTasks, http.StatusForbidden},
+		{"タスクの更新_タスクの更新", []string{models.ScopeWriteTasks}, models.PermissionWriteTasks, http.StatusOK},
+		{"カテゴリーの管理_カテゴリーの更新", []string{models.ScopeManageCategories}, models.PermissionWriteCategories, http.StatusOK},
+		{"すべてのスコープ_招待", []string{models.ScopeReadTasks, models.ScopeWriteTasks, models.ScopeManageCategories}, models.PermissionSendInvitations, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+
+			// オーナーでもスコープ外の操作はできない
+			mock.ExpectQuery("SELECT (.+) FROM `users` WHERE ID = ?").
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_group_id", "role"}).AddRow(1, 1, models.RoleOwner))
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set(constant.AUTH_USER_ID_KEY, uint(1))
+				c.Set(constant.AUTH_TOKEN_SCOPES_KEY, tt.scopes)
+				c.Next()
+			})
+			router.Use(PermissionMiddleware(db, tt.permission))
+			router.GET("/protected", func(c *gin.Context) {
+				c.String(http.StatusOK, "Protected endpoint")
+			})
+
+			req := httptest.NewRequest("GET", "/protected", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alicend/LookBack/app/utils"
+)
+
+// IPアドレスごとのリクエスト数を制限する
+// nameごとに別々に数える
+func RateLimitMiddleware(limiter *utils.RateLimiter, name string, limit utils.RateLimit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := limiter.Allow(utils.RateLimitKey(name, c.ClientIP()), limit)
+		if !allowed {
+			log.Printf("Rate limit exceeded: %s from %s", name, c.ClientIP())
+			log.Printf("リクエスト数が上限に達しました")
+			RespondTooManyRequests(c, retryAfter)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// 再試行までの秒数をRetry-Afterヘッダーに設定して429を返す
+// ハンドラー内でのメール送信回数やログイン試行の制限でも使う
+func RespondTooManyRequests(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":   "Too many requests",
+		"message": "リクエストが多すぎます。しばらくしてから再度お試しください",
+	})
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := utils.NewRateLimiter(utils.RateLimitConfig{})
+	router := gin.New()
+	router.Use(RateLimitMiddleware(limiter, "login", utils.RateLimit{Max: 2, Window: time.Minute}))
+	router.POST("/login", func(c *gin.Context) {
+		c.String(http.StatusOK, "OK")
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/login", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// 上限を超えると429とRetry-Afterヘッダーを返す
+	req := httptest.NewRequest("POST", "/login", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,356 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+// 監査ログに記録する操作
+const (
+	AuditActionLoginSucceeded       = "login.succeeded"
+	AuditActionLoginFailed          = "login.failed"
+	AuditActionLogout               = "logout"
+	AuditActionPasswordChanged      = "password.changed"
+	AuditActionPasswordReset        = "password.reset"
+	AuditActionEmailChanged         = "email.changed"
+	AuditActionUserGroupChanged     = "user.user_group_changed"
+	AuditActionUserDeleted          = "user.deleted"
+	AuditActionInvitationSent       = "invitation.sent"
+	AuditActionRoleChanged          = "role.changed"
+	AuditActionOwnershipTransferred = "ownership.transferred"
+	AuditActionUserGroupUpdated     = "user_group.updated"
+	AuditActionUserGroupDeleted     = "user_group.deleted"
+	AuditActionUserGroupRestored    = "user_group.restored"
+)
+
+// 監査ログテーブル定義
+// ユーザーグループごとに直前のエントリのハッシュを含めたハッシュで連結し、改ざんや削除を検出できるようにする
+// 保存期間を過ぎたエントリの削除以外では更新も削除もしない
+type AuditLog struct {
+	ID           uint      `gorm:"primarykey"`
+	CreatedAt    time.Time `gorm:"not null;index"`
+	UserGroupID  uint      `gorm:"not null;index"` // 0: ユーザーを特定できない操作
+	Action       string    `gorm:"size:50;not null;index"`
+	ActorID      uint      `gorm:"not null;index"` // 0: 未ログインでの操作
+	TargetUserID uint      `gorm:"not null;index"`
+	Detail       string    `gorm:"size:255;not null"`
+	IPAddress    string    `gorm:"size:45;not null"`
+	UserAgent    string    `gorm:"size:255;not null"`
+	PrevHash     string    `gorm:"size:64;not null"`
+	Hash         string    `gorm:"size:64;not null;unique"`
+}
+
+// 保存期間を過ぎて削除した監査ログの記録
+// 削除した最後のエントリのハッシュを残し、残っている最も古いエントリがそれに連結しているか検証できるようにする
+type AuditLogCheckpoint struct {
+	UserGroupID uint      `gorm:"primarykey;autoIncrement:false"`
+	UpdatedAt   time.Time `gorm:"not null"`
+	LastHash    string    `gorm:"size:64;not null"`
+	PurgedCount int64     `gorm:"not null"`
+}
+
+// 監査ログの検索条件
+type AuditLogQuery struct {
+	Action       string    `form:"action"`
+	ActorID      uint      `form:"actor_id"`
+	TargetUserID uint      `form:"target_user_id"`
+	From         time.Time `form:"from" time_format:"2006-01-02"`
+	To           time.Time `form:"to" time_format:"2006-01-02"` // 指定した日を含む
+	Page         int       `form:"page" binding:"omitempty,min=1"`
+	PerPage      int       `form:"per_page" binding:"omitempty,min=1,max=200"`
+}
+
+// 監査ログ一覧取得
+type AuditLogResponse struct {
+	ID             uint
+	Action         string
+	ActorID        uint
+	ActorName      string
+	TargetUserID   uint
+	TargetUserName string
+	Detail         string
+	IPAddress      string
+	UserAgent      string
+	CreatedAt      string
+}
+
+// 監査ログの検証結果
+// 末尾のエントリの削除は連結からは検出できないため、LastHashを控えておき次の検証結果と比較する
+type AuditLogVerification struct {
+	Valid    bool
+	Checked  int
+	Purged   int64  // 保存期間を過ぎて削除した件数
+	BrokenAt uint   // 最初に検証に失敗したエントリのID
+	LastHash string // 最新のエントリのハッシュ
+}
+
+func (auditLog *AuditLog) MigrateAuditLog(db *gorm.DB) error {
+	// 自動マイグレーション(AuditLogテーブルとAuditLogCheckpointテーブルを作成)
+	migrateErr := db.AutoMigrate(&AuditLog{}, &AuditLogCheckpoint{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// 監査ログを記録する
+// 同じユーザーグループの最後のエントリをロックし、そのハッシュに連結する
+// エントリがすべて削除されている場合は、削除した最後のエントリのハッシュに連結する
+func CreateAuditLog(db *gorm.DB, auditLog *AuditLog) error {
+	auditLog.UserAgent = truncate(auditLog.UserAgent, 255)
+	auditLog.Detail = truncate(auditLog.Detail, 255)
+	// データベースに保存される精度に揃えてからハッシュを計算する
+	auditLog.CreatedAt = time.Now().Truncate(time.Millisecond)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var last AuditLog
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_group_id = ?", auditLog.UserGroupID).
+			Order("id desc").
+			Limit(1).
+			Find(&last)
+		if result.Error != nil {
+			log.Printf("Error fetching last audit log: %v\n", result.Error)
+			return result.Error
+		}
+
+		if last.ID == 0 {
+			checkpoint, err := fetchAuditLogCheckpoint(tx.Clauses(clause.Locking{Strength: "UPDATE"}), auditLog.UserGroupID)
+			if err != nil {
+				return err
+			}
+			last.Hash = checkpoint.LastHash
+		}
+
+		auditLog.PrevHash = last.Hash
+		auditLog.Hash = auditLog.computeHash()
+
+		if err := tx.Create(auditLog).Error; err != nil {
+			log.Printf("Error creating audit log: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("監査ログの記録に成功")
+
+	return nil
+}
+
+// ユーザーグループの監査ログを新しい順に取得し、条件に一致する件数とあわせて返す
+func FetchAuditLogs(db *gorm.DB, userGroupID uint, query AuditLogQuery) ([]AuditLogResponse, int64, error) {
+	page, perPage := query.Page, query.PerPage
+	if page == 0 {
+		page = 1
+	}
+	if perPage == 0 {
+		perPage = constant.AUDIT_LOG_DEFAULT_PAGE_SIZE
+	}
+
+	filtered := db.Model(&AuditLog{}).Where("audit_logs.user_group_id = ?", userGroupID)
+	if query.Action != "" {
+		filtered = filtered.Where("audit_logs.action = ?", query.Action)
+	}
+	if query.ActorID != 0 {
+		filtered = filtered.Where("audit_logs.actor_id = ?", query.ActorID)
+	}
+	if query.TargetUserID != 0 {
+		filtered = filtered.Where("audit_logs.target_user_id = ?", query.TargetUserID)
+	}
+	if !query.From.IsZero() {
+		filtered = filtered.Where("audit_logs.created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		filtered = filtered.Where("audit_logs.created_at < ?", query.To.AddDate(0, 0, 1))
+	}
+	// 件数の取得と一覧の取得で同じ条件を使う
+	filtered = filtered.Session(&gorm.Session{})
+
+	var total int64
+	if err := filtered.Count(&total).Error; err != nil {
+		log.Printf("Error counting audit logs: %v", err)
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		AuditLog
+		ActorName      string
+		TargetUserName string
+	}
+	result := filtered.
+		Select("audit_logs.*, actors.name AS actor_name, targets.name AS target_user_name").
+		Joins("LEFT JOIN users AS actors ON actors.id = audit_logs.actor_id").
+		Joins("LEFT JOIN users AS targets ON targets.id = audit_logs.target_user_id").
+		Order("audit_logs.id desc").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Scan(&rows)
+	if result.Error != nil {
+		log.Printf("Error fetching audit logs: %v", result.Error)
+		return nil, 0, result.Error
+	}
+	log.Printf("監査ログの取得に成功")
+
+	auditLogResponses := make([]AuditLogResponse, len(rows))
+	for i, row := range rows {
+		auditLogResponses[i] = AuditLogResponse{
+			ID:             row.ID,
+			Action:         row.Action,
+			ActorID:        row.ActorID,
+			ActorName:      row.ActorName,
+			TargetUserID:   row.TargetUserID,
+			TargetUserName: row.TargetUserName,
+			Detail:         row.Detail,
+			IPAddress:      row.IPAddress,
+			UserAgent:      row.UserAgent,
+			CreatedAt:      row.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+	}
+
+	return auditLogResponses, total, nil
+}
+
+// ユーザーグループの監査ログのハッシュの連結を検証する
+// 保存期間を過ぎて削除された後は、削除した最後のエントリのハッシュを起点にする
+func VerifyAuditLogs(db *gorm.DB, userGroupID uint) (AuditLogVerification, error) {
+	checkpoint, err := fetchAuditLogCheckpoint(db, userGroupID)
+	if err != nil {
+		return AuditLogVerification{}, err
+	}
+
+	var auditLogs []AuditLog
+
+	result := db.Where("user_group_id = ?", userGroupID).Order("id asc").Find(&auditLogs)
+	if result.Error != nil {
+		log.Printf("Error fetching audit logs: %v", result.Error)
+		return AuditLogVerification{}, result.Error
+	}
+
+	verification := verifyAuditLogChain(checkpoint, auditLogs)
+	if !verification.Valid {
+		log.Printf("Audit log of user group %d is broken at %d", userGroupID, verification.BrokenAt)
+	}
+
+	return verification, nil
+}
+
+// 保存期間を過ぎた監査ログを削除し、削除した件数を返す
+// ユーザーグループごとに古い順に連続するエントリだけを削除し、削除した最後のエントリのハッシュと件数を記録する
+func PurgeAuditLogs(db *gorm.DB, before time.Time) (int64, error) {
+	var groups []struct {
+		UserGroupID uint
+		LastID      uint
+	}
+	result := db.Model(&AuditLog{}).
+		Select("user_group_id, MAX(id) AS last_id").
+		Where("created_at < ?", before).
+		Group("user_group_id").
+		Scan(&groups)
+	if result.Error != nil {
+		log.Printf("Error fetching audit logs to purge: %v", result.Error)
+		return 0, result.Error
+	}
+
+	var purged int64
+	for _, group := range groups {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var last AuditLog
+			if err := tx.Where("id = ?", group.LastID).First(&last).Error; err != nil {
+				log.Printf("Error fetching audit log with ID %d: %v", group.LastID, err)
+				return err
+			}
+
+			checkpoint, err := fetchAuditLogCheckpoint(tx.Clauses(clause.Locking{Strength: "UPDATE"}), group.UserGroupID)
+			if err != nil {
+				return err
+			}
+
+			result := tx.Where("user_group_id = ? AND id <= ?", group.UserGroupID, group.LastID).Delete(&AuditLog{})
+			if result.Error != nil {
+				log.Printf("Error purging audit logs: %v", result.Error)
+				return result.Error
+			}
+
+			checkpoint.UserGroupID = group.UserGroupID
+			checkpoint.LastHash = last.Hash
+			checkpoint.PurgedCount += result.RowsAffected
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&checkpoint).Error; err != nil {
+				log.Printf("Error saving audit log checkpoint: %v", err)
+				return err
+			}
+
+			purged += result.RowsAffected
+			return nil
+		})
+		if err != nil {
+			return purged, err
+		}
+	}
+	log.Printf("保存期間を過ぎた監査ログの削除に成功")
+
+	return purged, nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// 直前のエントリのハッシュと記録内容からハッシュを計算する
+func (auditLog *AuditLog) computeHash() string {
+	content, _ := json.Marshal([]interface{}{
+		auditLog.PrevHash,
+		auditLog.CreatedAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+		auditLog.UserGroupID,
+		auditLog.Action,
+		auditLog.ActorID,
+		auditLog.TargetUserID,
+		auditLog.Detail,
+		auditLog.IPAddress,
+		auditLog.UserAgent,
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// 古い順に並んだエントリが改ざんされず、先頭や途中のエントリも削除されていないか検証する
+// 最も古いエントリは、保存期間を過ぎて削除した最後のエントリ（削除していなければ空）に連結していなければならない
+func verifyAuditLogChain(checkpoint AuditLogCheckpoint, auditLogs []AuditLog) AuditLogVerification {
+	verification := AuditLogVerification{Valid: true, Purged: checkpoint.PurgedCount}
+
+	prevHash := checkpoint.LastHash
+	for _, auditLog := range auditLogs {
+		if auditLog.PrevHash != prevHash || auditLog.Hash != auditLog.computeHash() {
+			verification.Valid = false
+			verification.BrokenAt = auditLog.ID
+			return verification
+		}
+		prevHash = auditLog.Hash
+		verification.Checked++
+	}
+	verification.LastHash = prevHash
+
+	return verification
+}
+
+// ユーザーグループの監査ログの削除の記録を取得する（削除していない場合は空）
+func fetchAuditLogCheckpoint(db *gorm.DB, userGroupID uint) (AuditLogCheckpoint, error) {
+	var checkpoint AuditLogCheckpoint
+	if err := db.Where("user_group_id = ?", userGroupID).Limit(1).Find(&checkpoint).Error; err != nil {
+		log.Printf("Error fetching audit log checkpoint: %v", err)
+		return AuditLogCheckpoint{}, err
+	}
+
+	return checkpoint, nil
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+// ハッシュで連結した監査ログを作成する
+func newAuditLogChain(actions ...string) []AuditLog {
+	auditLogs := make([]AuditLog, len(actions))
+	prevHash := ""
+	for i, action := range actions {
+		auditLogs[i] = AuditLog{
+			ID:          uint(i + 1),
+			CreatedAt:   time.Date(2023, 10, 1, 9, 0, i, 0, time.UTC),
+			UserGroupID: 1,
+			Action:      action,
+			ActorID:     1,
+			PrevHash:    prevHash,
+		}
+		auditLogs[i].Hash = auditLogs[i].computeHash()
+		prevHash = auditLogs[i].Hash
+	}
+	return auditLogs
+}
+
+func TestVerifyAuditLogChain(t *testing.T) {
+	t.Run("改ざんなし", func(t *testing.T) {
+		auditLogs := newAuditLogChain(AuditActionLoginSucceeded, AuditActionPasswordChanged, AuditActionLogout)
+
+		verification := verifyAuditLogChain(AuditLogCheckpoint{}, auditLogs)
+		assert.True(t, verification.Valid)
+		assert.Equal(t, 3, verification.Checked)
+		assert.Equal(t, auditLogs[2].Hash, verification.LastHash)
+	})
+
+	t.Run("保存期間を過ぎて古いエントリが削除された", func(t *testing.T) {
+		auditLogs := newAuditLogChain(AuditActionLoginSucceeded, AuditActionPasswordChanged, AuditActionLogout)
+		checkpoint := AuditLogCheckpoint{UserGroupID: 1, LastHash: auditLogs[0].Hash, PurgedCount: 1}
+
+		verification := verifyAuditLogChain(checkpoint, auditLogs[1:])
+		assert.True(t, verification.Valid)
+		assert.Equal(t, int64(1), verification.Purged)
+	})
+
+	t.Run("先頭のエントリが削除された", func(t *testing.T) {
+		auditLogs := newAuditLogChain(AuditActionLoginSucceeded, AuditActionPasswordChanged, AuditActionLogout)
+
+		verification := verifyAuditLogChain(AuditLogCheckpoint{}, auditLogs[1:])
+		assert.False(t, verification.Valid)
+		assert.Equal(t, uint(2), verification.BrokenAt)
+
+		// 削除の記録より後のエントリまで削除された場合も検出する
+		checkpoint := AuditLogCheckpoint{UserGroupID: 1, LastHash: auditLogs[0].Hash, PurgedCount: 1}
+		verification = verifyAuditLogChain(checkpoint, auditLogs[2:])
+		assert.False(t, verification.Valid)
+		assert.Equal(t, uint(3), verification.BrokenAt)
+	})
+
+	t.Run("末尾のエントリが削除された", func(t *testing.T) {
+		auditLogs := newAuditLogChain(AuditActionLoginSucceeded, AuditActionPasswordChanged, AuditActionLogout)
+
+		// 連結は崩れないため、控えておいた最新のハッシュと比較して検出する
+		verification := verifyAuditLogChain(AuditLogCheckpoint{}, auditLogs[:2])
+		assert.True(t, verification.Valid)
+		assert.NotEqual(t, auditLogs[2].Hash, verification.LastHash)
+	})
+
+	t.Run("内容が書き換えられた", func(t *testing.T) {
+		auditLogs := newAuditLogChain(AuditActionLoginSucceeded, AuditActionRoleChanged, AuditActionLogout)
+		auditLogs[1].Detail = "role: owner"
+
+		verification := verifyAuditLogChain(AuditLogCheckpoint{}, auditLogs)
+		assert.False(t, verification.Valid)
+		assert.Equal(t, uint(2), verification.BrokenAt)
+	})
+
+	t.Run("途中のエントリが削除された", func(t *testing.T) {
+		auditLogs := newAuditLogChain(AuditActionLoginSucceeded, AuditActionUserDeleted, AuditActionLogout)
+		auditLogs = append(auditLogs[:1], auditLogs[2:]...)
+
+		verification := verifyAuditLogChain(AuditLogCheckpoint{}, auditLogs)
+		assert.False(t, verification.Valid)
+		assert.Equal(t, uint(3), verification.BrokenAt)
+	})
+}
+
+func TestAuditLog(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	otherUserGroup := &UserGroup{UserGroup: "OtherUserGroup"}
+	db.Create(otherUserGroup)
+	admin := &User{Name: "Admin", Password: "TestPassword", Email: "admin@example.com", UserGroupID: userGroup.ID, Role: RoleAdmin}
+	db.Create(admin)
+	member := &User{Name: "Member", Password: "TestPassword", Email: "member@example.com", UserGroupID: userGroup.ID, Role: RoleMember}
+	db.Create(member)
+
+	entries := []AuditLog{
+		{UserGroupID: userGroup.ID, Action: AuditActionLoginSucceeded, ActorID: admin.ID, TargetUserID: admin.ID, IPAddress: "127.0.0.1"},
+		{UserGroupID: userGroup.ID, Action: AuditActionRoleChanged, ActorID: admin.ID, TargetUserID: member.ID, Detail: "role: viewer"},
+		{UserGroupID: userGroup.ID, Action: AuditActionLoginFailed, TargetUserID: member.ID, Detail: "password"},
+		{UserGroupID: otherUserGroup.ID, Action: AuditActionLoginSucceeded},
+	}
+	for i := range entries {
+		err := CreateAuditLog(db, &entries[i])
+		assert.Nil(t, err)
+	}
+
+	// 同じユーザーグループの直前のエントリに連結する
+	assert.Equal(t, "", entries[0].PrevHash)
+	assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+	assert.Equal(t, entries[1].Hash, entries[2].PrevHash)
+	assert.Equal(t, "", entries[3].PrevHash)
+
+	t.Run("検索", func(t *testing.T) {
+		auditLogs, total, err := FetchAuditLogs(db, userGroup.ID, AuditLogQuery{})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, entries[2].ID, auditLogs[0].ID)
+		assert.Equal(t, "Member", auditLogs[0].TargetUserName)
+
+		auditLogs, total, err = FetchAuditLogs(db, userGroup.ID, AuditLogQuery{TargetUserID: member.ID, Action: AuditActionRoleChanged})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, "Admin", auditLogs[0].ActorName)
+
+		auditLogs, total, err = FetchAuditLogs(db, userGroup.ID, AuditLogQuery{Page: 2, PerPage: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Len(t, auditLogs, 1)
+		assert.Equal(t, entries[0].ID, auditLogs[0].ID)
+
+		_, total, err = FetchAuditLogs(db, userGroup.ID, AuditLogQuery{To: time.Now().AddDate(0, 0, -1)})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), total)
+	})
+
+	t.Run("検証", func(t *testing.T) {
+		verification, err := VerifyAuditLogs(db, userGroup.ID)
+		assert.Nil(t, err)
+		assert.True(t, verification.Valid)
+		assert.Equal(t, 3, verification.Checked)
+
+		// 直接書き換えられたエントリを検出する
+		db.Model(&AuditLog{}).Where("id = ?", entries[1].ID).Update("detail", "role: admin")
+		verification, err = VerifyAuditLogs(db, userGroup.ID)
+		assert.Nil(t, err)
+		assert.False(t, verification.Valid)
+		assert.Equal(t, entries[1].ID, verification.BrokenAt)
+	})
+
+	t.Run("保存期間を過ぎたエントリの削除", func(t *testing.T) {
+		db.Model(&AuditLog{}).Where("id = ?", entries[0].ID).Update("created_at", time.Now().AddDate(-2, 0, 0))
+
+		purged, err := PurgeAuditLogs(db, time.Now().AddDate(-1, 0, 0))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		_, total, _ := FetchAuditLogs(db, userGroup.ID, AuditLogQuery{})
+		assert.Equal(t, int64(2), total)
+
+		// 削除した最後のエントリのハッシュと件数を記録する
+		checkpoint, err := fetchAuditLogCheckpoint(db, userGroup.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, entries[0].Hash, checkpoint.LastHash)
+		verification, err := VerifyAuditLogs(db, userGroup.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), verification.Purged)
+
+		// すべて削除した後のエントリは削除の記録に連結する
+		db.Model(&AuditLog{}).Where("user_group_id = ?", otherUserGroup.ID).Update("created_at", time.Now().AddDate(-2, 0, 0))
+		_, err = PurgeAuditLogs(db, time.Now().AddDate(-1, 0, 0))
+		assert.Nil(t, err)
+		next := AuditLog{UserGroupID: otherUserGroup.ID, Action: AuditActionLogout}
+		assert.Nil(t, CreateAuditLog(db, &next))
+		assert.Equal(t, entries[3].Hash, next.PrevHash)
+		verification, err = VerifyAuditLogs(db, otherUserGroup.ID)
+		assert.Nil(t, err)
+		assert.True(t, verification.Valid)
+		assert.Equal(t, 1, verification.Checked)
+	})
+
+	// テストデータの削除
+	db.Unscoped().Where("user_group_id IN ?", []uint{userGroup.ID, otherUserGroup.ID}).Delete(&AuditLog{})
+	db.Where("user_group_id IN ?", []uint{userGroup.ID, otherUserGroup.ID}).Delete(&AuditLogCheckpoint{})
+	db.Unscoped().Delete(admin)
+	db.Unscoped().Delete(member)
+	db.Unscoped().Delete(userGroup)
+	db.Unscoped().Delete(otherUserGroup)
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound               = errors.New("指定されたデータは存在しません")
+	ErrForbidden              = errors.New("この操作を行う権限がありません")
+	ErrInvalidTaskCategory    = errors.New("指定されたカテゴリーは存在しません")
+	ErrInvalidTaskResponsible = errors.New("指定された担当者は存在しません")
+)
+
+// タスクが指定したユーザーグループのものか確認する
+// タスクの所属はカテゴリーのユーザーグループで判断する
+func AuthorizeTask(db *gorm.DB, taskID int, userGroupID uint) error {
+	var task Task
+	if err := db.Preload("Category").Where("id = ?", taskID).First(&task).Error; err != nil {
+		log.Printf("Error fetching task with ID %d: %v\n", taskID, err)
+		return toAuthorizationError(err)
+	}
+
+	if task.Category.UserGroupID != userGroupID {
+		log.Printf("Task %d does not belong to user group %d", taskID, userGroupID)
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// カテゴリーが指定したユーザーグループのものか確認する
+func AuthorizeCategory(db *gorm.DB, categoryID int, userGroupID uint) error {
+	var category Category
+	if err := db.Where("id = ?", categoryID).First(&category).Error; err != nil {
+		log.Printf("Error fetching category with ID %d: %v\n", categoryID, err)
+		return toAuthorizationError(err)
+	}
+
+	if category.UserGroupID != userGroupID {
+		log.Printf("Category %d does not belong to user group %d", categoryID, userGroupID)
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// 繰り返しタスクが指定したユーザーグループのものか確認する
+func AuthorizeRecurringTask(db *gorm.DB, recurringTaskID int, userGroupID uint) error {
+	var recurringTask RecurringTask
+	if err := db.Preload("Category").Where("id = ?", recurringTaskID).First(&recurringTask).Error; err != nil {
+		log.Printf("Error fetching recurring task with ID %d: %v\n", recurringTaskID, err)
+		return toAuthorizationError(err)
+	}
+
+	if recurringTask.Category.UserGroupID != userGroupID {
+		log.Printf("Recurring task %d does not belong to user group %d", recurringTaskID, userGroupID)
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// 操作対象のユーザーグループがログイン中のユーザーの所属するユーザーグループか確認する
+func AuthorizeUserGroup(db *gorm.DB, targetUserGroupID int, userGroupID uint) error {
+	var userGroup UserGroup
+	if err := db.Where("id = ?", targetUserGroupID).First(&userGroup).Error; err != nil {
+		log.Printf("Error fetching user group with ID %d: %v\n", targetUserGroupID, err)
+		return toAuthorizationError(err)
+	}
+
+	if userGroup.ID != userGroupID {
+		log.Printf("User group %d is not user group %d", targetUserGroupID, userGroupID)
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// タスクのカテゴリーと担当者が指定したユーザーグループのものか確認する
+// 他のユーザーグループのデータの存在を知られないよう、存在しない場合と同じエラーを返す
+func ValidateTaskReferences(db *gorm.DB, categoryID uint, responsible uint, userGroupID uint) error {
+	var categoryCount int64
+	if err := db.Model(&Category{}).Where("id = ? AND user_group_id = ?", categoryID, userGroupID).Count(&categoryCount).Error; err != nil {
+		log.Printf("Error counting categories: %v\n", err)
+		return err
+	}
+	if categoryCount == 0 {
+		log.Printf("Category %d does not belong to user group %d", categoryID, userGroupID)
+		return ErrInvalidTaskCategory
+	}
+
+	var userCount int64
+	if err := db.Model(&User{}).Where("id = ? AND user_group_id = ?", responsible, userGroupID).Count(&userCount).Error; err != nil {
+		log.Printf("Error counting users: %v\n", err)
+		return err
+	}
+	if userCount == 0 {
+		log.Printf("User %d does not belong to user group %d", responsible, userGroupID)
+		return ErrInvalidTaskResponsible
+	}
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func toAuthorizationError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"time"
+	"testing"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestAuthorizeTask(t *testing.T) {
+	// テスト用MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テーブルのマイグレーション
+	db.AutoMigrate(&UserGroup{}, &User{}, &Category{}, &Task{})
+
+	// テスト用のデータを作成
+	userGroup := UserGroup{UserGroup: "TestGroup"}
+	db.Create(&userGroup)
+	otherUserGroup := UserGroup{UserGroup: "OtherGroup"}
+	db.Create(&otherUserGroup)
+	user := User{Name: "TestUser", Password: "password", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(&user)
+	category := Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(&category)
+	estimate := uint(1)
+	startDate := time.Now()
+	task := Task{Task: "TestTask", Description: "TestDescription", Creator: user.ID, CategoryID: category.ID, Status: 1, Responsible: user.ID, Estimate: &estimate, StartDate: &startDate}
+	db.Create(&task)
+
+	// 同じユーザーグループのタスク
+	err = AuthorizeTask(db, int(task.ID), userGroup.ID)
+	assert.Nil(t, err, "AuthorizeTask should not return an error for own task")
+
+	// 他のユーザーグループのタスク
+	err = AuthorizeTask(db, int(task.ID), otherUserGroup.ID)
+	assert.ErrorIs(t, err, ErrForbidden, "AuthorizeTask should return ErrForbidden for other group's task")
+
+	// 存在しないタスク
+	err = AuthorizeTask(db, 0, userGroup.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "AuthorizeTask should return ErrNotFound for missing task")
+
+	db.Unscoped().Delete(&task)
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&otherUserGroup)
+	db.Unscoped().Delete(&userGroup)
+}
+
+func TestAuthorizeCategory(t *testing.T) {
+	// テスト用MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テーブルのマイグレーション
+	db.AutoMigrate(&UserGroup{}, &Category{})
+
+	// テスト用のデータを作成
+	userGroup := UserGroup{UserGroup: "TestGroup"}
+	db.Create(&userGroup)
+	otherUserGroup := UserGroup{UserGroup: "OtherGroup"}
+	db.Create(&otherUserGroup)
+	category := Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(&category)
+
+	err = AuthorizeCategory(db, int(category.ID), userGroup.ID)
+	assert.Nil(t, err, "AuthorizeCategory should not return an error for own category")
+
+	err = AuthorizeCategory(db, int(category.ID), otherUserGroup.ID)
+	assert.ErrorIs(t, err, ErrForbidden, "AuthorizeCategory should return ErrForbidden for other group's category")
+
+	err = AuthorizeCategory(db, 0, userGroup.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "AuthorizeCategory should return ErrNotFound for missing category")
+
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&otherUserGroup)
+	db.Unscoped().Delete(&userGroup)
+}
+
+func TestAuthorizeUserGroup(t *testing.T) {
+	// テスト用MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テーブルのマイグレーション
+	db.AutoMigrate(&UserGroup{})
+
+	// テスト用のデータを作成
+	userGroup := UserGroup{UserGroup: "TestGroup"}
+	db.Create(&userGroup)
+	otherUserGroup := UserGroup{UserGroup: "OtherGroup"}
+	db.Create(&otherUserGroup)
+
+	err = AuthorizeUserGroup(db, int(userGroup.ID), userGroup.ID)
+	assert.Nil(t, err, "AuthorizeUserGroup should not return an error for own user group")
+
+	err = AuthorizeUserGroup(db, int(otherUserGroup.ID), userGroup.ID)
+	assert.ErrorIs(t, err, ErrForbidden, "AuthorizeUserGroup should return ErrForbidden for other user group")
+
+	err = AuthorizeUserGroup(db, 0, userGroup.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "AuthorizeUserGroup should return ErrNotFound for missing user group")
+
+	db.Unscoped().Delete(&otherUserGroup)
+	db.Unscoped().Delete(&userGroup)
+}
+
+func TestValidateTaskReferences(t *testing.T) {
+	// テスト用MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テーブルのマイグレーション
+	db.AutoMigrate(&UserGroup{}, &User{}, &Category{})
+
+	// テスト用のデータを作成
+	userGroup := UserGroup{UserGroup: "TestGroup"}
+	db.Create(&userGroup)
+	otherUserGroup := UserGroup{UserGroup: "OtherGroup"}
+	db.Create(&otherUserGroup)
+	user := User{Name: "TestUser", Password: "password", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(&user)
+	otherUser := User{Name: "OtherUser", Password: "password", Email: "other@example.com", UserGroupID: otherUserGroup.ID}
+	db.Create(&otherUser)
+	category := Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(&category)
+	otherCategory := Category{Category: "OtherCategory", UserGroupID: otherUserGroup.ID}
+	db.Create(&otherCategory)
+
+	err = ValidateTaskReferences(db, category.ID, user.ID, userGroup.ID)
+	assert.Nil(t, err, "ValidateTaskReferences should not return an error for own category and user")
+
+	err = ValidateTaskReferences(db, otherCategory.ID, user.ID, userGroup.ID)
+	assert.ErrorIs(t, err, ErrInvalidTaskCategory, "Other group's category should be rejected")
+
+	err = ValidateTaskReferences(db, category.ID, otherUser.ID, userGroup.ID)
+	assert.ErrorIs(t, err, ErrInvalidTaskResponsible, "Other group's user should be rejected")
+
+	db.Unscoped().Delete(&otherCategory)
+	db.Unscoped().Delete(&category)
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&otherUserGroup)
+	db.Unscoped().Delete(&userGroup)
+}
@@ -13,11 +13,12 @@ type Category struct {
 	Category    string    `gorm:"size:255;not null" validate:"required,min=1,max=30"`
 	UserGroupID uint      `gorm:"not null"`
 	UserGroup   UserGroup `gorm:"foreignKey:UserGroupID"`
+	DeletionID  *uint     `gorm:"index"` // ゴミ箱に移動した削除操作
 }
 
 // カテゴリー作成の入力値
 type CategoryInput struct {
-	Category string `json:"category" binding:"required,min=1,max=30""`
+	Category string `json:"category" binding:"required,min=1,max=30"`
 }
 
 // カテゴリー一覧取得
@@ -71,7 +72,7 @@ func FetchCategory(db *gorm.DB, userID uint) ([]CategoryResponse, error) {
 
 	result := db.
 		Select("ID", "Category").
-		Where("user_group_id = ?", userGroupID).
+		Where("user_group_id = ? AND deleted_at IS NULL", userGroupID).
 		Order("Category asc").
 		Find(&categories)
 
@@ -113,7 +114,8 @@ func (category *Category) UpdateCategory(db *gorm.DB, categoryID int) error {
 	return nil
 }
 
-func (category *Category) DeleteCategoryAndRelatedTasks(db *gorm.DB, id int) error {
+// カテゴリーを関連するタスクとあわせてゴミ箱に移動する
+func (category *Category) DeleteCategoryAndRelatedTasks(db *gorm.DB, id int, actorID uint) error {
 
 	// トランザクションの開始
 	tx := db.Begin()
@@ -129,34 +131,26 @@ func (category *Category) DeleteCategoryAndRelatedTasks(db *gorm.DB, id int) err
 		return fmt.Errorf("カテゴリーが見つかりません")
 	}
 
-	// 削除するカテゴリに関連するタスクを検索
-	var relatedTasks []Task
-	searchTaskResult := db.Where("category_id = ?", id).Find(&relatedTasks)
-
-	if searchTaskResult.Error != nil {
-		log.Printf("Error finding related tasks: %v\n", searchTaskResult.Error)
+	deletionID, err := createDeletion(tx, category.UserGroupID, actorID, DeletionItemCategory, category.ID, category.Category)
+	if err != nil {
+		log.Println(err)
 		tx.Rollback()
-		return searchTaskResult.Error
+		return err
 	}
 
-	// 削除するカテゴリに関連するタスクを削除
-	for _, task := range relatedTasks {
-		deleteTaskResult := db.Unscoped().Delete(&task)
-		if deleteTaskResult.Error != nil {
-			log.Printf("Error deleting task: %v\n", deleteTaskResult.Error)
-			tx.Rollback()
-			return deleteTaskResult.Error
-		}
+	// 削除するカテゴリに関連するタスクとコメントをゴミ箱に移動
+	if err := softDeleteTasks(tx, actorID, deletionID, "category_id = ?", id); err != nil {
+		log.Println(err)
+		tx.Rollback()
+		return err
 	}
 	log.Printf("関連するタスクの削除に成功")
 
-	// カテゴリを削除
-	deleteCategoryResult := db.Unscoped().Delete(category, id)
-
-	if deleteCategoryResult.Error != nil {
-		log.Printf("Error deleting category: %v\n", deleteCategoryResult.Error)
+	// カテゴリをゴミ箱に移動
+	if err := softDelete(tx, &Category{}, deletionID, "id = ?", id); err != nil {
+		log.Printf("Error deleting category: %v\n", err)
 		tx.Rollback()
-		return deleteCategoryResult.Error
+		return err
 	}
 
 	if err := tx.Commit().Error; err != nil {
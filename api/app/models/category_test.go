@@ -243,7 +243,7 @@ func TestDeleteCategoryAndRelatedTasks(t *testing.T) {
 	}
 
 	// カテゴリとそれに関連するタスクの正常な削除
-	err = category.DeleteCategoryAndRelatedTasks(db, int(category.ID))
+	err = category.DeleteCategoryAndRelatedTasks(db, int(category.ID), creatorUser.ID)
 	assert.Nil(t, err, "DeleteCategoryAndRelatedTasks should not return an error for a valid delete")
 
 	// Confirm that the category and tasks were deleted
@@ -258,7 +258,7 @@ func TestDeleteCategoryAndRelatedTasks(t *testing.T) {
 	nonExistingCategory := &Category{
 		Category: "NonExistingCategory",
 	}
-	err = nonExistingCategory.DeleteCategoryAndRelatedTasks(db, 9999) // using a non-existing ID
+	err = nonExistingCategory.DeleteCategoryAndRelatedTasks(db, 9999, creatorUser.ID) // using a non-existing ID
 	assert.NotNil(t, err, "DeleteCategoryAndRelatedTasks should return an error for a non-existing category")
 
 	// テストデータの削除
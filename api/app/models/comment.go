@@ -0,0 +1,236 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCommentParent = errors.New("返信先のコメントが存在しません")
+
+// タスクのコメントテーブル定義
+// 返信は1階層までとし、返信への返信は同じコメントへの返信として扱わない
+// 退会したユーザーのコメントは残すため、投稿者には外部キーを設定しない
+type Comment struct {
+	gorm.Model
+	TaskID     uint   `gorm:"not null;index"`
+	AuthorID   uint   `gorm:"not null;index"`
+	ParentID   *uint  `gorm:"index"` // 返信先のコメント
+	Body       string `gorm:"type:text;not null"`
+	EditedAt   *time.Time
+	DeletionID *uint `gorm:"index"` // タスクと一緒にゴミ箱に移動した削除操作
+}
+
+type CommentInput struct {
+	Body     string `json:"body" binding:"required,min=1,max=2000"`
+	ParentID *uint  `json:"parent_id" binding:"omitempty,min=1"`
+}
+
+type CommentUpdateInput struct {
+	Body string `json:"body" binding:"required,min=1,max=2000"`
+}
+
+// コメント一覧取得
+// 返信のあるコメントが削除された場合は、返信を残すため本文を空にして返す
+type CommentResponse struct {
+	ID         uint
+	Body       string
+	Author     uint
+	AuthorName string // 退会したユーザーの場合は空
+	Deleted    bool
+	CreatedAt  string
+	EditedAt   string // 編集していない場合は空
+	Replies    []CommentResponse
+}
+
+func (comment *Comment) MigrateComment(db *gorm.DB) error {
+	// 自動マイグレーション(Commentテーブルを作成)
+	migrateErr := db.AutoMigrate(&Comment{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// タスクのコメントを返信とあわせて投稿の古い順に取得する
+func FetchComments(db *gorm.DB, taskID int) ([]CommentResponse, error) {
+	var rows []struct {
+		Comment
+		AuthorName string
+	}
+
+	result := db.Unscoped().Model(&Comment{}).
+		Select("comments.*, users.name AS author_name").
+		Joins("LEFT JOIN users ON users.id = comments.author_id").
+		Where("comments.task_id = ?", taskID).
+		Order("comments.id asc").
+		Scan(&rows)
+
+	if result.Error != nil {
+		log.Printf("Error fetching comments: %v\n", result.Error)
+		return nil, result.Error
+	}
+	log.Printf("コメントの取得に成功")
+
+	replies := map[uint][]CommentResponse{}
+	for _, row := range rows {
+		if row.ParentID != nil && !row.DeletedAt.Valid {
+			replies[*row.ParentID] = append(replies[*row.ParentID], toCommentResponse(row.Comment, row.AuthorName))
+		}
+	}
+
+	commentResponses := []CommentResponse{}
+	for _, row := range rows {
+		if row.ParentID != nil {
+			continue
+		}
+		// 削除済みのコメントは返信が残っている場合のみ返す
+		if row.DeletedAt.Valid && len(replies[row.ID]) == 0 {
+			continue
+		}
+
+		commentResponse := toCommentResponse(row.Comment, row.AuthorName)
+		commentResponse.Replies = replies[row.ID]
+		if commentResponse.Replies == nil {
+			commentResponse.Replies = []CommentResponse{}
+		}
+		commentResponses = append(commentResponses, commentResponse)
+	}
+
+	return commentResponses, nil
+}
+
+// タスクにコメントを投稿する
+// 返信先は同じタスクの返信ではないコメントに限る
+func CreateComment(db *gorm.DB, taskID int, authorID uint, input CommentInput) error {
+	if input.ParentID != nil {
+		var parentCount int64
+		result := db.Model(&Comment{}).
+			Where("id = ? AND task_id = ? AND parent_id IS NULL", *input.ParentID, taskID).
+			Count(&parentCount)
+		if result.Error != nil {
+			log.Printf("Error counting comments: %v\n", result.Error)
+			return result.Error
+		}
+		if parentCount == 0 {
+			log.Printf("Comment %d is not a top level comment of task %d", *input.ParentID, taskID)
+			return ErrInvalidCommentParent
+		}
+	}
+
+	comment := Comment{
+		TaskID:   uint(taskID),
+		AuthorID: authorID,
+		ParentID: input.ParentID,
+		Body:     input.Body,
+	}
+	if err := db.Create(&comment).Error; err != nil {
+		log.Printf("Error creating comment: %v\n", err)
+		return err
+	}
+	log.Printf("コメントの投稿に成功")
+
+	return nil
+}
+
+// コメントを編集する
+// 編集できるのは投稿者のみ
+func UpdateComment(db *gorm.DB, taskID int, commentID int, userID uint, body string) error {
+	comment, err := findTaskComment(db, taskID, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.AuthorID != userID {
+		log.Printf("User %d cannot edit comment %d of user %d", userID, comment.ID, comment.AuthorID)
+		return ErrForbidden
+	}
+
+	result := db.Model(&comment).Updates(map[string]interface{}{
+		"body":      body,
+		"edited_at": time.Now(),
+	})
+	if result.Error != nil {
+		log.Printf("Error updating comment: %v\n", result.Error)
+		return result.Error
+	}
+	log.Printf("コメントの編集に成功")
+
+	return nil
+}
+
+// コメントを削除する
+// 削除できるのは投稿者と、コメントを管理できる役割のメンバー
+func DeleteComment(db *gorm.DB, taskID int, commentID int, userID uint) error {
+	comment, err := findTaskComment(db, taskID, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.AuthorID != userID {
+		user, err := FindUserByID(db, userID)
+		if err != nil {
+			return toAuthorizationError(err)
+		}
+		if !HasPermission(user.Role, PermissionModerateComments) {
+			log.Printf("User %d (%s) cannot delete comment %d of user %d", userID, user.Role, comment.ID, comment.AuthorID)
+			return ErrForbidden
+		}
+	}
+
+	// 返信を残せるよう論理削除する
+	if err := db.Delete(&comment).Error; err != nil {
+		log.Printf("Error deleting comment: %v\n", err)
+		return err
+	}
+	log.Printf("コメントの削除に成功")
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func findTaskComment(db *gorm.DB, taskID int, commentID int) (Comment, error) {
+	var comment Comment
+	if err := db.Where("id = ? AND task_id = ?", commentID, taskID).First(&comment).Error; err != nil {
+		log.Printf("Error fetching comment with ID %d of task %d: %v\n", commentID, taskID, err)
+		return comment, toAuthorizationError(err)
+	}
+
+	return comment, nil
+}
+
+// 削除するタスクのコメントを返信も含めて物理削除する
+// タスクを削除する前に、削除するタスクのIDを取得するクエリを渡して呼び出す
+func deleteTaskComments(tx *gorm.DB, taskIDs interface{}) error {
+	if err := tx.Unscoped().Where("task_id IN (?)", taskIDs).Delete(&Comment{}).Error; err != nil {
+		return fmt.Errorf("error deleting comments: %v", err)
+	}
+
+	return nil
+}
+
+func toCommentResponse(comment Comment, authorName string) CommentResponse {
+	commentResponse := CommentResponse{
+		ID:         comment.ID,
+		Body:       comment.Body,
+		Author:     comment.AuthorID,
+		AuthorName: authorName,
+		Deleted:    comment.DeletedAt.Valid,
+		CreatedAt:  comment.CreatedAt.Format("2006-01-02 15:04"),
+	}
+	if comment.EditedAt != nil {
+		commentResponse.EditedAt = comment.EditedAt.Format("2006-01-02 15:04")
+	}
+	if commentResponse.Deleted {
+		commentResponse.Body = ""
+	}
+
+	return commentResponse
+}
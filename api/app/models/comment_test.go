@@ -0,0 +1,149 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestComments(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	admin := &User{Name: "Admin", Password: "TestPassword", Email: "admin@example.com", UserGroupID: userGroup.ID, Role: RoleAdmin}
+	db.Create(admin)
+	member := &User{Name: "Member", Password: "TestPassword", Email: "member@example.com", UserGroupID: userGroup.ID, Role: RoleMember}
+	db.Create(member)
+	category := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(category)
+	task := &Task{Task: "TestTask", Description: "TestDescription", Creator: admin.ID, CategoryID: category.ID, Status: 1, Responsible: admin.ID, Estimate: ptrToUint(5), StartDate: ptrToTime(time.Now())}
+	db.Create(task)
+	otherTask := &Task{Task: "OtherTask", Description: "OtherDescription", Creator: member.ID, CategoryID: category.ID, Status: 1, Responsible: member.ID, Estimate: ptrToUint(5), StartDate: ptrToTime(time.Now())}
+	db.Create(otherTask)
+	taskID := int(task.ID)
+
+	var parent Comment
+	t.Run("投稿と返信", func(t *testing.T) {
+		err := CreateComment(db, taskID, admin.ID, CommentInput{Body: "First"})
+		assert.Nil(t, err)
+		db.Where("task_id = ?", task.ID).First(&parent)
+
+		err = CreateComment(db, taskID, member.ID, CommentInput{Body: "Reply", ParentID: &parent.ID})
+		assert.Nil(t, err)
+
+		comments, err := FetchComments(db, taskID)
+		assert.Nil(t, err)
+		assert.Len(t, comments, 1)
+		assert.Equal(t, "First", comments[0].Body)
+		assert.Equal(t, "Admin", comments[0].AuthorName)
+		assert.Len(t, comments[0].Replies, 1)
+		assert.Equal(t, "Member", comments[0].Replies[0].AuthorName)
+	})
+
+	t.Run("失敗_返信への返信", func(t *testing.T) {
+		var reply Comment
+		db.Where("parent_id = ?", parent.ID).First(&reply)
+
+		err := CreateComment(db, taskID, admin.ID, CommentInput{Body: "Nested", ParentID: &reply.ID})
+		assert.Equal(t, ErrInvalidCommentParent, err)
+	})
+
+	t.Run("失敗_他のタスクのコメントへの返信", func(t *testing.T) {
+		err := CreateComment(db, int(otherTask.ID), admin.ID, CommentInput{Body: "Reply", ParentID: &parent.ID})
+		assert.Equal(t, ErrInvalidCommentParent, err)
+	})
+
+	t.Run("編集", func(t *testing.T) {
+		// 投稿者以外は編集できない
+		err := UpdateComment(db, taskID, int(parent.ID), member.ID, "Edited")
+		assert.Equal(t, ErrForbidden, err)
+
+		err = UpdateComment(db, taskID, int(parent.ID), admin.ID, "Edited")
+		assert.Nil(t, err)
+
+		comments, _ := FetchComments(db, taskID)
+		assert.Equal(t, "Edited", comments[0].Body)
+		assert.NotEqual(t, "", comments[0].EditedAt)
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		// メンバーは他のユーザーのコメントを削除できない
+		err := DeleteComment(db, taskID, int(parent.ID), member.ID)
+		assert.Equal(t, ErrForbidden, err)
+
+		// 他のタスクのコメントとしては削除できない
+		err = DeleteComment(db, int(otherTask.ID), int(parent.ID), admin.ID)
+		assert.Equal(t, ErrNotFound, err)
+
+		// 返信が残っている間は削除済みとして返す
+		err = DeleteComment(db, taskID, int(parent.ID), admin.ID)
+		assert.Nil(t, err)
+		comments, _ := FetchComments(db, taskID)
+		assert.Len(t, comments, 1)
+		assert.True(t, comments[0].Deleted)
+		assert.Equal(t, "", comments[0].Body)
+		assert.Len(t, comments[0].Replies, 1)
+
+		// 管理者は他のユーザーのコメントを削除できる
+		err = DeleteComment(db, taskID, int(comments[0].Replies[0].ID), admin.ID)
+		assert.Nil(t, err)
+		comments, _ = FetchComments(db, taskID)
+		assert.Len(t, comments, 0)
+	})
+
+	t.Run("タスクの削除", func(t *testing.T) {
+		CreateComment(db, taskID, member.ID, CommentInput{Body: "Comment"})
+
+		err := task.DeleteTask(db, taskID, admin.ID)
+		assert.Nil(t, err)
+
+		// コメントもタスクと一緒にゴミ箱に移る
+		var count int64
+		db.Model(&Comment{}).Where("task_id = ?", taskID).Count(&count)
+		assert.Equal(t, int64(0), count)
+		db.Unscoped().Model(&Comment{}).Where("task_id = ? AND deletion_id IS NOT NULL", taskID).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("ユーザーの削除", func(t *testing.T) {
+		// 他のユーザーのタスクへのコメントは残し、削除するタスクのコメントは削除する
+		CreateComment(db, int(otherTask.ID), admin.ID, CommentInput{Body: "Admin Comment"})
+		adminTask := &Task{Task: "AdminTask", Description: "AdminDescription", Creator: admin.ID, CategoryID: category.ID, Status: 1, Responsible: admin.ID, Estimate: ptrToUint(5), StartDate: ptrToTime(time.Now())}
+		db.Create(adminTask)
+		CreateComment(db, int(adminTask.ID), member.ID, CommentInput{Body: "Member Comment"})
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			deletionID, err := createDeletion(tx, userGroup.ID, admin.ID, DeletionItemUser, admin.ID, admin.Name)
+			if err != nil {
+				return err
+			}
+			return deleteUserTasks(tx, admin.ID, deletionID)
+		})
+		assert.Nil(t, err)
+
+		var count int64
+		db.Model(&Comment{}).Where("task_id = ?", adminTask.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+		db.Model(&Comment{}).Where("task_id = ?", otherTask.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	// テストデータの削除
+	db.Unscoped().Where("task_id = ?", otherTask.ID).Delete(&Comment{})
+	db.Unscoped().Delete(otherTask)
+	db.Unscoped().Delete(category)
+	db.Unscoped().Delete(admin)
+	db.Unscoped().Delete(member)
+	db.Unscoped().Delete(userGroup)
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+var ErrInvalidEmailChangeToken = errors.New("メールアドレス変更のリンクが無効か、有効期限が切れています")
+
+// メールアドレス変更申請テーブル定義
+// 新しいメールアドレス宛てのリンクで確認されるまでユーザーのメールアドレスは変更しない
+type EmailChangeRequest struct {
+	gorm.Model
+	UserID      uint       `gorm:"not null;index"`
+	User        User       `gorm:"foreignKey:UserID"`
+	OldEmail    string     `gorm:"size:255;not null"`
+	NewEmail    string     `gorm:"size:255;not null"`
+	TokenID     string     `gorm:"size:64;not null;unique"`
+	ExpiresAt   time.Time  `gorm:"not null"`
+	ConfirmedAt *time.Time
+	CanceledAt  *time.Time
+}
+
+// メールアドレス変更の確認・取り消しの入力値
+type EmailChangeTokenInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (emailChangeRequest *EmailChangeRequest) MigrateEmailChangeRequest(db *gorm.DB) error {
+	// 自動マイグレーション(EmailChangeRequestテーブルを作成)
+	migrateErr := db.AutoMigrate(&EmailChangeRequest{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// メールアドレス変更申請を作成し、確認用と取り消し用のトークンを返す
+// 同じユーザーの未完了の申請は取り消す
+func CreateEmailChangeRequest(db *gorm.DB, user User, newEmail string) (string, string, error) {
+	tokenID, err := utils.GenerateRandomString(32)
+	if err != nil {
+		log.Printf("Error generating token ID: %v\n", err)
+		return "", "", err
+	}
+
+	confirmToken, err := utils.GenerateEmailChangeToken(user.ID, newEmail, tokenID)
+	if err != nil {
+		log.Printf("Token generation failed: %v\n", err)
+		return "", "", err
+	}
+	cancelToken, err := utils.GenerateEmailChangeCancelToken(user.ID, tokenID)
+	if err != nil {
+		log.Printf("Token generation failed: %v\n", err)
+		return "", "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := cancelPendingEmailChangeRequests(tx, user.ID); err != nil {
+			return err
+		}
+
+		emailChangeRequest := &EmailChangeRequest{
+			UserID:    user.ID,
+			OldEmail:  user.Email,
+			NewEmail:  newEmail,
+			TokenID:   tokenID,
+			ExpiresAt: time.Now().Add(time.Hour * constant.EMAIL_CHANGE_TOKEN_LIFETIME_HOURS),
+		}
+		if err := tx.Create(emailChangeRequest).Error; err != nil {
+			log.Printf("Error creating email change request: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+	log.Printf("メールアドレス変更申請の作成に成功")
+
+	return confirmToken, cancelToken, nil
+}
+
+// 確認用トークンを検証し、申請されたメールアドレスにユーザーのメールアドレスを変更する
+func ConfirmEmailChangeRequest(db *gorm.DB, userID uint, confirmToken string) error {
+	claims, err := utils.ParseEmailChangeToken(confirmToken)
+	if err != nil {
+		log.Printf("Error parsing email change token: %v\n", err)
+		return ErrInvalidEmailChangeToken
+	}
+
+	// ログイン中のユーザー以外の申請は確認できない
+	if claims.UserID != userID {
+		log.Printf("Email change token for user %d was presented by user %d", claims.UserID, userID)
+		return ErrInvalidEmailChangeToken
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		emailChangeRequest, err := findPendingEmailChangeRequest(tx, claims)
+		if err != nil {
+			return err
+		}
+
+		if emailChangeRequest.NewEmail != claims.Email {
+			log.Printf("Email in token does not match email change request %d", emailChangeRequest.ID)
+			return ErrInvalidEmailChangeToken
+		}
+
+		updateUser := &User{
+			Email: emailChangeRequest.NewEmail,
+		}
+		if err := updateUser.UpdateEmail(tx, userID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&emailChangeRequest).Update("confirmed_at", time.Now()).Error; err != nil {
+			log.Printf("Error confirming email change request: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("メールアドレス変更の確認に成功")
+
+	return nil
+}
+
+// 取り消し用トークンを検証し、未完了のメールアドレス変更申請を取り消す
+func CancelEmailChangeRequest(db *gorm.DB, cancelToken string) error {
+	claims, err := utils.ParseEmailChangeCancelToken(cancelToken)
+	if err != nil {
+		log.Printf("Error parsing email change cancel token: %v\n", err)
+		return ErrInvalidEmailChangeToken
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		emailChangeRequest, err := findPendingEmailChangeRequest(tx, claims)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&emailChangeRequest).Update("canceled_at", time.Now()).Error; err != nil {
+			log.Printf("Error canceling email change request: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("メールアドレス変更の取り消しに成功")
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func findPendingEmailChangeRequest(tx *gorm.DB, claims utils.EmailChangeClaims) (EmailChangeRequest, error) {
+	var emailChangeRequest EmailChangeRequest
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_id = ? AND user_id = ?", claims.TokenID, claims.UserID).
+		First(&emailChangeRequest).Error; err != nil {
+		log.Printf("Email change request not found: %v\n", err)
+		return emailChangeRequest, ErrInvalidEmailChangeToken
+	}
+
+	if emailChangeRequest.ConfirmedAt != nil || emailChangeRequest.CanceledAt != nil || time.Now().After(emailChangeRequest.ExpiresAt) {
+		log.Printf("Email change request %d is already confirmed, canceled or expired", emailChangeRequest.ID)
+		return emailChangeRequest, ErrInvalidEmailChangeToken
+	}
+
+	return emailChangeRequest, nil
+}
+
+func cancelPendingEmailChangeRequests(tx *gorm.DB, userID uint) error {
+	result := tx.Model(&EmailChangeRequest{}).
+		Where("user_id = ? AND confirmed_at IS NULL AND canceled_at IS NULL", userID).
+		Update("canceled_at", time.Now())
+
+	if result.Error != nil {
+		log.Printf("Error canceling email change requests: %v\n", result.Error)
+		return result.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"testing"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestMigrateEmailChangeRequest(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// MigrateEmailChangeRequest関数をテスト
+	emailChangeRequest := &EmailChangeRequest{}
+	err = emailChangeRequest.MigrateEmailChangeRequest(db)
+	assert.Nil(t, err, "MigrateEmailChangeRequest should not return an error")
+
+	// EmailChangeRequestテーブルが正しく作成されているかを確認
+	hasTable := db.Migrator().HasTable(&EmailChangeRequest{})
+	assert.True(t, hasTable, "EmailChangeRequest table should be created")
+}
+
+func TestConfirmEmailChangeRequest(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{
+		UserGroup: "TestUserGroup",
+	}
+	db.Create(userGroup)
+
+	user := &User{
+		Name:        "TestUser",
+		Password:    "TestPassword",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(user)
+
+	otherUser := &User{
+		Name:        "OtherUser",
+		Password:    "TestPassword",
+		Email:       "other@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(otherUser)
+
+	confirmToken, _, err := CreateEmailChangeRequest(db, *user, "new@example.com")
+	assert.Nil(t, err)
+
+	// 申請の確認前はメールアドレスは変更されない
+	fetchedUser, _ := FindUserByID(db, user.ID)
+	assert.Equal(t, "test@example.com", fetchedUser.Email)
+
+	// 他のユーザーのトークンでは変更できない
+	err = ConfirmEmailChangeRequest(db, otherUser.ID, confirmToken)
+	assert.ErrorIs(t, err, ErrInvalidEmailChangeToken)
+
+	err = ConfirmEmailChangeRequest(db, user.ID, confirmToken)
+	assert.Nil(t, err)
+
+	fetchedUser, _ = FindUserByID(db, user.ID)
+	assert.Equal(t, "new@example.com", fetchedUser.Email)
+
+	// テストデータの削除
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&EmailChangeRequest{})
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
+
+func TestCancelEmailChangeRequest(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{
+		UserGroup: "TestUserGroup",
+	}
+	db.Create(userGroup)
+
+	user := &User{
+		Name:        "TestUser",
+		Password:    "TestPassword",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(user)
+
+	confirmToken, cancelToken, err := CreateEmailChangeRequest(db, *user, "new@example.com")
+	assert.Nil(t, err)
+
+	err = CancelEmailChangeRequest(db, cancelToken)
+	assert.Nil(t, err)
+
+	// 取り消した申請は確認できない
+	err = ConfirmEmailChangeRequest(db, user.ID, confirmToken)
+	assert.ErrorIs(t, err, ErrInvalidEmailChangeToken)
+
+	fetchedUser, _ := FindUserByID(db, user.ID)
+	assert.Equal(t, "test@example.com", fetchedUser.Email)
+
+	// テストデータの削除
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&EmailChangeRequest{})
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/utils"
 )
 
 func CreateGuestUser(db *gorm.DB) (User, error) {
@@ -33,9 +35,18 @@ func CreateGuestUser(db *gorm.DB) (User, error) {
 
 	// Users の挿入
 	users := []User{
-		{Name: "山田太郎", Password: "password123", Email: "yamada@example.com", UserGroupID: userGroup.ID},
-		{Name: "佐藤花子", Password: "password456", Email: "sato@example.com", UserGroupID: userGroup.ID},
-		{Name: "鈴木一郎", Password: "password789", Email: "suzuki@example.com", UserGroupID: userGroup.ID},
+		{Name: "山田太郎", Password: "password123", Email: "yamada@example.com", UserGroupID: userGroup.ID, Role: RoleOwner},
+		{Name: "佐藤花子", Password: "password456", Email: "sato@example.com", UserGroupID: userGroup.ID, Role: RoleAdmin},
+		{Name: "鈴木一郎", Password: "password789", Email: "suzuki@example.com", UserGroupID: userGroup.ID, Role: RoleMember},
+	}
+	for i := range users {
+		hashedPassword, err := utils.HashPassword(users[i].Password)
+		if err != nil {
+			tx.Rollback()
+			log.Printf("Error hashing password: %v\n", err)
+			return User{}, err
+		}
+		users[i].Password = hashedPassword
 	}
 	if err := tx.Create(&users).Error; err != nil {
 		tx.Rollback()
@@ -134,12 +145,20 @@ func DeleteGuestUser(db *gorm.DB) error {
 	
 	// UserGroupIDが0であるUserのIDを取得
 	var userIds []uint
-	if err := tx.Model(&User{}).Where("user_group_id = ?", 0).Pluck("id", &userIds).Error; err != nil {
+	if err := tx.Unscoped().Model(&User{}).Where("user_group_id = ?", 0).Pluck("id", &userIds).Error; err != nil {
 		tx.Rollback()
 		log.Printf("Error fetching users: %v\n", err)
 		return err
 	}
 	
+	// 取得したUser IDsに紐づくTaskのコメントを削除
+	guestTaskIDs := tx.Unscoped().Model(&Task{}).Select("id").Where("creator IN ? OR responsible IN ?", userIds, userIds)
+	if err := deleteTaskComments(tx, guestTaskIDs); err != nil {
+		tx.Rollback()
+		log.Printf("Error deleting comments linked to users: %v\n", err)
+		return err
+	}
+
 	// 取得したUser IDsに紐づくTaskを削除
 	if err := tx.Unscoped().Where("creator IN ? OR responsible IN ?", userIds, userIds).Delete(&Task{}).Error; err != nil {
 		tx.Rollback()
@@ -147,6 +166,13 @@ func DeleteGuestUser(db *gorm.DB) error {
 		return err
 	}
 
+	// 取得したUser IDsに紐づくセッションや各種トークンを削除
+	if err := deleteUserAuthRecords(tx, userIds); err != nil {
+		tx.Rollback()
+		log.Printf("Error deleting auth records linked to users: %v\n", err)
+		return err
+	}
+
 	// UserGroupIDが0であるCategoryを削除
 	if err := tx.Unscoped().Where("user_group_id = ?", 0).Delete(&Category{}).Error; err != nil {
 		tx.Rollback()
@@ -161,6 +187,13 @@ func DeleteGuestUser(db *gorm.DB) error {
 		return err
 	}
 
+	// UserGroupIDが0であるゴミ箱の削除操作を削除
+	if err := tx.Where("user_group_id = ?", 0).Delete(&Deletion{}).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error deleting deletions: %v\n", err)
+		return err
+	}
+
 	// 最後にIDが0であるUserGroupを削除
 	if err := tx.Unscoped().Where("id = ?", 0).Delete(&UserGroup{}).Error; err != nil {
 		tx.Rollback()
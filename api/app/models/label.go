@@ -0,0 +1,328 @@
+package models
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDuplicateLabel    = errors.New("入力したラベル名は登録済みです")
+	ErrInvalidTaskLabel  = errors.New("ラベルが正しくありません")
+	ErrInvalidLabelMerge = errors.New("統合先のラベルが正しくありません")
+)
+
+// ラベルのテーブル定義
+// ラベルはユーザーグループごとに作成し、カテゴリーと異なり1つのタスクに複数付けられる
+type Label struct {
+	ID          uint      `gorm:"primarykey"`
+	UserGroupID uint      `gorm:"not null;uniqueIndex:idx_label_name"`
+	UserGroup   UserGroup `gorm:"foreignKey:UserGroupID;constraint:OnDelete:CASCADE;"`
+	Name        string    `gorm:"size:30;not null;uniqueIndex:idx_label_name"`
+	Color       string    `gorm:"size:7;not null"`
+}
+
+// タスクとラベルの関連のテーブル定義
+// タスクまたはラベルを削除した場合は関連だけを削除する
+type TaskLabel struct {
+	TaskID  uint  `gorm:"primaryKey"`
+	Task    Task  `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE;"`
+	LabelID uint  `gorm:"primaryKey;index"`
+	Label   Label `gorm:"foreignKey:LabelID;constraint:OnDelete:CASCADE;"`
+}
+
+type LabelInput struct {
+	Name  string `json:"Name" binding:"required,min=1,max=30"`
+	Color string `json:"Color" binding:"required,hexcolor"`
+}
+
+// 統合元のラベルを付けたタスクに統合先のラベルを付け、統合元のラベルを削除する
+type LabelMergeInput struct {
+	Target uint `json:"Target" binding:"required"`
+}
+
+type LabelResponse struct {
+	ID    uint
+	Name  string
+	Color string
+}
+
+// TableName メソッドを追加して、この構造体がラベルテーブルに対応することを指定する
+func (LabelResponse) TableName() string {
+	return "labels"
+}
+
+func (label *Label) MigrateLabel(db *gorm.DB) error {
+	// 自動マイグレーション(Label, TaskLabelテーブルを作成)
+	migrateErr := db.AutoMigrate(&Label{}, &TaskLabel{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// ユーザーグループのラベルを名前順に取得する
+func FetchLabels(db *gorm.DB, userGroupID uint) ([]LabelResponse, error) {
+	labels := []LabelResponse{}
+
+	result := db.Select("id, name, color").
+		Where("user_group_id = ?", userGroupID).
+		Order("name asc").
+		Find(&labels)
+	if result.Error != nil {
+		log.Printf("Error fetching labels: %v\n", result.Error)
+		return nil, result.Error
+	}
+	log.Printf("ラベルの取得に成功")
+
+	return labels, nil
+}
+
+func CreateLabel(db *gorm.DB, userGroupID uint, input LabelInput) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkDuplicateLabel(tx, userGroupID, input.Name, 0); err != nil {
+			return err
+		}
+
+		label := Label{UserGroupID: userGroupID, Name: input.Name, Color: input.Color}
+		if err := tx.Create(&label).Error; err != nil {
+			log.Printf("Error creating label: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("ラベルの作成に成功")
+
+	return nil
+}
+
+func UpdateLabel(db *gorm.DB, userGroupID uint, id int, input LabelInput) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		label, err := findLabel(tx, userGroupID, uint(id))
+		if err != nil {
+			return err
+		}
+		if err := checkDuplicateLabel(tx, userGroupID, input.Name, label.ID); err != nil {
+			return err
+		}
+
+		result := tx.Model(&Label{}).Where("id = ?", label.ID).Updates(map[string]interface{}{
+			"name":  input.Name,
+			"color": input.Color,
+		})
+		if result.Error != nil {
+			log.Printf("Error updating label: %v\n", result.Error)
+			return result.Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("ラベルの更新に成功")
+
+	return nil
+}
+
+// ラベルを削除し、タスクからラベルを外す（タスクは削除しない）
+func DeleteLabel(db *gorm.DB, userGroupID uint, id int) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		label, err := findLabel(tx, userGroupID, uint(id))
+		if err != nil {
+			return err
+		}
+
+		return deleteLabel(tx, label.ID)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("ラベルの削除に成功")
+
+	return nil
+}
+
+// idのラベルをtargetIDのラベルに統合する
+// 統合元のラベルを付けたタスクには統合先のラベルを付け、統合元のラベルは削除する
+func MergeLabel(db *gorm.DB, userGroupID uint, id int, targetID uint) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		source, err := findLabel(tx, userGroupID, uint(id))
+		if err != nil {
+			return err
+		}
+		if targetID == source.ID {
+			return ErrInvalidLabelMerge
+		}
+		target, err := findLabel(tx, userGroupID, targetID)
+		if errors.Is(err, ErrNotFound) {
+			return ErrInvalidLabelMerge
+		}
+		if err != nil {
+			return err
+		}
+
+		var taskIDs []uint
+		if err := tx.Model(&TaskLabel{}).Where("label_id = ?", source.ID).Pluck("task_id", &taskIDs).Error; err != nil {
+			log.Printf("Error fetching task labels: %v\n", err)
+			return err
+		}
+		if len(taskIDs) > 0 {
+			taskLabels := make([]TaskLabel, len(taskIDs))
+			for i, taskID := range taskIDs {
+				taskLabels[i] = TaskLabel{TaskID: taskID, LabelID: target.ID}
+			}
+			// 統合先のラベルを付けているタスクはそのままにする
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&taskLabels).Error; err != nil {
+				log.Printf("Error creating task labels: %v\n", err)
+				return err
+			}
+		}
+
+		return deleteLabel(tx, source.ID)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("ラベルの統合に成功")
+
+	return nil
+}
+
+// タスクに付けるラベルがすべてユーザーグループのものか確認する
+func ValidateTaskLabels(db *gorm.DB, labelIDs []uint, userGroupID uint) error {
+	labelIDs = uniqueLabelIDs(labelIDs)
+	if len(labelIDs) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(&Label{}).Where("id IN ? AND user_group_id = ?", labelIDs, userGroupID).Count(&count).Error; err != nil {
+		log.Printf("Error counting labels: %v\n", err)
+		return err
+	}
+	if count != int64(len(labelIDs)) {
+		log.Printf("Labels %v do not belong to user group %d", labelIDs, userGroupID)
+		return ErrInvalidTaskLabel
+	}
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+
+// ユーザーグループのラベルを取得する（別のユーザーグループのラベルは存在しないものとする）
+func findLabel(tx *gorm.DB, userGroupID uint, id uint) (Label, error) {
+	var label Label
+	if err := tx.Where("id = ? AND user_group_id = ?", id, userGroupID).First(&label).Error; err != nil {
+		log.Printf("Error fetching label with ID %d: %v\n", id, err)
+		return Label{}, toAuthorizationError(err)
+	}
+
+	return label, nil
+}
+
+// ユーザーグループにexceptID以外で同じ名前のラベルがないか確認する
+func checkDuplicateLabel(tx *gorm.DB, userGroupID uint, name string, exceptID uint) error {
+	var count int64
+	if err := tx.Model(&Label{}).Where("user_group_id = ? AND name = ? AND id <> ?", userGroupID, name, exceptID).Count(&count).Error; err != nil {
+		log.Printf("Error counting labels: %v\n", err)
+		return err
+	}
+	if count > 0 {
+		log.Printf("Label with name %s already exists in user group %d", name, userGroupID)
+		return ErrDuplicateLabel
+	}
+
+	return nil
+}
+
+func deleteLabel(tx *gorm.DB, labelID uint) error {
+	if err := tx.Where("label_id = ?", labelID).Delete(&TaskLabel{}).Error; err != nil {
+		log.Printf("Error deleting task labels: %v\n", err)
+		return err
+	}
+	if err := tx.Where("id = ?", labelID).Delete(&Label{}).Error; err != nil {
+		log.Printf("Error deleting label: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// タスクのラベルをlabelIDsに置き換える
+func setTaskLabels(tx *gorm.DB, taskID uint, labelIDs []uint) error {
+	if err := tx.Where("task_id = ?", taskID).Delete(&TaskLabel{}).Error; err != nil {
+		log.Printf("Error deleting task labels: %v\n", err)
+		return err
+	}
+
+	labelIDs = uniqueLabelIDs(labelIDs)
+	if len(labelIDs) == 0 {
+		return nil
+	}
+	taskLabels := make([]TaskLabel, len(labelIDs))
+	for i, labelID := range labelIDs {
+		taskLabels[i] = TaskLabel{TaskID: taskID, LabelID: labelID}
+	}
+	if err := tx.Create(&taskLabels).Error; err != nil {
+		log.Printf("Error creating task labels: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// タスクごとのラベルを名前順に取得する
+func fetchTaskLabels(db *gorm.DB, taskIDs []uint) (map[uint][]LabelResponse, error) {
+	taskLabels := map[uint][]LabelResponse{}
+	if len(taskIDs) == 0 {
+		return taskLabels, nil
+	}
+
+	var rows []struct {
+		TaskID uint
+		ID     uint
+		Name   string
+		Color  string
+	}
+	result := db.Table("task_labels").
+		Select("task_labels.task_id, labels.id, labels.name, labels.color").
+		Joins("JOIN labels ON task_labels.label_id = labels.id").
+		Where("task_labels.task_id IN ?", taskIDs).
+		Order("labels.name asc").
+		Scan(&rows)
+	if result.Error != nil {
+		log.Printf("Error fetching task labels: %v\n", result.Error)
+		return nil, result.Error
+	}
+
+	for _, row := range rows {
+		taskLabels[row.TaskID] = append(taskLabels[row.TaskID], LabelResponse{ID: row.ID, Name: row.Name, Color: row.Color})
+	}
+
+	return taskLabels, nil
+}
+
+func uniqueLabelIDs(labelIDs []uint) []uint {
+	seen := map[uint]bool{}
+	unique := []uint{}
+	for _, labelID := range labelIDs {
+		if !seen[labelID] {
+			seen[labelID] = true
+			unique = append(unique, labelID)
+		}
+	}
+
+	return unique
+}
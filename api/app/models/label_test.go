@@ -0,0 +1,119 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestLabels(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	otherUserGroup := &UserGroup{UserGroup: "OtherUserGroup"}
+	db.Create(otherUserGroup)
+	user := &User{Name: "TestUser", Password: "TestPassword", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(user)
+	category := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(category)
+
+	assert.Nil(t, CreateLabel(db, userGroup.ID, LabelInput{Name: "バグ", Color: "#F44336"}))
+	assert.Nil(t, CreateLabel(db, userGroup.ID, LabelInput{Name: "不具合", Color: "#E91E63"}))
+	assert.Nil(t, CreateLabel(db, userGroup.ID, LabelInput{Name: "改善", Color: "#4CAF50"}))
+	assert.Nil(t, CreateLabel(db, otherUserGroup.ID, LabelInput{Name: "バグ", Color: "#F44336"}))
+	labelIDs := map[string]uint{}
+	labels, err := FetchLabels(db, userGroup.ID)
+	assert.Nil(t, err)
+	assert.Len(t, labels, 3)
+	for _, label := range labels {
+		labelIDs[label.Name] = label.ID
+	}
+	otherLabels, _ := FetchLabels(db, otherUserGroup.ID)
+
+	newTask := func(name string, labels []uint) *Task {
+		task := &Task{Task: name, Description: "TestDescription", Creator: user.ID, CategoryID: category.ID, Status: 1, Responsible: user.ID, Estimate: ptrToUint(1), StartDate: ptrToTime(time.Now()), LabelIDs: labels}
+		assert.Nil(t, task.CreateTask(db))
+		return task
+	}
+	bugTask := newTask("BugTask", []uint{labelIDs["バグ"], labelIDs["改善"]})
+	defectTask := newTask("DefectTask", []uint{labelIDs["不具合"]})
+	plainTask := newTask("PlainTask", nil)
+
+	t.Run("作成と更新", func(t *testing.T) {
+		assert.Equal(t, ErrDuplicateLabel, CreateLabel(db, userGroup.ID, LabelInput{Name: "バグ", Color: "#000000"}))
+		assert.Equal(t, ErrDuplicateLabel, UpdateLabel(db, userGroup.ID, int(labelIDs["改善"]), LabelInput{Name: "バグ", Color: "#000000"}))
+		assert.Equal(t, ErrNotFound, UpdateLabel(db, userGroup.ID, int(otherLabels[0].ID), LabelInput{Name: "Other", Color: "#000000"}))
+		assert.Nil(t, UpdateLabel(db, userGroup.ID, int(labelIDs["改善"]), LabelInput{Name: "改善", Color: "#8BC34A"}))
+	})
+
+	t.Run("タスクのラベル", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidTaskLabel, ValidateTaskLabels(db, []uint{otherLabels[0].ID}, userGroup.ID))
+		assert.Nil(t, ValidateTaskLabels(db, []uint{labelIDs["バグ"], labelIDs["バグ"]}, userGroup.ID))
+
+		taskResponses, err := FetchTaskBoardTasks(db, user.ID, TaskQuery{})
+		assert.Nil(t, err)
+		assert.Len(t, taskResponses, 3)
+		assert.Len(t, taskResponses[0].Labels, 2)
+		assert.Equal(t, "#8BC34A", taskResponses[0].Labels[1].Color)
+		assert.Len(t, taskResponses[2].Labels, 0)
+
+		// いずれかのラベルを付けたタスクに絞り込む
+		taskResponses, err = FetchTaskBoardTasks(db, user.ID, TaskQuery{Labels: []uint{labelIDs["バグ"], labelIDs["不具合"]}})
+		assert.Nil(t, err)
+		assert.Len(t, taskResponses, 2)
+
+		// ラベルを指定しない更新ではラベルを変更しない
+		plainTask.Status = 1
+		assert.Nil(t, plainTask.UpdateTask(db, int(plainTask.ID), false, user.ID))
+		plainTask.LabelIDs = []uint{labelIDs["改善"]}
+		assert.Nil(t, plainTask.UpdateTask(db, int(plainTask.ID), false, user.ID))
+		taskResponses, err = FetchTaskBoardTasks(db, user.ID, TaskQuery{Labels: []uint{labelIDs["改善"]}})
+		assert.Nil(t, err)
+		assert.Len(t, taskResponses, 2)
+	})
+
+	t.Run("統合", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidLabelMerge, MergeLabel(db, userGroup.ID, int(labelIDs["不具合"]), labelIDs["不具合"]))
+		assert.Equal(t, ErrInvalidLabelMerge, MergeLabel(db, userGroup.ID, int(labelIDs["不具合"]), otherLabels[0].ID))
+		assert.Nil(t, MergeLabel(db, userGroup.ID, int(labelIDs["不具合"]), labelIDs["バグ"]))
+
+		labels, err := FetchLabels(db, userGroup.ID)
+		assert.Nil(t, err)
+		assert.Len(t, labels, 2)
+		taskResponses, err := FetchTaskBoardTasks(db, user.ID, TaskQuery{Labels: []uint{labelIDs["バグ"]}})
+		assert.Nil(t, err)
+		assert.Len(t, taskResponses, 2)
+	})
+
+	t.Run("削除してもタスクは削除しない", func(t *testing.T) {
+		assert.Nil(t, DeleteLabel(db, userGroup.ID, int(labelIDs["バグ"])))
+		assert.Equal(t, ErrNotFound, DeleteLabel(db, userGroup.ID, int(otherLabels[0].ID)))
+
+		taskResponses, err := FetchTaskBoardTasks(db, user.ID, TaskQuery{})
+		assert.Nil(t, err)
+		assert.Len(t, taskResponses, 3)
+		assert.Len(t, taskResponses[1].Labels, 0)
+	})
+
+	// 後処理: テスト用のデータを削除
+	for _, task := range []*Task{bugTask, defectTask, plainTask} {
+		db.Where("task_id = ?", task.ID).Delete(&TaskHistory{})
+		db.Unscoped().Delete(task)
+	}
+	db.Where("user_group_id IN ?", []uint{userGroup.ID, otherUserGroup.ID}).Delete(&Label{})
+	db.Unscoped().Delete(category)
+	db.Unscoped().Delete(user)
+	db.Unscoped().Delete(otherUserGroup)
+	db.Unscoped().Delete(userGroup)
+}
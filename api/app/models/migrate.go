@@ -25,5 +25,80 @@ func Migrate(db *gorm.DB) error {
 		return err
 	}
 
+	passwordResetToken := &PasswordResetToken{}
+	if err := passwordResetToken.MigratePasswordResetToken(db); err != nil {
+		return err
+	}
+
+	emailChangeRequest := &EmailChangeRequest{}
+	if err := emailChangeRequest.MigrateEmailChangeRequest(db); err != nil {
+		return err
+	}
+
+	session := &Session{}
+	if err := session.MigrateSession(db); err != nil {
+		return err
+	}
+
+	twoFactorAuth := &TwoFactorAuth{}
+	if err := twoFactorAuth.MigrateTwoFactorAuth(db); err != nil {
+		return err
+	}
+
+	personalAccessToken := &PersonalAccessToken{}
+	if err := personalAccessToken.MigratePersonalAccessToken(db); err != nil {
+		return err
+	}
+
+	oidcIdentity := &OIDCIdentity{}
+	if err := oidcIdentity.MigrateOIDCIdentity(db); err != nil {
+		return err
+	}
+
+	comment := &Comment{}
+	if err := comment.MigrateComment(db); err != nil {
+		return err
+	}
+
+	taskDependency := &TaskDependency{}
+	if err := taskDependency.MigrateTaskDependency(db); err != nil {
+		return err
+	}
+
+	recurringTask := &RecurringTask{}
+	if err := recurringTask.MigrateRecurringTask(db); err != nil {
+		return err
+	}
+
+	timeEntry := &TimeEntry{}
+	if err := timeEntry.MigrateTimeEntry(db); err != nil {
+		return err
+	}
+
+	taskHistory := &TaskHistory{}
+	if err := taskHistory.MigrateTaskHistory(db); err != nil {
+		return err
+	}
+
+	workflowStatus := &WorkflowStatus{}
+	if err := workflowStatus.MigrateWorkflowStatus(db); err != nil {
+		return err
+	}
+
+	label := &Label{}
+	if err := label.MigrateLabel(db); err != nil {
+		return err
+	}
+
+	deletion := &Deletion{}
+	if err := deletion.MigrateDeletion(db); err != nil {
+		return err
+	}
+
+	auditLog := &AuditLog{}
+	if err := auditLog.MigrateAuditLog(db); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -34,4 +34,61 @@ func TestMigrate(t *testing.T) {
 
 	hasTable = db.Migrator().HasTable(&UserGroup{})
 	assert.True(t, hasTable, "UserGroup table should be created")
+
+	hasTable = db.Migrator().HasTable(&PasswordResetToken{})
+	assert.True(t, hasTable, "PasswordResetToken table should be created")
+
+	hasTable = db.Migrator().HasTable(&EmailChangeRequest{})
+	assert.True(t, hasTable, "EmailChangeRequest table should be created")
+
+	hasTable = db.Migrator().HasTable(&Session{})
+	assert.True(t, hasTable, "Session table should be created")
+
+	hasTable = db.Migrator().HasTable(&TwoFactorAuth{})
+	assert.True(t, hasTable, "TwoFactorAuth table should be created")
+
+	hasTable = db.Migrator().HasTable(&RecoveryCode{})
+	assert.True(t, hasTable, "RecoveryCode table should be created")
+
+	hasTable = db.Migrator().HasTable(&PersonalAccessToken{})
+	assert.True(t, hasTable, "PersonalAccessToken table should be created")
+
+	hasTable = db.Migrator().HasTable(&OIDCIdentity{})
+	assert.True(t, hasTable, "OIDCIdentity table should be created")
+
+	hasTable = db.Migrator().HasTable(&Comment{})
+	assert.True(t, hasTable, "Comment table should be created")
+
+	hasTable = db.Migrator().HasTable(&TaskDependency{})
+	assert.True(t, hasTable, "TaskDependency table should be created")
+
+	hasTable = db.Migrator().HasTable(&RecurringTask{})
+	assert.True(t, hasTable, "RecurringTask table should be created")
+
+	hasTable = db.Migrator().HasTable(&RecurringTaskSkip{})
+	assert.True(t, hasTable, "RecurringTaskSkip table should be created")
+
+	hasTable = db.Migrator().HasTable(&TimeEntry{})
+	assert.True(t, hasTable, "TimeEntry table should be created")
+
+	hasTable = db.Migrator().HasTable(&TaskHistory{})
+	assert.True(t, hasTable, "TaskHistory table should be created")
+
+	hasTable = db.Migrator().HasTable(&WorkflowStatus{})
+	assert.True(t, hasTable, "WorkflowStatus table should be created")
+
+	hasTable = db.Migrator().HasTable(&WorkflowTransition{})
+	assert.True(t, hasTable, "WorkflowTransition table should be created")
+
+	hasTable = db.Migrator().HasTable(&Label{})
+	assert.True(t, hasTable, "Label table should be created")
+
+	hasTable = db.Migrator().HasTable(&TaskLabel{})
+	assert.True(t, hasTable, "TaskLabel table should be created")
+
+	hasTable = db.Migrator().HasTable(&Deletion{})
+	assert.True(t, hasTable, "Deletion table should be created")
+
+	hasTable = db.Migrator().HasTable(&AuditLog{})
+	assert.True(t, hasTable, "AuditLog table should be created")
 }
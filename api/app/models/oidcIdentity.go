@@ -0,0 +1,158 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/utils"
+)
+
+var (
+	ErrOIDCEmailNotVerified = errors.New("IdPでメールアドレスが確認されていません")
+	ErrOIDCUserNotFound     = errors.New("このアカウントは登録されていません。管理者に招待を依頼してください")
+)
+
+// IdPのアカウントとユーザーの紐付けテーブル定義
+// 発行者(iss)とサブジェクト(sub)の組でIdPのアカウントを識別する
+type OIDCIdentity struct {
+	gorm.Model
+	UserID     uint   `gorm:"not null;index"`
+	User       User   `gorm:"foreignKey:UserID"`
+	Issuer     string `gorm:"size:255;not null;uniqueIndex:idx_oidc_identities_issuer_subject"`
+	Subject    string `gorm:"size:255;not null;uniqueIndex:idx_oidc_identities_issuer_subject"`
+	DeletionID *uint  `gorm:"index"` // ユーザーと一緒にゴミ箱に移動した削除操作
+}
+
+// IdPでログインしたユーザーの作成方法
+// UserGroupIDが0の場合はユーザーを作成しない
+type OIDCProvisioning struct {
+	UserGroupID uint
+}
+
+func (OIDCIdentity) TableName() string {
+	return "oidc_identities"
+}
+
+func (oidcIdentity *OIDCIdentity) MigrateOIDCIdentity(db *gorm.DB) error {
+	// 自動マイグレーション(OIDCIdentityテーブルを作成)
+	migrateErr := db.AutoMigrate(&OIDCIdentity{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// IdPのアカウントに紐づくユーザーを取得する
+// 未連携の場合は確認済みのメールアドレスで既存のユーザーと紐付け、
+// 既存のユーザーがいなければprovisioningに従ってユーザーを作成する
+func FindOrLinkOIDCUser(db *gorm.DB, claims utils.OIDCClaims, provisioning OIDCProvisioning) (User, error) {
+	var user User
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var oidcIdentity OIDCIdentity
+		err := tx.Where("issuer = ? AND subject = ?", claims.Issuer, claims.Subject).First(&oidcIdentity).Error
+		if err == nil {
+			if err := tx.Where("id = ?", oidcIdentity.UserID).First(&user).Error; err != nil {
+				log.Printf("Error fetching user with ID %d: %v\n", oidcIdentity.UserID, err)
+				return err
+			}
+			return nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Error fetching oidc identity: %v\n", err)
+			return err
+		}
+
+		// 確認されていないメールアドレスで他人のアカウントに紐付けられないようにする
+		if !claims.EmailVerified || claims.Email == "" {
+			log.Printf("OIDC login with unverified email for subject %s", claims.Subject)
+			return ErrOIDCEmailNotVerified
+		}
+
+		err = tx.Where("email = ?", claims.Email).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if provisioning.UserGroupID == 0 {
+				log.Printf("OIDC user is not registered and cannot be provisioned")
+				return ErrOIDCUserNotFound
+			}
+			created, err := createOIDCUser(tx, claims, provisioning.UserGroupID)
+			if err != nil {
+				return err
+			}
+			user = *created
+		} else if err != nil {
+			log.Printf("Error fetching user by email: %v\n", err)
+			return err
+		}
+
+		// 退会してゴミ箱にあるユーザーに紐付いていたIdPのアカウントは紐付け直せる
+		if err := releaseDeletedOIDCIdentity(tx, claims.Issuer, claims.Subject); err != nil {
+			log.Printf("Error releasing deleted oidc identity: %v\n", err)
+			return err
+		}
+
+		oidcIdentity = OIDCIdentity{
+			UserID:  user.ID,
+			Issuer:  claims.Issuer,
+			Subject: claims.Subject,
+		}
+		if err := tx.Create(&oidcIdentity).Error; err != nil {
+			log.Printf("Error creating oidc identity: %v\n", err)
+			return err
+		}
+		log.Printf("IdPのアカウントとユーザーの紐付けに成功")
+
+		return nil
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// IdPでログインするユーザーを作成する
+// パスワードでのログインはできないよう、ランダムなパスワードを設定する
+func createOIDCUser(tx *gorm.DB, claims utils.OIDCClaims, userGroupID uint) (*User, error) {
+	var userGroup UserGroup
+	if err := tx.Where("id = ?", userGroupID).First(&userGroup).Error; err != nil {
+		log.Printf("Error fetching user group with ID %d: %v\n", userGroupID, err)
+		return nil, ErrOIDCUserNotFound
+	}
+
+	password, err := utils.GenerateRandomString(32)
+	if err != nil {
+		log.Printf("Error generating password: %v\n", err)
+		return nil, err
+	}
+
+	newUser := &User{
+		Name:        oidcUsername(claims),
+		Password:    password,
+		Email:       claims.Email,
+		UserGroupID: userGroup.ID,
+		Role:        RoleMember,
+	}
+	return newUser.CreateUser(tx)
+}
+
+// ユーザー名は30文字以内にする
+func oidcUsername(claims utils.OIDCClaims) string {
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+
+	runes := []rune(name)
+	if len(runes) > 30 {
+		runes = runes[:30]
+	}
+	return string(runes)
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestOIDCUsername(t *testing.T) {
+	assert.Equal(t, "Test User", oidcUsername(utils.OIDCClaims{Name: " Test User ", Email: "test@example.com"}))
+	assert.Equal(t, "test", oidcUsername(utils.OIDCClaims{Email: "test@example.com"}))
+	assert.Equal(t, "あいうえおかきくけこさしすせそたちつてとなにぬねのはひふへほ", oidcUsername(utils.OIDCClaims{Name: "あいうえおかきくけこさしすせそたちつてとなにぬねのはひふへほまみむめも"}))
+}
+
+func TestFindOrLinkOIDCUser(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+
+	user := &User{
+		Name:        "TestUser",
+		Password:    "TestPassword",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(user)
+
+	claims := utils.OIDCClaims{
+		Issuer:        "https://idp.example.com",
+		Subject:       "user-1",
+		Email:         "test@example.com",
+		EmailVerified: true,
+	}
+
+	// 未確認のメールアドレスでは紐付けない
+	unverifiedClaims := claims
+	unverifiedClaims.EmailVerified = false
+	_, err = FindOrLinkOIDCUser(db, unverifiedClaims, OIDCProvisioning{})
+	assert.ErrorIs(t, err, ErrOIDCEmailNotVerified)
+
+	// 確認済みのメールアドレスで既存のユーザーと紐付ける
+	linked, err := FindOrLinkOIDCUser(db, claims, OIDCProvisioning{})
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, linked.ID)
+
+	// 紐付け後はIdPのメールアドレスが変わっても同じユーザーでログインできる
+	changedClaims := claims
+	changedClaims.Email = "changed@example.com"
+	changedClaims.EmailVerified = false
+	linked, err = FindOrLinkOIDCUser(db, changedClaims, OIDCProvisioning{})
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, linked.ID)
+
+	// 未登録のユーザーはユーザーグループの指定がなければ作成しない
+	newClaims := utils.OIDCClaims{
+		Issuer:        "https://idp.example.com",
+		Subject:       "user-2",
+		Email:         "new@example.com",
+		EmailVerified: true,
+		Name:          "New User",
+	}
+	_, err = FindOrLinkOIDCUser(db, newClaims, OIDCProvisioning{})
+	assert.ErrorIs(t, err, ErrOIDCUserNotFound)
+
+	created, err := FindOrLinkOIDCUser(db, newClaims, OIDCProvisioning{UserGroupID: userGroup.ID})
+	assert.Nil(t, err)
+	assert.Equal(t, "New User", created.Name)
+	assert.Equal(t, userGroup.ID, created.UserGroupID)
+	assert.Equal(t, RoleMember, created.Role)
+
+	// テストデータの削除
+	db.Unscoped().Where("user_id IN ?", []uint{user.ID, created.ID}).Delete(&OIDCIdentity{})
+	db.Unscoped().Delete(&User{}, created.ID)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+var ErrInvalidPasswordResetToken = errors.New("パスワードリセットのリンクが無効か、有効期限が切れています")
+
+// パスワードリセットトークンテーブル定義
+// トークン本体(JWT)は保存せず、トークンID(jti)で使用済み・失効を管理する
+type PasswordResetToken struct {
+	gorm.Model
+	UserID    uint       `gorm:"not null;index"`
+	User      User       `gorm:"foreignKey:UserID"`
+	TokenID   string     `gorm:"size:64;not null;unique"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+func (passwordResetToken *PasswordResetToken) MigratePasswordResetToken(db *gorm.DB) error {
+	// 自動マイグレーション(PasswordResetTokenテーブルを作成)
+	migrateErr := db.AutoMigrate(&PasswordResetToken{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// ユーザーのパスワードリセットトークンを発行する
+// 未使用の古いトークンはすべて失効させる
+func IssuePasswordResetToken(db *gorm.DB, user User) (string, error) {
+	tokenID, err := utils.GenerateRandomString(32)
+	if err != nil {
+		log.Printf("Error generating token ID: %v\n", err)
+		return "", err
+	}
+
+	tokenString, err := utils.GeneratePasswordResetToken(user.ID, tokenID, utils.PasswordFingerprint(user.Password))
+	if err != nil {
+		log.Printf("Token generation failed: %v\n", err)
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := RevokePasswordResetTokens(tx, user.ID); err != nil {
+			return err
+		}
+
+		resetToken := &PasswordResetToken{
+			UserID:    user.ID,
+			TokenID:   tokenID,
+			ExpiresAt: time.Now().Add(time.Minute * constant.PASSWORD_RESET_TOKEN_LIFETIME_MINUTES),
+		}
+		if err := tx.Create(resetToken).Error; err != nil {
+			log.Printf("Error creating password reset token: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	log.Printf("パスワードリセットトークンの発行に成功")
+
+	return tokenString, nil
+}
+
+// ユーザーの未使用のパスワードリセットトークンをすべて失効させる
+func RevokePasswordResetTokens(db *gorm.DB, userID uint) error {
+	result := db.Model(&PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		log.Printf("Error revoking password reset tokens: %v\n", result.Error)
+		return result.Error
+	}
+
+	return nil
+}
+
+// パスワードリセットトークンを検証し、使用済みにしたうえで対象のユーザーを返す
+// 呼び出し側のトランザクション内で使うことを想定している
+func consumePasswordResetToken(tx *gorm.DB, tokenString string) (User, error) {
+	var user User
+
+	claims, err := utils.ParsePasswordResetToken(tokenString)
+	if err != nil {
+		log.Printf("Error parsing password reset token: %v\n", err)
+		return user, ErrInvalidPasswordResetToken
+	}
+
+	// 同時に同じトークンが使われないよう行ロックを取得
+	var resetToken PasswordResetToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_id = ? AND user_id = ?", claims.TokenID, claims.UserID).
+		First(&resetToken).Error; err != nil {
+		log.Printf("Password reset token not found: %v\n", err)
+		return user, ErrInvalidPasswordResetToken
+	}
+
+	if resetToken.UsedAt != nil || resetToken.RevokedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		log.Printf("Password reset token %d is already used, revoked or expired", resetToken.ID)
+		return user, ErrInvalidPasswordResetToken
+	}
+
+	if err := tx.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+		log.Printf("Error fetching user with ID %d: %v\n", claims.UserID, err)
+		return user, ErrInvalidPasswordResetToken
+	}
+
+	// 発行後にパスワードが変更されていればトークンは無効
+	if utils.PasswordFingerprint(user.Password) != claims.PasswordFingerprint {
+		log.Printf("Password of user %d has changed since the reset token was issued", user.ID)
+		return user, ErrInvalidPasswordResetToken
+	}
+
+	if err := tx.Model(&resetToken).Update("used_at", time.Now()).Error; err != nil {
+		log.Printf("Error marking password reset token as used: %v\n", err)
+		return user, err
+	}
+
+	return user, nil
+}
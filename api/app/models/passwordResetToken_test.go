@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+	"testing"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestMigratePasswordResetToken(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// MigratePasswordResetToken関数をテスト
+	passwordResetToken := &PasswordResetToken{}
+	err = passwordResetToken.MigratePasswordResetToken(db)
+	assert.Nil(t, err, "MigratePasswordResetToken should not return an error")
+
+	// PasswordResetTokenテーブルが正しく作成されているかを確認
+	hasTable := db.Migrator().HasTable(&PasswordResetToken{})
+	assert.True(t, hasTable, "PasswordResetToken table should be created")
+}
+
+func TestIssuePasswordResetToken(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{
+		UserGroup: "TestUserGroup",
+	}
+	db.Create(userGroup)
+
+	user := &User{
+		Name:        "TestUser",
+		Password:    "TestPassword",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(user)
+
+	firstToken, err := IssuePasswordResetToken(db, *user)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, firstToken)
+
+	secondToken, err := IssuePasswordResetToken(db, *user)
+	assert.Nil(t, err)
+	assert.NotEqual(t, firstToken, secondToken)
+
+	// 有効なトークンは最新の1件のみ
+	var activeCount int64
+	db.Model(&PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL AND revoked_at IS NULL", user.ID).
+		Count(&activeCount)
+	assert.Equal(t, int64(1), activeCount)
+
+	// 古いトークンは使用できない
+	resetUser := &User{Password: "TestNewPassword"}
+	err = resetUser.ResetUserPassword(db, firstToken)
+	assert.ErrorIs(t, err, ErrInvalidPasswordResetToken)
+
+	// テストデータの削除
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&PasswordResetToken{})
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
+
+func TestResetUserPasswordWithExpiredToken(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{
+		UserGroup: "TestUserGroup",
+	}
+	db.Create(userGroup)
+
+	user := &User{
+		Name:        "TestUser",
+		Password:    "TestPassword",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(user)
+
+	resetToken, err := IssuePasswordResetToken(db, *user)
+	assert.Nil(t, err)
+
+	// サーバー側の有効期限を過去にする
+	db.Model(&PasswordResetToken{}).
+		Where("user_id = ?", user.ID).
+		Update("expires_at", time.Now().Add(-time.Minute))
+
+	resetUser := &User{Password: "TestNewPassword"}
+	err = resetUser.ResetUserPassword(db, resetToken)
+	assert.ErrorIs(t, err, ErrInvalidPasswordResetToken)
+
+	// テストデータの削除
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&PasswordResetToken{})
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
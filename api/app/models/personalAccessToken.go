@@ -0,0 +1,240 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+var ErrInvalidPersonalAccessToken = errors.New("アクセストークンが無効か、有効期限が切れています")
+
+// アクセストークンのスコープ
+const (
+	ScopeReadTasks        = "tasks:read"
+	ScopeWriteTasks       = "tasks:write"
+	ScopeManageCategories = "categories:manage"
+)
+
+// スコープごとに許可する操作（ユーザーの役割で許可されている操作に限る）
+var scopePermissions = map[string][]Permission{
+	ScopeReadTasks: {
+		PermissionReadTasks,
+		PermissionReadCategories,
+	},
+	ScopeWriteTasks: {
+		PermissionReadTasks,
+		PermissionWriteTasks,
+		PermissionReadCategories,
+	},
+	ScopeManageCategories: {
+		PermissionReadCategories,
+		PermissionWriteCategories,
+	},
+}
+
+// パーソナルアクセストークンテーブル定義
+// トークンはダイジェストのみを保存し、一覧で見分けるために先頭の数文字を保存する
+type PersonalAccessToken struct {
+	gorm.Model
+	UserID      uint   `gorm:"not null;index"`
+	User        User   `gorm:"foreignKey:UserID"`
+	Name        string `gorm:"size:100;not null"`
+	TokenHash   string `gorm:"size:64;not null;unique"`
+	TokenPrefix string `gorm:"size:16;not null"`
+	Scopes      string `gorm:"size:255;not null"` // カンマ区切り
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+}
+
+type PersonalAccessTokenInput struct {
+	Name          string   `json:"name" binding:"required,min=1,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=tasks:read tasks:write categories:manage"`
+	ExpiresInDays uint     `json:"expires_in_days" binding:"omitempty,min=1,max=365"` // 省略した場合は無期限
+}
+
+// パーソナルアクセストークン一覧取得
+type PersonalAccessTokenResponse struct {
+	ID          uint
+	Name        string
+	TokenPrefix string
+	Scopes      []string
+	ExpiresAt   string
+	LastUsedAt  string
+	CreatedAt   string
+}
+
+func (personalAccessToken *PersonalAccessToken) MigratePersonalAccessToken(db *gorm.DB) error {
+	// 自動マイグレーション(PersonalAccessTokenテーブルを作成)
+	migrateErr := db.AutoMigrate(&PersonalAccessToken{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// パーソナルアクセストークンを発行する
+// トークンを平文で返すのはこの時だけ
+func CreatePersonalAccessToken(db *gorm.DB, userID uint, input PersonalAccessTokenInput) (PersonalAccessTokenResponse, string, error) {
+	random, err := utils.GenerateRandomString(32)
+	if err != nil {
+		log.Printf("Error generating personal access token: %v\n", err)
+		return PersonalAccessTokenResponse{}, "", err
+	}
+	tokenString := constant.PERSONAL_ACCESS_TOKEN_PREFIX + random
+
+	personalAccessToken := PersonalAccessToken{
+		UserID:      userID,
+		Name:        input.Name,
+		TokenHash:   utils.HashToken(tokenString),
+		TokenPrefix: tokenString[:len(constant.PERSONAL_ACCESS_TOKEN_PREFIX)+4],
+		Scopes:      strings.Join(uniqueScopes(input.Scopes), ","),
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().Add(time.Hour * 24 * time.Duration(input.ExpiresInDays))
+		personalAccessToken.ExpiresAt = &expiresAt
+	}
+
+	if err := db.Create(&personalAccessToken).Error; err != nil {
+		log.Printf("Error creating personal access token: %v\n", err)
+		return PersonalAccessTokenResponse{}, "", err
+	}
+	log.Printf("パーソナルアクセストークンの発行に成功")
+
+	return personalAccessToken.toResponse(), tokenString, nil
+}
+
+// ログイン中のユーザーのパーソナルアクセストークンを作成日の新しい順に取得する
+func FetchPersonalAccessTokens(db *gorm.DB, userID uint) ([]PersonalAccessTokenResponse, error) {
+	var personalAccessTokens []PersonalAccessToken
+
+	result := db.Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&personalAccessTokens)
+
+	if result.Error != nil {
+		log.Printf("Error fetching personal access tokens: %v", result.Error)
+		return nil, result.Error
+	}
+	log.Printf("パーソナルアクセストークンの取得に成功")
+
+	responses := make([]PersonalAccessTokenResponse, len(personalAccessTokens))
+	for i, personalAccessToken := range personalAccessTokens {
+		responses[i] = personalAccessToken.toResponse()
+	}
+
+	return responses, nil
+}
+
+// ログイン中のユーザーのパーソナルアクセストークンを削除する
+func DeletePersonalAccessToken(db *gorm.DB, userID uint, tokenID int) error {
+	result := db.Unscoped().Where("id = ? AND user_id = ?", tokenID, userID).Delete(&PersonalAccessToken{})
+
+	if result.Error != nil {
+		log.Printf("Error deleting personal access token: %v\n", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	log.Printf("パーソナルアクセストークンの削除に成功")
+
+	return nil
+}
+
+// 有効期限内のパーソナルアクセストークンを取得する
+func FindActivePersonalAccessToken(db *gorm.DB, tokenString string) (PersonalAccessToken, error) {
+	var personalAccessToken PersonalAccessToken
+
+	if !strings.HasPrefix(tokenString, constant.PERSONAL_ACCESS_TOKEN_PREFIX) {
+		return personalAccessToken, ErrInvalidPersonalAccessToken
+	}
+
+	if err := db.Where("token_hash = ?", utils.HashToken(tokenString)).First(&personalAccessToken).Error; err != nil {
+		log.Printf("Personal access token not found: %v\n", err)
+		return personalAccessToken, ErrInvalidPersonalAccessToken
+	}
+
+	if personalAccessToken.ExpiresAt != nil && !time.Now().Before(*personalAccessToken.ExpiresAt) {
+		log.Printf("Personal access token %d is expired", personalAccessToken.ID)
+		return personalAccessToken, ErrInvalidPersonalAccessToken
+	}
+
+	return personalAccessToken, nil
+}
+
+// 最終使用日時を更新する
+// リクエストの度に書き込まないよう、一定時間が経過している場合のみ更新する
+func (personalAccessToken *PersonalAccessToken) Touch(db *gorm.DB) error {
+	now := time.Now()
+	if personalAccessToken.LastUsedAt != nil && now.Sub(*personalAccessToken.LastUsedAt) < time.Second*constant.SESSION_LAST_SEEN_INTERVAL_SECONDS {
+		return nil
+	}
+
+	result := db.Model(&PersonalAccessToken{}).Where("id = ?", personalAccessToken.ID).Update("last_used_at", now)
+	if result.Error != nil {
+		log.Printf("Error updating personal access token: %v\n", result.Error)
+		return result.Error
+	}
+	personalAccessToken.LastUsedAt = &now
+
+	return nil
+}
+
+func (personalAccessToken *PersonalAccessToken) ScopeList() []string {
+	if personalAccessToken.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(personalAccessToken.Scopes, ",")
+}
+
+// スコープのいずれかで操作が許可されているか確認する
+func ScopesAllow(scopes []string, permission Permission) bool {
+	for _, scope := range scopes {
+		for _, p := range scopePermissions[scope] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func (personalAccessToken *PersonalAccessToken) toResponse() PersonalAccessTokenResponse {
+	response := PersonalAccessTokenResponse{
+		ID:          personalAccessToken.ID,
+		Name:        personalAccessToken.Name,
+		TokenPrefix: personalAccessToken.TokenPrefix,
+		Scopes:      personalAccessToken.ScopeList(),
+		CreatedAt:   personalAccessToken.CreatedAt.Format("2006-01-02 15:04"),
+	}
+	if personalAccessToken.ExpiresAt != nil {
+		response.ExpiresAt = personalAccessToken.ExpiresAt.Format("2006-01-02 15:04")
+	}
+	if personalAccessToken.LastUsedAt != nil {
+		response.LastUsedAt = personalAccessToken.LastUsedAt.Format("2006-01-02 15:04")
+	}
+	return response
+}
+
+func uniqueScopes(scopes []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
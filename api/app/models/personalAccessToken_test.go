@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestScopesAllow(t *testing.T) {
+	assert.True(t, ScopesAllow([]string{ScopeReadTasks}, PermissionReadTasks))
+	assert.False(t, ScopesAllow([]string{ScopeReadTasks}, PermissionWriteTasks))
+	assert.True(t, ScopesAllow([]string{ScopeReadTasks, ScopeWriteTasks}, PermissionWriteTasks))
+	assert.False(t, ScopesAllow([]string{ScopeWriteTasks}, PermissionWriteCategories))
+	assert.True(t, ScopesAllow([]string{ScopeManageCategories}, PermissionWriteCategories))
+	assert.False(t, ScopesAllow([]string{}, PermissionReadTasks))
+}
+
+func TestPersonalAccessToken(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+
+	user := &User{
+		Name:        "TestUser",
+		Password:    "TestPassword",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(user)
+
+	otherUser := &User{
+		Name:        "OtherUser",
+		Password:    "TestPassword",
+		Email:       "other@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(otherUser)
+
+	response, tokenString, err := CreatePersonalAccessToken(db, user.ID, PersonalAccessTokenInput{
+		Name:          "CI",
+		Scopes:        []string{ScopeReadTasks, ScopeReadTasks, ScopeWriteTasks},
+		ExpiresInDays: 30,
+	})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(tokenString, constant.PERSONAL_ACCESS_TOKEN_PREFIX))
+	assert.True(t, strings.HasPrefix(tokenString, response.TokenPrefix))
+	assert.Equal(t, []string{ScopeReadTasks, ScopeWriteTasks}, response.Scopes)
+
+	// トークンは平文で保存しない
+	var stored PersonalAccessToken
+	db.First(&stored, response.ID)
+	assert.NotEqual(t, tokenString, stored.TokenHash)
+
+	found, err := FindActivePersonalAccessToken(db, tokenString)
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, found.UserID)
+
+	_, err = FindActivePersonalAccessToken(db, tokenString+"x")
+	assert.ErrorIs(t, err, ErrInvalidPersonalAccessToken)
+
+	// 最終使用日時が記録される
+	assert.Nil(t, found.Touch(db))
+	tokens, _ := FetchPersonalAccessTokens(db, user.ID)
+	assert.Len(t, tokens, 1)
+	assert.NotEmpty(t, tokens[0].LastUsedAt)
+
+	// 他のユーザーのトークンは削除できない
+	err = DeletePersonalAccessToken(db, otherUser.ID, int(response.ID))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = DeletePersonalAccessToken(db, user.ID, int(response.ID))
+	assert.Nil(t, err)
+	_, err = FindActivePersonalAccessToken(db, tokenString)
+	assert.ErrorIs(t, err, ErrInvalidPersonalAccessToken)
+
+	// テストデータの削除
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
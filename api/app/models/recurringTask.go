@@ -0,0 +1,443 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/alicend/LookBack/app/utils"
+)
+
+var (
+	ErrInvalidRRule      = errors.New("繰り返しルールが正しくありません")
+	ErrInvalidOccurrence = errors.New("指定された日は繰り返しの予定日ではありません")
+)
+
+// 繰り返しタスクのテンプレートテーブル定義
+// 繰り返しルールの予定日になると、テンプレートからタスクを作成する
+// 作成・編集より前の予定日のタスクは作成しない
+type RecurringTask struct {
+	gorm.Model
+	Task              string     `gorm:"size:255;not null"`
+	Description       string     `gorm:"size:255;not null"`
+	Creator           uint       `gorm:"not null"`
+	CreatorUserID     User       `gorm:"foreignKey:Creator;constraint:OnDelete:CASCADE;"`
+	CategoryID        uint       `gorm:"not null"`
+	Category          Category   `gorm:"foreignKey:CategoryID;constraint:OnDelete:CASCADE;"`
+	Responsible       uint       `gorm:"not null"`
+	ResponsibleUserID User       `gorm:"foreignKey:Responsible;constraint:OnDelete:CASCADE;"`
+	Estimate          uint       `gorm:"not null"`
+	RRule             string     `gorm:"column:rrule;size:255;not null"`
+	StartDate         time.Time  `gorm:"not null"`
+	LastOccurrence    *time.Time // タスクを作成済み（またはスキップ済み）の最後の予定日
+	StoppedAt         *time.Time // 停止した繰り返しからはタスクを作成しない
+}
+
+// 繰り返しをスキップする予定日
+type RecurringTaskSkip struct {
+	ID              uint          `gorm:"primarykey"`
+	RecurringTaskID uint          `gorm:"not null;uniqueIndex:idx_recurring_task_skip"`
+	RecurringTask   RecurringTask `gorm:"foreignKey:RecurringTaskID;constraint:OnDelete:CASCADE;"`
+	Date            time.Time     `gorm:"not null;uniqueIndex:idx_recurring_task_skip"`
+}
+
+type RecurringTaskInput struct {
+	Task        string `json:"Task" binding:"required,min=1,max=255"`
+	Description string `json:"Description" binding:"required,min=1,max=255"`
+	StartDate   string `json:"StartDate" binding:"required,min=1,max=24"`
+	Estimate    *uint  `json:"Estimate" binding:"required,min=1,max=1000"`
+	Responsible uint   `json:"Responsible" binding:"required"`
+	CategoryID  uint   `json:"Category" binding:"required"`
+	RRule       string `json:"RRule" binding:"required,min=1,max=255"`
+}
+
+type RecurringTaskSkipInput struct {
+	Date string `json:"Date" binding:"required,min=1,max=24"`
+}
+
+type RecurringTaskResponse struct {
+	ID                  uint
+	Task                string
+	Description         string
+	Category            uint
+	CategoryName        string
+	Estimate            uint
+	Responsible         uint
+	ResponsibleUserName string
+	RRule               string
+	StartDate           string
+	NextOccurrence      string // 次にタスクを作成する予定日（繰り返しが終了した場合は空）
+	SkippedDates        []string
+	Stopped             bool
+	CreatedAt           string
+}
+
+func (recurringTask *RecurringTask) MigrateRecurringTask(db *gorm.DB) error {
+	// 自動マイグレーション(RecurringTask, RecurringTaskSkipテーブルを作成)
+	migrateErr := db.AutoMigrate(&RecurringTask{}, &RecurringTaskSkip{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// 繰り返しタスクを作成し、予定日になっていればタスクも作成する
+func (recurringTask *RecurringTask) CreateRecurringTask(db *gorm.DB, horizon time.Time) error {
+	if err := validateRRule(recurringTask.RRule); err != nil {
+		return err
+	}
+
+	recurringTask.StartDate = toDate(recurringTask.StartDate)
+	yesterday := toDate(time.Now()).AddDate(0, 0, -1)
+	recurringTask.LastOccurrence = &yesterday
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(recurringTask).Error; err != nil {
+			log.Printf("Error creating recurring task: %v\n", err)
+			return err
+		}
+
+		_, err := generateRecurringTask(tx, recurringTask.ID, horizon)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("繰り返しタスクの作成に成功")
+
+	return nil
+}
+
+func FetchRecurringTasks(db *gorm.DB, userGroupID uint) ([]RecurringTaskResponse, error) {
+	var recurringTasks []RecurringTask
+
+	result := db.Preload("ResponsibleUserID").
+		Preload("Category").
+		Joins("JOIN categories ON recurring_tasks.category_id = categories.id AND categories.deleted_at IS NULL").
+		Where("categories.user_group_id = ?", userGroupID).
+		Order("recurring_tasks.created_at asc").
+		Find(&recurringTasks)
+
+	if result.Error != nil {
+		log.Printf("Error fetching recurring tasks: %v\n", result.Error)
+		return nil, result.Error
+	}
+
+	var skips []RecurringTaskSkip
+	result = db.Joins("JOIN recurring_tasks ON recurring_task_skips.recurring_task_id = recurring_tasks.id").
+		Joins("JOIN categories ON recurring_tasks.category_id = categories.id AND categories.deleted_at IS NULL").
+		Where("categories.user_group_id = ?", userGroupID).
+		Order("recurring_task_skips.date asc").
+		Find(&skips)
+
+	if result.Error != nil {
+		log.Printf("Error fetching recurring task skips: %v\n", result.Error)
+		return nil, result.Error
+	}
+	log.Printf("繰り返しタスクの取得に成功")
+
+	skippedDates := map[uint][]time.Time{}
+	for _, skip := range skips {
+		skippedDates[skip.RecurringTaskID] = append(skippedDates[skip.RecurringTaskID], toDate(skip.Date))
+	}
+
+	recurringTaskResponses := make([]RecurringTaskResponse, len(recurringTasks))
+	for i, recurringTask := range recurringTasks {
+		recurringTaskResponses[i] = toRecurringTaskResponse(recurringTask, skippedDates[recurringTask.ID])
+	}
+
+	return recurringTaskResponses, nil
+}
+
+// 繰り返しタスクを編集する
+// 作成済みのタスクは変更せず、今日以降の予定日のタスクに反映する
+func (recurringTask *RecurringTask) UpdateRecurringTask(db *gorm.DB, id int, horizon time.Time) error {
+	if err := validateRRule(recurringTask.RRule); err != nil {
+		return err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current RecurringTask
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&current).Error; err != nil {
+			log.Printf("Error fetching recurring task with ID %d: %v\n", id, err)
+			return toAuthorizationError(err)
+		}
+
+		lastOccurrence := toDate(time.Now()).AddDate(0, 0, -1)
+		if current.LastOccurrence != nil && current.LastOccurrence.After(lastOccurrence) {
+			lastOccurrence = toDate(*current.LastOccurrence)
+		}
+
+		result := tx.Model(&current).Updates(map[string]interface{}{
+			"task":            recurringTask.Task,
+			"description":     recurringTask.Description,
+			"category_id":     recurringTask.CategoryID,
+			"responsible":     recurringTask.Responsible,
+			"estimate":        recurringTask.Estimate,
+			"rrule":           recurringTask.RRule,
+			"start_date":      toDate(recurringTask.StartDate),
+			"last_occurrence": lastOccurrence,
+		})
+		if result.Error != nil {
+			log.Printf("Error updating recurring task: %v\n", result.Error)
+			return result.Error
+		}
+
+		_, err := generateRecurringTask(tx, uint(id), horizon)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("繰り返しタスクの更新に成功")
+
+	return nil
+}
+
+// 繰り返しを停止する（作成済みのタスクは残す）
+func StopRecurringTask(db *gorm.DB, id int) error {
+	result := db.Model(&RecurringTask{}).Where("id = ? AND stopped_at IS NULL", id).Update("stopped_at", time.Now())
+	if result.Error != nil {
+		log.Printf("Error stopping recurring task: %v\n", result.Error)
+		return result.Error
+	}
+	log.Printf("繰り返しタスクの停止に成功")
+
+	return nil
+}
+
+// まだタスクを作成していない予定日をスキップする
+func SkipRecurringTaskOccurrence(db *gorm.DB, id int, date time.Time) error {
+	date = toDate(date)
+
+	var recurringTask RecurringTask
+	if err := db.Where("id = ?", id).First(&recurringTask).Error; err != nil {
+		log.Printf("Error fetching recurring task with ID %d: %v\n", id, err)
+		return toAuthorizationError(err)
+	}
+
+	rule, err := utils.ParseRRule(recurringTask.RRule)
+	if err != nil {
+		log.Printf("Invalid rrule of recurring task %d: %v", id, err)
+		return fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+
+	alreadyGenerated := recurringTask.LastOccurrence != nil && !date.After(toDate(*recurringTask.LastOccurrence))
+	if alreadyGenerated || !rule.Includes(toDate(recurringTask.StartDate), date) {
+		log.Printf("%s is not an upcoming occurrence of recurring task %d", date.Format("2006-01-02"), id)
+		return ErrInvalidOccurrence
+	}
+
+	// スキップ済みの場合は何もしない
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&RecurringTaskSkip{RecurringTaskID: recurringTask.ID, Date: date})
+	if result.Error != nil {
+		log.Printf("Error creating recurring task skip: %v\n", result.Error)
+		return result.Error
+	}
+	log.Printf("繰り返しタスクの予定日のスキップに成功")
+
+	return nil
+}
+
+// 繰り返しタスクを削除する（作成済みのタスクは残す）
+func DeleteRecurringTask(db *gorm.DB, id int) error {
+	if err := db.Unscoped().Delete(&RecurringTask{}, id).Error; err != nil {
+		log.Printf("Error deleting recurring task: %v\n", err)
+		return err
+	}
+	log.Printf("繰り返しタスクの削除に成功")
+
+	return nil
+}
+
+// 停止していない繰り返しタスクから、horizonまでの予定日のタスクを作成し、作成した件数を返す
+func GenerateRecurringTasks(db *gorm.DB, horizon time.Time) (int, error) {
+	var ids []uint
+	// ゴミ箱にあるカテゴリーや担当者の繰り返しタスクからは作成しない
+	result := db.Model(&RecurringTask{}).
+		Joins("JOIN categories ON recurring_tasks.category_id = categories.id AND categories.deleted_at IS NULL").
+		Joins("JOIN users ON recurring_tasks.responsible = users.id AND users.deleted_at IS NULL").
+		Where("recurring_tasks.stopped_at IS NULL").
+		Pluck("recurring_tasks.id", &ids)
+	if err := result.Error; err != nil {
+		log.Printf("Error fetching recurring tasks: %v\n", err)
+		return 0, err
+	}
+
+	total := 0
+	for _, id := range ids {
+		// 1件の失敗で他の繰り返しタスクを止めないよう、繰り返しタスクごとにトランザクションを分ける
+		err := db.Transaction(func(tx *gorm.DB) error {
+			created, err := generateRecurringTask(tx, id, horizon)
+			total += created
+			return err
+		})
+		if err != nil {
+			log.Printf("Error generating tasks of recurring task %d: %v", id, err)
+		}
+	}
+	log.Printf("繰り返しタスクからのタスクの作成に成功")
+
+	return total, nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// 繰り返しタスクをロックし、前回作成した予定日の翌日からhorizonまでの予定日のタスクを作成する
+// 複数のサーバーで同時に実行しても同じ予定日のタスクを重複して作成しない
+func generateRecurringTask(tx *gorm.DB, id uint, horizon time.Time) (int, error) {
+	var recurringTask RecurringTask
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&recurringTask).Error; err != nil {
+		return 0, err
+	}
+	if recurringTask.StoppedAt != nil {
+		return 0, nil
+	}
+
+	rule, err := utils.ParseRRule(recurringTask.RRule)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+
+	var skipDates []time.Time
+	if err := tx.Model(&RecurringTaskSkip{}).Where("recurring_task_id = ?", id).Pluck("date", &skipDates).Error; err != nil {
+		return 0, err
+	}
+	skipped := map[string]bool{}
+	for _, skipDate := range skipDates {
+		skipped[skipDate.Format("2006-01-02")] = true
+	}
+
+	horizon = toDate(horizon)
+	var lastOccurrence time.Time
+	if recurringTask.LastOccurrence != nil {
+		lastOccurrence = toDate(*recurringTask.LastOccurrence)
+	}
+
+	var occurrences []time.Time
+	rule.Each(toDate(recurringTask.StartDate), func(occurrence time.Time, _ int) bool {
+		if occurrence.After(horizon) {
+			return false
+		}
+		if occurrence.After(lastOccurrence) {
+			occurrences = append(occurrences, occurrence)
+		}
+		return true
+	})
+	if len(occurrences) == 0 {
+		return 0, nil
+	}
+
+	// 作成するタスクはユーザーグループの最初の未着手のステータスとする
+	workflows, err := fetchCategoryWorkflows(tx, []uint{recurringTask.CategoryID})
+	if err != nil {
+		return 0, err
+	}
+	workflow := workflows.of(recurringTask.CategoryID)
+
+	created := 0
+	for _, occurrence := range occurrences {
+		if skipped[occurrence.Format("2006-01-02")] {
+			continue
+		}
+
+		startDate := occurrence
+		estimate := recurringTask.Estimate
+		rank, err := lastColumnRank(tx, recurringTask.CategoryID, workflow.initialStatus())
+		if err != nil {
+			return created, err
+		}
+		task := Task{
+			Task:            recurringTask.Task,
+			Description:     recurringTask.Description,
+			Creator:         recurringTask.Creator,
+			CategoryID:      recurringTask.CategoryID,
+			Status:          workflow.initialStatus(),
+			Responsible:     recurringTask.Responsible,
+			Estimate:        &estimate,
+			StartDate:       &startDate,
+			RecurringTaskID: &recurringTask.ID,
+			Rank:            rank,
+		}
+		if err := tx.Create(&task).Error; err != nil {
+			return created, err
+		}
+		// 繰り返しタスクの作成者が作成したタスクとして変更履歴を記録する
+		if err := recordTaskHistory(tx, task.ID, task.Creator, nil, &task); err != nil {
+			return created, err
+		}
+		created++
+	}
+
+	last := occurrences[len(occurrences)-1]
+	if err := tx.Model(&recurringTask).Update("last_occurrence", last).Error; err != nil {
+		return created, err
+	}
+
+	return created, nil
+}
+
+func validateRRule(value string) error {
+	if _, err := utils.ParseRRule(value); err != nil {
+		log.Printf("Invalid rrule %q: %v", value, err)
+		return fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+	return nil
+}
+
+// 日付として扱うため、時刻を切り捨ててUTCに揃える
+func toDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func toRecurringTaskResponse(recurringTask RecurringTask, skippedDates []time.Time) RecurringTaskResponse {
+	response := RecurringTaskResponse{
+		ID:                  recurringTask.ID,
+		Task:                recurringTask.Task,
+		Description:         recurringTask.Description,
+		Category:            recurringTask.Category.ID,
+		CategoryName:        recurringTask.Category.Category,
+		Estimate:            recurringTask.Estimate,
+		Responsible:         recurringTask.ResponsibleUserID.ID,
+		ResponsibleUserName: recurringTask.ResponsibleUserID.Name,
+		RRule:               recurringTask.RRule,
+		StartDate:           recurringTask.StartDate.Format("2006-01-02"),
+		SkippedDates:        []string{},
+		Stopped:             recurringTask.StoppedAt != nil,
+		CreatedAt:           recurringTask.CreatedAt.Format("2006-01-02 15:04"),
+	}
+
+	var lastOccurrence time.Time
+	if recurringTask.LastOccurrence != nil {
+		lastOccurrence = toDate(*recurringTask.LastOccurrence)
+	}
+	skipped := map[string]bool{}
+	for _, skippedDate := range skippedDates {
+		// 作成済みの予定日より前のスキップは返さない
+		if skippedDate.After(lastOccurrence) {
+			response.SkippedDates = append(response.SkippedDates, skippedDate.Format("2006-01-02"))
+			skipped[skippedDate.Format("2006-01-02")] = true
+		}
+	}
+
+	rule, err := utils.ParseRRule(recurringTask.RRule)
+	if err != nil || response.Stopped {
+		return response
+	}
+	rule.Each(toDate(recurringTask.StartDate), func(occurrence time.Time, _ int) bool {
+		if occurrence.After(lastOccurrence) && !skipped[occurrence.Format("2006-01-02")] {
+			response.NextOccurrence = occurrence.Format("2006-01-02")
+			return false
+		}
+		return true
+	})
+
+	return response
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestRecurringTasks(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	user := &User{Name: "TestUser", Password: "TestPassword", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(user)
+	category := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(category)
+
+	today := toDate(time.Now())
+	recurringTask := &RecurringTask{
+		Task:        "Weekly Chore",
+		Description: "TestDescription",
+		Creator:     user.ID,
+		CategoryID:  category.ID,
+		Responsible: user.ID,
+		Estimate:    1,
+		RRule:       "FREQ=DAILY;INTERVAL=7",
+		StartDate:   today.AddDate(0, 0, -7),
+	}
+	countTasks := func() int64 {
+		var count int64
+		db.Model(&Task{}).Where("recurring_task_id = ?", recurringTask.ID).Count(&count)
+		return count
+	}
+
+	t.Run("失敗_不正な繰り返しルール", func(t *testing.T) {
+		invalid := &RecurringTask{RRule: "FREQ=YEARLY"}
+		err := invalid.CreateRecurringTask(db, today)
+		assert.ErrorIs(t, err, ErrInvalidRRule)
+	})
+
+	t.Run("作成", func(t *testing.T) {
+		// 作成より前の予定日のタスクは作成せず、今日のタスクのみ作成する
+		err := recurringTask.CreateRecurringTask(db, today)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), countTasks())
+
+		var task Task
+		db.Where("recurring_task_id = ?", recurringTask.ID).First(&task)
+		assert.Equal(t, today.Format("2006-01-02"), task.StartDate.Format("2006-01-02"))
+		assert.Equal(t, uint(1), task.Status)
+	})
+
+	t.Run("スキップ", func(t *testing.T) {
+		// 作成済みの予定日や予定日でない日はスキップできない
+		assert.Equal(t, ErrInvalidOccurrence, SkipRecurringTaskOccurrence(db, int(recurringTask.ID), today))
+		assert.Equal(t, ErrInvalidOccurrence, SkipRecurringTaskOccurrence(db, int(recurringTask.ID), today.AddDate(0, 0, 8)))
+
+		assert.Nil(t, SkipRecurringTaskOccurrence(db, int(recurringTask.ID), today.AddDate(0, 0, 7)))
+
+		recurringTasks, err := FetchRecurringTasks(db, userGroup.ID)
+		assert.Nil(t, err)
+		assert.Len(t, recurringTasks, 1)
+		assert.Equal(t, []string{today.AddDate(0, 0, 7).Format("2006-01-02")}, recurringTasks[0].SkippedDates)
+		assert.Equal(t, today.AddDate(0, 0, 14).Format("2006-01-02"), recurringTasks[0].NextOccurrence)
+	})
+
+	t.Run("定期的な作成", func(t *testing.T) {
+		// 同じ期限で繰り返し実行しても重複して作成しない
+		_, err := GenerateRecurringTasks(db, today)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), countTasks())
+
+		// スキップした予定日は作成しない
+		_, err = GenerateRecurringTasks(db, today.AddDate(0, 0, 14))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), countTasks())
+	})
+
+	t.Run("編集", func(t *testing.T) {
+		recurringTask.RRule = "FREQ=DAILY;COUNT=3"
+		recurringTask.StartDate = today.AddDate(0, 0, 15)
+		err := recurringTask.UpdateRecurringTask(db, int(recurringTask.ID), today.AddDate(0, 0, 30))
+		assert.Nil(t, err)
+		// 回数に達した後は作成しない
+		assert.Equal(t, int64(5), countTasks())
+
+		recurringTasks, _ := FetchRecurringTasks(db, userGroup.ID)
+		assert.Equal(t, "", recurringTasks[0].NextOccurrence)
+	})
+
+	t.Run("停止", func(t *testing.T) {
+		recurringTask.RRule = "FREQ=DAILY"
+		err := recurringTask.UpdateRecurringTask(db, int(recurringTask.ID), today.AddDate(0, 0, 30))
+		assert.Nil(t, err)
+		countBefore := countTasks()
+
+		assert.Nil(t, StopRecurringTask(db, int(recurringTask.ID)))
+		_, err = GenerateRecurringTasks(db, today.AddDate(0, 0, 60))
+		assert.Nil(t, err)
+		assert.Equal(t, countBefore, countTasks())
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		// 作成済みのタスクは残す
+		assert.Nil(t, DeleteRecurringTask(db, int(recurringTask.ID)))
+		var count int64
+		db.Model(&Task{}).Where("category_id = ? AND recurring_task_id IS NULL", category.ID).Count(&count)
+		assert.NotEqual(t, int64(0), count)
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Where("category_id = ?", category.ID).Delete(&Task{})
+	db.Unscoped().Delete(category)
+	db.Unscoped().Delete(user)
+	db.Unscoped().Delete(userGroup)
+}
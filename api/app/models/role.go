@@ -0,0 +1,215 @@
+package models
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ユーザーグループ内の役割
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleViewer = "viewer"
+)
+
+// 役割ごとに許可する操作
+type Permission string
+
+const (
+	PermissionReadTasks         Permission = "tasks:read"
+	PermissionWriteTasks        Permission = "tasks:write"
+	PermissionReadCategories    Permission = "categories:read"
+	PermissionWriteCategories   Permission = "categories:write"
+	PermissionSendInvitations   Permission = "invitations:send"
+	PermissionManageMembers     Permission = "members:manage"
+	PermissionUpdateUserGroup   Permission = "user-group:update"
+	PermissionDeleteUserGroup   Permission = "user-group:delete"
+	PermissionTransferOwnership Permission = "user-group:transfer"
+	PermissionReadAuditLogs     Permission = "audit-logs:read"
+	PermissionModerateComments  Permission = "comments:moderate"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleViewer: {
+		PermissionReadTasks,
+		PermissionReadCategories,
+	},
+	RoleMember: {
+		PermissionReadTasks,
+		PermissionWriteTasks,
+		PermissionReadCategories,
+		PermissionWriteCategories,
+	},
+	RoleAdmin: {
+		PermissionReadTasks,
+		PermissionWriteTasks,
+		PermissionReadCategories,
+		PermissionWriteCategories,
+		PermissionSendInvitations,
+		PermissionManageMembers,
+		PermissionUpdateUserGroup,
+		PermissionReadAuditLogs,
+		PermissionModerateComments,
+	},
+	RoleOwner: {
+		PermissionReadTasks,
+		PermissionWriteTasks,
+		PermissionReadCategories,
+		PermissionWriteCategories,
+		PermissionSendInvitations,
+		PermissionManageMembers,
+		PermissionUpdateUserGroup,
+		PermissionDeleteUserGroup,
+		PermissionTransferOwnership,
+		PermissionReadAuditLogs,
+		PermissionModerateComments,
+	},
+}
+
+// 役割の強さ（自分より弱い役割のメンバーのみ変更できる）
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+var (
+	ErrCannotTransferToSelf = errors.New("自分自身にオーナーを譲渡することはできません")
+	ErrOwnerMustTransfer    = errors.New("オーナーは他のメンバーにオーナーを譲渡してから操作してください")
+)
+
+// メンバーの役割変更の入力値
+// オーナーへの変更はオーナーの譲渡で行う
+type RoleUpdateInput struct {
+	Role string `json:"role" binding:"required,oneof=admin member viewer"`
+}
+
+// オーナーの譲渡の入力値
+type OwnershipTransferInput struct {
+	UserID uint `json:"user_id" binding:"required,min=1"`
+}
+
+// 役割に操作が許可されているか確認する
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// 同じユーザーグループのメンバーの役割を変更する
+// 自分より弱い役割のメンバーを、自分より弱い役割にのみ変更できる
+func ChangeUserRole(db *gorm.DB, actorID uint, targetUserID uint, role string) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		actor, target, err := findActorAndTarget(tx, actorID, targetUserID)
+		if err != nil {
+			return err
+		}
+
+		if roleRanks[actor.Role] <= roleRanks[target.Role] || roleRanks[actor.Role] <= roleRanks[role] {
+			log.Printf("User %d (%s) cannot change role of user %d (%s) to %s", actor.ID, actor.Role, target.ID, target.Role, role)
+			return ErrForbidden
+		}
+
+		if err := tx.Model(&User{}).Where("id = ?", target.ID).Update("role", role).Error; err != nil {
+			log.Printf("Error updating role: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("メンバーの役割の変更に成功")
+
+	return nil
+}
+
+// オーナーを同じユーザーグループの他のメンバーに譲渡する
+// 譲渡した元のオーナーは管理者になる
+func TransferOwnership(db *gorm.DB, ownerID uint, targetUserID uint) error {
+	if ownerID == targetUserID {
+		return ErrCannotTransferToSelf
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		owner, target, err := findActorAndTarget(tx, ownerID, targetUserID)
+		if err != nil {
+			return err
+		}
+
+		if owner.Role != RoleOwner {
+			log.Printf("User %d is not the owner of user group %d", owner.ID, owner.UserGroupID)
+			return ErrForbidden
+		}
+
+		if err := tx.Model(&User{}).Where("id = ?", target.ID).Update("role", RoleOwner).Error; err != nil {
+			log.Printf("Error updating role: %v\n", err)
+			return err
+		}
+
+		if err := tx.Model(&User{}).Where("id = ?", owner.ID).Update("role", RoleAdmin).Error; err != nil {
+			log.Printf("Error updating role: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("オーナーの譲渡に成功")
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func findActorAndTarget(tx *gorm.DB, actorID uint, targetUserID uint) (User, User, error) {
+	var actor, target User
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", actorID).First(&actor).Error; err != nil {
+		log.Printf("Error fetching user with ID %d: %v\n", actorID, err)
+		return actor, target, toAuthorizationError(err)
+	}
+
+	// 他のユーザーグループのメンバーは存在しないものとして扱う
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_group_id = ?", targetUserID, actor.UserGroupID).
+		First(&target).Error; err != nil {
+		log.Printf("Error fetching user with ID %d in user group %d: %v\n", targetUserID, actor.UserGroupID, err)
+		return actor, target, toAuthorizationError(err)
+	}
+
+	return actor, target, nil
+}
+
+// オーナーのいないユーザーグループは最初に登録したユーザーをオーナーにする
+// 役割を導入する前に作成されたユーザーグループのためのマイグレーション
+func assignMissingOwners(db *gorm.DB) error {
+	result := db.Exec(`
+		UPDATE users
+		JOIN (
+			SELECT MIN(id) AS id FROM users
+			WHERE deleted_at IS NULL
+			GROUP BY user_group_id
+			HAVING SUM(role = ?) = 0
+		) AS first_users ON users.id = first_users.id
+		SET users.role = ?`, RoleOwner, RoleOwner)
+
+	if result.Error != nil {
+		log.Printf("Error assigning owners: %v\n", result.Error)
+		return result.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"testing"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestHasPermission(t *testing.T) {
+	// 閲覧者は参照のみ
+	assert.True(t, HasPermission(RoleViewer, PermissionReadTasks))
+	assert.False(t, HasPermission(RoleViewer, PermissionWriteTasks))
+
+	// メンバーはタスクとカテゴリーを編集できるが招待はできない
+	assert.True(t, HasPermission(RoleMember, PermissionWriteTasks))
+	assert.True(t, HasPermission(RoleMember, PermissionWriteCategories))
+	assert.False(t, HasPermission(RoleMember, PermissionSendInvitations))
+	assert.False(t, HasPermission(RoleMember, PermissionReadAuditLogs))
+	assert.False(t, HasPermission(RoleMember, PermissionModerateComments))
+
+	// 管理者はメンバーを管理できるがユーザーグループを削除できない
+	assert.True(t, HasPermission(RoleAdmin, PermissionManageMembers))
+	assert.True(t, HasPermission(RoleAdmin, PermissionReadAuditLogs))
+	assert.True(t, HasPermission(RoleAdmin, PermissionModerateComments))
+	assert.False(t, HasPermission(RoleAdmin, PermissionDeleteUserGroup))
+	assert.False(t, HasPermission(RoleAdmin, PermissionTransferOwnership))
+
+	// オーナーはすべての操作ができる
+	assert.True(t, HasPermission(RoleOwner, PermissionDeleteUserGroup))
+	assert.True(t, HasPermission(RoleOwner, PermissionTransferOwnership))
+
+	// 未知の役割には何も許可しない
+	assert.False(t, HasPermission("", PermissionReadTasks))
+}
+
+func TestChangeUserRole(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	otherUserGroup := &UserGroup{UserGroup: "OtherUserGroup"}
+	db.Create(otherUserGroup)
+
+	owner := &User{Name: "Owner", Password: "TestPassword", Email: "owner@example.com", UserGroupID: userGroup.ID, Role: RoleOwner}
+	db.Create(owner)
+	admin := &User{Name: "Admin", Password: "TestPassword", Email: "admin@example.com", UserGroupID: userGroup.ID, Role: RoleAdmin}
+	db.Create(admin)
+	member := &User{Name: "Member", Password: "TestPassword", Email: "member@example.com", UserGroupID: userGroup.ID, Role: RoleMember}
+	db.Create(member)
+	otherUser := &User{Name: "Other", Password: "TestPassword", Email: "other@example.com", UserGroupID: otherUserGroup.ID, Role: RoleMember}
+	db.Create(otherUser)
+
+	// 管理者はメンバーを閲覧者にできる
+	err = ChangeUserRole(db, admin.ID, member.ID, RoleViewer)
+	assert.Nil(t, err)
+	updated, _ := FindUserByID(db, member.ID)
+	assert.Equal(t, RoleViewer, updated.Role)
+
+	// 管理者は他のメンバーを管理者にできない
+	err = ChangeUserRole(db, admin.ID, member.ID, RoleAdmin)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// 管理者はオーナーの役割を変更できない
+	err = ChangeUserRole(db, admin.ID, owner.ID, RoleMember)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// 他のユーザーグループのユーザーは変更できない
+	err = ChangeUserRole(db, owner.ID, otherUser.ID, RoleViewer)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// オーナーは管理者をメンバーにできる
+	err = ChangeUserRole(db, owner.ID, admin.ID, RoleMember)
+	assert.Nil(t, err)
+	updated, _ = FindUserByID(db, admin.ID)
+	assert.Equal(t, RoleMember, updated.Role)
+
+	// テストデータの削除
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&member)
+	db.Unscoped().Delete(&admin)
+	db.Unscoped().Delete(&owner)
+	db.Unscoped().Delete(&otherUserGroup)
+	db.Unscoped().Delete(&userGroup)
+}
+
+func TestTransferOwnership(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+
+	owner := &User{Name: "Owner", Password: "TestPassword", Email: "owner@example.com", UserGroupID: userGroup.ID, Role: RoleOwner}
+	db.Create(owner)
+	member := &User{Name: "Member", Password: "TestPassword", Email: "member@example.com", UserGroupID: userGroup.ID, Role: RoleMember}
+	db.Create(member)
+
+	// 自分自身には譲渡できない
+	err = TransferOwnership(db, owner.ID, owner.ID)
+	assert.ErrorIs(t, err, ErrCannotTransferToSelf)
+
+	// オーナー以外は譲渡できない
+	err = TransferOwnership(db, member.ID, owner.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// 他のメンバーが残っているとオーナーはユーザーグループを抜けられない
+	err = owner.DeleteUserAndRelatedTasks(db, owner.ID)
+	assert.ErrorIs(t, err, ErrOwnerMustTransfer)
+
+	// 譲渡すると元のオーナーは管理者になる
+	err = TransferOwnership(db, owner.ID, member.ID)
+	assert.Nil(t, err)
+	newOwner, _ := FindUserByID(db, member.ID)
+	assert.Equal(t, RoleOwner, newOwner.Role)
+	formerOwner, _ := FindUserByID(db, owner.ID)
+	assert.Equal(t, RoleAdmin, formerOwner.Role)
+
+	// テストデータの削除
+	db.Unscoped().Delete(&member)
+	db.Unscoped().Delete(&owner)
+	db.Unscoped().Delete(&userGroup)
+}
@@ -0,0 +1,312 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+var (
+	ErrInvalidSession      = errors.New("セッションが無効です。再度ログインしてください")
+	ErrInvalidRefreshToken = errors.New("セッションの有効期限が切れています。再度ログインしてください")
+)
+
+// セッションテーブル定義
+// アクセストークン(JWT)のsidにTokenIDを持たせ、リフレッシュトークンはダイジェストのみを保存する
+type Session struct {
+	gorm.Model
+	UserID           uint      `gorm:"not null;index"`
+	User             User      `gorm:"foreignKey:UserID"`
+	TokenID          string    `gorm:"size:64;not null;unique"`
+	RefreshTokenHash string    `gorm:"size:64;not null"`
+	UserAgent        string    `gorm:"size:255;not null"`
+	IPAddress        string    `gorm:"size:45;not null"`
+	LastSeenAt       time.Time `gorm:"not null"`
+	ExpiresAt        time.Time `gorm:"not null"`
+	RevokedAt        *time.Time
+}
+
+// セッション一覧取得
+type SessionResponse struct {
+	ID         uint
+	UserAgent  string
+	IPAddress  string
+	LastSeenAt string
+	CreatedAt  string
+	Current    bool
+}
+
+func (session *Session) MigrateSession(db *gorm.DB) error {
+	// 自動マイグレーション(Sessionテーブルを作成)
+	migrateErr := db.AutoMigrate(&Session{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// ログイン時にセッションを作成し、セッションとリフレッシュトークンを返す
+func CreateSession(db *gorm.DB, userID uint, userAgent string, ipAddress string) (Session, string, error) {
+	var session Session
+
+	tokenID, err := utils.GenerateRandomString(32)
+	if err != nil {
+		log.Printf("Error generating token ID: %v\n", err)
+		return session, "", err
+	}
+
+	refreshToken, err := generateRefreshToken(tokenID)
+	if err != nil {
+		log.Printf("Error generating refresh token: %v\n", err)
+		return session, "", err
+	}
+
+	now := time.Now()
+	session = Session{
+		UserID:           userID,
+		TokenID:          tokenID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		UserAgent:        truncate(userAgent, 255),
+		IPAddress:        truncate(ipAddress, 45),
+		LastSeenAt:       now,
+		ExpiresAt:        now.Add(time.Hour * 24 * constant.REFRESH_TOKEN_LIFETIME_DAYS),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		log.Printf("Error creating session: %v\n", err)
+		return session, "", err
+	}
+	log.Printf("セッションの作成に成功")
+
+	return session, refreshToken, nil
+}
+
+// リフレッシュトークンを検証し、新しいリフレッシュトークンに差し替える
+// 差し替え済みの古いトークンが使われた場合は盗用とみなしてセッションを失効させる
+func RotateSession(db *gorm.DB, refreshToken string, userAgent string, ipAddress string) (Session, string, error) {
+	var session Session
+	var newRefreshToken string
+
+	tokenID, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		log.Printf("Malformed refresh token")
+		return session, "", ErrInvalidRefreshToken
+	}
+
+	reused := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 同時に同じトークンで更新されないよう行ロックを取得
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_id = ?", tokenID).
+			First(&session).Error; err != nil {
+			log.Printf("Session not found: %v\n", err)
+			return ErrInvalidRefreshToken
+		}
+
+		if !session.isActive() {
+			log.Printf("Session %d is already revoked or expired", session.ID)
+			return ErrInvalidRefreshToken
+		}
+
+		if session.RefreshTokenHash != utils.HashToken(refreshToken) {
+			log.Printf("Reuse of rotated refresh token detected for session %d", session.ID)
+			reused = true
+			return ErrInvalidRefreshToken
+		}
+
+		var err error
+		newRefreshToken, err = generateRefreshToken(tokenID)
+		if err != nil {
+			log.Printf("Error generating refresh token: %v\n", err)
+			return err
+		}
+
+		session.RefreshTokenHash = utils.HashToken(newRefreshToken)
+		session.UserAgent = truncate(userAgent, 255)
+		session.IPAddress = truncate(ipAddress, 45)
+		session.LastSeenAt = time.Now()
+		if err := tx.Model(&session).Updates(Session{
+			RefreshTokenHash: session.RefreshTokenHash,
+			UserAgent:        session.UserAgent,
+			IPAddress:        session.IPAddress,
+			LastSeenAt:       session.LastSeenAt,
+		}).Error; err != nil {
+			log.Printf("Error rotating refresh token: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if reused {
+		// ロールバックされないよう、トランザクションの外で失効させる
+		if err := revokeSessions(db.Where("id = ?", session.ID)); err != nil {
+			return session, "", err
+		}
+	}
+	if err != nil {
+		return session, "", err
+	}
+	log.Printf("リフレッシュトークンの更新に成功")
+
+	return session, newRefreshToken, nil
+}
+
+// 失効・期限切れでないセッションを取得する
+func FindActiveSession(db *gorm.DB, tokenID string) (Session, error) {
+	var session Session
+
+	if err := db.Where("token_id = ?", tokenID).First(&session).Error; err != nil {
+		log.Printf("Session not found: %v\n", err)
+		return session, ErrInvalidSession
+	}
+
+	if !session.isActive() {
+		log.Printf("Session %d is already revoked or expired", session.ID)
+		return session, ErrInvalidSession
+	}
+
+	return session, nil
+}
+
+// 最終アクセス日時を更新する
+// リクエストの度に書き込まないよう、一定時間が経過している場合のみ更新する
+func (session *Session) Touch(db *gorm.DB, ipAddress string) error {
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < time.Second*constant.SESSION_LAST_SEEN_INTERVAL_SECONDS {
+		return nil
+	}
+
+	result := db.Model(&Session{}).Where("id = ?", session.ID).Updates(Session{
+		IPAddress:  truncate(ipAddress, 45),
+		LastSeenAt: now,
+	})
+	if result.Error != nil {
+		log.Printf("Error updating session: %v\n", result.Error)
+		return result.Error
+	}
+	session.LastSeenAt = now
+
+	return nil
+}
+
+// ログイン中のユーザーの有効なセッションを最終アクセス日時の新しい順に取得する
+func FetchSessions(db *gorm.DB, userID uint, currentTokenID string) ([]SessionResponse, error) {
+	var sessions []Session
+
+	result := db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions)
+
+	if result.Error != nil {
+		log.Printf("Error fetching sessions: %v", result.Error)
+		return nil, result.Error
+	}
+	log.Printf("セッションの取得に成功")
+
+	sessionResponses := make([]SessionResponse, len(sessions))
+	for i, session := range sessions {
+		sessionResponses[i] = SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			LastSeenAt: session.LastSeenAt.Format("2006-01-02 15:04"),
+			CreatedAt:  session.CreatedAt.Format("2006-01-02 15:04"),
+			Current:    session.TokenID == currentTokenID,
+		}
+	}
+
+	return sessionResponses, nil
+}
+
+// ログイン中のユーザーのセッションを1件失効させる
+func RevokeSession(db *gorm.DB, userID uint, sessionID int) error {
+	var session Session
+	if err := db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).First(&session).Error; err != nil {
+		log.Printf("Error fetching session with ID %d: %v\n", sessionID, err)
+		return toAuthorizationError(err)
+	}
+
+	if err := revokeSessions(db.Where("id = ?", session.ID)); err != nil {
+		return err
+	}
+	log.Printf("セッションの失効に成功")
+
+	return nil
+}
+
+// ログアウト時にリフレッシュトークンに対応するセッションを失効させる
+func RevokeSessionByRefreshToken(db *gorm.DB, refreshToken string) error {
+	tokenID, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return ErrInvalidRefreshToken
+	}
+
+	if err := revokeSessions(db.Where("token_id = ? AND refresh_token_hash = ?", tokenID, utils.HashToken(refreshToken))); err != nil {
+		return err
+	}
+	log.Printf("セッションの失効に成功")
+
+	return nil
+}
+
+// ユーザーのセッションをすべて失効させる
+func RevokeAllSessions(db *gorm.DB, userID uint) error {
+	if err := revokeSessions(db.Where("user_id = ?", userID)); err != nil {
+		return err
+	}
+	log.Printf("すべてのセッションの失効に成功")
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func (session *Session) isActive() bool {
+	return session.RevokedAt == nil && time.Now().Before(session.ExpiresAt)
+}
+
+func revokeSessions(query *gorm.DB) error {
+	result := query.Model(&Session{}).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		log.Printf("Error revoking sessions: %v\n", result.Error)
+		return result.Error
+	}
+
+	return nil
+}
+
+// リフレッシュトークンは「セッションのトークンID.ランダムな文字列」の形式
+func generateRefreshToken(tokenID string) (string, error) {
+	secret, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return "", err
+	}
+	return tokenID + "." + secret, nil
+}
+
+func parseRefreshToken(refreshToken string) (string, bool) {
+	parts := strings.SplitN(refreshToken, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func truncate(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+	return value[:maxLength]
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"testing"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestMigrateSession(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// MigrateSession関数をテスト
+	session := &Session{}
+	err = session.MigrateSession(db)
+	assert.Nil(t, err, "MigrateSession should not return an error")
+
+	// Sessionテーブルが正しく作成されているかを確認
+	hasTable := db.Migrator().HasTable(&Session{})
+	assert.True(t, hasTable, "Session table should be created")
+}
+
+func TestRotateSession(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{
+		UserGroup: "TestUserGroup",
+	}
+	db.Create(userGroup)
+
+	user := &User{
+		Name:        "TestUser",
+		Password:    "TestPassword",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(user)
+
+	session, firstRefreshToken, err := CreateSession(db, user.ID, "TestAgent", "127.0.0.1")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, firstRefreshToken)
+
+	// リフレッシュトークンは差し替えられる
+	rotatedSession, secondRefreshToken, err := RotateSession(db, firstRefreshToken, "TestAgent", "127.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, session.ID, rotatedSession.ID)
+	assert.NotEqual(t, firstRefreshToken, secondRefreshToken)
+
+	// 差し替え済みのトークンを再利用するとセッションごと失効する
+	_, _, err = RotateSession(db, firstRefreshToken, "TestAgent", "127.0.0.1")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	_, _, err = RotateSession(db, secondRefreshToken, "TestAgent", "127.0.0.1")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	_, err = FindActiveSession(db, session.TokenID)
+	assert.ErrorIs(t, err, ErrInvalidSession)
+
+	// テストデータの削除
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&Session{})
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
+
+func TestRevokeSession(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{
+		UserGroup: "TestUserGroup",
+	}
+	db.Create(userGroup)
+
+	user := &User{
+		Name:        "TestUser",
+		Password:    "TestPassword",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(user)
+
+	otherUser := &User{
+		Name:        "OtherUser",
+		Password:    "TestPassword",
+		Email:       "other@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(otherUser)
+
+	firstSession, _, _ := CreateSession(db, user.ID, "FirstAgent", "127.0.0.1")
+	secondSession, _, _ := CreateSession(db, user.ID, "SecondAgent", "127.0.0.1")
+	otherSession, _, _ := CreateSession(db, otherUser.ID, "OtherAgent", "127.0.0.1")
+
+	sessions, err := FetchSessions(db, user.ID, firstSession.TokenID)
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 2)
+
+	// 他のユーザーのセッションは失効できない
+	err = RevokeSession(db, user.ID, int(otherSession.ID))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 1件だけ失効させる
+	err = RevokeSession(db, user.ID, int(secondSession.ID))
+	assert.Nil(t, err)
+	sessions, _ = FetchSessions(db, user.ID, firstSession.TokenID)
+	assert.Len(t, sessions, 1)
+	assert.True(t, sessions[0].Current)
+
+	// パスワードを変更するとすべてのセッションが失効する
+	updateUser := &User{Password: "TestNewPassword"}
+	err = updateUser.UpdateUserPassword(db, user.ID)
+	assert.Nil(t, err)
+	_, err = FindActiveSession(db, firstSession.TokenID)
+	assert.ErrorIs(t, err, ErrInvalidSession)
+
+	// 他のユーザーのセッションは影響を受けない
+	_, err = FindActiveSession(db, otherSession.TokenID)
+	assert.Nil(t, err)
+
+	// テストデータの削除
+	db.Unscoped().Where("user_id IN ?", []uint{user.ID, otherUser.ID}).Delete(&Session{})
+	db.Unscoped().Delete(&otherUser)
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
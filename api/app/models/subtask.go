@@ -0,0 +1,354 @@
+package models
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidSubtask      = errors.New("指定されたタスクはサブタスクにできません")
+	ErrInvalidSubtaskOrder = errors.New("サブタスクの並び順が正しくありません")
+	ErrIncompleteSubtasks  = errors.New("完了していないサブタスクがあります")
+)
+
+// サブタスクの追加の入力値
+// サブタスクは1階層までとし、サブタスクを持つタスクはサブタスクにできない
+type SubtaskInput struct {
+	SubtaskID uint `json:"Subtask" binding:"required"`
+}
+
+// サブタスクの並び替えの入力値
+// 親タスクのすべてのサブタスクを新しい並び順で指定する
+type SubtaskOrderInput struct {
+	SubtaskIDs []uint `json:"Subtasks" binding:"required"`
+}
+
+// 親タスクごとのサブタスクの集計
+type subtaskSummary struct {
+	ParentID         uint
+	SubtaskCount     int64
+	SubtaskDoneCount int64
+	SubtaskEstimate  uint
+}
+
+// 親タスクのサブタスクを並び順に取得する
+func FetchSubtasks(db *gorm.DB, parentID int) ([]TaskResponse, error) {
+	var tasks []Task
+
+	result := db.Preload("CreatorUserID").
+		Preload("ResponsibleUserID").
+		Preload("Category").
+		Where("parent_id = ?", parentID).
+		Order("position asc, id asc").
+		Find(&tasks)
+
+	if result.Error != nil {
+		log.Printf("Error fetching subtasks: %v\n", result.Error)
+		return nil, result.Error
+	}
+	log.Printf("サブタスクの取得に成功")
+
+	return toTaskResponses(db, tasks)
+}
+
+// タスクを親タスクのサブタスクの末尾に追加する
+// 親タスクとサブタスクが同じユーザーグループのものかは呼び出し元で確認する
+func AddSubtask(db *gorm.DB, parentID int, subtaskID int) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var parent, subtask Task
+		if err := tx.Where("id = ?", parentID).First(&parent).Error; err != nil {
+			log.Printf("Error fetching task with ID %d: %v\n", parentID, err)
+			return toAuthorizationError(err)
+		}
+		if err := tx.Where("id = ?", subtaskID).First(&subtask).Error; err != nil {
+			log.Printf("Error fetching task with ID %d: %v\n", subtaskID, err)
+			return toAuthorizationError(err)
+		}
+
+		// 自身、サブタスク、サブタスクを持つタスクは追加できない
+		if parent.ID == subtask.ID || parent.ParentID != nil {
+			log.Printf("Task %d cannot have subtasks", parent.ID)
+			return ErrInvalidSubtask
+		}
+		if subtask.ParentID != nil && *subtask.ParentID != parent.ID {
+			log.Printf("Task %d is already a subtask of task %d", subtask.ID, *subtask.ParentID)
+			return ErrInvalidSubtask
+		}
+		if subtask.ParentID != nil {
+			// 既に追加済み
+			return nil
+		}
+
+		var childCount int64
+		if err := tx.Model(&Task{}).Where("parent_id = ?", subtask.ID).Count(&childCount).Error; err != nil {
+			log.Printf("Error counting subtasks: %v\n", err)
+			return err
+		}
+		if childCount > 0 {
+			log.Printf("Task %d has subtasks", subtask.ID)
+			return ErrInvalidSubtask
+		}
+
+		var lastPosition struct{ Position *uint }
+		if err := tx.Model(&Task{}).Select("MAX(position) AS position").Where("parent_id = ?", parent.ID).Scan(&lastPosition).Error; err != nil {
+			log.Printf("Error fetching subtask position: %v\n", err)
+			return err
+		}
+		position := uint(0)
+		if lastPosition.Position != nil {
+			position = *lastPosition.Position + 1
+		}
+
+		result := tx.Model(&subtask).Updates(map[string]interface{}{
+			"parent_id": parent.ID,
+			"position":  position,
+		})
+		if result.Error != nil {
+			log.Printf("Error adding subtask: %v\n", result.Error)
+			return result.Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("サブタスクの追加に成功")
+
+	return nil
+}
+
+// サブタスクを指定した順に並び替える
+func ReorderSubtasks(db *gorm.DB, parentID int, subtaskIDs []uint) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var currentIDs []uint
+		if err := tx.Model(&Task{}).Where("parent_id = ?", parentID).Pluck("id", &currentIDs).Error; err != nil {
+			log.Printf("Error fetching subtasks: %v\n", err)
+			return err
+		}
+
+		// 過不足や重複なく、すべてのサブタスクが指定されていること
+		remaining := map[uint]bool{}
+		for _, id := range currentIDs {
+			remaining[id] = true
+		}
+		if len(subtaskIDs) != len(currentIDs) {
+			log.Printf("Expected %d subtasks of task %d, got %d", len(currentIDs), parentID, len(subtaskIDs))
+			return ErrInvalidSubtaskOrder
+		}
+		for _, id := range subtaskIDs {
+			if !remaining[id] {
+				log.Printf("Task %d is not a subtask of task %d", id, parentID)
+				return ErrInvalidSubtaskOrder
+			}
+			delete(remaining, id)
+		}
+
+		for position, id := range subtaskIDs {
+			if err := tx.Model(&Task{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				log.Printf("Error updating subtask position: %v\n", err)
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("サブタスクの並び替えに成功")
+
+	return nil
+}
+
+// サブタスクを親タスクから切り離し、通常のタスクに戻す
+func DetachSubtask(db *gorm.DB, parentID int, subtaskID int) error {
+	result := db.Model(&Task{}).
+		Where("id = ? AND parent_id = ?", subtaskID, parentID).
+		Updates(map[string]interface{}{
+			"parent_id": nil,
+			"position":  0,
+		})
+
+	if result.Error != nil {
+		log.Printf("Error detaching subtask: %v\n", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		log.Printf("Task %d is not a subtask of task %d", subtaskID, parentID)
+		return ErrNotFound
+	}
+	log.Printf("サブタスクの切り離しに成功")
+
+	return nil
+}
+
+// タスクのステータスを更新する
+// 完了またはLook Backの種類のステータスに移動する場合のサブタスクの扱いはcompleteSubtasksに従う
+// ステータスはユーザーグループで許可した遷移のみ変更できる（サブタスクはあわせて移動する）
+// 完了またはLook Backの種類のステータスに移動したタスクの計測中のタイマーは終了する
+// ステータスを変更したタスクごとにactorIDのユーザーの変更履歴を記録する
+func UpdateTaskStatus(db *gorm.DB, id int, status uint, cascadeSubtasks bool, actorID uint) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var task Task
+		if err := tx.Where("id = ?", id).First(&task).Error; err != nil {
+			log.Printf("Error fetching task with ID %d: %v\n", id, err)
+			return err
+		}
+
+		workflow, err := fetchTaskWorkflow(tx, task)
+		if err != nil {
+			return err
+		}
+		if _, ok := workflow.find(status); !ok {
+			log.Printf("Status %d does not exist in workflow of task %d", status, id)
+			return ErrInvalidTaskStatus
+		}
+		if !workflow.canTransition(task.Status, status) {
+			log.Printf("Transition of task %d from status %d to %d is not allowed", id, task.Status, status)
+			return ErrStatusTransitionNotAllowed
+		}
+
+		if err := completeSubtasks(tx, workflow, task.ID, status, cascadeSubtasks, actorID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&Task{}).Where("id = ?", id).Update("status", status).Error; err != nil {
+			log.Printf("Error updating task: %v\n", err)
+			return err
+		}
+
+		// 完了またはLook Backの種類のステータスに移動したタスクのタイマーを終了する
+		if workflow.isDone(status) {
+			if err := stopTaskTimers(tx, []int{id}); err != nil {
+				log.Println(err)
+				return err
+			}
+		}
+
+		after := task
+		after.Status = status
+
+		return recordTaskHistory(tx, task.ID, actorID, &task, &after)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("タスクのステータスの更新に成功")
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// 親タスクを完了またはLook Backの種類のステータスに移動する前に、サブタスクの扱いを適用する
+// cascadeSubtasksがtrueならまだそのステータスまで進んでいないサブタスクをあわせて移動し、
+// falseならすべてのサブタスクが完了していなければErrIncompleteSubtasksを返す
+// タスクの更新や移動でも同じ扱いにするため、ステータスを変更する処理はすべてここを通す
+func completeSubtasks(tx *gorm.DB, workflow groupWorkflow, parentID uint, status uint, cascadeSubtasks bool, actorID uint) error {
+	if !workflow.isDone(status) {
+		return nil
+	}
+
+	if !cascadeSubtasks {
+		var incompleteCount int64
+		if err := tx.Model(&Task{}).Where("parent_id = ? AND status NOT IN ?", parentID, workflow.statusesOf(WorkflowStatusKindDone, WorkflowStatusKindArchived)).Count(&incompleteCount).Error; err != nil {
+			log.Printf("Error counting subtasks: %v\n", err)
+			return err
+		}
+		if incompleteCount > 0 {
+			log.Printf("Task %d has %d incomplete subtasks", parentID, incompleteCount)
+			return ErrIncompleteSubtasks
+		}
+		return nil
+	}
+
+	// 完了の種類のステータスに移動する場合、Look Backの種類のステータスのサブタスクは戻さない
+	completed := []uint{status}
+	if workflow.kind(status) == WorkflowStatusKindDone {
+		completed = append(completed, workflow.statusesOf(WorkflowStatusKindArchived)...)
+	}
+	var subtasks []Task
+	if err := tx.Where("parent_id = ? AND status NOT IN ?", parentID, completed).Find(&subtasks).Error; err != nil {
+		log.Printf("Error fetching subtasks: %v\n", err)
+		return err
+	}
+	if len(subtasks) == 0 {
+		return nil
+	}
+
+	subtaskIDs := make([]uint, len(subtasks))
+	for i, subtask := range subtasks {
+		subtaskIDs[i] = subtask.ID
+	}
+	if err := tx.Model(&Task{}).Where("id IN ?", subtaskIDs).Update("status", status).Error; err != nil {
+		log.Printf("Error updating subtasks: %v\n", err)
+		return err
+	}
+	if err := stopTaskTimers(tx, subtaskIDs); err != nil {
+		log.Println(err)
+		return err
+	}
+
+	for _, before := range subtasks {
+		after := before
+		after.Status = status
+		if err := recordTaskHistory(tx, before.ID, actorID, &before, &after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 親タスクごとにサブタスクの件数、完了した件数、見積もりの合計を集計する
+func fetchSubtaskSummaries(db *gorm.DB, parentIDs []uint) (map[uint]subtaskSummary, error) {
+	summaries := map[uint]subtaskSummary{}
+	if len(parentIDs) == 0 {
+		return summaries, nil
+	}
+
+	// 完了したかどうかはステータスの種類で決まるため、ステータスごとに集計してから合算する
+	var rows []struct {
+		ParentID        uint
+		CategoryID      uint
+		Status          uint
+		SubtaskCount    int64
+		SubtaskEstimate uint
+	}
+	result := db.Model(&Task{}).
+		Select("parent_id, category_id, status, COUNT(*) AS subtask_count, COALESCE(SUM(estimate), 0) AS subtask_estimate").
+		Where("parent_id IN ?", parentIDs).
+		Group("parent_id, category_id, status").
+		Scan(&rows)
+
+	if result.Error != nil {
+		log.Printf("Error fetching subtask summaries: %v\n", result.Error)
+		return nil, result.Error
+	}
+
+	categoryIDs := make([]uint, len(rows))
+	for i, row := range rows {
+		categoryIDs[i] = row.CategoryID
+	}
+	workflows, err := fetchCategoryWorkflows(db, categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		summary := summaries[row.ParentID]
+		summary.ParentID = row.ParentID
+		summary.SubtaskCount += row.SubtaskCount
+		summary.SubtaskEstimate += row.SubtaskEstimate
+		if workflows.of(row.CategoryID).isDone(row.Status) {
+			summary.SubtaskDoneCount += row.SubtaskCount
+		}
+		summaries[row.ParentID] = summary
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestSubtasks(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	user := &User{Name: "TestUser", Password: "TestPassword", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(user)
+	category := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(category)
+	newTask := func(name string, estimate uint) *Task {
+		task := &Task{Task: name, Description: "TestDescription", Creator: user.ID, CategoryID: category.ID, Status: 1, Responsible: user.ID, Estimate: ptrToUint(estimate), StartDate: ptrToTime(time.Now())}
+		db.Create(task)
+		return task
+	}
+	parent := newTask("Parent", 10)
+	first := newTask("First", 3)
+	second := newTask("Second", 5)
+	parentID := int(parent.ID)
+
+	t.Run("追加と集計", func(t *testing.T) {
+		assert.Nil(t, AddSubtask(db, parentID, int(first.ID)))
+		assert.Nil(t, AddSubtask(db, parentID, int(second.ID)))
+		db.Model(&Task{}).Where("id = ?", first.ID).Update("status", 3)
+
+		subtasks, err := FetchSubtasks(db, parentID)
+		assert.Nil(t, err)
+		assert.Len(t, subtasks, 2)
+		assert.Equal(t, first.ID, subtasks[0].ID)
+		assert.Equal(t, &parent.ID, subtasks[0].Parent)
+
+		taskResponses, err := toTaskResponses(db, []Task{*parent})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), taskResponses[0].SubtaskCount)
+		assert.Equal(t, int64(1), taskResponses[0].SubtaskDoneCount)
+		assert.Equal(t, uint(8), taskResponses[0].SubtaskEstimate)
+	})
+
+	t.Run("失敗_サブタスクの入れ子", func(t *testing.T) {
+		// サブタスクにはサブタスクを追加できない
+		other := newTask("Other", 1)
+		defer db.Unscoped().Delete(other)
+		assert.Equal(t, ErrInvalidSubtask, AddSubtask(db, int(first.ID), int(other.ID)))
+
+		// サブタスクを持つタスクはサブタスクにできない
+		assert.Equal(t, ErrInvalidSubtask, AddSubtask(db, int(other.ID), parentID))
+
+		// 他の親タスクのサブタスクは追加できない
+		assert.Equal(t, ErrInvalidSubtask, AddSubtask(db, int(other.ID), int(first.ID)))
+	})
+
+	t.Run("並び替え", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidSubtaskOrder, ReorderSubtasks(db, parentID, []uint{second.ID}))
+		assert.Equal(t, ErrInvalidSubtaskOrder, ReorderSubtasks(db, parentID, []uint{second.ID, second.ID}))
+
+		assert.Nil(t, ReorderSubtasks(db, parentID, []uint{second.ID, first.ID}))
+		subtasks, _ := FetchSubtasks(db, parentID)
+		assert.Equal(t, second.ID, subtasks[0].ID)
+		assert.Equal(t, first.ID, subtasks[1].ID)
+	})
+
+	t.Run("Look Backへの移動", func(t *testing.T) {
+		// 完了していないサブタスクがある場合は移動できない
+		assert.Equal(t, ErrIncompleteSubtasks, UpdateTaskStatus(db, parentID, 4, false, user.ID))
+
+		// タスクの更新やタスクボードでの移動でも同じ扱いにする
+		update := *parent
+		update.Status = 4
+		assert.Equal(t, ErrIncompleteSubtasks, update.UpdateTask(db, parentID, false, user.ID))
+		assert.Equal(t, ErrIncompleteSubtasks, MoveTask(db, parentID, TaskMoveInput{Status: 3}, false, user.ID))
+
+		// サブタスクもあわせて移動する
+		assert.Nil(t, UpdateTaskStatus(db, parentID, 4, true, user.ID))
+		var subtask Task
+		db.First(&subtask, second.ID)
+		assert.Equal(t, uint(4), subtask.Status)
+	})
+
+	t.Run("切り離し", func(t *testing.T) {
+		assert.Equal(t, ErrNotFound, DetachSubtask(db, int(first.ID), int(second.ID)))
+
+		assert.Nil(t, DetachSubtask(db, parentID, int(second.ID)))
+		subtasks, _ := FetchSubtasks(db, parentID)
+		assert.Len(t, subtasks, 1)
+	})
+
+	t.Run("親タスクの削除", func(t *testing.T) {
+		// 親タスクを削除するとサブタスクもゴミ箱に移る
+		assert.Nil(t, parent.DeleteTask(db, parentID, user.ID))
+		var subtask Task
+		err := db.First(&subtask, first.ID).Error
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Delete(first)
+	db.Unscoped().Delete(second)
+	db.Unscoped().Delete(category)
+	db.Unscoped().Delete(user)
+	db.Unscoped().Delete(userGroup)
+}
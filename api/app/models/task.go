@@ -8,29 +8,45 @@ import (
 	"gorm.io/gorm"
 )
 
+
 type Task struct {
 	gorm.Model
-	Task              string     `gorm:"size:255;not null" validate:"required,min=1,max=30`
-	Description       string     `gorm:"size:255;not null" validate:"required,min=1,max=30"`
-	Creator           uint       `gorm:"not null"`
-	CreatorUserID     User       `gorm:"foreignKey:Creator;"`
-	CategoryID        uint       `gorm:"not null"`
-	Category          Category   `gorm:"foreignKey:CategoryID;"`
-	Status            uint       `gorm:"not null" validate:"required,min=1,max=4"`
-	Responsible       uint       `gorm:"not null"`
-	ResponsibleUserID User       `gorm:"foreignKey:Responsible;"`
-	Estimate          *uint      `gorm:"not null" validate:"required,min=1,max=1000"`
-	StartDate         *time.Time `gorm:"not null"`
+	Task              string         `gorm:"size:255;not null" validate:"required,min=1,max=30"`
+	Description       string         `gorm:"size:255;not null" validate:"required,min=1,max=30"`
+	Creator           uint           `gorm:"not null"`
+	CreatorUserID     User           `gorm:"foreignKey:Creator;"`
+	CategoryID        uint           `gorm:"not null"`
+	Category          Category       `gorm:"foreignKey:CategoryID;"`
+	Status            uint           `gorm:"not null" validate:"required,min=1"` // ユーザーグループのWorkflowStatusの番号
+	Responsible       uint           `gorm:"not null"`
+	ResponsibleUserID User           `gorm:"foreignKey:Responsible;"`
+	Estimate          *uint          `gorm:"not null" validate:"required,min=1,max=1000"`
+	StartDate         *time.Time     `gorm:"not null"`
+	ParentID          *uint          `gorm:"index"`
+	Parent            *Task          `gorm:"foreignKey:ParentID;constraint:OnDelete:SET NULL;"` // 親タスクを削除した場合はサブタスクを切り離す
+	Position          uint           `gorm:"not null;default:0"`                                // 親タスク内でのサブタスクの並び順
+	RecurringTaskID   *uint          `gorm:"index"`
+	RecurringTask     *RecurringTask `gorm:"foreignKey:RecurringTaskID;constraint:OnDelete:SET NULL;"` // 繰り返しタスクから作成したタスク
+	DeletionID        *uint          `gorm:"index"`                                                    // ゴミ箱に移動した削除操作
+	Rank              string         `gorm:"column:board_rank;size:255;not null;default:'';index"`     // ステータスの列内での並び順（辞書順）
+	LabelIDs          []uint         `gorm:"-"`                                                        // 作成・更新で付けるラベル（nilの場合は変更しない）
 }
 
 type TaskInput struct {
 	Task        string `json:"Task" binding:"required,min=1,max=255"`
 	Description string `json:"Description" binding:"required,min=1,max=255"`
 	StartDate   string `json:"StartDate" binding:"required,min=1,max=24"`
-	Estimate    *uint  `json:"Estimate" binding:"required",min=1,max=1000"`
+	Estimate    *uint  `json:"Estimate" binding:"required,min=1,max=1000"`
 	Responsible uint   `json:"Responsible" binding:"required"`
-	Status      uint   `json:"Status" binding:"required", min=1,max=4"`
+	Status      uint   `json:"Status" binding:"required,min=1"`
 	CategoryID  uint   `json:"Category" binding:"required"`
+	LabelIDs    []uint `json:"Labels"` // 指定しない場合は更新でラベルを変更しない
+}
+
+// タスク一覧の絞り込み条件
+// ラベルを複数指定した場合はいずれかのラベルを付けたタスクとする
+type TaskQuery struct {
+	Labels []uint `form:"label"`
 }
 
 type TaskResponse struct {
@@ -39,9 +55,12 @@ type TaskResponse struct {
 	Description         string
 	Status              uint
 	StatusName          string
+	StatusColor         string
+	StatusKind          string
 	Category            uint
 	CategoryName        string
 	Estimate            *uint
+	ActualMinutes       int64 // 作業時間の合計（分）
 	StartDate           string
 	Responsible         uint
 	ResponsibleUserName string
@@ -49,6 +68,16 @@ type TaskResponse struct {
 	CreatorUserName     string
 	CreatedAt           string
 	UpdatedAt           string
+	Parent              *uint
+	Position            uint
+	Rank                string
+	SubtaskCount        int64
+	SubtaskDoneCount    int64 // 完了またはLook Backのサブタスクの件数
+	SubtaskEstimate     uint // サブタスクの見積もりの合計
+	Blocked             bool // 完了していない依存先のタスクがある
+	StartDateWarnings   []StartDateWarning
+	RecurringTask       *uint
+	Labels              []LabelResponse
 }
 
 // TableName メソッドを追加して、この構造体がタスクテーブルに対応することを指定する
@@ -67,32 +96,59 @@ func (task *Task) MigrateTasks(db *gorm.DB) error {
 	return nil
 }
 
+// 作成者を変更者として変更履歴を記録する
+// タスクはステータスの列の末尾に並べる
 func (task *Task) CreateTask(db *gorm.DB) (error) {
-	result := db.Create(task)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		rank, err := lastColumnRank(tx, task.CategoryID, task.Status)
+		if err != nil {
+			return err
+		}
+		task.Rank = rank
 
-	if result.Error != nil {
-		log.Printf("Error creating task: %v\n", result.Error)
-		return result.Error
+		result := tx.Create(task)
+
+		if result.Error != nil {
+			log.Printf("Error creating task: %v\n", result.Error)
+			return result.Error
+		}
+
+		if task.LabelIDs != nil {
+			if err := setTaskLabels(tx, task.ID, task.LabelIDs); err != nil {
+				return err
+			}
+		}
+
+		return recordTaskHistory(tx, task.ID, task.Creator, nil, task)
+	})
+	if err != nil {
+		return err
 	}
 	log.Printf("タスクの作成に成功")
 
 	return nil
 }
 
-func FetchTaskBoardTasks(db *gorm.DB, userID uint) ([]TaskResponse, error) {
+func FetchTaskBoardTasks(db *gorm.DB, userID uint, query TaskQuery) ([]TaskResponse, error) {
 	userGroupID, err := FetchUserGroupIDByUserID(db, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	workflow, err := fetchWorkflow(db, userGroupID)
+	if err != nil {
+		return nil, err
+	}
+
 	var tasks []Task
 
-	result := db.Preload("CreatorUserID").
+	// Look Backの種類のステータスのタスクは除く
+	result := filterTasks(db, query).Preload("CreatorUserID").
 		Preload("ResponsibleUserID").
 		Preload("Category").
 		Joins("JOIN categories ON tasks.category_id = categories.id").
-		Where("tasks.status != ? AND categories.user_group_id = ?", 4, userGroupID).
-		Order("tasks.created_at asc").
+		Where("tasks.status NOT IN ? AND categories.user_group_id = ?", workflow.statusesOf(WorkflowStatusKindArchived), userGroupID).
+		Order("tasks.board_rank asc, tasks.created_at asc").
 		Find(&tasks)
 
 	if result.Error != nil {
@@ -101,44 +157,27 @@ func FetchTaskBoardTasks(db *gorm.DB, userID uint) ([]TaskResponse, error) {
 	}
 	log.Printf("タスクボード用のタスクの取得に成功")
 
-	taskResponses := make([]TaskResponse, len(tasks))
-	for i, task := range tasks {
-
-		taskResponses[i] = TaskResponse{
-			ID:                  task.ID,
-			Task:                task.Task,
-			Description:         task.Description,
-			Status:              task.Status,
-			StatusName:          statusToString(task.Status),
-			Category:            task.Category.ID,
-			CategoryName:        task.Category.Category,
-			Estimate:            task.Estimate,
-			StartDate:           task.StartDate.Format("2006-01-02"),
-			Responsible:         task.ResponsibleUserID.ID,
-			ResponsibleUserName: task.ResponsibleUserID.Name,
-			Creator:             task.CreatorUserID.ID,
-			CreatorUserName:     task.CreatorUserID.Name,
-			CreatedAt:           task.CreatedAt.Format("2006-01-02 15:04"),
-			UpdatedAt:           task.UpdatedAt.Format("2006-01-02 15:04"),
-		}
-	}
-
-	return taskResponses, nil
+	return toTaskResponses(db, tasks)
 }
 
-func FetchLookBackTasks(db *gorm.DB, userID uint) ([]TaskResponse, error) {
+func FetchLookBackTasks(db *gorm.DB, userID uint, query TaskQuery) ([]TaskResponse, error) {
 	userGroupID, err := FetchUserGroupIDByUserID(db, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	workflow, err := fetchWorkflow(db, userGroupID)
+	if err != nil {
+		return nil, err
+	}
+
 	var tasks []Task
 
-	result := db.Preload("CreatorUserID").
+	result := filterTasks(db, query).Preload("CreatorUserID").
 		Preload("ResponsibleUserID").
 		Preload("Category").
 		Joins("JOIN categories ON tasks.category_id = categories.id").
-		Where("tasks.status = ? AND categories.user_group_id = ?", 4, userGroupID).
+		Where("tasks.status IN ? AND categories.user_group_id = ?", workflow.statusesOf(WorkflowStatusKindArchived), userGroupID).
 		Order("tasks.created_at asc").
 		Find(&tasks)
 
@@ -149,59 +188,128 @@ func FetchLookBackTasks(db *gorm.DB, userID uint) ([]TaskResponse, error) {
 	}
 	log.Printf("ルックバック用のタスクの取得に成功")
 
-	taskResponses := make([]TaskResponse, len(tasks))
-	for i, task := range tasks {
+	return toTaskResponses(db, tasks)
+}
 
-		taskResponses[i] = TaskResponse{
-			ID:                  task.ID,
-			Task:                task.Task,
-			Description:         task.Description,
-			Status:              task.Status,
-			StatusName:          statusToString(task.Status),
-			Category:            task.Category.ID,
-			CategoryName:        task.Category.Category,
-			Estimate:            task.Estimate,
-			StartDate:           task.StartDate.Format("2006-01-02"),
-			Responsible:         task.ResponsibleUserID.ID,
-			ResponsibleUserName: task.ResponsibleUserID.Name,
-			Creator:             task.CreatorUserID.ID,
-			CreatorUserName:     task.CreatorUserID.Name,
-			CreatedAt:           task.CreatedAt.Format("2006-01-02 15:04"),
-			UpdatedAt:           task.UpdatedAt.Format("2006-01-02 15:04"),
-		}
+// WIP制限の確認に使うタスクの担当者を取得する
+func FetchTaskResponsible(db *gorm.DB, id int) (uint, error) {
+	var task Task
+	if err := db.Select("id, responsible").Where("id = ?", id).First(&task).Error; err != nil {
+		log.Printf("Error fetching task with ID %d: %v\n", id, err)
+		return 0, toAuthorizationError(err)
 	}
 
-	return taskResponses, nil
+	return task.Responsible, nil
 }
 
-func (task *Task) UpdateTask(db *gorm.DB, id int) (error) {
+// 変更した項目をactorIDのユーザーの変更履歴として記録する
+// ステータスはユーザーグループで許可した遷移のみ変更できる
+// 完了またはLook Backの種類のステータスに移動する場合は計測中のタイマーを終了し、サブタスクはcascadeSubtasksに従って扱う
+// ステータスを変更した場合は変更後のステータスの列の末尾に並べる
+func (task *Task) UpdateTask(db *gorm.DB, id int, cascadeSubtasks bool, actorID uint) (error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var before Task
+		if err := tx.Where("id = ?", id).First(&before).Error; err != nil {
+			log.Printf("Error fetching task with ID %d: %v\n", id, err)
+			return err
+		}
 
-	result := db.Model(task).Where("id = ?", id).Updates(Task{
-		Task:        task.Task,
-		Description: task.Description,
-		CategoryID:  task.CategoryID,
-		Status:      task.Status,
-		Responsible: task.Responsible,
-		Estimate:    task.Estimate,
-		StartDate:   task.StartDate,
-	})
+		workflow, err := fetchTaskWorkflow(tx, before)
+		if err != nil {
+			return err
+		}
+		if _, ok := workflow.find(task.Status); !ok {
+			log.Printf("Status %d does not exist in workflow of task %d", task.Status, id)
+			return ErrInvalidTaskStatus
+		}
+		if !workflow.canTransition(before.Status, task.Status) {
+			log.Printf("Transition of task %d from status %d to %d is not allowed", id, before.Status, task.Status)
+			return ErrStatusTransitionNotAllowed
+		}
 
-	if result.Error != nil {
-		log.Printf("Error updating task: %v\n", result.Error)
-		return result.Error
+		rank := before.Rank
+		if before.Status != task.Status {
+			if err := completeSubtasks(tx, workflow, before.ID, task.Status, cascadeSubtasks, actorID); err != nil {
+				return err
+			}
+			if rank, err = lastColumnRank(tx, task.CategoryID, task.Status); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(task).Where("id = ?", id).Updates(Task{
+			Task:        task.Task,
+			Description: task.Description,
+			CategoryID:  task.CategoryID,
+			Status:      task.Status,
+			Responsible: task.Responsible,
+			Estimate:    task.Estimate,
+			StartDate:   task.StartDate,
+			Rank:        rank,
+		})
+
+		if result.Error != nil {
+			log.Printf("Error updating task: %v\n", result.Error)
+			return result.Error
+		}
+
+		if workflow.isDone(task.Status) {
+			if err := stopTaskTimers(tx, []int{id}); err != nil {
+				log.Println(err)
+				return err
+			}
+		}
+
+		if task.LabelIDs != nil {
+			if err := setTaskLabels(tx, before.ID, task.LabelIDs); err != nil {
+				return err
+			}
+		}
+
+		after := before
+		after.Task = task.Task
+		after.Description = task.Description
+		after.CategoryID = task.CategoryID
+		after.Status = task.Status
+		after.Responsible = task.Responsible
+		after.Estimate = task.Estimate
+		after.StartDate = task.StartDate
+		after.Rank = rank
+
+		return recordTaskHistory(tx, before.ID, actorID, &before, &after)
+	})
+	if err != nil {
+		return err
 	}
 	log.Printf("タスクの更新に成功")
 
 	return nil
 }
 
-func (task *Task) DeleteTask(db *gorm.DB, id int) error {
+// タスクをサブタスクとコメントとあわせてゴミ箱に移動し、actorIDのユーザーの変更履歴として記録する
+func (task *Task) DeleteTask(db *gorm.DB, id int, actorID uint) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var before Task
+		if err := tx.Preload("Category").Where("id = ?", id).First(&before).Error; err != nil {
+			log.Printf("Error fetching task with ID %d: %v\n", id, err)
+			return err
+		}
 
-	result := db.Unscoped().Delete(task, id)
+		deletionID, err := createDeletion(tx, before.Category.UserGroupID, actorID, DeletionItemTask, before.ID, before.Task)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
 
-	if result.Error != nil {
-		log.Printf("Error deleting task: %v\n", result.Error)
-		return result.Error
+		if err := softDeleteTasks(tx, actorID, deletionID, "id = ?", id); err != nil {
+			log.Println(err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	log.Printf("タスクの削除に成功")
@@ -209,15 +317,11 @@ func (task *Task) DeleteTask(db *gorm.DB, id int) error {
 	return nil
 }
 
-func deleteUserTasks(tx *gorm.DB, userID uint) error {
-	// Creatorに関連するタスクを削除
-	if err := tx.Unscoped().Where("creator = ?", userID).Delete(&Task{}).Error; err != nil {
-		return fmt.Errorf("error deleting tasks by creator: %v", err)
-	}
-
-	// Responsibleに関連するタスクを削除
-	if err := tx.Unscoped().Where("responsible = ?", userID).Delete(&Task{}).Error; err != nil {
-		return fmt.Errorf("error deleting tasks by responsible: %v", err)
+// 作成者または担当者であるタスクをゴミ箱に移動する
+// 削除したタスクのコメントはあわせて論理削除し、他のユーザーのタスクへのコメントは残す
+func deleteUserTasks(tx *gorm.DB, userID uint, deletionID uint) error {
+	if err := softDeleteTasks(tx, userID, deletionID, "creator = ? OR responsible = ?", userID, userID); err != nil {
+		return fmt.Errorf("error deleting tasks by user: %v", err)
 	}
 
 	return nil
@@ -226,17 +330,89 @@ func deleteUserTasks(tx *gorm.DB, userID uint) error {
 // ==================================================================
 // 以下はプライベート関数
 // ==================================================================
-func statusToString(status uint) string {
-	switch status {
-	case 1:
-		return "未着"
-	case 2:
-		return "進行中"
-	case 3:
-		return "完了"
-	case 4:
-		return "Look Back"
-	default:
-		return "Unknown status"
-	}
-}
\ No newline at end of file
+// 絞り込み条件に合うタスク
+func filterTasks(db *gorm.DB, query TaskQuery) *gorm.DB {
+	if len(query.Labels) == 0 {
+		return db
+	}
+
+	return db.Where("tasks.id IN (?)", db.Model(&TaskLabel{}).Select("task_id").Where("label_id IN ?", query.Labels))
+}
+
+// サブタスクの件数と見積もりの合計、依存先のタスクの状況、作業時間の合計をあわせてレスポンスに変換する
+func toTaskResponses(db *gorm.DB, tasks []Task) ([]TaskResponse, error) {
+	taskIDs := make([]uint, len(tasks))
+	for i, task := range tasks {
+		taskIDs[i] = task.ID
+	}
+
+	workflows, err := fetchCategoryWorkflows(db, taskCategoryIDs(tasks))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := fetchSubtaskSummaries(db, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked, warnings, err := fetchBlockedTasks(db, taskIDs, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	actualMinutes, err := fetchActualMinutes(db, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := fetchTaskLabels(db, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	taskResponses := make([]TaskResponse, len(tasks))
+	for i, task := range tasks {
+		summary := summaries[task.ID]
+		workflowStatus, _ := workflows.of(task.CategoryID).find(task.Status)
+
+		taskResponses[i] = TaskResponse{
+			ID:                  task.ID,
+			Task:                task.Task,
+			Description:         task.Description,
+			Status:              task.Status,
+			StatusName:          workflows.of(task.CategoryID).name(task.Status),
+			StatusColor:         workflowStatus.Color,
+			StatusKind:          workflowStatus.Kind,
+			Category:            task.Category.ID,
+			CategoryName:        task.Category.Category,
+			Estimate:            task.Estimate,
+			ActualMinutes:       actualMinutes[task.ID],
+			StartDate:           task.StartDate.Format("2006-01-02"),
+			Responsible:         task.ResponsibleUserID.ID,
+			ResponsibleUserName: task.ResponsibleUserID.Name,
+			Creator:             task.CreatorUserID.ID,
+			CreatorUserName:     task.CreatorUserID.Name,
+			CreatedAt:           task.CreatedAt.Format("2006-01-02 15:04"),
+			UpdatedAt:           task.UpdatedAt.Format("2006-01-02 15:04"),
+			Parent:              task.ParentID,
+			Position:            task.Position,
+			Rank:                task.Rank,
+			SubtaskCount:        summary.SubtaskCount,
+			SubtaskDoneCount:    summary.SubtaskDoneCount,
+			SubtaskEstimate:     summary.SubtaskEstimate,
+			Blocked:             blocked[task.ID],
+			StartDateWarnings:   warnings[task.ID],
+			RecurringTask:       task.RecurringTaskID,
+			Labels:              labels[task.ID],
+		}
+		if taskResponses[i].StartDateWarnings == nil {
+			taskResponses[i].StartDateWarnings = []StartDateWarning{}
+		}
+		if taskResponses[i].Labels == nil {
+			taskResponses[i].Labels = []LabelResponse{}
+		}
+	}
+
+	return taskResponses, nil
+}
@@ -0,0 +1,368 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidDependency = errors.New("タスク自身を依存先にすることはできません")
+	ErrDependencyCycle   = errors.New("依存関係が循環するため追加できません")
+)
+
+// タスクの依存関係テーブル定義
+// TaskIDのタスクはBlockerIDのタスクが完了するまで着手できない
+// どちらかのタスクを削除した場合は依存関係も削除する
+type TaskDependency struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	TaskID    uint `gorm:"not null;uniqueIndex:idx_task_dependency"`
+	Task      Task `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE;"`
+	BlockerID uint `gorm:"not null;uniqueIndex:idx_task_dependency;index"`
+	Blocker   Task `gorm:"foreignKey:BlockerID;constraint:OnDelete:CASCADE;"`
+}
+
+type TaskDependencyInput struct {
+	TaskID uint `json:"Task" binding:"required"`
+}
+
+// 依存関係にあるタスク
+type DependencyTaskResponse struct {
+	ID             uint
+	Task           string
+	Status         uint
+	StatusName     string
+	StartDate      string
+	ExpectedFinish string // 開始日に見積もり（日数）を加えた完了予定日
+}
+
+// タスクの依存関係の取得
+type TaskDependenciesResponse struct {
+	BlockedBy []DependencyTaskResponse // このタスクより先に完了する必要があるタスク
+	Blocks    []DependencyTaskResponse // このタスクの完了を待っているタスク
+}
+
+// 完了予定日より前に開始する依存先のタスクの警告
+type StartDateWarning struct {
+	Blocker        uint
+	BlockerName    string
+	ExpectedFinish string
+}
+
+// カテゴリーの依存関係グラフ
+// カテゴリー外のタスクと依存関係がある場合は、そのタスクもノードに含める
+type DependencyGraphResponse struct {
+	Nodes []DependencyGraphNode
+	Edges []DependencyGraphEdge
+}
+
+type DependencyGraphNode struct {
+	DependencyTaskResponse
+	Category uint
+	Blocked  bool
+}
+
+type DependencyGraphEdge struct {
+	From uint // 依存先のタスク
+	To   uint // 依存元のタスク
+}
+
+func (taskDependency *TaskDependency) MigrateTaskDependency(db *gorm.DB) error {
+	// 自動マイグレーション(TaskDependencyテーブルを作成)
+	migrateErr := db.AutoMigrate(&TaskDependency{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// タスクが依存するタスクと、タスクに依存するタスクを取得する
+func FetchTaskDependencies(db *gorm.DB, taskID int) (TaskDependenciesResponse, error) {
+	response := TaskDependenciesResponse{}
+
+	var blockers []Task
+	result := db.Joins("JOIN task_dependencies ON task_dependencies.blocker_id = tasks.id").
+		Where("task_dependencies.task_id = ?", taskID).
+		Order("tasks.id asc").
+		Find(&blockers)
+	if result.Error != nil {
+		log.Printf("Error fetching blockers: %v\n", result.Error)
+		return response, result.Error
+	}
+
+	var blocked []Task
+	result = db.Joins("JOIN task_dependencies ON task_dependencies.task_id = tasks.id").
+		Where("task_dependencies.blocker_id = ?", taskID).
+		Order("tasks.id asc").
+		Find(&blocked)
+	if result.Error != nil {
+		log.Printf("Error fetching blocked tasks: %v\n", result.Error)
+		return response, result.Error
+	}
+	log.Printf("タスクの依存関係の取得に成功")
+
+	workflows, err := fetchCategoryWorkflows(db, taskCategoryIDs(append(blockers, blocked...)))
+	if err != nil {
+		return response, err
+	}
+
+	response.BlockedBy = make([]DependencyTaskResponse, len(blockers))
+	for i, blocker := range blockers {
+		response.BlockedBy[i] = toDependencyTaskResponse(blocker, workflows.of(blocker.CategoryID))
+	}
+	response.Blocks = make([]DependencyTaskResponse, len(blocked))
+	for i, task := range blocked {
+		response.Blocks[i] = toDependencyTaskResponse(task, workflows.of(task.CategoryID))
+	}
+
+	return response, nil
+}
+
+// taskIDのタスクがblockerIDのタスクに依存する関係を追加する
+// 両方のタスクが同じユーザーグループのものかは呼び出し元で確認する
+func CreateTaskDependency(db *gorm.DB, taskID uint, blockerID uint) error {
+	if taskID == blockerID {
+		log.Printf("Task %d cannot depend on itself", taskID)
+		return ErrInvalidDependency
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 依存先のタスクが既にこのタスクに依存していれば循環する
+		cyclic, err := dependsOn(tx, blockerID, taskID)
+		if err != nil {
+			return err
+		}
+		if cyclic {
+			log.Printf("Dependency from task %d to task %d forms a cycle", taskID, blockerID)
+			return ErrDependencyCycle
+		}
+
+		var count int64
+		if err := tx.Model(&TaskDependency{}).Where("task_id = ? AND blocker_id = ?", taskID, blockerID).Count(&count).Error; err != nil {
+			log.Printf("Error counting task dependencies: %v\n", err)
+			return err
+		}
+		if count > 0 {
+			// 既に追加済み
+			return nil
+		}
+
+		if err := tx.Create(&TaskDependency{TaskID: taskID, BlockerID: blockerID}).Error; err != nil {
+			log.Printf("Error creating task dependency: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("タスクの依存関係の追加に成功")
+
+	return nil
+}
+
+// taskIDのタスクがblockerIDのタスクに依存する関係を削除する
+func DeleteTaskDependency(db *gorm.DB, taskID uint, blockerID uint) error {
+	result := db.Where("task_id = ? AND blocker_id = ?", taskID, blockerID).Delete(&TaskDependency{})
+	if result.Error != nil {
+		log.Printf("Error deleting task dependency: %v\n", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		log.Printf("Task %d does not depend on task %d", taskID, blockerID)
+		return ErrNotFound
+	}
+	log.Printf("タスクの依存関係の削除に成功")
+
+	return nil
+}
+
+// カテゴリーのタスクの依存関係グラフを取得する
+func FetchCategoryDependencyGraph(db *gorm.DB, categoryID int) (DependencyGraphResponse, error) {
+	graph := DependencyGraphResponse{Nodes: []DependencyGraphNode{}, Edges: []DependencyGraphEdge{}}
+
+	categoryTaskIDs := db.Model(&Task{}).Select("id").Where("category_id = ?", categoryID)
+	var dependencies []TaskDependency
+	result := db.Where("task_id IN (?) OR blocker_id IN (?)", categoryTaskIDs, categoryTaskIDs).
+		Order("id asc").
+		Find(&dependencies)
+	if result.Error != nil {
+		log.Printf("Error fetching task dependencies: %v\n", result.Error)
+		return graph, result.Error
+	}
+
+	connectedIDs := []uint{}
+	for _, dependency := range dependencies {
+		connectedIDs = append(connectedIDs, dependency.TaskID, dependency.BlockerID)
+	}
+
+	var tasks []Task
+	result = db.Where("category_id = ? OR id IN ?", categoryID, connectedIDs).
+		Order("id asc").
+		Find(&tasks)
+	if result.Error != nil {
+		log.Printf("Error fetching tasks: %v\n", result.Error)
+		return graph, result.Error
+	}
+	log.Printf("依存関係グラフの取得に成功")
+
+	workflows, err := fetchCategoryWorkflows(db, taskCategoryIDs(tasks))
+	if err != nil {
+		return graph, err
+	}
+
+	// ゴミ箱にあるタスクとの依存関係は除く
+	done := map[uint]bool{}
+	for _, task := range tasks {
+		done[task.ID] = workflows.of(task.CategoryID).isDone(task.Status)
+	}
+	graph.Edges = []DependencyGraphEdge{}
+	blocked := map[uint]bool{}
+	for _, dependency := range dependencies {
+		blockerDone, blockerFound := done[dependency.BlockerID]
+		if _, taskFound := done[dependency.TaskID]; !taskFound || !blockerFound {
+			continue
+		}
+		graph.Edges = append(graph.Edges, DependencyGraphEdge{From: dependency.BlockerID, To: dependency.TaskID})
+		if !blockerDone {
+			blocked[dependency.TaskID] = true
+		}
+	}
+
+	for _, task := range tasks {
+		graph.Nodes = append(graph.Nodes, DependencyGraphNode{
+			DependencyTaskResponse: toDependencyTaskResponse(task, workflows.of(task.CategoryID)),
+			Category:               task.CategoryID,
+			Blocked:                blocked[task.ID],
+		})
+	}
+
+	return graph, nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// fromのタスクが直接または間接的にtoのタスクに依存しているか確認する
+func dependsOn(tx *gorm.DB, from uint, to uint) (bool, error) {
+	visited := map[uint]bool{from: true}
+	frontier := []uint{from}
+
+	for len(frontier) > 0 {
+		var blockerIDs []uint
+		if err := tx.Model(&TaskDependency{}).Where("task_id IN ?", frontier).Pluck("blocker_id", &blockerIDs).Error; err != nil {
+			log.Printf("Error fetching task dependencies: %v\n", err)
+			return false, err
+		}
+
+		frontier = nil
+		for _, blockerID := range blockerIDs {
+			if blockerID == to {
+				return true, nil
+			}
+			if !visited[blockerID] {
+				visited[blockerID] = true
+				frontier = append(frontier, blockerID)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// 完了していない依存先があるタスクと、完了予定日より前に開始するタスクの警告を取得する
+func fetchBlockedTasks(db *gorm.DB, taskIDs []uint, tasks []Task) (map[uint]bool, map[uint][]StartDateWarning, error) {
+	blocked := map[uint]bool{}
+	warnings := map[uint][]StartDateWarning{}
+	if len(taskIDs) == 0 {
+		return blocked, warnings, nil
+	}
+
+	var rows []struct {
+		TaskID uint
+		Task
+	}
+	result := db.Model(&Task{}).
+		Select("task_dependencies.task_id, tasks.*").
+		Joins("JOIN task_dependencies ON task_dependencies.blocker_id = tasks.id").
+		Where("task_dependencies.task_id IN ?", taskIDs).
+		Order("tasks.id asc").
+		Scan(&rows)
+	if result.Error != nil {
+		log.Printf("Error fetching blockers: %v\n", result.Error)
+		return nil, nil, result.Error
+	}
+
+	startDates := map[uint]*time.Time{}
+	for _, task := range tasks {
+		startDates[task.ID] = task.StartDate
+	}
+
+	blockers := make([]Task, len(rows))
+	for i, row := range rows {
+		blockers[i] = row.Task
+	}
+	workflows, err := fetchCategoryWorkflows(db, taskCategoryIDs(blockers))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, row := range rows {
+		if workflows.of(row.CategoryID).isDone(row.Status) {
+			continue
+		}
+		blocked[row.TaskID] = true
+
+		expectedFinish := expectedFinishDate(row.Task)
+		startDate := startDates[row.TaskID]
+		if startDate != nil && expectedFinish != nil && startDate.Before(*expectedFinish) {
+			warnings[row.TaskID] = append(warnings[row.TaskID], StartDateWarning{
+				Blocker:        row.ID,
+				BlockerName:    row.Task.Task,
+				ExpectedFinish: expectedFinish.Format("2006-01-02"),
+			})
+		}
+	}
+
+	return blocked, warnings, nil
+}
+
+// 開始日に見積もりの日数を加えて完了予定日を求める
+func expectedFinishDate(task Task) *time.Time {
+	if task.StartDate == nil || task.Estimate == nil {
+		return nil
+	}
+	expectedFinish := task.StartDate.AddDate(0, 0, int(*task.Estimate))
+	return &expectedFinish
+}
+
+func taskCategoryIDs(tasks []Task) []uint {
+	categoryIDs := make([]uint, len(tasks))
+	for i, task := range tasks {
+		categoryIDs[i] = task.CategoryID
+	}
+	return categoryIDs
+}
+
+func toDependencyTaskResponse(task Task, workflow groupWorkflow) DependencyTaskResponse {
+	response := DependencyTaskResponse{
+		ID:         task.ID,
+		Task:       task.Task,
+		Status:     task.Status,
+		StatusName: workflow.name(task.Status),
+	}
+	if task.StartDate != nil {
+		response.StartDate = task.StartDate.Format("2006-01-02")
+	}
+	if expectedFinish := expectedFinishDate(task); expectedFinish != nil {
+		response.ExpectedFinish = expectedFinish.Format("2006-01-02")
+	}
+
+	return response
+}
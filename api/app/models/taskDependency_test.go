@@ -0,0 +1,119 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestExpectedFinishDate(t *testing.T) {
+	startDate := time.Date(2023, 1, 30, 0, 0, 0, 0, time.UTC)
+
+	expectedFinish := expectedFinishDate(Task{StartDate: &startDate, Estimate: ptrToUint(3)})
+	assert.Equal(t, "2023-02-02", expectedFinish.Format("2006-01-02"))
+
+	assert.Nil(t, expectedFinishDate(Task{StartDate: &startDate}))
+}
+
+func TestTaskDependencies(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	user := &User{Name: "TestUser", Password: "TestPassword", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(user)
+	category := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(category)
+	otherCategory := &Category{Category: "OtherCategory", UserGroupID: userGroup.ID}
+	db.Create(otherCategory)
+	newTask := func(name string, categoryID uint, startDate time.Time) *Task {
+		task := &Task{Task: name, Description: "TestDescription", Creator: user.ID, CategoryID: categoryID, Status: 1, Responsible: user.ID, Estimate: ptrToUint(5), StartDate: ptrToTime(startDate)}
+		db.Create(task)
+		return task
+	}
+	now := time.Now()
+	design := newTask("Design", category.ID, now)
+	build := newTask("Build", category.ID, now.AddDate(0, 0, 2))
+	release := newTask("Release", otherCategory.ID, now.AddDate(0, 0, 30))
+
+	t.Run("追加", func(t *testing.T) {
+		assert.Nil(t, CreateTaskDependency(db, build.ID, design.ID))
+		assert.Nil(t, CreateTaskDependency(db, release.ID, build.ID))
+		// 追加済みの依存関係はそのまま
+		assert.Nil(t, CreateTaskDependency(db, build.ID, design.ID))
+
+		dependencies, err := FetchTaskDependencies(db, int(build.ID))
+		assert.Nil(t, err)
+		assert.Len(t, dependencies.BlockedBy, 1)
+		assert.Equal(t, design.ID, dependencies.BlockedBy[0].ID)
+		assert.Len(t, dependencies.Blocks, 1)
+		assert.Equal(t, release.ID, dependencies.Blocks[0].ID)
+	})
+
+	t.Run("失敗_循環", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidDependency, CreateTaskDependency(db, design.ID, design.ID))
+		assert.Equal(t, ErrDependencyCycle, CreateTaskDependency(db, design.ID, build.ID))
+		// 間接的な循環
+		assert.Equal(t, ErrDependencyCycle, CreateTaskDependency(db, design.ID, release.ID))
+	})
+
+	t.Run("ブロックと開始日の警告", func(t *testing.T) {
+		taskResponses, err := toTaskResponses(db, []Task{*design, *build, *release})
+		assert.Nil(t, err)
+		assert.False(t, taskResponses[0].Blocked)
+		assert.True(t, taskResponses[1].Blocked)
+		// Designの完了予定日より前に開始する
+		assert.Len(t, taskResponses[1].StartDateWarnings, 1)
+		assert.Equal(t, design.ID, taskResponses[1].StartDateWarnings[0].Blocker)
+		// Buildの完了予定日より後に開始する
+		assert.True(t, taskResponses[2].Blocked)
+		assert.Len(t, taskResponses[2].StartDateWarnings, 0)
+
+		// 依存先が完了すればブロックされない
+		db.Model(&Task{}).Where("id = ?", design.ID).Update("status", 3)
+		taskResponses, _ = toTaskResponses(db, []Task{*build})
+		assert.False(t, taskResponses[0].Blocked)
+		assert.Len(t, taskResponses[0].StartDateWarnings, 0)
+	})
+
+	t.Run("依存関係グラフ", func(t *testing.T) {
+		graph, err := FetchCategoryDependencyGraph(db, int(category.ID))
+		assert.Nil(t, err)
+		// カテゴリー外のReleaseも含む
+		assert.Len(t, graph.Nodes, 3)
+		assert.Len(t, graph.Edges, 2)
+		assert.Equal(t, DependencyGraphEdge{From: design.ID, To: build.ID}, graph.Edges[0])
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		assert.Equal(t, ErrNotFound, DeleteTaskDependency(db, design.ID, build.ID))
+		assert.Nil(t, DeleteTaskDependency(db, build.ID, design.ID))
+
+		// ゴミ箱にあるタスクはブロックしない
+		assert.Nil(t, build.DeleteTask(db, int(build.ID), user.ID))
+		taskResponses, err := toTaskResponses(db, []Task{*release})
+		assert.Nil(t, err)
+		assert.False(t, taskResponses[0].Blocked)
+		dependencies, err := FetchTaskDependencies(db, int(release.ID))
+		assert.Nil(t, err)
+		assert.Len(t, dependencies.BlockedBy, 0)
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Delete(design)
+	db.Unscoped().Delete(release)
+	db.Unscoped().Delete(category)
+	db.Unscoped().Delete(otherCategory)
+	db.Unscoped().Delete(user)
+	db.Unscoped().Delete(userGroup)
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TaskHistoryActionCreate  = "create"
+	TaskHistoryActionUpdate  = "update"
+	TaskHistoryActionStatus  = "status" // ステータスのみの変更
+	TaskHistoryActionDelete  = "delete"
+	TaskHistoryActionRestore = "restore" // ゴミ箱からの復元
+)
+
+// タスクの変更履歴テーブル定義
+// 削除したタスクや退会したユーザーの履歴も残すため、外部キーを設定しない
+type TaskHistory struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	TaskID    uint   `gorm:"not null;index"`
+	ActorID   uint   `gorm:"not null"`
+	Action    string `gorm:"size:16;not null"`
+	Changes   string `gorm:"type:text;not null"` // TaskFieldChangeのスライスのJSON
+}
+
+// 項目ごとの変更前と変更後の値
+// カテゴリーと担当者はID、開始日は"2006-01-02"の形式で記録し、作成・復元時の変更前と削除時の変更後は空にする
+type TaskFieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+type TaskHistoryResponse struct {
+	ID        uint
+	Action    string
+	Actor     uint
+	ActorName string // 退会したユーザーの場合は空
+	Changes   []TaskFieldChange
+	CreatedAt string
+}
+
+func (taskHistory *TaskHistory) MigrateTaskHistory(db *gorm.DB) error {
+	// 自動マイグレーション(TaskHistoryテーブルを作成)
+	migrateErr := db.AutoMigrate(&TaskHistory{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// タスクの変更履歴を古い順に取得する
+func FetchTaskHistory(db *gorm.DB, taskID int) ([]TaskHistoryResponse, error) {
+	var rows []struct {
+		TaskHistory
+		ActorName string
+	}
+
+	result := db.Model(&TaskHistory{}).
+		Select("task_histories.*, users.name AS actor_name").
+		Joins("LEFT JOIN users ON users.id = task_histories.actor_id").
+		Where("task_histories.task_id = ?", taskID).
+		Order("task_histories.id asc").
+		Scan(&rows)
+
+	if result.Error != nil {
+		log.Printf("Error fetching task history: %v\n", result.Error)
+		return nil, result.Error
+	}
+	log.Printf("タスクの変更履歴の取得に成功")
+
+	taskHistoryResponses := make([]TaskHistoryResponse, len(rows))
+	for i, row := range rows {
+		changes := []TaskFieldChange{}
+		if err := json.Unmarshal([]byte(row.Changes), &changes); err != nil {
+			log.Printf("Error decoding task history %d: %v\n", row.ID, err)
+			return nil, err
+		}
+
+		taskHistoryResponses[i] = TaskHistoryResponse{
+			ID:        row.ID,
+			Action:    row.Action,
+			Actor:     row.ActorID,
+			ActorName: row.ActorName,
+			Changes:   changes,
+			CreatedAt: row.CreatedAt.Format("2006-01-02 15:04"),
+		}
+	}
+
+	return taskHistoryResponses, nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// タスクの変更履歴を記録する
+// beforeとafterのどちらかにnilを渡すと、作成または削除として全項目を記録する
+// 変更された項目がない場合は記録しない
+func recordTaskHistory(tx *gorm.DB, taskID uint, actorID uint, before *Task, after *Task) error {
+	changes := diffTaskFields(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	action := TaskHistoryActionUpdate
+	switch {
+	case before == nil:
+		action = TaskHistoryActionCreate
+	case after == nil:
+		action = TaskHistoryActionDelete
+	case len(changes) == 1 && changes[0].Field == "Status":
+		action = TaskHistoryActionStatus
+	}
+
+	return createTaskHistory(tx, taskID, actorID, action, changes)
+}
+
+func createTaskHistory(tx *gorm.DB, taskID uint, actorID uint, action string, changes []TaskFieldChange) error {
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	taskHistory := TaskHistory{
+		TaskID:  taskID,
+		ActorID: actorID,
+		Action:  action,
+		Changes: string(changesJSON),
+	}
+	if err := tx.Create(&taskHistory).Error; err != nil {
+		log.Printf("Error creating task history: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// 変更前と変更後のタスクで値が異なる項目を返す
+func diffTaskFields(before *Task, after *Task) []TaskFieldChange {
+	beforeValues := taskFieldValues(before)
+	afterValues := taskFieldValues(after)
+
+	changes := []TaskFieldChange{}
+	for i, field := range taskHistoryFields {
+		if beforeValues[i] != afterValues[i] {
+			changes = append(changes, TaskFieldChange{Field: field, Before: beforeValues[i], After: afterValues[i]})
+		}
+	}
+
+	return changes
+}
+
+// 変更履歴に記録する項目（TaskInputのキーと同じ名前）
+var taskHistoryFields = []string{"Task", "Description", "Category", "Status", "Responsible", "Estimate", "StartDate"}
+
+func taskFieldValues(task *Task) []string {
+	if task == nil {
+		return make([]string, len(taskHistoryFields))
+	}
+
+	estimate := ""
+	if task.Estimate != nil {
+		estimate = strconv.FormatUint(uint64(*task.Estimate), 10)
+	}
+	startDate := ""
+	if task.StartDate != nil {
+		startDate = task.StartDate.Format("2006-01-02")
+	}
+
+	return []string{
+		task.Task,
+		task.Description,
+		strconv.FormatUint(uint64(task.CategoryID), 10),
+		strconv.FormatUint(uint64(task.Status), 10),
+		strconv.FormatUint(uint64(task.Responsible), 10),
+		estimate,
+		startDate,
+	}
+}
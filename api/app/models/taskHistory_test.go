@@ -0,0 +1,104 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestDiffTaskFields(t *testing.T) {
+	startDate := time.Date(2023, 1, 30, 0, 0, 0, 0, time.UTC)
+	before := Task{Task: "Before", Description: "Description", CategoryID: 1, Status: 1, Responsible: 2, Estimate: ptrToUint(3), StartDate: &startDate}
+
+	// 作成時は全項目を記録する
+	changes := diffTaskFields(nil, &before)
+	assert.Len(t, changes, len(taskHistoryFields))
+	assert.Equal(t, TaskFieldChange{Field: "StartDate", Before: "", After: "2023-01-30"}, changes[6])
+
+	after := before
+	after.Task = "After"
+	after.Estimate = ptrToUint(5)
+	assert.Equal(t, []TaskFieldChange{
+		{Field: "Task", Before: "Before", After: "After"},
+		{Field: "Estimate", Before: "3", After: "5"},
+	}, diffTaskFields(&before, &after))
+
+	assert.Empty(t, diffTaskFields(&before, &before))
+}
+
+func TestTaskHistory(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	user := &User{Name: "TestUser", Password: "TestPassword", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(user)
+	otherUser := &User{Name: "OtherUser", Password: "TestPassword", Email: "other@example.com", UserGroupID: userGroup.ID}
+	db.Create(otherUser)
+	category := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(category)
+	task := &Task{Task: "TestTask", Description: "TestDescription", Creator: user.ID, CategoryID: category.ID, Status: 1, Responsible: user.ID, Estimate: ptrToUint(5), StartDate: ptrToTime(time.Now())}
+	assert.Nil(t, task.CreateTask(db))
+	taskID := int(task.ID)
+
+	t.Run("作成と更新", func(t *testing.T) {
+		update := *task
+		update.Description = "UpdatedDescription"
+		update.Responsible = otherUser.ID
+		assert.Nil(t, update.UpdateTask(db, taskID, false, otherUser.ID))
+
+		// 変更がない場合は記録しない
+		assert.Nil(t, update.UpdateTask(db, taskID, false, otherUser.ID))
+
+		history, err := FetchTaskHistory(db, taskID)
+		assert.Nil(t, err)
+		assert.Len(t, history, 2)
+		assert.Equal(t, TaskHistoryActionCreate, history[0].Action)
+		assert.Equal(t, user.ID, history[0].Actor)
+		assert.Equal(t, TaskHistoryActionUpdate, history[1].Action)
+		assert.Equal(t, "OtherUser", history[1].ActorName)
+		assert.Len(t, history[1].Changes, 2)
+		assert.Equal(t, "Description", history[1].Changes[0].Field)
+		assert.Equal(t, "TestDescription", history[1].Changes[0].Before)
+		assert.Equal(t, "UpdatedDescription", history[1].Changes[0].After)
+	})
+
+	t.Run("ステータスの変更", func(t *testing.T) {
+		assert.Nil(t, UpdateTaskStatus(db, taskID, 3, false, user.ID))
+
+		history, err := FetchTaskHistory(db, taskID)
+		assert.Nil(t, err)
+		assert.Len(t, history, 3)
+		assert.Equal(t, TaskHistoryActionStatus, history[2].Action)
+		assert.Equal(t, []TaskFieldChange{{Field: "Status", Before: "1", After: "3"}}, history[2].Changes)
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		assert.Nil(t, task.DeleteTask(db, taskID, user.ID))
+
+		// 削除したタスクの履歴も残す
+		history, err := FetchTaskHistory(db, taskID)
+		assert.Nil(t, err)
+		assert.Len(t, history, 4)
+		assert.Equal(t, TaskHistoryActionDelete, history[3].Action)
+		assert.Equal(t, "3", history[3].Changes[3].Before)
+		assert.Equal(t, "", history[3].Changes[3].After)
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Where("task_id = ?", task.ID).Delete(&TaskHistory{})
+	db.Unscoped().Delete(category)
+	db.Unscoped().Delete(otherUser)
+	db.Unscoped().Delete(user)
+	db.Unscoped().Delete(userGroup)
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/utils"
+)
+
+var ErrInvalidTaskMove = errors.New("タスクの移動先が正しくありません")
+
+// タスクボードの列の間でタスクを移動する
+// BeforeとAfterは移動先の列で直前・直後に並ぶタスクで、どちらも指定しない場合は列の末尾に移動する
+type TaskMoveInput struct {
+	Status uint  `json:"Status" binding:"required,min=1"`
+	Before *uint `json:"Before"`
+	After  *uint `json:"After"`
+}
+
+// タスクをStatusの列のBeforeとAfterのタスクの間に移動し、actorIDのユーザーの変更履歴として記録する
+// 移動するタスクのランクだけを変更し、列のほかのタスクのランクは変更しない
+// Look Backの種類のステータスへはタスクボードから移動できない
+// 完了の種類のステータスに移動する場合、サブタスクはcascadeSubtasksに従って扱う
+func MoveTask(db *gorm.DB, id int, input TaskMoveInput, cascadeSubtasks bool, actorID uint) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var before Task
+		if err := tx.Preload("Category").Where("id = ?", id).First(&before).Error; err != nil {
+			log.Printf("Error fetching task with ID %d: %v\n", id, err)
+			return toAuthorizationError(err)
+		}
+
+		workflow, err := fetchTaskWorkflow(tx, before)
+		if err != nil {
+			return err
+		}
+		if _, ok := workflow.find(input.Status); !ok {
+			log.Printf("Status %d does not exist in workflow of task %d", input.Status, id)
+			return ErrInvalidTaskStatus
+		}
+		if workflow.kind(input.Status) == WorkflowStatusKindArchived {
+			log.Printf("Task %d cannot be moved to archived status %d", id, input.Status)
+			return ErrInvalidTaskMove
+		}
+		if !workflow.canTransition(before.Status, input.Status) {
+			log.Printf("Transition of task %d from status %d to %d is not allowed", id, before.Status, input.Status)
+			return ErrStatusTransitionNotAllowed
+		}
+
+		if before.Status != input.Status {
+			if err := completeSubtasks(tx, workflow, before.ID, input.Status, cascadeSubtasks, actorID); err != nil {
+				return err
+			}
+		}
+
+		userGroupID := before.Category.UserGroupID
+		if err := ensureColumnRanks(tx, userGroupID, input.Status); err != nil {
+			return err
+		}
+
+		rank, err := rankBetweenNeighbors(tx, userGroupID, input, before.ID)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&Task{}).Where("id = ?", id).Updates(map[string]interface{}{"status": input.Status, "board_rank": rank})
+		if result.Error != nil {
+			log.Printf("Error moving task: %v\n", result.Error)
+			return result.Error
+		}
+
+		if before.Status != input.Status && workflow.isDone(input.Status) {
+			if err := stopTaskTimers(tx, []int{id}); err != nil {
+				log.Println(err)
+				return err
+			}
+		}
+
+		after := before
+		after.Status = input.Status
+		after.Rank = rank
+
+		return recordTaskHistory(tx, before.ID, actorID, &before, &after)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("タスクの移動に成功")
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+
+// ユーザーグループのstatusの列のタスク
+func columnTasks(tx *gorm.DB, userGroupID uint, status uint) *gorm.DB {
+	return tx.Model(&Task{}).
+		Joins("JOIN categories ON tasks.category_id = categories.id AND categories.deleted_at IS NULL").
+		Where("tasks.status = ? AND categories.user_group_id = ?", status, userGroupID)
+}
+
+// カテゴリーのユーザーグループのstatusの列の末尾に並べるランクを返す
+func lastColumnRank(tx *gorm.DB, categoryID uint, status uint) (string, error) {
+	var category Category
+	if err := tx.Select("id, user_group_id").Where("id = ?", categoryID).First(&category).Error; err != nil {
+		log.Printf("Error fetching category with ID %d: %v\n", categoryID, err)
+		return "", toAuthorizationError(err)
+	}
+
+	var last string
+	if err := columnTasks(tx, category.UserGroupID, status).Select("COALESCE(MAX(tasks.board_rank), '')").Scan(&last).Error; err != nil {
+		log.Printf("Error fetching task rank: %v\n", err)
+		return "", err
+	}
+
+	return utils.RankBetween(last, "")
+}
+
+// ランクのない列のタスク（ランクを追加する前に作成したタスクなど）に、作成日時の順でランクのあるタスクより前のランクを付ける
+func ensureColumnRanks(tx *gorm.DB, userGroupID uint, status uint) error {
+	var unranked []Task
+	if err := columnTasks(tx, userGroupID, status).Select("tasks.id").Where("tasks.board_rank = ''").Order("tasks.created_at asc, tasks.id asc").Find(&unranked).Error; err != nil {
+		log.Printf("Error fetching tasks: %v\n", err)
+		return err
+	}
+	if len(unranked) == 0 {
+		return nil
+	}
+
+	var first string
+	if err := columnTasks(tx, userGroupID, status).Select("COALESCE(MIN(tasks.board_rank), '')").Where("tasks.board_rank <> ''").Scan(&first).Error; err != nil {
+		log.Printf("Error fetching task rank: %v\n", err)
+		return err
+	}
+
+	ranks, err := utils.RanksBetween("", first, len(unranked))
+	if err != nil {
+		return err
+	}
+	for i, task := range unranked {
+		if err := tx.Model(&Task{}).Where("id = ?", task.ID).Update("board_rank", ranks[i]).Error; err != nil {
+			log.Printf("Error updating task rank: %v\n", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 移動先の前後のタスクの間のランクを返す
+// 片方だけを指定した場合は、もう片方を列で隣り合うタスクとする
+func rankBetweenNeighbors(tx *gorm.DB, userGroupID uint, input TaskMoveInput, taskID uint) (string, error) {
+	prev, err := neighborRank(tx, userGroupID, input.Status, input.Before, taskID)
+	if err != nil {
+		return "", err
+	}
+	next, err := neighborRank(tx, userGroupID, input.Status, input.After, taskID)
+	if err != nil {
+		return "", err
+	}
+
+	others := func() *gorm.DB {
+		return columnTasks(tx, userGroupID, input.Status).Where("tasks.id <> ?", taskID)
+	}
+	switch {
+	case input.Before != nil && input.After == nil:
+		err = others().Select("COALESCE(MIN(tasks.board_rank), '')").Where("tasks.board_rank > ?", prev).Scan(&next).Error
+	case input.Before == nil && input.After != nil:
+		err = others().Select("COALESCE(MAX(tasks.board_rank), '')").Where("tasks.board_rank < ?", next).Scan(&prev).Error
+	case input.Before == nil && input.After == nil:
+		err = others().Select("COALESCE(MAX(tasks.board_rank), '')").Scan(&prev).Error
+	}
+	if err != nil {
+		log.Printf("Error fetching task rank: %v\n", err)
+		return "", err
+	}
+
+	rank, err := utils.RankBetween(prev, next)
+	if err != nil {
+		log.Printf("Cannot rank task %d between %q and %q: %v", taskID, prev, next, err)
+		return "", ErrInvalidTaskMove
+	}
+
+	return rank, nil
+}
+
+// 移動先の列の前後のタスクのランクを返す
+// 別のユーザーグループや別の列のタスク、移動するタスク自身は指定できない
+func neighborRank(tx *gorm.DB, userGroupID uint, status uint, neighborID *uint, taskID uint) (string, error) {
+	if neighborID == nil {
+		return "", nil
+	}
+	if *neighborID == taskID {
+		return "", ErrInvalidTaskMove
+	}
+
+	var neighbor Task
+	result := columnTasks(tx, userGroupID, status).Select("tasks.id, tasks.board_rank").Where("tasks.id = ?", *neighborID).Limit(1).Find(&neighbor)
+	if result.Error != nil {
+		log.Printf("Error fetching task with ID %d: %v\n", *neighborID, result.Error)
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		log.Printf("Task %d is not in status %d of user group %d", *neighborID, status, userGroupID)
+		return "", ErrInvalidTaskMove
+	}
+
+	return neighbor.Rank, nil
+}
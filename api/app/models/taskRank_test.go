@@ -0,0 +1,101 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestMoveTask(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	user := &User{Name: "TestUser", Password: "TestPassword", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(user)
+	category := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(category)
+	// ランクを追加する前に作成したタスク
+	legacy := &Task{Task: "Legacy", Description: "TestDescription", Creator: user.ID, CategoryID: category.ID, Status: 1, Responsible: user.ID, Estimate: ptrToUint(1), StartDate: ptrToTime(time.Now())}
+	db.Create(legacy)
+	newTask := func(name string) *Task {
+		task := &Task{Task: name, Description: "TestDescription", Creator: user.ID, CategoryID: category.ID, Status: 1, Responsible: user.ID, Estimate: ptrToUint(1), StartDate: ptrToTime(time.Now())}
+		assert.Nil(t, task.CreateTask(db))
+		return task
+	}
+	first := newTask("First")
+	second := newTask("Second")
+	third := newTask("Third")
+
+	boardTaskNames := func() []string {
+		taskResponses, err := FetchTaskBoardTasks(db, user.ID, TaskQuery{})
+		assert.Nil(t, err)
+		names := []string{}
+		for _, taskResponse := range taskResponses {
+			names = append(names, taskResponse.Task)
+		}
+		return names
+	}
+
+	t.Run("作成したタスクは列の末尾に並ぶ", func(t *testing.T) {
+		assert.True(t, first.Rank < second.Rank && second.Rank < third.Rank)
+		assert.Equal(t, []string{"Legacy", "First", "Second", "Third"}, boardTaskNames())
+	})
+
+	t.Run("前後のタスクの間に移動", func(t *testing.T) {
+		assert.Nil(t, MoveTask(db, int(third.ID), TaskMoveInput{Status: 1, Before: &legacy.ID, After: &first.ID}, false, user.ID))
+		assert.Equal(t, []string{"Legacy", "Third", "First", "Second"}, boardTaskNames())
+
+		// 直前のタスクだけを指定
+		assert.Nil(t, MoveTask(db, int(second.ID), TaskMoveInput{Status: 1, Before: &legacy.ID}, false, user.ID))
+		assert.Equal(t, []string{"Legacy", "Second", "Third", "First"}, boardTaskNames())
+
+		// 直後のタスクだけを指定
+		assert.Nil(t, MoveTask(db, int(legacy.ID), TaskMoveInput{Status: 1, After: &first.ID}, false, user.ID))
+		assert.Equal(t, []string{"Second", "Third", "Legacy", "First"}, boardTaskNames())
+	})
+
+	t.Run("別の列に移動", func(t *testing.T) {
+		assert.Nil(t, MoveTask(db, int(first.ID), TaskMoveInput{Status: 2}, false, user.ID))
+		assert.Nil(t, MoveTask(db, int(second.ID), TaskMoveInput{Status: 2, After: &first.ID}, false, user.ID))
+
+		var moved []Task
+		db.Where("status = ? AND category_id = ?", 2, category.ID).Order("board_rank asc").Find(&moved)
+		assert.Len(t, moved, 2)
+		assert.Equal(t, second.ID, moved[0].ID)
+
+		histories, err := FetchTaskHistory(db, int(first.ID))
+		assert.Nil(t, err)
+		assert.NotEmpty(t, histories)
+	})
+
+	t.Run("失敗", func(t *testing.T) {
+		// 前後のタスクが移動先の列にない
+		assert.Equal(t, ErrInvalidTaskMove, MoveTask(db, int(third.ID), TaskMoveInput{Status: 1, Before: &first.ID}, false, user.ID))
+		// 前後のタスクの順序が逆
+		assert.Equal(t, ErrInvalidTaskMove, MoveTask(db, int(first.ID), TaskMoveInput{Status: 2, Before: &second.ID, After: &second.ID}, false, user.ID))
+		assert.Equal(t, ErrInvalidTaskMove, MoveTask(db, int(third.ID), TaskMoveInput{Status: 1, Before: &third.ID}, false, user.ID))
+		// Look Backの種類のステータス
+		assert.Equal(t, ErrInvalidTaskMove, MoveTask(db, int(third.ID), TaskMoveInput{Status: 4}, false, user.ID))
+		assert.Equal(t, ErrInvalidTaskStatus, MoveTask(db, int(third.ID), TaskMoveInput{Status: 9}, false, user.ID))
+	})
+
+	// 後処理: テスト用のデータを削除
+	for _, task := range []*Task{legacy, first, second, third} {
+		db.Where("task_id = ?", task.ID).Delete(&TaskHistory{})
+		db.Unscoped().Delete(task)
+	}
+	db.Unscoped().Delete(category)
+	db.Unscoped().Delete(user)
+	db.Unscoped().Delete(userGroup)
+}
@@ -135,7 +135,7 @@ func TestFetchTaskBoardTasks(t *testing.T) {
 		t.Fatalf("failed to create task: %v", err)
 	}
 
-	taskResponses, err := FetchTaskBoardTasks(db, user.ID)
+	taskResponses, err := FetchTaskBoardTasks(db, user.ID, TaskQuery{})
 	if err != nil {
 		t.Fatalf("FetchTaskBoardTasks returned an error: %v", err)
 	}
@@ -197,7 +197,7 @@ func TestFetchLookBackTasks(t *testing.T) {
 	db.Create(task)
 
 	// FetchLookBackTasks関数の実行
-	taskResponses, err := FetchLookBackTasks(db, user.ID)
+	taskResponses, err := FetchLookBackTasks(db, user.ID, TaskQuery{})
 	assert.Nil(t, err, "FetchLookBackTasks should not return an error")
 	assert.Equal(t, 1, len(taskResponses), "Should fetch one look back task")
 
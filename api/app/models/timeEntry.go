@@ -0,0 +1,286 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidTimeEntry    = errors.New("作業の終了時刻は開始時刻より後にしてください")
+	ErrTimerAlreadyRunning = errors.New("すでに計測中の作業があります")
+	ErrTimerNotRunning     = errors.New("このタスクの作業は計測されていません")
+)
+
+// タスクの作業時間テーブル定義
+// StoppedAtがNULLの記録は計測中のタイマーを表す
+// タスクまたはユーザーを削除した場合は作業時間も削除する
+type TimeEntry struct {
+	ID            uint `gorm:"primarykey"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	TaskID        uint      `gorm:"not null;index"`
+	Task          Task      `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE;"`
+	UserID        uint      `gorm:"not null;index"`
+	User          User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+	StartedAt     time.Time `gorm:"not null"`
+	StoppedAt     *time.Time
+	RunningUserID *uint `gorm:"uniqueIndex"` // 計測中のみUserIDを設定し、計測中のタイマーをユーザーごとに1件に制限する
+}
+
+// 手動で記録する作業時間（計測中の記録を編集した場合は計測を終了する）
+type TimeEntryInput struct {
+	StartedAt string `json:"StartedAt" binding:"required,min=1,max=25"`
+	StoppedAt string `json:"StoppedAt" binding:"required,min=1,max=25"`
+}
+
+type TimeEntryResponse struct {
+	ID        uint
+	Task      uint
+	User      uint
+	UserName  string
+	StartedAt string
+	StoppedAt string // 計測中の場合は空
+	Running   bool
+	Minutes   int64 // 計測中の場合は現在までの時間
+}
+
+func (timeEntry *TimeEntry) MigrateTimeEntry(db *gorm.DB) error {
+	// 自動マイグレーション(TimeEntryテーブルを作成)
+	migrateErr := db.AutoMigrate(&TimeEntry{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// タスクの作業時間を開始の古い順に取得する
+func FetchTimeEntries(db *gorm.DB, taskID int) ([]TimeEntryResponse, error) {
+	var timeEntries []TimeEntry
+	result := db.Preload("User").Where("task_id = ?", taskID).Order("started_at asc, id asc").Find(&timeEntries)
+
+	if result.Error != nil {
+		log.Printf("Error fetching time entries: %v\n", result.Error)
+		return nil, result.Error
+	}
+	log.Printf("作業時間の取得に成功")
+
+	now := time.Now()
+	timeEntryResponses := make([]TimeEntryResponse, len(timeEntries))
+	for i, timeEntry := range timeEntries {
+		timeEntryResponses[i] = toTimeEntryResponse(timeEntry, now)
+	}
+
+	return timeEntryResponses, nil
+}
+
+// タスクの作業時間の計測を開始する
+// 計測中のタイマーはユーザーごとに1件までとし、他のタスクを計測中の場合は開始できない
+func StartTimer(db *gorm.DB, taskID int, userID uint) error {
+	var runningCount int64
+	if err := db.Model(&TimeEntry{}).Where("running_user_id = ?", userID).Count(&runningCount).Error; err != nil {
+		log.Printf("Error counting running time entries: %v\n", err)
+		return err
+	}
+	if runningCount > 0 {
+		log.Printf("User %d already has a running timer", userID)
+		return ErrTimerAlreadyRunning
+	}
+
+	timeEntry := TimeEntry{
+		TaskID:        uint(taskID),
+		UserID:        userID,
+		StartedAt:     time.Now(),
+		RunningUserID: &userID,
+	}
+	if err := db.Create(&timeEntry).Error; err != nil {
+		log.Printf("Error creating time entry: %v\n", err)
+		return err
+	}
+	log.Printf("作業時間の計測を開始")
+
+	return nil
+}
+
+// ログイン中のユーザーが計測中のタスクの作業時間の計測を終了する
+func StopTimer(db *gorm.DB, taskID int, userID uint) error {
+	result := db.Model(&TimeEntry{}).
+		Where("task_id = ? AND running_user_id = ?", taskID, userID).
+		Updates(map[string]interface{}{
+			"stopped_at":      time.Now(),
+			"running_user_id": nil,
+		})
+
+	if result.Error != nil {
+		log.Printf("Error stopping time entry: %v\n", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		log.Printf("User %d has no running timer for task %d", userID, taskID)
+		return ErrTimerNotRunning
+	}
+	log.Printf("作業時間の計測を終了")
+
+	return nil
+}
+
+// 作業時間を手動で記録する
+func CreateTimeEntry(db *gorm.DB, taskID int, userID uint, startedAt time.Time, stoppedAt time.Time) error {
+	if !stoppedAt.After(startedAt) {
+		log.Printf("Invalid time entry: %v - %v", startedAt, stoppedAt)
+		return ErrInvalidTimeEntry
+	}
+
+	timeEntry := TimeEntry{
+		TaskID:    uint(taskID),
+		UserID:    userID,
+		StartedAt: startedAt,
+		StoppedAt: &stoppedAt,
+	}
+	if err := db.Create(&timeEntry).Error; err != nil {
+		log.Printf("Error creating time entry: %v\n", err)
+		return err
+	}
+	log.Printf("作業時間の記録に成功")
+
+	return nil
+}
+
+// 作業時間を編集する
+// 編集できるのは記録したユーザーのみ
+func UpdateTimeEntry(db *gorm.DB, taskID int, timeEntryID int, userID uint, startedAt time.Time, stoppedAt time.Time) error {
+	if !stoppedAt.After(startedAt) {
+		log.Printf("Invalid time entry: %v - %v", startedAt, stoppedAt)
+		return ErrInvalidTimeEntry
+	}
+
+	timeEntry, err := findUserTimeEntry(db, taskID, timeEntryID, userID)
+	if err != nil {
+		return err
+	}
+
+	result := db.Model(&timeEntry).Updates(map[string]interface{}{
+		"started_at":      startedAt,
+		"stopped_at":      stoppedAt,
+		"running_user_id": nil,
+	})
+	if result.Error != nil {
+		log.Printf("Error updating time entry: %v\n", result.Error)
+		return result.Error
+	}
+	log.Printf("作業時間の編集に成功")
+
+	return nil
+}
+
+// 作業時間を削除する
+// 削除できるのは記録したユーザーのみ
+func DeleteTimeEntry(db *gorm.DB, taskID int, timeEntryID int, userID uint) error {
+	timeEntry, err := findUserTimeEntry(db, taskID, timeEntryID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Delete(&timeEntry).Error; err != nil {
+		log.Printf("Error deleting time entry: %v\n", err)
+		return err
+	}
+	log.Printf("作業時間の削除に成功")
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func findUserTimeEntry(db *gorm.DB, taskID int, timeEntryID int, userID uint) (TimeEntry, error) {
+	var timeEntry TimeEntry
+	if err := db.Where("id = ? AND task_id = ?", timeEntryID, taskID).First(&timeEntry).Error; err != nil {
+		log.Printf("Error fetching time entry with ID %d of task %d: %v\n", timeEntryID, taskID, err)
+		return timeEntry, toAuthorizationError(err)
+	}
+
+	if timeEntry.UserID != userID {
+		log.Printf("User %d cannot edit time entry %d of user %d", userID, timeEntry.ID, timeEntry.UserID)
+		return timeEntry, ErrForbidden
+	}
+
+	return timeEntry, nil
+}
+
+// 完了またはLook Backに移動したタスクの計測中のタイマーを終了する
+// 対象のタスクのIDのスライス、またはIDを取得するクエリを渡して呼び出す
+func stopTaskTimers(tx *gorm.DB, taskIDs interface{}) error {
+	result := tx.Model(&TimeEntry{}).
+		Where("task_id IN (?) AND running_user_id IS NOT NULL", taskIDs).
+		Updates(map[string]interface{}{
+			"stopped_at":      time.Now(),
+			"running_user_id": nil,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("error stopping timers: %v", result.Error)
+	}
+
+	return nil
+}
+
+// タスクごとの作業時間の合計（分）を集計する
+// 計測中のタイマーは現在までの時間を含める
+func fetchActualMinutes(db *gorm.DB, taskIDs []uint) (map[uint]int64, error) {
+	actualMinutes := map[uint]int64{}
+	if len(taskIDs) == 0 {
+		return actualMinutes, nil
+	}
+
+	var timeEntries []TimeEntry
+	result := db.Select("task_id", "started_at", "stopped_at").Where("task_id IN ?", taskIDs).Find(&timeEntries)
+	if result.Error != nil {
+		log.Printf("Error fetching time entries: %v\n", result.Error)
+		return nil, result.Error
+	}
+
+	now := time.Now()
+	durations := map[uint]time.Duration{}
+	for _, timeEntry := range timeEntries {
+		durations[timeEntry.TaskID] += timeEntryDuration(timeEntry, now)
+	}
+	for taskID, duration := range durations {
+		actualMinutes[taskID] = int64(duration / time.Minute)
+	}
+
+	return actualMinutes, nil
+}
+
+func timeEntryDuration(timeEntry TimeEntry, now time.Time) time.Duration {
+	stoppedAt := now
+	if timeEntry.StoppedAt != nil {
+		stoppedAt = *timeEntry.StoppedAt
+	}
+	if stoppedAt.Before(timeEntry.StartedAt) {
+		return 0
+	}
+
+	return stoppedAt.Sub(timeEntry.StartedAt)
+}
+
+func toTimeEntryResponse(timeEntry TimeEntry, now time.Time) TimeEntryResponse {
+	timeEntryResponse := TimeEntryResponse{
+		ID:        timeEntry.ID,
+		Task:      timeEntry.TaskID,
+		User:      timeEntry.UserID,
+		UserName:  timeEntry.User.Name,
+		StartedAt: timeEntry.StartedAt.Format("2006-01-02 15:04"),
+		Running:   timeEntry.StoppedAt == nil,
+		Minutes:   int64(timeEntryDuration(timeEntry, now) / time.Minute),
+	}
+	if timeEntry.StoppedAt != nil {
+		timeEntryResponse.StoppedAt = timeEntry.StoppedAt.Format("2006-01-02 15:04")
+	}
+
+	return timeEntryResponse
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestTimeEntryDuration(t *testing.T) {
+	startedAt := time.Date(2023, 1, 30, 9, 0, 0, 0, time.UTC)
+	stoppedAt := startedAt.Add(90 * time.Minute)
+	now := startedAt.Add(2 * time.Hour)
+
+	assert.Equal(t, 90*time.Minute, timeEntryDuration(TimeEntry{StartedAt: startedAt, StoppedAt: &stoppedAt}, now))
+
+	// 計測中の場合は現在までの時間
+	assert.Equal(t, 2*time.Hour, timeEntryDuration(TimeEntry{StartedAt: startedAt}, now))
+
+	// 開始時刻が現在より後の場合は0
+	assert.Equal(t, time.Duration(0), timeEntryDuration(TimeEntry{StartedAt: now.Add(time.Hour)}, now))
+}
+
+func TestTimeEntries(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	user := &User{Name: "TestUser", Password: "TestPassword", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(user)
+	otherUser := &User{Name: "OtherUser", Password: "TestPassword", Email: "other@example.com", UserGroupID: userGroup.ID}
+	db.Create(otherUser)
+	category := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(category)
+	newTask := func(name string) *Task {
+		task := &Task{Task: name, Description: "TestDescription", Creator: user.ID, CategoryID: category.ID, Status: 2, Responsible: user.ID, Estimate: ptrToUint(1), StartDate: ptrToTime(time.Now())}
+		db.Create(task)
+		return task
+	}
+	task := newTask("Task")
+	otherTask := newTask("OtherTask")
+	taskID := int(task.ID)
+
+	t.Run("手動で記録", func(t *testing.T) {
+		startedAt := time.Now().Add(-3 * time.Hour)
+		assert.Nil(t, CreateTimeEntry(db, taskID, user.ID, startedAt, startedAt.Add(90*time.Minute)))
+		assert.Equal(t, ErrInvalidTimeEntry, CreateTimeEntry(db, taskID, user.ID, startedAt, startedAt))
+
+		taskResponses, err := toTaskResponses(db, []Task{*task})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(90), taskResponses[0].ActualMinutes)
+	})
+
+	t.Run("タイマー", func(t *testing.T) {
+		assert.Nil(t, StartTimer(db, taskID, user.ID))
+
+		// 計測中のタイマーはユーザーごとに1件まで
+		assert.Equal(t, ErrTimerAlreadyRunning, StartTimer(db, int(otherTask.ID), user.ID))
+		assert.Nil(t, StartTimer(db, int(otherTask.ID), otherUser.ID))
+
+		assert.Equal(t, ErrTimerNotRunning, StopTimer(db, int(otherTask.ID), user.ID))
+		assert.Nil(t, StopTimer(db, taskID, user.ID))
+		assert.Equal(t, ErrTimerNotRunning, StopTimer(db, taskID, user.ID))
+
+		timeEntries, err := FetchTimeEntries(db, taskID)
+		assert.Nil(t, err)
+		assert.Len(t, timeEntries, 2)
+		assert.False(t, timeEntries[1].Running)
+	})
+
+	t.Run("完了したタスクのタイマーを終了", func(t *testing.T) {
+		assert.Nil(t, UpdateTaskStatus(db, int(otherTask.ID), 3, false, user.ID))
+
+		timeEntries, err := FetchTimeEntries(db, int(otherTask.ID))
+		assert.Nil(t, err)
+		assert.Len(t, timeEntries, 1)
+		assert.False(t, timeEntries[0].Running)
+
+		// タイマーを終了したユーザーは再び計測を開始できる
+		assert.Nil(t, StartTimer(db, taskID, otherUser.ID))
+		task.Status = 4
+		assert.Nil(t, task.UpdateTask(db, taskID, false, user.ID))
+		assert.Equal(t, ErrTimerNotRunning, StopTimer(db, taskID, otherUser.ID))
+	})
+
+	t.Run("編集と削除", func(t *testing.T) {
+		timeEntries, err := FetchTimeEntries(db, taskID)
+		assert.Nil(t, err)
+		timeEntryID := int(timeEntries[0].ID)
+
+		// 記録したユーザー以外は編集できない
+		startedAt := time.Now().Add(-2 * time.Hour)
+		assert.Equal(t, ErrForbidden, UpdateTimeEntry(db, taskID, timeEntryID, otherUser.ID, startedAt, startedAt.Add(time.Hour)))
+		assert.Equal(t, ErrNotFound, UpdateTimeEntry(db, int(otherTask.ID), timeEntryID, user.ID, startedAt, startedAt.Add(time.Hour)))
+		assert.Nil(t, UpdateTimeEntry(db, taskID, timeEntryID, user.ID, startedAt, startedAt.Add(time.Hour)))
+
+		timeEntries, err = FetchTimeEntries(db, taskID)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(60), timeEntries[0].Minutes)
+
+		assert.Equal(t, ErrForbidden, DeleteTimeEntry(db, taskID, timeEntryID, otherUser.ID))
+		assert.Nil(t, DeleteTimeEntry(db, taskID, timeEntryID, user.ID))
+		timeEntries, err = FetchTimeEntries(db, taskID)
+		assert.Nil(t, err)
+		assert.Len(t, timeEntries, 2)
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Unscoped().Delete(task)
+	db.Unscoped().Delete(otherTask)
+	db.Unscoped().Delete(category)
+	db.Unscoped().Delete(otherUser)
+	db.Unscoped().Delete(user)
+	db.Unscoped().Delete(userGroup)
+}
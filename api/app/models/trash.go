@@ -0,0 +1,497 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/utils"
+)
+
+// ゴミ箱に移動したデータの種類
+const (
+	DeletionItemTask      = "task"
+	DeletionItemCategory  = "category"
+	DeletionItemUser      = "user"
+	DeletionItemUserGroup = "user_group"
+)
+
+var (
+	ErrInvalidCredentials       = errors.New("メールアドレスまたはパスワードが違います")
+	ErrRestoreConflict          = errors.New("関連するデータがゴミ箱にあるため復元できません")
+	ErrRestoreDuplicateCategory = errors.New("同じ名前のカテゴリーがあるため復元できません")
+)
+
+// 削除操作（ゴミ箱）テーブル定義
+// 論理削除したデータには削除操作のIDを設定し、親と一緒に削除した子のデータもまとめて復元できるようにする
+// 削除したユーザーの操作も残すため、削除したユーザーには外部キーを設定しない
+type Deletion struct {
+	ID          uint      `gorm:"primarykey"`
+	CreatedAt   time.Time `gorm:"index"`
+	UserGroupID uint      `gorm:"not null;index"`
+	ActorID     uint      `gorm:"not null"`
+	ItemType    string    `gorm:"size:16;not null"`
+	ItemID      uint      `gorm:"not null"`
+	ItemName    string    `gorm:"size:255;not null"`
+}
+
+// ゴミ箱一覧取得
+type DeletionResponse struct {
+	ID        uint
+	ItemType  string
+	ItemID    uint
+	ItemName  string
+	TaskCount int64 // 一緒に削除したタスクを含むタスクの件数
+	Actor     uint
+	ActorName string // 退会したユーザーの場合は空
+	DeletedAt string
+}
+
+func (deletion *Deletion) MigrateDeletion(db *gorm.DB) error {
+	// 自動マイグレーション(Deletionテーブルを作成)
+	migrateErr := db.AutoMigrate(&Deletion{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// ユーザーグループのゴミ箱を削除の新しい順に取得する
+func FetchDeletions(db *gorm.DB, userGroupID uint) ([]DeletionResponse, error) {
+	var rows []struct {
+		Deletion
+		ActorName string
+	}
+
+	result := db.Model(&Deletion{}).
+		Select("deletions.*, users.name AS actor_name").
+		Joins("LEFT JOIN users ON users.id = deletions.actor_id AND users.deleted_at IS NULL").
+		Where("deletions.user_group_id = ?", userGroupID).
+		Order("deletions.id desc").
+		Scan(&rows)
+
+	if result.Error != nil {
+		log.Printf("Error fetching deletions: %v\n", result.Error)
+		return nil, result.Error
+	}
+
+	deletionIDs := make([]uint, len(rows))
+	for i, row := range rows {
+		deletionIDs[i] = row.ID
+	}
+
+	var taskCounts []struct {
+		DeletionID uint
+		TaskCount  int64
+	}
+	result = db.Unscoped().Model(&Task{}).
+		Select("deletion_id, COUNT(*) AS task_count").
+		Where("deletion_id IN ?", deletionIDs).
+		Group("deletion_id").
+		Scan(&taskCounts)
+
+	if result.Error != nil {
+		log.Printf("Error counting deleted tasks: %v\n", result.Error)
+		return nil, result.Error
+	}
+	log.Printf("ゴミ箱の取得に成功")
+
+	taskCountByDeletion := map[uint]int64{}
+	for _, taskCount := range taskCounts {
+		taskCountByDeletion[taskCount.DeletionID] = taskCount.TaskCount
+	}
+
+	deletionResponses := make([]DeletionResponse, len(rows))
+	for i, row := range rows {
+		deletionResponses[i] = DeletionResponse{
+			ID:        row.ID,
+			ItemType:  row.ItemType,
+			ItemID:    row.ItemID,
+			ItemName:  row.ItemName,
+			TaskCount: taskCountByDeletion[row.ID],
+			Actor:     row.ActorID,
+			ActorName: row.ActorName,
+			DeletedAt: row.CreatedAt.Format("2006-01-02 15:04"),
+		}
+	}
+
+	return deletionResponses, nil
+}
+
+// ゴミ箱のデータを、一緒に削除した子のデータとあわせて復元する
+// 復元できるのは削除したデータを作成・変更できる役割のメンバー
+// パーソナルアクセストークンで認証した場合はscopesでも許可されている必要がある（nilの場合はスコープで制限しない）
+// 関連するデータが別の操作でゴミ箱にある場合や、同じ名前のカテゴリーがある場合は復元できない
+func RestoreDeletion(db *gorm.DB, deletionID int, userID uint, scopes []string) error {
+	user, err := FindUserByID(db, userID)
+	if err != nil {
+		return toAuthorizationError(err)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var deletion Deletion
+		if err := tx.Where("id = ? AND user_group_id = ?", deletionID, user.UserGroupID).First(&deletion).Error; err != nil {
+			log.Printf("Error fetching deletion with ID %d: %v\n", deletionID, err)
+			return toAuthorizationError(err)
+		}
+
+		if !HasPermission(user.Role, restorePermission(deletion.ItemType)) {
+			log.Printf("User %d (%s) cannot restore %s %d", userID, user.Role, deletion.ItemType, deletion.ItemID)
+			return ErrForbidden
+		}
+		if scopes != nil && !ScopesAllow(scopes, restorePermission(deletion.ItemType)) {
+			log.Printf("Personal access token of user %d does not have scope to restore %s %d", userID, deletion.ItemType, deletion.ItemID)
+			return ErrForbidden
+		}
+
+		return restoreDeletion(tx, deletion, userID)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("ゴミ箱からの復元に成功")
+
+	return nil
+}
+
+// ユーザーグループを削除したユーザーが、ゴミ箱にある自分のアカウントのメールアドレスとパスワードでユーザーグループを復元する
+// ユーザーグループと一緒に自分のアカウントもゴミ箱に移動してログインできないため、ゴミ箱の一覧を経由せずに復元できるようにする
+// 登録済みかどうかを推測されないように、存在しないユーザーとパスワード不一致は同じエラーにする
+func RestoreDeletedUserGroup(db *gorm.DB, email string, password string) (User, error) {
+	var user User
+	err := db.Unscoped().Where("email = ? AND deleted_at IS NOT NULL", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.CompareDummyPassword(password)
+		return User{}, ErrInvalidCredentials
+	} else if err != nil {
+		log.Printf("Error fetching deleted user: %v\n", err)
+		return User{}, err
+	}
+	if !user.VerifyPassword(password) {
+		log.Printf("Password of deleted user %d does not match", user.ID)
+		return User{}, ErrInvalidCredentials
+	}
+	if user.DeletionID == nil {
+		return User{}, ErrNotFound
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 復元できるのは自分が削除したユーザーグループのみ
+		var deletion Deletion
+		if err := tx.Where("id = ? AND item_type = ? AND actor_id = ?", *user.DeletionID, DeletionItemUserGroup, user.ID).First(&deletion).Error; err != nil {
+			log.Printf("Error fetching user group deletion of user %d: %v\n", user.ID, err)
+			return toAuthorizationError(err)
+		}
+
+		return restoreDeletion(tx, deletion, user.ID)
+	})
+	if err != nil {
+		return User{}, err
+	}
+	log.Printf("ユーザーグループの復元に成功")
+
+	return user, nil
+}
+
+// beforeより前にゴミ箱に移動したデータを完全に削除し、削除した削除操作の件数を返す
+func PurgeDeletions(db *gorm.DB, before time.Time) (int, error) {
+	var ids []uint
+	if err := db.Model(&Deletion{}).Where("created_at < ?", before).Order("id asc").Pluck("id", &ids).Error; err != nil {
+		log.Printf("Error fetching deletions: %v\n", err)
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range ids {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return purgeDeletion(tx, id)
+		})
+		if err != nil {
+			log.Printf("Error purging deletion %d: %v", id, err)
+			return purged, err
+		}
+		purged++
+	}
+	log.Printf("ゴミ箱のデータの削除に成功: %d件", purged)
+
+	return purged, nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// 削除操作を記録し、論理削除するデータに設定するIDを返す
+func createDeletion(tx *gorm.DB, userGroupID uint, actorID uint, itemType string, itemID uint, itemName string) (uint, error) {
+	deletion := Deletion{
+		UserGroupID: userGroupID,
+		ActorID:     actorID,
+		ItemType:    itemType,
+		ItemID:      itemID,
+		ItemName:    itemName,
+	}
+	if err := tx.Create(&deletion).Error; err != nil {
+		return 0, fmt.Errorf("error creating deletion: %v", err)
+	}
+
+	return deletion.ID, nil
+}
+
+// 削除されていないデータを論理削除し、削除操作のIDを設定する
+func softDelete(tx *gorm.DB, model interface{}, deletionID uint, query interface{}, args ...interface{}) error {
+	return tx.Model(model).
+		Where(query, args...).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "deletion_id": deletionID}).Error
+}
+
+// タスクをサブタスクとあわせてゴミ箱に移動する
+// タスクごとに削除の変更履歴を記録し、計測中のタイマーを終了し、コメントもあわせて論理削除する
+func softDeleteTasks(tx *gorm.DB, actorID uint, deletionID uint, query interface{}, args ...interface{}) error {
+	var tasks []Task
+	if err := tx.Where(query, args...).Find(&tasks).Error; err != nil {
+		return fmt.Errorf("error fetching tasks: %v", err)
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]uint, len(tasks))
+	for i, task := range tasks {
+		taskIDs[i] = task.ID
+	}
+
+	var subtasks []Task
+	if err := tx.Where("parent_id IN ? AND id NOT IN ?", taskIDs, taskIDs).Find(&subtasks).Error; err != nil {
+		return fmt.Errorf("error fetching subtasks: %v", err)
+	}
+	for _, subtask := range subtasks {
+		tasks = append(tasks, subtask)
+		taskIDs = append(taskIDs, subtask.ID)
+	}
+
+	for _, task := range tasks {
+		if err := recordTaskHistory(tx, task.ID, actorID, &task, nil); err != nil {
+			return err
+		}
+	}
+
+	if err := stopTaskTimers(tx, taskIDs); err != nil {
+		return err
+	}
+
+	if err := softDelete(tx, &Comment{}, deletionID, "task_id IN ?", taskIDs); err != nil {
+		return fmt.Errorf("error deleting comments: %v", err)
+	}
+
+	if err := softDelete(tx, &Task{}, deletionID, "id IN ?", taskIDs); err != nil {
+		return fmt.Errorf("error deleting tasks: %v", err)
+	}
+
+	return nil
+}
+
+// 削除操作でゴミ箱に移動したデータを復元し、削除操作をゴミ箱から除く
+// 復元したタスクにはuserIDのユーザーの変更履歴として記録する
+func restoreDeletion(tx *gorm.DB, deletion Deletion, userID uint) error {
+	if err := validateRestore(tx, deletion.ID); err != nil {
+		return err
+	}
+
+	// 別の操作でゴミ箱にある親タスクからは切り離す
+	trashedTaskIDs := tx.Unscoped().Model(&Task{}).Select("id").Where("deleted_at IS NOT NULL AND (deletion_id IS NULL OR deletion_id <> ?)", deletion.ID)
+	result := tx.Unscoped().Model(&Task{}).
+		Where("deletion_id = ? AND parent_id IN (?)", deletion.ID, trashedTaskIDs).
+		Update("parent_id", nil)
+	if result.Error != nil {
+		log.Printf("Error detaching subtasks: %v\n", result.Error)
+		return result.Error
+	}
+
+	var tasks []Task
+	if err := tx.Unscoped().Where("deletion_id = ?", deletion.ID).Find(&tasks).Error; err != nil {
+		log.Printf("Error fetching deleted tasks: %v\n", err)
+		return err
+	}
+
+	for _, model := range []interface{}{&UserGroup{}, &User{}, &TwoFactorAuth{}, &RecoveryCode{}, &OIDCIdentity{}, &Category{}, &Task{}, &Comment{}} {
+		result := tx.Unscoped().Model(model).
+			Where("deletion_id = ?", deletion.ID).
+			Updates(map[string]interface{}{"deleted_at": nil, "deletion_id": nil})
+		if result.Error != nil {
+			log.Printf("Error restoring deleted data: %v\n", result.Error)
+			return result.Error
+		}
+	}
+
+	for _, task := range tasks {
+		if err := createTaskHistory(tx, task.ID, userID, TaskHistoryActionRestore, diffTaskFields(nil, &task)); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Delete(&deletion).Error; err != nil {
+		log.Printf("Error deleting deletion: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// 復元するデータが、別の操作でゴミ箱にあるデータや削除されていないデータと矛盾しないか確認する
+func validateRestore(tx *gorm.DB, deletionID uint) error {
+	trashed := func(model interface{}) *gorm.DB {
+		return tx.Unscoped().Model(model).Select("id").Where("deleted_at IS NOT NULL AND (deletion_id IS NULL OR deletion_id <> ?)", deletionID)
+	}
+
+	checks := []*gorm.DB{
+		tx.Unscoped().Model(&Task{}).Where("deletion_id = ?", deletionID).
+			Where("category_id IN (?) OR creator IN (?) OR responsible IN (?)", trashed(&Category{}), trashed(&User{}), trashed(&User{})),
+		tx.Unscoped().Model(&Category{}).Where("deletion_id = ? AND user_group_id IN (?)", deletionID, trashed(&UserGroup{})),
+		tx.Unscoped().Model(&User{}).Where("deletion_id = ? AND user_group_id IN (?)", deletionID, trashed(&UserGroup{})),
+	}
+	for _, check := range checks {
+		var conflictCount int64
+		if err := check.Count(&conflictCount).Error; err != nil {
+			log.Printf("Error validating restore: %v\n", err)
+			return err
+		}
+		if conflictCount > 0 {
+			log.Printf("Deletion %d has %d items depending on other deletions", deletionID, conflictCount)
+			return ErrRestoreConflict
+		}
+	}
+
+	var duplicateCount int64
+	result := tx.Unscoped().Table("categories AS deleted").
+		Joins("JOIN categories ON categories.user_group_id = deleted.user_group_id AND categories.category = deleted.category AND categories.deleted_at IS NULL").
+		Where("deleted.deletion_id = ?", deletionID).
+		Count(&duplicateCount)
+	if result.Error != nil {
+		log.Printf("Error validating restore: %v\n", result.Error)
+		return result.Error
+	}
+	if duplicateCount > 0 {
+		log.Printf("Deletion %d has categories with duplicate names", deletionID)
+		return ErrRestoreDuplicateCategory
+	}
+
+	return nil
+}
+
+// 削除操作でゴミ箱に移動したデータを完全に削除する
+// 削除するユーザーやカテゴリーを参照するため、別の操作でゴミ箱に移動したデータもあわせて削除する
+func purgeDeletion(tx *gorm.DB, deletionID uint) error {
+	var userGroupIDs, userIDs, categoryIDs, taskIDs []uint
+
+	if err := tx.Unscoped().Model(&UserGroup{}).Where("deletion_id = ?", deletionID).Pluck("id", &userGroupIDs).Error; err != nil {
+		return fmt.Errorf("error fetching user groups: %v", err)
+	}
+
+	if err := tx.Unscoped().Model(&User{}).
+		Where("deleted_at IS NOT NULL AND (deletion_id = ? OR user_group_id IN ?)", deletionID, userGroupIDs).
+		Pluck("id", &userIDs).Error; err != nil {
+		return fmt.Errorf("error fetching users: %v", err)
+	}
+
+	if err := tx.Unscoped().Model(&Category{}).
+		Where("deleted_at IS NOT NULL AND (deletion_id = ? OR user_group_id IN ?)", deletionID, userGroupIDs).
+		Pluck("id", &categoryIDs).Error; err != nil {
+		return fmt.Errorf("error fetching categories: %v", err)
+	}
+
+	if err := tx.Unscoped().Model(&Task{}).
+		Where("deleted_at IS NOT NULL AND (deletion_id = ? OR category_id IN ? OR creator IN ? OR responsible IN ?)", deletionID, categoryIDs, userIDs, userIDs).
+		Pluck("id", &taskIDs).Error; err != nil {
+		return fmt.Errorf("error fetching tasks: %v", err)
+	}
+
+	if err := deleteTaskComments(tx, taskIDs); err != nil {
+		return err
+	}
+	if err := tx.Where("task_id IN ?", taskIDs).Delete(&TaskHistory{}).Error; err != nil {
+		return fmt.Errorf("error deleting task histories: %v", err)
+	}
+	if err := tx.Unscoped().Where("id IN ?", taskIDs).Delete(&Task{}).Error; err != nil {
+		return fmt.Errorf("error deleting tasks: %v", err)
+	}
+
+	if err := deleteUserAuthRecords(tx, userIDs); err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("id IN ?", userIDs).Delete(&User{}).Error; err != nil {
+		return fmt.Errorf("error deleting users: %v", err)
+	}
+
+	if err := tx.Unscoped().Where("id IN ?", categoryIDs).Delete(&Category{}).Error; err != nil {
+		return fmt.Errorf("error deleting categories: %v", err)
+	}
+
+	if err := tx.Unscoped().Where("id IN ?", userGroupIDs).Delete(&UserGroup{}).Error; err != nil {
+		return fmt.Errorf("error deleting user groups: %v", err)
+	}
+
+	// 完全に削除したデータの削除操作もゴミ箱から除く
+	result := tx.Where("id = ?", deletionID).
+		Or("item_type = ? AND item_id IN ?", DeletionItemTask, taskIDs).
+		Or("item_type = ? AND item_id IN ?", DeletionItemUser, userIDs).
+		Or("item_type = ? AND item_id IN ?", DeletionItemCategory, categoryIDs).
+		Or("item_type = ? AND item_id IN ?", DeletionItemUserGroup, userGroupIDs).
+		Delete(&Deletion{})
+	if result.Error != nil {
+		return fmt.Errorf("error deleting deletions: %v", result.Error)
+	}
+
+	return nil
+}
+
+// 同じメールアドレスで登録し直せるよう、ゴミ箱にあるユーザーのメールアドレスを使われないアドレスに変更する
+// ユーザーグループの削除などでほかのデータと一緒にゴミ箱に移動している場合もあるため、削除操作は完全に削除しない
+// 復元したユーザーは変更後のアドレスのままになる
+func releaseDeletedUserEmail(tx *gorm.DB, email string) error {
+	var user User
+	err := tx.Unscoped().Where("email = ? AND deleted_at IS NOT NULL", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	log.Printf("Releasing email of deleted user %d", user.ID)
+	return tx.Unscoped().Model(&User{}).Where("id = ?", user.ID).Update("email", releasedEmail(user.ID)).Error
+}
+
+// 同じIdPのアカウントで紐付け直せるよう、ゴミ箱にあるユーザーの紐付けだけを完全に削除する
+func releaseDeletedOIDCIdentity(tx *gorm.DB, issuer string, subject string) error {
+	result := tx.Unscoped().Where("issuer = ? AND subject = ? AND deleted_at IS NOT NULL", issuer, subject).Delete(&OIDCIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Released deleted oidc identity for subject %s", subject)
+	}
+
+	return nil
+}
+
+// ゴミ箱にあるユーザーのメールアドレスを置き換えるアドレス（.invalidは予約済みのドメイン）
+func releasedEmail(userID uint) string {
+	return fmt.Sprintf("deleted-user-%d@lookback.invalid", userID)
+}
+
+// ゴミ箱のデータの種類ごとに、復元に必要な権限を返す
+func restorePermission(itemType string) Permission {
+	switch itemType {
+	case DeletionItemTask:
+		return PermissionWriteTasks
+	case DeletionItemCategory:
+		return PermissionWriteCategories
+	case DeletionItemUser:
+		return PermissionManageMembers
+	default:
+		return PermissionDeleteUserGroup
+	}
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestTrash(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	admin := &User{Name: "Admin", Password: "TestPassword", Email: "admin@example.com", UserGroupID: userGroup.ID, Role: RoleAdmin}
+	db.Create(admin)
+	member := &User{Name: "Member", Password: "TestPassword", Email: "member@example.com", UserGroupID: userGroup.ID, Role: RoleMember}
+	db.Create(member)
+	category := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(category)
+	newTask := func(name string, responsible uint) *Task {
+		task := &Task{Task: name, Description: "TestDescription", Creator: admin.ID, CategoryID: category.ID, Status: 1, Responsible: responsible, Estimate: ptrToUint(5), StartDate: ptrToTime(time.Now())}
+		db.Create(task)
+		return task
+	}
+	task := newTask("Task", admin.ID)
+	memberTask := newTask("MemberTask", member.ID)
+
+	fetchDeletion := func(itemType string, itemID uint) DeletionResponse {
+		deletions, err := FetchDeletions(db, userGroup.ID)
+		assert.Nil(t, err)
+		for _, deletion := range deletions {
+			if deletion.ItemType == itemType && deletion.ItemID == itemID {
+				return deletion
+			}
+		}
+		t.Fatalf("deletion not found: %s %d", itemType, itemID)
+		return DeletionResponse{}
+	}
+
+	t.Run("カテゴリーの削除と復元", func(t *testing.T) {
+		assert.Nil(t, category.DeleteCategoryAndRelatedTasks(db, int(category.ID), admin.ID))
+
+		deletion := fetchDeletion(DeletionItemCategory, category.ID)
+		assert.Equal(t, "TestCategory", deletion.ItemName)
+		assert.Equal(t, int64(2), deletion.TaskCount)
+		assert.Equal(t, "Admin", deletion.ActorName)
+
+		var count int64
+		db.Model(&Task{}).Where("category_id = ?", category.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		// 同じ名前のカテゴリーがある場合は復元できない
+		duplicate := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+		db.Create(duplicate)
+		assert.Equal(t, ErrRestoreDuplicateCategory, RestoreDeletion(db, int(deletion.ID), admin.ID, nil))
+		db.Unscoped().Delete(duplicate)
+
+		// メンバーはカテゴリーを復元できない
+		assert.Equal(t, ErrForbidden, RestoreDeletion(db, int(deletion.ID), member.ID, nil))
+
+		assert.Nil(t, RestoreDeletion(db, int(deletion.ID), admin.ID, nil))
+		db.Model(&Task{}).Where("category_id = ?", category.ID).Count(&count)
+		assert.Equal(t, int64(2), count)
+
+		history, err := FetchTaskHistory(db, int(task.ID))
+		assert.Nil(t, err)
+		assert.Equal(t, TaskHistoryActionRestore, history[len(history)-1].Action)
+	})
+
+	t.Run("失敗_関連するデータがゴミ箱にある", func(t *testing.T) {
+		assert.Nil(t, memberTask.DeleteTask(db, int(memberTask.ID), admin.ID))
+		taskDeletion := fetchDeletion(DeletionItemTask, memberTask.ID)
+		assert.Nil(t, member.DeleteUserAndRelatedTasks(db, member.ID))
+
+		// 担当者がゴミ箱にあるタスクは復元できない
+		assert.Equal(t, ErrRestoreConflict, RestoreDeletion(db, int(taskDeletion.ID), admin.ID, nil))
+
+		userDeletion := fetchDeletion(DeletionItemUser, member.ID)
+		assert.Nil(t, RestoreDeletion(db, int(userDeletion.ID), admin.ID, nil))
+		assert.Nil(t, RestoreDeletion(db, int(taskDeletion.ID), admin.ID, nil))
+
+		var restored Task
+		assert.Nil(t, db.First(&restored, memberTask.ID).Error)
+	})
+
+	t.Run("ユーザーの削除と復元_二要素認証とIdPの紐付け", func(t *testing.T) {
+		leaving := &User{Name: "Leaving", Password: "TestPassword", Email: "leaving@example.com", UserGroupID: userGroup.ID, Role: RoleMember}
+		db.Create(leaving)
+		enabledAt := time.Now()
+		db.Create(&TwoFactorAuth{UserID: leaving.ID, Secret: "TESTSECRET", EnabledAt: &enabledAt})
+		db.Create(&RecoveryCode{UserID: leaving.ID, CodeHash: "TestCodeHash"})
+		db.Create(&OIDCIdentity{UserID: leaving.ID, Issuer: "https://idp.example.com", Subject: "leaving"})
+		db.Create(&PersonalAccessToken{UserID: leaving.ID, Name: "TestToken", TokenHash: "TestTokenHash"})
+
+		assert.Nil(t, leaving.DeleteUserAndRelatedTasks(db, leaving.ID))
+
+		var count int64
+		for _, model := range []interface{}{&TwoFactorAuth{}, &RecoveryCode{}, &OIDCIdentity{}, &PersonalAccessToken{}} {
+			db.Model(model).Where("user_id = ?", leaving.ID).Count(&count)
+			assert.Equal(t, int64(0), count)
+		}
+
+		// 二要素認証の設定とIdPの紐付けはユーザーと一緒に復元し、アクセストークンは復元しない
+		deletion := fetchDeletion(DeletionItemUser, leaving.ID)
+		assert.Nil(t, RestoreDeletion(db, int(deletion.ID), admin.ID, nil))
+
+		enabled, err := IsTwoFactorEnabled(db, leaving.ID)
+		assert.Nil(t, err)
+		assert.True(t, enabled)
+		for _, model := range []interface{}{&RecoveryCode{}, &OIDCIdentity{}} {
+			db.Model(model).Where("user_id = ?", leaving.ID).Count(&count)
+			assert.Equal(t, int64(1), count)
+		}
+		db.Unscoped().Model(&PersonalAccessToken{}).Where("user_id = ?", leaving.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		deleteUserAuthRecords(db, []uint{leaving.ID})
+		db.Unscoped().Delete(leaving)
+	})
+
+	t.Run("ユーザーグループの削除と復元", func(t *testing.T) {
+		deletedGroup := &UserGroup{UserGroup: "DeletedUserGroup"}
+		db.Create(deletedGroup)
+		hashedPassword, err := utils.HashPassword("TestPassword")
+		assert.Nil(t, err)
+		owner := &User{Name: "Owner", Password: hashedPassword, Email: "owner@example.com", UserGroupID: deletedGroup.ID, Role: RoleOwner}
+		db.Create(owner)
+		groupMember := &User{Name: "GroupMember", Password: hashedPassword, Email: "group-member@example.com", UserGroupID: deletedGroup.ID, Role: RoleMember}
+		db.Create(groupMember)
+		groupCategory := &Category{Category: "GroupCategory", UserGroupID: deletedGroup.ID}
+		db.Create(groupCategory)
+		groupTask := &Task{Task: "GroupTask", Description: "TestDescription", Creator: owner.ID, CategoryID: groupCategory.ID, Status: 1, Responsible: groupMember.ID, Estimate: ptrToUint(5), StartDate: ptrToTime(time.Now())}
+		db.Create(groupTask)
+
+		assert.Nil(t, deletedGroup.DeleteUserGroupAndRelatedUsers(db, int(deletedGroup.ID), owner.ID))
+
+		// 削除したオーナーもメンバーと一緒にゴミ箱に移動する
+		for _, user := range []*User{owner, groupMember} {
+			err = db.First(&User{}, user.ID).Error
+			assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		}
+		deletions, err := FetchDeletions(db, deletedGroup.ID)
+		assert.Nil(t, err)
+		assert.Len(t, deletions, 1)
+		assert.Equal(t, DeletionItemUserGroup, deletions[0].ItemType)
+		assert.Equal(t, int64(1), deletions[0].TaskCount)
+
+		// パスワードが違う場合と、削除していないメンバーは復元できない
+		_, err = RestoreDeletedUserGroup(db, owner.Email, "WrongPassword")
+		assert.Equal(t, ErrInvalidCredentials, err)
+		_, err = RestoreDeletedUserGroup(db, groupMember.Email, "TestPassword")
+		assert.Equal(t, ErrNotFound, err)
+
+		// ゴミ箱にあるメンバーのメールアドレスで登録しても、ユーザーグループのゴミ箱は削除されない
+		reregistered, err := (&User{Name: "Reregistered", Password: "TestPassword", Email: groupMember.Email, UserGroupID: userGroup.ID}).CreateUser(db)
+		assert.Nil(t, err)
+		assert.Nil(t, db.First(&Deletion{}, deletions[0].ID).Error)
+
+		// 削除したオーナーは自分の認証情報でユーザーグループを復元できる
+		restoredOwner, err := RestoreDeletedUserGroup(db, owner.Email, "TestPassword")
+		assert.Nil(t, err)
+		assert.Equal(t, owner.ID, restoredOwner.ID)
+		assert.Nil(t, db.First(&User{}, owner.ID).Error)
+		assert.Nil(t, db.First(&UserGroup{}, deletedGroup.ID).Error)
+		assert.Nil(t, db.First(&Category{}, groupCategory.ID).Error)
+		assert.Nil(t, db.First(&Task{}, groupTask.ID).Error)
+
+		// メールアドレスを登録し直されたメンバーは別のアドレスで復元する
+		var restoredMember User
+		assert.Nil(t, db.First(&restoredMember, groupMember.ID).Error)
+		assert.NotEqual(t, groupMember.Email, restoredMember.Email)
+
+		db.Where("task_id = ?", groupTask.ID).Delete(&TaskHistory{})
+		db.Where("user_group_id = ?", deletedGroup.ID).Delete(&Deletion{})
+		db.Unscoped().Delete(groupTask)
+		db.Unscoped().Delete(groupCategory)
+		db.Unscoped().Delete(groupMember)
+		db.Unscoped().Delete(reregistered)
+		db.Unscoped().Delete(owner)
+		db.Unscoped().Delete(deletedGroup)
+	})
+
+	t.Run("完全に削除", func(t *testing.T) {
+		assert.Nil(t, task.DeleteTask(db, int(task.ID), admin.ID))
+		deletion := fetchDeletion(DeletionItemTask, task.ID)
+
+		// 保存期間内のデータは削除しない
+		purged, err := PurgeDeletions(db, time.Now().Add(-time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, 0, purged)
+
+		purged, err = PurgeDeletions(db, time.Now().Add(time.Minute))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, purged)
+
+		err = db.Unscoped().First(&Task{}, task.ID).Error
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		assert.Equal(t, ErrNotFound, RestoreDeletion(db, int(deletion.ID), admin.ID, nil))
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Where("task_id IN ?", []uint{task.ID, memberTask.ID}).Delete(&TaskHistory{})
+	db.Where("user_group_id = ?", userGroup.ID).Delete(&Deletion{})
+	db.Unscoped().Delete(memberTask)
+	db.Unscoped().Delete(category)
+	db.Unscoped().Delete(admin)
+	db.Unscoped().Delete(member)
+	db.Unscoped().Delete(userGroup)
+}
@@ -0,0 +1,292 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("二要素認証は既に有効です")
+	ErrTwoFactorNotEnrolled    = errors.New("二要素認証の登録を開始してください")
+	ErrTwoFactorNotEnabled     = errors.New("二要素認証は有効になっていません")
+	ErrInvalidTwoFactorCode    = errors.New("認証コードが正しくありません")
+)
+
+// 二要素認証(TOTP)テーブル定義
+// EnabledAtがnilの間は登録途中（確認コード未入力）
+type TwoFactorAuth struct {
+	gorm.Model
+	UserID       uint   `gorm:"not null;unique"`
+	User         User   `gorm:"foreignKey:UserID"`
+	Secret       string `gorm:"size:64;not null"`
+	LastUsedStep int64  `gorm:"not null;default:0"` // 同じコードの再利用を防ぐ
+	EnabledAt    *time.Time
+	DeletionID   *uint `gorm:"index"` // ユーザーと一緒にゴミ箱に移動した削除操作
+}
+
+// リカバリーコードテーブル定義
+// コードは平文で保存せず、ダイジェストのみ保存する
+type RecoveryCode struct {
+	gorm.Model
+	UserID     uint   `gorm:"not null;index"`
+	User       User   `gorm:"foreignKey:UserID"`
+	CodeHash   string `gorm:"size:64;not null"`
+	UsedAt     *time.Time
+	DeletionID *uint `gorm:"index"` // ユーザーと一緒にゴミ箱に移動した削除操作
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorDisableInput struct {
+	Password string `json:"password" binding:"required,min=8,max=255"`
+}
+
+// ログイン時の二要素認証の入力値（Codeには認証コードかリカバリーコードを入力する）
+type TwoFactorVerifyInput struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type TwoFactorEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+func (twoFactorAuth *TwoFactorAuth) MigrateTwoFactorAuth(db *gorm.DB) error {
+	// 自動マイグレーション(TwoFactorAuthテーブルとRecoveryCodeテーブルを作成)
+	migrateErr := db.AutoMigrate(&TwoFactorAuth{}, &RecoveryCode{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	return nil
+}
+
+// 二要素認証の登録を開始し、認証アプリに登録するシークレットを発行する
+// 登録途中のシークレットがあれば作り直す
+func StartTwoFactorEnrollment(db *gorm.DB, user User) (TwoFactorEnrollmentResponse, error) {
+	var response TwoFactorEnrollmentResponse
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("Error generating TOTP secret: %v\n", err)
+		return response, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var existing TwoFactorAuth
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", user.ID).First(&existing).Error
+		if err == nil && existing.EnabledAt != nil {
+			return ErrTwoFactorAlreadyEnabled
+		} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Error fetching two factor auth: %v\n", err)
+			return err
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&TwoFactorAuth{}).Error; err != nil {
+			log.Printf("Error deleting two factor auth: %v\n", err)
+			return err
+		}
+
+		twoFactorAuth := &TwoFactorAuth{
+			UserID: user.ID,
+			Secret: secret,
+		}
+		if err := tx.Create(twoFactorAuth).Error; err != nil {
+			log.Printf("Error creating two factor auth: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return response, err
+	}
+	log.Printf("二要素認証の登録の開始に成功")
+
+	response = TwoFactorEnrollmentResponse{
+		Secret:     secret,
+		OtpauthURI: utils.TOTPURI(user.Email, secret),
+	}
+	return response, nil
+}
+
+// 認証アプリのコードを確認して二要素認証を有効にし、リカバリーコードを発行する
+// リカバリーコードは平文で返すのはこの時だけ
+func ConfirmTwoFactorEnrollment(db *gorm.DB, userID uint, code string) ([]string, error) {
+	var recoveryCodes []string
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var twoFactorAuth TwoFactorAuth
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&twoFactorAuth).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTwoFactorNotEnrolled
+			}
+			log.Printf("Error fetching two factor auth: %v\n", err)
+			return err
+		}
+
+		if twoFactorAuth.EnabledAt != nil {
+			return ErrTwoFactorAlreadyEnabled
+		}
+
+		step, ok := utils.ValidateTOTPCode(twoFactorAuth.Secret, code, time.Now())
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+
+		now := time.Now()
+		if err := tx.Model(&twoFactorAuth).Updates(map[string]interface{}{
+			"enabled_at":     now,
+			"last_used_step": step,
+		}).Error; err != nil {
+			log.Printf("Error enabling two factor auth: %v\n", err)
+			return err
+		}
+
+		codes, err := replaceRecoveryCodes(tx, userID)
+		if err != nil {
+			return err
+		}
+		recoveryCodes = codes
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("二要素認証の有効化に成功")
+
+	return recoveryCodes, nil
+}
+
+// 二要素認証を無効にし、リカバリーコードも削除する
+func DisableTwoFactor(db *gorm.DB, userID uint) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("user_id = ? AND enabled_at IS NOT NULL", userID).Delete(&TwoFactorAuth{})
+		if result.Error != nil {
+			log.Printf("Error deleting two factor auth: %v\n", result.Error)
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTwoFactorNotEnabled
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			log.Printf("Error deleting recovery codes: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("二要素認証の無効化に成功")
+
+	return nil
+}
+
+// 二要素認証が有効か確認する
+func IsTwoFactorEnabled(db *gorm.DB, userID uint) (bool, error) {
+	var count int64
+	result := db.Model(&TwoFactorAuth{}).Where("user_id = ? AND enabled_at IS NOT NULL", userID).Count(&count)
+	if result.Error != nil {
+		log.Printf("Error fetching two factor auth: %v\n", result.Error)
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// ログイン時の認証コードまたはリカバリーコードを検証する
+// 一度使ったコードは再利用できない
+func VerifyTwoFactorCode(db *gorm.DB, userID uint, code string) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var twoFactorAuth TwoFactorAuth
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND enabled_at IS NOT NULL", userID).
+			First(&twoFactorAuth).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTwoFactorNotEnabled
+			}
+			log.Printf("Error fetching two factor auth: %v\n", err)
+			return err
+		}
+
+		if step, ok := utils.ValidateTOTPCode(twoFactorAuth.Secret, code, time.Now()); ok {
+			if step <= twoFactorAuth.LastUsedStep {
+				log.Printf("TOTP code reused for user %d", userID)
+				return ErrInvalidTwoFactorCode
+			}
+			return tx.Model(&twoFactorAuth).Update("last_used_step", step).Error
+		}
+
+		return useRecoveryCode(tx, userID, code)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("二要素認証に成功")
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// 既存のリカバリーコードを削除し、新しいリカバリーコードを発行する
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		log.Printf("Error deleting recovery codes: %v\n", err)
+		return nil, err
+	}
+
+	codes := make([]string, 0, constant.RECOVERY_CODE_COUNT)
+	records := make([]RecoveryCode, 0, constant.RECOVERY_CODE_COUNT)
+	for i := 0; i < constant.RECOVERY_CODE_COUNT; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			log.Printf("Error generating recovery code: %v\n", err)
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, RecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashRecoveryCode(code),
+		})
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		log.Printf("Error creating recovery codes: %v\n", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func useRecoveryCode(tx *gorm.DB, userID uint, code string) error {
+	result := tx.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, utils.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		log.Printf("Error using recovery code: %v\n", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	log.Printf("User %d used a recovery code", userID)
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+	"time"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/utils"
+)
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+
+	user := &User{
+		Name:        "TestUser",
+		Password:    "TestPassword",
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(user)
+
+	// 確認コードを入力するまでは有効にならない
+	enrollment, err := StartTwoFactorEnrollment(db, *user)
+	assert.Nil(t, err)
+	assert.Contains(t, enrollment.OtpauthURI, enrollment.Secret)
+	enabled, _ := IsTwoFactorEnabled(db, user.ID)
+	assert.False(t, enabled)
+
+	_, err = ConfirmTwoFactorEnrollment(db, user.ID, "000000")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	code, _ := utils.GenerateTOTPCode(enrollment.Secret, time.Now())
+	recoveryCodes, err := ConfirmTwoFactorEnrollment(db, user.ID, code)
+	assert.Nil(t, err)
+	assert.Len(t, recoveryCodes, constant.RECOVERY_CODE_COUNT)
+	enabled, _ = IsTwoFactorEnabled(db, user.ID)
+	assert.True(t, enabled)
+
+	// 有効化に使ったコードはログインに再利用できない
+	err = VerifyTwoFactorCode(db, user.ID, code)
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	// リカバリーコードは一度だけ使える
+	err = VerifyTwoFactorCode(db, user.ID, recoveryCodes[0])
+	assert.Nil(t, err)
+	err = VerifyTwoFactorCode(db, user.ID, recoveryCodes[0])
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	// 無効にするとリカバリーコードも削除される
+	err = DisableTwoFactor(db, user.ID)
+	assert.Nil(t, err)
+	enabled, _ = IsTwoFactorEnabled(db, user.ID)
+	assert.False(t, enabled)
+	var count int64
+	db.Model(&RecoveryCode{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// テストデータの削除
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
+}
@@ -1,11 +1,12 @@
 package models
 
 import (
-	"crypto/sha256"
 	"fmt"
 	"log"
 
 	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/utils"
 )
 
 type User struct {
@@ -15,6 +16,8 @@ type User struct {
 	Email       string    `gorm:"size:255;not null;unique" validate:"required,email"`
 	UserGroupID uint      `gorm:"not null"`
 	UserGroup   UserGroup `gorm:"foreignKey:UserGroupID;"`
+	Role        string    `gorm:"size:20;not null;default:member"`
+	DeletionID  *uint     `gorm:"index"` // ゴミ箱に移動した削除操作
 }
 
 type UserLoginInput struct {
@@ -22,18 +25,20 @@ type UserLoginInput struct {
 	Password string `json:"password" binding:"required,min=8,max=255"`
 }
 
+// メールアドレスは登録用メールのトークンから取得する
 type UserSignUpInput struct {
-	Name      string `json:"username" binding:"required,min=1,max=30"`
-	Password  string `json:"password" binding:"required,min=8,max=255"`
-	Email     string `json:"email" binding:"required,email"`
-	UserGroup string `json:"user_group" binding:"required,min=1,max=30"`
+	Name       string `json:"username" binding:"required,min=1,max=30"`
+	Password   string `json:"password" binding:"required,min=8,max=255"`
+	EmailToken string `json:"email_token" binding:"required"`
+	UserGroup  string `json:"user_group" binding:"required,min=1,max=30"`
 }
 
+// メールアドレスとユーザーグループは招待メールのトークンから取得する
 type UserInviteSignUpInput struct {
-	Name      string `json:"username" binding:"required,min=1,max=30"`
-	Password  string `json:"password" binding:"required,min=8,max=255"`
-	Email     string `json:"email" binding:"required,email"`
-	UserGroup uint   `json:"user_group" binding:"required"`
+	Name           string `json:"username" binding:"required,min=1,max=30"`
+	Password       string `json:"password" binding:"required,min=8,max=255"`
+	EmailToken     string `json:"email_token" binding:"required"`
+	UserGroupToken string `json:"user_group_token" binding:"required"`
 }
 
 type EmailUpdateInput struct {
@@ -50,17 +55,19 @@ type UserPasswordUpdateInput struct {
 }
 
 type UserPasswordResetInput struct {
-	Email    string `json:"email" binding:"required,email"`
+	Token    string `json:"token" binding:"required"`
 	Password string `json:"password" binding:"required,min=8,max=255"`
 }
 
+// 招待メールのユーザーグループのトークンで参加するユーザーグループを指定する
 type UserGroupUpdateInput struct {
-	NewUserGroupID uint `json:"user_group_id" binding:"required,min=1"`
+	UserGroupToken string `json:"user_group_token" binding:"required"`
 }
 
 type UserResponse struct {
 	ID   uint
 	Name string
+	Role string
 }
 
 type CurrentUserResponse struct {
@@ -69,6 +76,7 @@ type CurrentUserResponse struct {
 	Name        string
 	UserGroupID uint
 	UserGroup   string
+	Role        string
 }
 
 // TableName メソッドを追加して、この構造体がユーザーテーブルに対応することを指定する
@@ -89,7 +97,8 @@ func (user *User) MigrateUser(db *gorm.DB) error {
 		return migrateErr
 	}
 
-	return nil
+	// 役割を導入する前に作成されたユーザーグループにオーナーを設定
+	return assignMissingOwners(db)
 }
 
 func (user *User) CreateUser(db *gorm.DB) (*User, error) {
@@ -100,11 +109,27 @@ func (user *User) CreateUser(db *gorm.DB) (*User, error) {
 		return nil, fmt.Errorf("入力したメールアドレスは登録済みです")
 	}
 
+	// 退会してゴミ箱にあるユーザーのメールアドレスは登録し直せる
+	if err := releaseDeletedUserEmail(db, user.Email); err != nil {
+		log.Printf("Error releasing email of deleted user: %v", err)
+		return nil, err
+	}
+
+	hashedPassword, err := utils.HashPassword(user.Password)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		return nil, err
+	}
+
 	user = &User{
 		Name:        user.Name,
-		Password:    Encrypt(user.Password),
+		Password:    hashedPassword,
 		Email:       user.Email,
 		UserGroupID: user.UserGroupID,
+		Role:        user.Role,
+	}
+	if user.Role == "" {
+		user.Role = RoleMember
 	}
 	result := db.Create(user)
 
@@ -119,7 +144,7 @@ func (user *User) CreateUser(db *gorm.DB) (*User, error) {
 
 func FindUserByIDWithoutPassword(db *gorm.DB, userID uint) (CurrentUserResponse, error) {
 	var user CurrentUserResponse
-	result := db.Table("users").Select("users.id, users.email, users.name, users.user_group_id, user_groups.user_group").
+	result := db.Table("users").Select("users.id, users.email, users.name, users.user_group_id, user_groups.user_group, users.role").
 		Joins("left join user_groups on user_groups.id = users.user_group_id").
 		Where("users.id = ?", userID).
 		First(&user)
@@ -181,8 +206,8 @@ func FindUsersAll(db *gorm.DB, userID uint) ([]UserResponse, error) {
 
 	var users []UserResponse
 	result := db.
-		Select("id", "Name").
-		Where("user_group_id = ?", userGroupID).
+		Select("id", "Name", "Role").
+		Where("user_group_id = ? AND deleted_at IS NULL", userGroupID).
 		Order("Name asc").
 		Find(&users)
 
@@ -209,6 +234,12 @@ func (user *User) UpdateEmail(db *gorm.DB, userID uint) error {
 		return fmt.Errorf("入力したメールアドレス他のユーザーが登録済みです")
 	}
 
+	// 退会してゴミ箱にあるユーザーのメールアドレスには変更できる
+	if err := releaseDeletedUserEmail(db, user.Email); err != nil {
+		log.Printf("Error releasing email of deleted user: %v", err)
+		return err
+	}
+
 	result := db.Model(user).Where("id = ?", userID).Updates(User{
 		Email: user.Email,
 	})
@@ -250,47 +281,104 @@ func (user *User) UpdateUsername(db *gorm.DB, userID uint) error {
 }
 
 func (user *User) UpdateUserPassword(db *gorm.DB, userID uint) error {
-	result := db.Model(user).Where("id = ?", userID).Updates(User{
-		Password: Encrypt(user.Password),
-	})
+	hashedPassword, err := utils.HashPassword(user.Password)
+	if err != nil {
+		log.Printf("Error hashing password: %v\n", err)
+		return err
+	}
 
-	if result.Error != nil {
-		log.Printf("Error updating user: %v\n", result.Error)
-		return result.Error
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(user).Where("id = ?", userID).Updates(User{
+			Password: hashedPassword,
+		})
+		if result.Error != nil {
+			log.Printf("Error updating user: %v\n", result.Error)
+			return result.Error
+		}
+
+		// パスワードを変更したらすべてのセッションを失効させる
+		return RevokeAllSessions(tx, userID)
+	})
+	if err != nil {
+		return err
 	}
 	log.Printf("ログインユーザーのパスワードの更新に成功")
 
 	return nil
 }
 
-func (user *User) ResetUserPassword(db *gorm.DB) error {
-	result := db.Model(user).Where("email = ?", user.Email).Updates(User{
-		Password: Encrypt(user.Password),
-	})
+// パスワードリセットトークンを検証し、トークンに紐づくユーザーのパスワードを更新する
+func (user *User) ResetUserPassword(db *gorm.DB, resetToken string) error {
+	hashedPassword, err := utils.HashPassword(user.Password)
+	if err != nil {
+		log.Printf("Error hashing password: %v\n", err)
+		return err
+	}
 
-	if result.Error != nil {
-		log.Printf("Error updating user: %v\n", result.Error)
-		return result.Error
+	err = db.Transaction(func(tx *gorm.DB) error {
+		targetUser, err := consumePasswordResetToken(tx, resetToken)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&User{}).Where("id = ?", targetUser.ID).Updates(User{
+			Password: hashedPassword,
+		})
+		if result.Error != nil {
+			log.Printf("Error updating user: %v\n", result.Error)
+			return result.Error
+		}
+
+		// 同じユーザーに発行済みの他のトークンも失効させる
+		if err := RevokePasswordResetTokens(tx, targetUser.ID); err != nil {
+			return err
+		}
+
+		// パスワードを変更したらすべてのセッションを失効させる
+		if err := RevokeAllSessions(tx, targetUser.ID); err != nil {
+			return err
+		}
+
+		user.ID = targetUser.ID
+		user.Email = targetUser.Email
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	log.Printf("パスワードのリセットに成功")
 
 	return nil
 }
 
+// 他のユーザーグループに移動したユーザーはメンバーとして参加する
 func (user *User) UpdateUserGroup(db *gorm.DB, userID uint) error {
-	result := db.Model(user).Where("id = ?", userID).Updates(User{
-		UserGroupID: user.UserGroupID,
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureOwnerCanLeave(tx, userID); err != nil {
+			return err
+		}
+
+		result := tx.Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"user_group_id": user.UserGroupID,
+			"role":          RoleMember,
+		})
+		if result.Error != nil {
+			log.Printf("Error updating user: %v\n", result.Error)
+			return result.Error
+		}
+
+		return nil
 	})
-
-	if result.Error != nil {
-		log.Printf("Error updating user: %v\n", result.Error)
-		return result.Error
+	if err != nil {
+		return err
 	}
 	log.Printf("ログインユーザーのユーザーグループの更新に成功")
 
 	return nil
 }
 
+// ユーザーを作成者または担当者であるタスクとあわせてゴミ箱に移動する
+// セッションや各種トークンは復元しないが、二要素認証の設定とIdPのアカウントの紐付けは復元する
 func (user *User) DeleteUserAndRelatedTasks(db *gorm.DB, id uint) error {
 	tx := db.Begin()
 	if tx.Error != nil {
@@ -298,13 +386,38 @@ func (user *User) DeleteUserAndRelatedTasks(db *gorm.DB, id uint) error {
 		return tx.Error
 	}
 
-	if err := deleteUserTasks(tx, id); err != nil {
+	if err := ensureOwnerCanLeave(tx, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var deleteUser User
+	if err := tx.Where("id = ?", id).First(&deleteUser).Error; err != nil {
+		log.Printf("Error fetching user with ID %d: %v\n", id, err)
+		tx.Rollback()
+		return err
+	}
+
+	deletionID, err := createDeletion(tx, deleteUser.UserGroupID, id, DeletionItemUser, deleteUser.ID, deleteUser.Name)
+	if err != nil {
+		log.Println(err)
+		tx.Rollback()
+		return err
+	}
+
+	if err := deleteUserTasks(tx, id, deletionID); err != nil {
+		log.Println(err)
+		tx.Rollback()
+		return err
+	}
+
+	if err := revokeUserAuthRecords(tx, []uint{id}, deletionID); err != nil {
 		log.Println(err)
 		tx.Rollback()
 		return err
 	}
 
-	if err := tx.Unscoped().Where("id = ?", id).Delete(&User{}).Error; err != nil {
+	if err := softDelete(tx, &User{}, deletionID, "id = ?", id); err != nil {
 		log.Printf("Error deleting user: %v\n", err)
 		tx.Rollback()
 		return err
@@ -320,10 +433,124 @@ func (user *User) DeleteUserAndRelatedTasks(db *gorm.DB, id uint) error {
 }
 
 func (u *User) VerifyPassword(inputPassword string) bool {
-	return u.Password == Encrypt(inputPassword)
+	return utils.ComparePassword(u.Password, inputPassword)
 }
 
-func Encrypt(char string) string {
-	encryptText := fmt.Sprintf("%x", sha256.Sum256([]byte(char)))
-	return encryptText
-}
\ No newline at end of file
+// 保存済みのハッシュが旧形式または古いコストの場合に、検証済みのパスワードで再ハッシュする
+func (u *User) RehashPasswordIfNeeded(db *gorm.DB, inputPassword string) error {
+	if !utils.PasswordNeedsRehash(u.Password) {
+		return nil
+	}
+
+	hashedPassword, err := utils.HashPassword(inputPassword)
+	if err != nil {
+		log.Printf("Error hashing password: %v\n", err)
+		return err
+	}
+
+	result := db.Model(&User{}).Where("id = ?", u.ID).Update("password", hashedPassword)
+	if result.Error != nil {
+		log.Printf("Error rehashing password: %v\n", result.Error)
+		return result.Error
+	}
+	u.Password = hashedPassword
+	log.Printf("パスワードの再ハッシュに成功")
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// ゴミ箱に移動するユーザーのセッションや各種トークンを削除する
+// 二要素認証の設定とIdPのアカウントの紐付けは、ユーザーと一緒に復元できるよう削除操作のIDを設定して論理削除する
+func revokeUserAuthRecords(tx *gorm.DB, userIDs []uint, deletionID uint) error {
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&Session{}).Error; err != nil {
+		return fmt.Errorf("error deleting sessions: %v", err)
+	}
+
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&PasswordResetToken{}).Error; err != nil {
+		return fmt.Errorf("error deleting password reset tokens: %v", err)
+	}
+
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&EmailChangeRequest{}).Error; err != nil {
+		return fmt.Errorf("error deleting email change requests: %v", err)
+	}
+
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&PersonalAccessToken{}).Error; err != nil {
+		return fmt.Errorf("error deleting personal access tokens: %v", err)
+	}
+
+	if err := softDelete(tx, &TwoFactorAuth{}, deletionID, "user_id IN ?", userIDs); err != nil {
+		return fmt.Errorf("error deleting two factor auth: %v", err)
+	}
+
+	if err := softDelete(tx, &RecoveryCode{}, deletionID, "user_id IN ?", userIDs); err != nil {
+		return fmt.Errorf("error deleting recovery codes: %v", err)
+	}
+
+	if err := softDelete(tx, &OIDCIdentity{}, deletionID, "user_id IN ?", userIDs); err != nil {
+		return fmt.Errorf("error deleting oidc identities: %v", err)
+	}
+
+	return nil
+}
+
+// ユーザーに紐づくセッションや各種トークンを、ゴミ箱にあるものも含めて完全に削除する
+func deleteUserAuthRecords(tx *gorm.DB, userIDs []uint) error {
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&Session{}).Error; err != nil {
+		return fmt.Errorf("error deleting sessions: %v", err)
+	}
+
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&PasswordResetToken{}).Error; err != nil {
+		return fmt.Errorf("error deleting password reset tokens: %v", err)
+	}
+
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&EmailChangeRequest{}).Error; err != nil {
+		return fmt.Errorf("error deleting email change requests: %v", err)
+	}
+
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&TwoFactorAuth{}).Error; err != nil {
+		return fmt.Errorf("error deleting two factor auth: %v", err)
+	}
+
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&RecoveryCode{}).Error; err != nil {
+		return fmt.Errorf("error deleting recovery codes: %v", err)
+	}
+
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&PersonalAccessToken{}).Error; err != nil {
+		return fmt.Errorf("error deleting personal access tokens: %v", err)
+	}
+
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&OIDCIdentity{}).Error; err != nil {
+		return fmt.Errorf("error deleting oidc identities: %v", err)
+	}
+
+	return nil
+}
+
+// 他のメンバーが残っているユーザーグループのオーナーは、オーナーを譲渡するまで抜けられない
+func ensureOwnerCanLeave(tx *gorm.DB, userID uint) error {
+	var user User
+	if err := tx.Where("id = ?", userID).First(&user).Error; err != nil {
+		log.Printf("Error fetching user with ID %d: %v\n", userID, err)
+		return err
+	}
+
+	if user.Role != RoleOwner {
+		return nil
+	}
+
+	var memberCount int64
+	if err := tx.Model(&User{}).Where("user_group_id = ? AND id <> ?", user.UserGroupID, user.ID).Count(&memberCount).Error; err != nil {
+		log.Printf("Error counting users in user group %d: %v\n", user.UserGroupID, err)
+		return err
+	}
+
+	if memberCount > 0 {
+		log.Printf("Owner %d must transfer ownership of user group %d", user.ID, user.UserGroupID)
+		return ErrOwnerMustTransfer
+	}
+
+	return nil
+}
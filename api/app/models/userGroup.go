@@ -10,7 +10,8 @@ import (
 // ユーザーグループテーブル定義 
 type UserGroup struct {
 	gorm.Model
-	UserGroup string `gorm:"size:255;not null" validate:"required,min=1,max=30"`
+	UserGroup  string `gorm:"size:255;not null" validate:"required,min=1,max=30"`
+	DeletionID *uint  `gorm:"index"` // ゴミ箱に移動した削除操作
 }
 
 // ユーザーグループ作成の入力値
@@ -58,7 +59,7 @@ func (userGroup *UserGroup) CreateUserGroup(db *gorm.DB) (uint, error) {
 func FetchUserGroups(db *gorm.DB) ([]UserGroupResponse, error) {
 	var userGroups []UserGroupResponse
 
-	result := db.Select("id", "user_group").Order("user_group asc").Find(&userGroups)
+	result := db.Select("id", "user_group").Where("deleted_at IS NULL").Order("user_group asc").Find(&userGroups)
 
 	if result.Error != nil {
 		log.Printf("Error fetching user_group: %v", result.Error)
@@ -110,7 +111,9 @@ func (userGroup *UserGroup) UpdateUserGroup(db *gorm.DB, userGroupID int) error
 	return nil
 }
 
-func (userGroup *UserGroup) DeleteUserGroupAndRelatedUsers(db *gorm.DB, userGroupID int) error {
+// ユーザーグループを所属するユーザー、カテゴリー、タスクとあわせてゴミ箱に移動する
+// 削除したユーザーもゴミ箱に移動するため、復元はRestoreDeletedUserGroupで削除したユーザーの認証情報を使って行う
+func (userGroup *UserGroup) DeleteUserGroupAndRelatedUsers(db *gorm.DB, userGroupID int, actorID uint) error {
 
 	// トランザクションの開始
 	tx := db.Begin()
@@ -123,41 +126,59 @@ func (userGroup *UserGroup) DeleteUserGroupAndRelatedUsers(db *gorm.DB, userGrou
 	var checkUserGroup UserGroup
 	if err := db.Where("id = ?", userGroupID).First(&checkUserGroup).Error; err != nil {
 		log.Printf("UserGroup with ID %d does not exist: %v\n", userGroupID, err)
+		tx.Rollback()
 		return fmt.Errorf("指定されたユーザーグループは存在しません")
 	}
 
+	deletionID, err := createDeletion(tx, checkUserGroup.ID, actorID, DeletionItemUserGroup, checkUserGroup.ID, checkUserGroup.UserGroup)
+	if err != nil {
+		log.Println(err)
+		tx.Rollback()
+		return err
+	}
+
 	// 関連するユーザーの取得
 	var users []User
 	if err := tx.Where("user_group_id = ?", userGroupID).Find(&users).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
+	userIDs := make([]uint, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+
+	// 関連するユーザーとカテゴリーに紐づくタスクとコメントをゴミ箱に移動
+	groupCategoryIDs := tx.Model(&Category{}).Select("id").Where("user_group_id = ?", userGroupID)
+	if err := softDeleteTasks(tx, actorID, deletionID, "category_id IN (?) OR creator IN ? OR responsible IN ?", groupCategoryIDs, userIDs, userIDs); err != nil {
+		log.Println(err)
+		tx.Rollback()
+		return err
+	}
+	log.Printf("関連するタスクの削除に成功")
 
-	// 関連するユーザーに紐づくタスクの削除
-	for _, user := range users {
-		if err := tx.Unscoped().Where("creator = ? OR responsible = ?", user.ID, user.ID).Delete(&Task{}).Error; err != nil {
-			tx.Rollback()
-			return err
-		}
-		log.Printf("関連するタスクの削除に成功: ユーザーID %d", user.ID)
+	// 関連するユーザーのセッションや各種トークンの削除
+	if err := revokeUserAuthRecords(tx, userIDs, deletionID); err != nil {
+		tx.Rollback()
+		return err
 	}
 
-	// 関連するユーザーの削除
-	if err := tx.Unscoped().Where("user_group_id = ?", userGroupID).Delete(&User{}).Error; err != nil {
+	// 関連するユーザーをゴミ箱に移動
+	if err := softDelete(tx, &User{}, deletionID, "user_group_id = ?", userGroupID); err != nil {
 		tx.Rollback()
 		return err
 	}
 	log.Printf("関連するユーザーの削除に成功")
 
-	// 関連するカテゴリの削除
-	if err := tx.Unscoped().Where("user_group_id = ?", userGroupID).Delete(&Category{}).Error; err != nil {
+	// 関連するカテゴリをゴミ箱に移動
+	if err := softDelete(tx, &Category{}, deletionID, "user_group_id = ?", userGroupID); err != nil {
 		tx.Rollback()
 		return err
 	}
 	log.Printf("関連するカテゴリの削除に成功")
 
-	// ユーザーグループの削除
-	if err := tx.Unscoped().Delete(&UserGroup{}, userGroupID).Error; err != nil {
+	// ユーザーグループをゴミ箱に移動
+	if err := softDelete(tx, &UserGroup{}, deletionID, "id = ?", userGroupID); err != nil {
 		tx.Rollback()
 		return err
 	}
@@ -201,7 +201,7 @@ func TestDeleteUserGroupAndRelatedUsers(t *testing.T) {
 	db.Create(task)
 
 	// 正常ケースのテスト
-	err = userGroup.DeleteUserGroupAndRelatedUsers(db, int(userGroup.ID))
+	err = userGroup.DeleteUserGroupAndRelatedUsers(db, int(userGroup.ID), user1.ID)
 	assert.Nil(t, err)
 
 	// ユーザーグループと関連データが削除されたか確認
@@ -222,6 +222,6 @@ func TestDeleteUserGroupAndRelatedUsers(t *testing.T) {
 	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
 
 	// 存在しないUserGroupIDのケース
-	err = userGroup.DeleteUserGroupAndRelatedUsers(db, 9999) // 仮に存在しないIDとする
+	err = userGroup.DeleteUserGroupAndRelatedUsers(db, 9999, user1.ID) // 仮に存在しないIDとする
 	assert.NotNil(t, err)
 }
\ No newline at end of file
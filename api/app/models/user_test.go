@@ -1,12 +1,15 @@
 package models
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"testing"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/alicend/LookBack/app/constant"
+	"github.com/alicend/LookBack/app/utils"
 )
 
 func TestMigrateUser(t *testing.T) {
@@ -358,7 +361,7 @@ func TestUpdateUserPassword(t *testing.T) {
 
 	var updatedUser User
 	db.Where("id = ?", user.ID).First(&updatedUser)
-	assert.Equal(t, Encrypt("NewPassword"), updatedUser.Password)
+	assert.True(t, utils.ComparePassword(updatedUser.Password, "NewPassword"))
 
 	// テストデータの削除
 	db.Unscoped().Delete(&user)
@@ -386,17 +389,28 @@ func TestResetUserPassword(t *testing.T) {
 	}
 	db.Create(user)
 
+	resetToken, err := IssuePasswordResetToken(db, *user)
+	assert.Nil(t, err)
+
 	// パスワードリセット
-	err = user.ResetUserPassword(db)
+	resetUser := &User{Password: "TestNewPassword"}
+	err = resetUser.ResetUserPassword(db, resetToken)
 	assert.Nil(t, err)
+	assert.Equal(t, user.ID, resetUser.ID)
 
 	// 更新後のデータ取得
 	var updatedUser User
 	db.Where("id = ?", user.ID).First(&updatedUser)
 	// パスワードが更新されていることを確認
-	assert.NotEqual(t, "TestOldPassword", updatedUser.Password)
+	assert.True(t, updatedUser.VerifyPassword("TestNewPassword"))
+
+	// 同じトークンは再利用できない
+	reuseUser := &User{Password: "TestOtherPassword"}
+	err = reuseUser.ResetUserPassword(db, resetToken)
+	assert.ErrorIs(t, err, ErrInvalidPasswordResetToken)
 
 	// テストデータの削除
+	db.Unscoped().Where("user_id = ?", user.ID).Delete(&PasswordResetToken{})
 	db.Unscoped().Delete(&user)
 	db.Unscoped().Delete(&userGroup)
 }
@@ -438,8 +452,11 @@ func TestDeleteUserAndRelatedTasks(t *testing.T) {
 }
 
 func TestVerifyPassword(t *testing.T) {
+	hashedPassword, err := utils.HashPassword("password")
+	assert.Nil(t, err)
+
 	user := &User{
-			Password: Encrypt("password"),
+			Password: hashedPassword,
 	}
 
 	if !user.VerifyPassword("password") {
@@ -449,5 +466,51 @@ func TestVerifyPassword(t *testing.T) {
 	if user.VerifyPassword("wrong_password") {
 			t.Errorf("Password verification should fail for wrong password")
 	}
+
+	// 旧形式（SHA-256）のハッシュも検証できる
+	legacyUser := &User{
+			Password: fmt.Sprintf("%x", sha256.Sum256([]byte("password"))),
+	}
+
+	if !legacyUser.VerifyPassword("password") {
+			t.Errorf("Legacy password verification failed")
+	}
+}
+
+func TestRehashPasswordIfNeeded(t *testing.T) {
+	// テスト用MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	// テストデータ作成（旧形式のハッシュを持つユーザー）
+	userGroup := &UserGroup{
+		UserGroup: "TestUserGroup",
+	}
+	db.Create(userGroup)
+
+	legacyHash := fmt.Sprintf("%x", sha256.Sum256([]byte("password")))
+	user := &User{
+		Name:        "TestUser",
+		Password:    legacyHash,
+		Email:       "test@example.com",
+		UserGroupID: userGroup.ID,
+	}
+	db.Create(user)
+
+	err = user.RehashPasswordIfNeeded(db, "password")
+	assert.Nil(t, err)
+
+	// 新しい形式で保存され、同じパスワードで検証できることを確認
+	var updatedUser User
+	db.Where("id = ?", user.ID).First(&updatedUser)
+	assert.NotEqual(t, legacyHash, updatedUser.Password)
+	assert.False(t, utils.PasswordNeedsRehash(updatedUser.Password))
+	assert.True(t, updatedUser.VerifyPassword("password"))
+
+	// テストデータの削除
+	db.Unscoped().Delete(&user)
+	db.Unscoped().Delete(&userGroup)
 }
 
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+var ErrWipLimitExceeded = errors.New("WIP制限を超えるため変更できません")
+
+// タスクの作成・変更で超えるWIP制限
+// ステータスの制限の場合はResponsibleを0とする
+type WipLimitViolation struct {
+	Status              uint
+	StatusName          string
+	Responsible         uint
+	ResponsibleUserName string
+	Limit               uint
+	Count               int64 // 変更後のタスクの件数
+}
+
+// タスクボードに表示するWIP制限に対する現在の件数
+type WipCountResponse struct {
+	Status           uint
+	StatusName       string
+	WipLimit         uint // 0の場合は上限なし
+	Count            int64
+	AssigneeWipLimit uint // 0の場合は上限なし
+	Assignees        []AssigneeWipCountResponse
+}
+
+type AssigneeWipCountResponse struct {
+	Responsible         uint
+	ResponsibleUserName string
+	Count               int64
+}
+
+// タスクをstatusのステータス、responsibleの担当者にした場合に超えるWIP制限を返す
+// taskIDが0の場合は新しいタスクとする
+// 変更前から同じステータス（担当者の制限は同じ担当者）のタスクは件数が増えないため確認しない
+func CheckWipLimits(db *gorm.DB, userGroupID uint, taskID int, status uint, responsible uint) ([]WipLimitViolation, error) {
+	violations := []WipLimitViolation{}
+
+	workflow, err := fetchWorkflow(db, userGroupID)
+	if err != nil {
+		return nil, err
+	}
+	workflowStatus, ok := workflow.find(status)
+	if !ok || (workflowStatus.WipLimit == 0 && workflowStatus.AssigneeWipLimit == 0) {
+		return violations, nil
+	}
+
+	var before Task
+	if taskID != 0 {
+		if err := db.Where("id = ?", taskID).First(&before).Error; err != nil {
+			log.Printf("Error fetching task with ID %d: %v\n", taskID, err)
+			return nil, toAuthorizationError(err)
+		}
+	}
+	sameStatus := taskID != 0 && before.Status == status
+
+	statusTasks := func() *gorm.DB {
+		return db.Model(&Task{}).
+			Joins("JOIN categories ON tasks.category_id = categories.id AND categories.deleted_at IS NULL").
+			Where("tasks.status = ? AND categories.user_group_id = ? AND tasks.id <> ?", status, userGroupID, taskID)
+	}
+
+	if workflowStatus.WipLimit > 0 && !sameStatus {
+		var count int64
+		if err := statusTasks().Count(&count).Error; err != nil {
+			log.Printf("Error counting tasks: %v\n", err)
+			return nil, err
+		}
+		if count+1 > int64(workflowStatus.WipLimit) {
+			violations = append(violations, WipLimitViolation{
+				Status:     status,
+				StatusName: workflowStatus.Name,
+				Limit:      workflowStatus.WipLimit,
+				Count:      count + 1,
+			})
+		}
+	}
+
+	if workflowStatus.AssigneeWipLimit > 0 && !(sameStatus && before.Responsible == responsible) {
+		var count int64
+		if err := statusTasks().Where("tasks.responsible = ?", responsible).Count(&count).Error; err != nil {
+			log.Printf("Error counting tasks: %v\n", err)
+			return nil, err
+		}
+		if count+1 > int64(workflowStatus.AssigneeWipLimit) {
+			var user User
+			if err := db.Select("id, name").Where("id = ?", responsible).First(&user).Error; err != nil {
+				log.Printf("Error fetching user with ID %d: %v\n", responsible, err)
+				return nil, toAuthorizationError(err)
+			}
+			violations = append(violations, WipLimitViolation{
+				Status:              status,
+				StatusName:          workflowStatus.Name,
+				Responsible:         responsible,
+				ResponsibleUserName: user.Name,
+				Limit:               workflowStatus.AssigneeWipLimit,
+				Count:               count + 1,
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+// WIP制限のあるステータスごとに、現在のタスクの件数を取得する
+func FetchWipCounts(db *gorm.DB, userGroupID uint) ([]WipCountResponse, error) {
+	wipCounts := []WipCountResponse{}
+
+	workflow, err := fetchWorkflow(db, userGroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Status              uint
+		Responsible         uint
+		ResponsibleUserName string
+		TaskCount           int64
+	}
+	result := db.Model(&Task{}).
+		Select("tasks.status, tasks.responsible, users.name AS responsible_user_name, COUNT(*) AS task_count").
+		Joins("JOIN categories ON tasks.category_id = categories.id AND categories.deleted_at IS NULL").
+		Joins("JOIN users ON tasks.responsible = users.id").
+		Where("categories.user_group_id = ?", userGroupID).
+		Group("tasks.status, tasks.responsible, users.name").
+		Order("tasks.responsible asc").
+		Scan(&rows)
+	if result.Error != nil {
+		log.Printf("Error counting tasks: %v\n", result.Error)
+		return nil, result.Error
+	}
+	log.Printf("WIP制限に対する件数の取得に成功")
+
+	for _, workflowStatus := range workflow.statuses {
+		if workflowStatus.WipLimit == 0 && workflowStatus.AssigneeWipLimit == 0 {
+			continue
+		}
+
+		wipCount := WipCountResponse{
+			Status:           workflowStatus.Status,
+			StatusName:       workflowStatus.Name,
+			WipLimit:         workflowStatus.WipLimit,
+			AssigneeWipLimit: workflowStatus.AssigneeWipLimit,
+			Assignees:        []AssigneeWipCountResponse{},
+		}
+		for _, row := range rows {
+			if row.Status != workflowStatus.Status {
+				continue
+			}
+			wipCount.Count += row.TaskCount
+			if workflowStatus.AssigneeWipLimit > 0 {
+				wipCount.Assignees = append(wipCount.Assignees, AssigneeWipCountResponse{
+					Responsible:         row.Responsible,
+					ResponsibleUserName: row.ResponsibleUserName,
+					Count:               row.TaskCount,
+				})
+			}
+		}
+		wipCounts = append(wipCounts, wipCount)
+	}
+
+	return wipCounts, nil
+}
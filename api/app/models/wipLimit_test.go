@@ -0,0 +1,97 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestWipLimits(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	user := &User{Name: "TestUser", Password: "TestPassword", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(user)
+	otherUser := &User{Name: "OtherUser", Password: "TestPassword", Email: "other@example.com", UserGroupID: userGroup.ID}
+	db.Create(otherUser)
+	category := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(category)
+	newTask := func(name string, status uint, responsible uint) *Task {
+		task := &Task{Task: name, Description: "TestDescription", Creator: user.ID, CategoryID: category.ID, Status: status, Responsible: responsible, Estimate: ptrToUint(1), StartDate: ptrToTime(time.Now())}
+		db.Create(task)
+		return task
+	}
+	inProgress := newTask("InProgress", 2, user.ID)
+	todo := newTask("Todo", 1, user.ID)
+	otherTodo := newTask("OtherTodo", 1, otherUser.ID)
+
+	// 進行中は全体で2件、担当者ごとに1件まで
+	err = UpdateWorkflowStatus(db, userGroup.ID, 2, WorkflowStatusInput{Name: "進行中", Order: 2, Color: "#2196F3", Kind: WorkflowStatusKindActive, WipLimit: 2, AssigneeWipLimit: 1})
+	assert.Nil(t, err)
+
+	t.Run("担当者ごとの制限", func(t *testing.T) {
+		violations, err := CheckWipLimits(db, userGroup.ID, int(todo.ID), 2, user.ID)
+		assert.Nil(t, err)
+		assert.Len(t, violations, 1)
+		assert.Equal(t, user.ID, violations[0].Responsible)
+		assert.Equal(t, "TestUser", violations[0].ResponsibleUserName)
+		assert.Equal(t, int64(2), violations[0].Count)
+
+		// 変更前から同じステータスと担当者のタスクは確認しない
+		violations, err = CheckWipLimits(db, userGroup.ID, int(inProgress.ID), 2, user.ID)
+		assert.Nil(t, err)
+		assert.Len(t, violations, 0)
+
+		violations, err = CheckWipLimits(db, userGroup.ID, int(otherTodo.ID), 2, otherUser.ID)
+		assert.Nil(t, err)
+		assert.Len(t, violations, 0)
+	})
+
+	t.Run("ステータスの制限", func(t *testing.T) {
+		db.Model(otherTodo).Update("status", 2)
+
+		// 新しいタスクは全体の制限を超える
+		violations, err := CheckWipLimits(db, userGroup.ID, 0, 2, otherUser.ID)
+		assert.Nil(t, err)
+		assert.Len(t, violations, 2)
+		assert.Equal(t, uint(0), violations[0].Responsible)
+		assert.Equal(t, uint(2), violations[0].Limit)
+		assert.Equal(t, int64(3), violations[0].Count)
+
+		// 制限のないステータス
+		violations, err = CheckWipLimits(db, userGroup.ID, 0, 1, user.ID)
+		assert.Nil(t, err)
+		assert.Len(t, violations, 0)
+	})
+
+	t.Run("件数", func(t *testing.T) {
+		wipCounts, err := FetchWipCounts(db, userGroup.ID)
+		assert.Nil(t, err)
+		assert.Len(t, wipCounts, 1)
+		assert.Equal(t, uint(2), wipCounts[0].Status)
+		assert.Equal(t, int64(2), wipCounts[0].Count)
+		assert.Len(t, wipCounts[0].Assignees, 2)
+		assert.Equal(t, int64(1), wipCounts[0].Assignees[0].Count)
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Where("user_group_id = ?", userGroup.ID).Delete(&WorkflowStatus{})
+	db.Unscoped().Delete(inProgress)
+	db.Unscoped().Delete(todo)
+	db.Unscoped().Delete(otherTodo)
+	db.Unscoped().Delete(category)
+	db.Unscoped().Delete(otherUser)
+	db.Unscoped().Delete(user)
+	db.Unscoped().Delete(userGroup)
+}
@@ -0,0 +1,557 @@
+package models
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// ステータスの種類（タスクボードとLook Backのどちらに表示するかを決める）
+const (
+	WorkflowStatusKindTodo     = "todo"
+	WorkflowStatusKindActive   = "active"
+	WorkflowStatusKindDone     = "done"
+	WorkflowStatusKindArchived = "archived" // Look Backに表示する
+)
+
+var (
+	ErrInvalidTaskStatus          = errors.New("指定されたステータスは存在しません")
+	ErrInvalidWorkflowTransition  = errors.New("ステータスの遷移が正しくありません")
+	ErrStatusTransitionNotAllowed = errors.New("このステータスには変更できません")
+	ErrWorkflowStatusInUse        = errors.New("このステータスのタスクがあるため削除できません")
+	ErrWorkflowStatusRequired     = errors.New("未着手とLook Backの種類のステータスはそれぞれ1つ以上必要です")
+)
+
+// ユーザーグループごとのステータスのテーブル定義
+// タスクのStatusにはIDではなくユーザーグループ内の番号(Status)を保存する
+type WorkflowStatus struct {
+	ID          uint      `gorm:"primarykey"`
+	UserGroupID uint      `gorm:"not null;uniqueIndex:idx_workflow_status"`
+	UserGroup   UserGroup `gorm:"foreignKey:UserGroupID;constraint:OnDelete:CASCADE;"`
+	Status      uint      `gorm:"not null;uniqueIndex:idx_workflow_status"`
+	Name        string    `gorm:"size:50;not null"`
+	Order       uint      `gorm:"column:sort_order;not null"`
+	Color       string    `gorm:"size:7;not null"`
+	Kind        string    `gorm:"size:16;not null"`
+	// このステータスのタスクの上限（0の場合は上限なし）
+	WipLimit uint `gorm:"not null;default:0"`
+	// 担当者ごとのこのステータスのタスクの上限（0の場合は上限なし）
+	AssigneeWipLimit uint `gorm:"not null;default:0"`
+}
+
+// ステータスの遷移のテーブル定義
+// ユーザーグループに遷移が1件もない場合は、すべてのステータスの間で変更できる
+type WorkflowTransition struct {
+	ID          uint      `gorm:"primarykey"`
+	UserGroupID uint      `gorm:"not null;index"`
+	UserGroup   UserGroup `gorm:"foreignKey:UserGroupID;constraint:OnDelete:CASCADE;"`
+	FromStatus  uint      `gorm:"not null"`
+	ToStatus    uint      `gorm:"not null"`
+}
+
+type WorkflowStatusInput struct {
+	Name  string `json:"Name" binding:"required,max=50"`
+	Order uint   `json:"Order"`
+	Color string `json:"Color" binding:"required,hexcolor"`
+	Kind  string `json:"Kind" binding:"required,oneof=todo active done archived"`
+	// 0の場合は上限なし
+	WipLimit         uint `json:"WipLimit"`
+	AssigneeWipLimit uint `json:"AssigneeWipLimit"`
+}
+
+type WorkflowTransitionInput struct {
+	From uint `json:"From" binding:"required"`
+	To   uint `json:"To" binding:"required"`
+}
+
+// 遷移の一覧をすべて置き換える（空の場合はすべてのステータスの間で変更できる）
+type WorkflowTransitionsInput struct {
+	Transitions []WorkflowTransitionInput `json:"Transitions" binding:"dive"`
+}
+
+type WorkflowStatusResponse struct {
+	Status           uint
+	Name             string
+	Order            uint
+	Color            string
+	Kind             string
+	WipLimit         uint
+	AssigneeWipLimit uint
+}
+
+type WorkflowResponse struct {
+	Statuses    []WorkflowStatusResponse
+	Transitions []WorkflowTransitionInput
+}
+
+// 既存のタスクのステータス（1〜4）に対応するデフォルトのステータス
+var defaultWorkflowStatuses = []WorkflowStatus{
+	{Status: 1, Name: "未着", Order: 1, Color: "#9E9E9E", Kind: WorkflowStatusKindTodo},
+	{Status: 2, Name: "進行中", Order: 2, Color: "#2196F3", Kind: WorkflowStatusKindActive},
+	{Status: 3, Name: "完了", Order: 3, Color: "#4CAF50", Kind: WorkflowStatusKindDone},
+	{Status: 4, Name: "Look Back", Order: 4, Color: "#795548", Kind: WorkflowStatusKindArchived},
+}
+
+func (workflowStatus *WorkflowStatus) MigrateWorkflowStatus(db *gorm.DB) error {
+	// 自動マイグレーション(WorkflowStatus, WorkflowTransitionテーブルを作成)
+	migrateErr := db.AutoMigrate(&WorkflowStatus{}, &WorkflowTransition{})
+	if migrateErr != nil {
+		log.Printf("failed to migrate database: %v", migrateErr)
+		return migrateErr
+	}
+
+	// ステータスがないユーザーグループにデフォルトのステータスを作成する
+	var userGroupIDs []uint
+	result := db.Unscoped().Model(&UserGroup{}).
+		Where("id NOT IN (?)", db.Model(&WorkflowStatus{}).Select("user_group_id")).
+		Pluck("id", &userGroupIDs)
+	if result.Error != nil {
+		log.Printf("failed to migrate database: %v", result.Error)
+		return result.Error
+	}
+
+	for _, userGroupID := range userGroupIDs {
+		if err := createDefaultWorkflowStatuses(db, userGroupID); err != nil {
+			log.Printf("failed to migrate database: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ユーザーグループのステータスを並び順に、遷移とあわせて取得する
+func FetchWorkflow(db *gorm.DB, userGroupID uint) (WorkflowResponse, error) {
+	workflow, err := fetchWorkflow(db, userGroupID)
+	if err != nil {
+		return WorkflowResponse{}, err
+	}
+	log.Printf("ステータスの取得に成功")
+
+	response := WorkflowResponse{
+		Statuses:    make([]WorkflowStatusResponse, len(workflow.statuses)),
+		Transitions: []WorkflowTransitionInput{},
+	}
+	for i, workflowStatus := range workflow.statuses {
+		response.Statuses[i] = WorkflowStatusResponse{
+			Status:           workflowStatus.Status,
+			Name:             workflowStatus.Name,
+			Order:            workflowStatus.Order,
+			Color:            workflowStatus.Color,
+			Kind:             workflowStatus.Kind,
+			WipLimit:         workflowStatus.WipLimit,
+			AssigneeWipLimit: workflowStatus.AssigneeWipLimit,
+		}
+	}
+	for _, workflowStatus := range workflow.statuses {
+		for _, to := range workflow.statuses {
+			if workflow.transitions[workflowStatus.Status][to.Status] {
+				response.Transitions = append(response.Transitions, WorkflowTransitionInput{From: workflowStatus.Status, To: to.Status})
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// ステータスを追加する（番号はユーザーグループ内で最も大きい番号の次とする）
+func CreateWorkflowStatus(db *gorm.DB, userGroupID uint, input WorkflowStatusInput) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		workflow, err := ensureWorkflowStatuses(tx, userGroupID)
+		if err != nil {
+			return err
+		}
+
+		var status uint
+		for _, workflowStatus := range workflow.statuses {
+			if workflowStatus.Status > status {
+				status = workflowStatus.Status
+			}
+		}
+
+		workflowStatus := WorkflowStatus{
+			UserGroupID:      userGroupID,
+			Status:           status + 1,
+			Name:             input.Name,
+			Order:            input.Order,
+			Color:            input.Color,
+			Kind:             input.Kind,
+			WipLimit:         input.WipLimit,
+			AssigneeWipLimit: input.AssigneeWipLimit,
+		}
+		if err := tx.Create(&workflowStatus).Error; err != nil {
+			log.Printf("Error creating workflow status: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("ステータスの作成に成功")
+
+	return nil
+}
+
+// ステータスの名前、並び順、色、種類、WIP制限を変更する
+// 未着手とLook Backの種類の最後のステータスは別の種類に変更できない
+func UpdateWorkflowStatus(db *gorm.DB, userGroupID uint, status uint, input WorkflowStatusInput) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		workflow, err := ensureWorkflowStatuses(tx, userGroupID)
+		if err != nil {
+			return err
+		}
+
+		workflowStatus, ok := workflow.find(status)
+		if !ok {
+			return ErrNotFound
+		}
+		if workflowStatus.Kind != input.Kind && workflow.isLastOfRequiredKind(status) {
+			return ErrWorkflowStatusRequired
+		}
+
+		result := tx.Model(&WorkflowStatus{}).Where("id = ?", workflowStatus.ID).Updates(map[string]interface{}{
+			"name":               input.Name,
+			"sort_order":         input.Order,
+			"color":              input.Color,
+			"kind":               input.Kind,
+			"wip_limit":          input.WipLimit,
+			"assignee_wip_limit": input.AssigneeWipLimit,
+		})
+		if result.Error != nil {
+			log.Printf("Error updating workflow status: %v\n", result.Error)
+			return result.Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("ステータスの更新に成功")
+
+	return nil
+}
+
+// ステータスを削除し、あわせてそのステータスとの遷移も削除する
+// ゴミ箱にあるタスクを含め、このステータスのタスクがある場合は削除できない
+func DeleteWorkflowStatus(db *gorm.DB, userGroupID uint, status uint) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		workflow, err := ensureWorkflowStatuses(tx, userGroupID)
+		if err != nil {
+			return err
+		}
+
+		workflowStatus, ok := workflow.find(status)
+		if !ok {
+			return ErrNotFound
+		}
+		if workflow.isLastOfRequiredKind(status) {
+			return ErrWorkflowStatusRequired
+		}
+
+		var taskCount int64
+		result := tx.Unscoped().Model(&Task{}).
+			Joins("JOIN categories ON tasks.category_id = categories.id").
+			Where("tasks.status = ? AND categories.user_group_id = ?", status, userGroupID).
+			Count(&taskCount)
+		if result.Error != nil {
+			log.Printf("Error counting tasks: %v\n", result.Error)
+			return result.Error
+		}
+		if taskCount > 0 {
+			log.Printf("Workflow status %d of user group %d has %d tasks", status, userGroupID, taskCount)
+			return ErrWorkflowStatusInUse
+		}
+
+		if err := tx.Where("user_group_id = ? AND (from_status = ? OR to_status = ?)", userGroupID, status, status).Delete(&WorkflowTransition{}).Error; err != nil {
+			log.Printf("Error deleting workflow transitions: %v\n", err)
+			return err
+		}
+
+		if err := tx.Delete(&workflowStatus).Error; err != nil {
+			log.Printf("Error deleting workflow status: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("ステータスの削除に成功")
+
+	return nil
+}
+
+// ステータスの遷移をすべて置き換える
+func UpdateWorkflowTransitions(db *gorm.DB, userGroupID uint, transitions []WorkflowTransitionInput) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		workflow, err := ensureWorkflowStatuses(tx, userGroupID)
+		if err != nil {
+			return err
+		}
+
+		rows := []WorkflowTransition{}
+		added := map[WorkflowTransitionInput]bool{}
+		for _, transition := range transitions {
+			_, fromFound := workflow.find(transition.From)
+			_, toFound := workflow.find(transition.To)
+			if !fromFound || !toFound || transition.From == transition.To {
+				log.Printf("Invalid workflow transition from %d to %d", transition.From, transition.To)
+				return ErrInvalidWorkflowTransition
+			}
+			if added[transition] {
+				continue
+			}
+			added[transition] = true
+			rows = append(rows, WorkflowTransition{UserGroupID: userGroupID, FromStatus: transition.From, ToStatus: transition.To})
+		}
+
+		if err := tx.Where("user_group_id = ?", userGroupID).Delete(&WorkflowTransition{}).Error; err != nil {
+			log.Printf("Error deleting workflow transitions: %v\n", err)
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			log.Printf("Error creating workflow transitions: %v\n", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("ステータスの遷移の更新に成功")
+
+	return nil
+}
+
+// ステータスがユーザーグループに存在するか確認する
+func ValidateTaskStatus(db *gorm.DB, status uint, userGroupID uint) error {
+	workflow, err := fetchWorkflow(db, userGroupID)
+	if err != nil {
+		return err
+	}
+	if _, ok := workflow.find(status); !ok {
+		log.Printf("Status %d does not belong to user group %d", status, userGroupID)
+		return ErrInvalidTaskStatus
+	}
+
+	return nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// ユーザーグループのステータスと遷移
+type groupWorkflow struct {
+	statuses    []WorkflowStatus       // 並び順
+	transitions map[uint]map[uint]bool // 空の場合はすべてのステータスの間で変更できる
+}
+
+// ユーザーグループのステータスと遷移を取得する
+// ステータスを作成していないユーザーグループはデフォルトのステータスとする
+func fetchWorkflow(db *gorm.DB, userGroupID uint) (groupWorkflow, error) {
+	workflows, err := fetchWorkflows(db, []uint{userGroupID})
+	if err != nil {
+		return groupWorkflow{}, err
+	}
+
+	return workflows.of(userGroupID), nil
+}
+
+// ユーザーグループごとのステータスと遷移
+type userGroupWorkflows map[uint]groupWorkflow
+
+func (workflows userGroupWorkflows) of(userGroupID uint) groupWorkflow {
+	if workflow, ok := workflows[userGroupID]; ok {
+		return workflow
+	}
+	return groupWorkflow{statuses: defaultWorkflowStatuses, transitions: map[uint]map[uint]bool{}}
+}
+
+func fetchWorkflows(db *gorm.DB, userGroupIDs []uint) (userGroupWorkflows, error) {
+	workflows := userGroupWorkflows{}
+	if len(userGroupIDs) == 0 {
+		return workflows, nil
+	}
+
+	var statuses []WorkflowStatus
+	if err := db.Where("user_group_id IN ?", userGroupIDs).Order("sort_order asc, status asc").Find(&statuses).Error; err != nil {
+		log.Printf("Error fetching workflow statuses: %v\n", err)
+		return nil, err
+	}
+
+	var transitions []WorkflowTransition
+	if err := db.Where("user_group_id IN ?", userGroupIDs).Find(&transitions).Error; err != nil {
+		log.Printf("Error fetching workflow transitions: %v\n", err)
+		return nil, err
+	}
+
+	for _, workflowStatus := range statuses {
+		userGroupWorkflow, ok := workflows[workflowStatus.UserGroupID]
+		if !ok {
+			userGroupWorkflow = groupWorkflow{transitions: map[uint]map[uint]bool{}}
+		}
+		userGroupWorkflow.statuses = append(userGroupWorkflow.statuses, workflowStatus)
+		workflows[workflowStatus.UserGroupID] = userGroupWorkflow
+	}
+
+	for _, transition := range transitions {
+		userGroupWorkflow, ok := workflows[transition.UserGroupID]
+		if !ok {
+			continue
+		}
+		if userGroupWorkflow.transitions[transition.FromStatus] == nil {
+			userGroupWorkflow.transitions[transition.FromStatus] = map[uint]bool{}
+		}
+		userGroupWorkflow.transitions[transition.FromStatus][transition.ToStatus] = true
+	}
+
+	return workflows, nil
+}
+
+// カテゴリーのユーザーグループごとのステータスと遷移を、カテゴリーIDをキーとして取得する
+// ゴミ箱にあるカテゴリーのタスクも表示するため、論理削除したカテゴリーも含める
+type categoryWorkflows map[uint]groupWorkflow
+
+func (workflows categoryWorkflows) of(categoryID uint) groupWorkflow {
+	return userGroupWorkflows(workflows).of(categoryID)
+}
+
+func fetchCategoryWorkflows(db *gorm.DB, categoryIDs []uint) (categoryWorkflows, error) {
+	workflows := categoryWorkflows{}
+	if len(categoryIDs) == 0 {
+		return workflows, nil
+	}
+
+	var categories []Category
+	if err := db.Unscoped().Select("id, user_group_id").Where("id IN ?", categoryIDs).Find(&categories).Error; err != nil {
+		log.Printf("Error fetching categories: %v\n", err)
+		return nil, err
+	}
+
+	userGroupIDs := make([]uint, len(categories))
+	for i, category := range categories {
+		userGroupIDs[i] = category.UserGroupID
+	}
+	workflowsByUserGroup, err := fetchWorkflows(db, userGroupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, category := range categories {
+		workflows[category.ID] = workflowsByUserGroup.of(category.UserGroupID)
+	}
+
+	return workflows, nil
+}
+
+// タスクのカテゴリーのユーザーグループのステータスと遷移を取得する
+func fetchTaskWorkflow(db *gorm.DB, task Task) (groupWorkflow, error) {
+	workflows, err := fetchCategoryWorkflows(db, []uint{task.CategoryID})
+	if err != nil {
+		return groupWorkflow{}, err
+	}
+
+	return workflows.of(task.CategoryID), nil
+}
+
+// ユーザーグループのステータスを作成していなければデフォルトのステータスを作成してから取得する
+func ensureWorkflowStatuses(tx *gorm.DB, userGroupID uint) (groupWorkflow, error) {
+	var count int64
+	if err := tx.Model(&WorkflowStatus{}).Where("user_group_id = ?", userGroupID).Count(&count).Error; err != nil {
+		log.Printf("Error counting workflow statuses: %v\n", err)
+		return groupWorkflow{}, err
+	}
+	if count == 0 {
+		if err := createDefaultWorkflowStatuses(tx, userGroupID); err != nil {
+			log.Printf("Error creating workflow statuses: %v\n", err)
+			return groupWorkflow{}, err
+		}
+	}
+
+	return fetchWorkflow(tx, userGroupID)
+}
+
+func createDefaultWorkflowStatuses(tx *gorm.DB, userGroupID uint) error {
+	statuses := make([]WorkflowStatus, len(defaultWorkflowStatuses))
+	for i, workflowStatus := range defaultWorkflowStatuses {
+		workflowStatus.UserGroupID = userGroupID
+		statuses[i] = workflowStatus
+	}
+
+	return tx.Create(&statuses).Error
+}
+
+func (workflow groupWorkflow) find(status uint) (WorkflowStatus, bool) {
+	for _, workflowStatus := range workflow.statuses {
+		if workflowStatus.Status == status {
+			return workflowStatus, true
+		}
+	}
+	return WorkflowStatus{}, false
+}
+
+func (workflow groupWorkflow) kind(status uint) string {
+	workflowStatus, _ := workflow.find(status)
+	return workflowStatus.Kind
+}
+
+func (workflow groupWorkflow) name(status uint) string {
+	workflowStatus, ok := workflow.find(status)
+	if !ok {
+		return "Unknown status"
+	}
+	return workflowStatus.Name
+}
+
+// 完了またはLook Backの種類のステータスか
+func (workflow groupWorkflow) isDone(status uint) bool {
+	kind := workflow.kind(status)
+	return kind == WorkflowStatusKindDone || kind == WorkflowStatusKindArchived
+}
+
+// 指定した種類のステータスの番号を返す
+func (workflow groupWorkflow) statusesOf(kinds ...string) []uint {
+	statuses := []uint{}
+	for _, workflowStatus := range workflow.statuses {
+		for _, kind := range kinds {
+			if workflowStatus.Kind == kind {
+				statuses = append(statuses, workflowStatus.Status)
+			}
+		}
+	}
+	return statuses
+}
+
+// 新しいタスクのステータス（並び順が最初の未着手の種類のステータス）
+func (workflow groupWorkflow) initialStatus() uint {
+	statuses := workflow.statusesOf(WorkflowStatusKindTodo)
+	if len(statuses) == 0 {
+		return defaultWorkflowStatuses[0].Status
+	}
+	return statuses[0]
+}
+
+func (workflow groupWorkflow) canTransition(from uint, to uint) bool {
+	if from == to || len(workflow.transitions) == 0 {
+		return true
+	}
+	return workflow.transitions[from][to]
+}
+
+// 未着手とLook Backの種類で、最後の1つのステータスか
+func (workflow groupWorkflow) isLastOfRequiredKind(status uint) bool {
+	kind := workflow.kind(status)
+	if kind != WorkflowStatusKindTodo && kind != WorkflowStatusKindArchived {
+		return false
+	}
+	return len(workflow.statusesOf(kind)) == 1
+}
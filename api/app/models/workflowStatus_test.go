@@ -0,0 +1,122 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+func TestGroupWorkflow(t *testing.T) {
+	workflow := userGroupWorkflows{}.of(1)
+
+	// ステータスを作成していない場合はデフォルトのステータス
+	assert.Equal(t, "進行中", workflow.name(2))
+	assert.Equal(t, "Unknown status", workflow.name(5))
+	assert.True(t, workflow.isDone(3))
+	assert.True(t, workflow.isDone(4))
+	assert.False(t, workflow.isDone(2))
+	assert.Equal(t, []uint{4}, workflow.statusesOf(WorkflowStatusKindArchived))
+	assert.Equal(t, uint(1), workflow.initialStatus())
+	assert.True(t, workflow.isLastOfRequiredKind(1))
+	assert.False(t, workflow.isLastOfRequiredKind(2))
+
+	// 遷移がない場合はすべてのステータスの間で変更できる
+	assert.True(t, workflow.canTransition(1, 4))
+
+	workflow.transitions = map[uint]map[uint]bool{1: {2: true}}
+	assert.True(t, workflow.canTransition(1, 2))
+	assert.True(t, workflow.canTransition(3, 3))
+	assert.False(t, workflow.canTransition(1, 4))
+	assert.False(t, workflow.canTransition(2, 1))
+}
+
+func TestWorkflowStatuses(t *testing.T) {
+	// MySQLデータベースに接続
+	db, err := gorm.Open(mysql.Open(constant.TEST_DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to MySQL database: %v", err)
+	}
+
+	// テストデータの作成
+	userGroup := &UserGroup{UserGroup: "TestUserGroup"}
+	db.Create(userGroup)
+	user := &User{Name: "TestUser", Password: "TestPassword", Email: "test@example.com", UserGroupID: userGroup.ID}
+	db.Create(user)
+	category := &Category{Category: "TestCategory", UserGroupID: userGroup.ID}
+	db.Create(category)
+	task := &Task{Task: "TestTask", Description: "TestDescription", Creator: user.ID, CategoryID: category.ID, Status: 1, Responsible: user.ID, Estimate: ptrToUint(5), StartDate: ptrToTime(time.Now())}
+	db.Create(task)
+	taskID := int(task.ID)
+
+	t.Run("追加", func(t *testing.T) {
+		err := CreateWorkflowStatus(db, userGroup.ID, WorkflowStatusInput{Name: "レビュー", Order: 3, Color: "#FF9800", Kind: WorkflowStatusKindActive})
+		assert.Nil(t, err)
+
+		workflow, err := FetchWorkflow(db, userGroup.ID)
+		assert.Nil(t, err)
+		assert.Len(t, workflow.Statuses, 5)
+		assert.Equal(t, "レビュー", workflow.Statuses[2].Name)
+		assert.Equal(t, uint(5), workflow.Statuses[2].Status)
+	})
+
+	t.Run("遷移", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidWorkflowTransition, UpdateWorkflowTransitions(db, userGroup.ID, []WorkflowTransitionInput{{From: 1, To: 9}}))
+
+		transitions := []WorkflowTransitionInput{{From: 1, To: 2}, {From: 2, To: 5}, {From: 5, To: 3}, {From: 3, To: 4}}
+		assert.Nil(t, UpdateWorkflowTransitions(db, userGroup.ID, transitions))
+
+		// 許可していない遷移には変更できない
+		task.Status = 3
+		assert.Equal(t, ErrStatusTransitionNotAllowed, task.UpdateTask(db, taskID, false, user.ID))
+		assert.Equal(t, ErrStatusTransitionNotAllowed, UpdateTaskStatus(db, taskID, 4, false, user.ID))
+
+		task.Status = 2
+		assert.Nil(t, task.UpdateTask(db, taskID, false, user.ID))
+		task.Status = 9
+		assert.Equal(t, ErrInvalidTaskStatus, task.UpdateTask(db, taskID, false, user.ID))
+	})
+
+	t.Run("種類でタスクボードとLook Backを分ける", func(t *testing.T) {
+		// 進行中をLook Backの種類に変更する
+		err := UpdateWorkflowStatus(db, userGroup.ID, 2, WorkflowStatusInput{Name: "保留", Order: 2, Color: "#607D8B", Kind: WorkflowStatusKindArchived})
+		assert.Nil(t, err)
+
+		taskResponses, err := FetchLookBackTasks(db, user.ID, TaskQuery{})
+		assert.Nil(t, err)
+		assert.Len(t, taskResponses, 1)
+		assert.Equal(t, "保留", taskResponses[0].StatusName)
+		assert.Equal(t, WorkflowStatusKindArchived, taskResponses[0].StatusKind)
+
+		taskResponses, err = FetchTaskBoardTasks(db, user.ID, TaskQuery{})
+		assert.Nil(t, err)
+		assert.Len(t, taskResponses, 0)
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		// タスクがあるステータスは削除できない
+		assert.Equal(t, ErrWorkflowStatusInUse, DeleteWorkflowStatus(db, userGroup.ID, 2))
+		// 最後の未着手の種類のステータスは削除できない
+		assert.Equal(t, ErrWorkflowStatusRequired, DeleteWorkflowStatus(db, userGroup.ID, 1))
+		assert.Equal(t, ErrNotFound, DeleteWorkflowStatus(db, userGroup.ID, 9))
+
+		assert.Nil(t, DeleteWorkflowStatus(db, userGroup.ID, 5))
+		workflow, err := FetchWorkflow(db, userGroup.ID)
+		assert.Nil(t, err)
+		assert.Len(t, workflow.Statuses, 4)
+		assert.Len(t, workflow.Transitions, 2)
+	})
+
+	// 後処理: テスト用のデータを削除
+	db.Where("task_id = ?", task.ID).Delete(&TaskHistory{})
+	db.Where("user_group_id = ?", userGroup.ID).Delete(&WorkflowTransition{})
+	db.Where("user_group_id = ?", userGroup.ID).Delete(&WorkflowStatus{})
+	db.Unscoped().Delete(task)
+	db.Unscoped().Delete(category)
+	db.Unscoped().Delete(user)
+	db.Unscoped().Delete(userGroup)
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/alicend/LookBack/app/config"
 	"github.com/alicend/LookBack/app/controllers"
 	"github.com/alicend/LookBack/app/middleware"
+	"github.com/alicend/LookBack/app/models"
+	"github.com/alicend/LookBack/app/utils"
 )
 
 func SetupRouter(db *gorm.DB) *gin.Engine {
@@ -14,53 +16,140 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	// CORS設定
 	config.CorsSetting(r)
 
+	limiter := utils.NewRateLimiter(utils.GetRateLimitConfig())
 	handler := controllers.Handler{
 		DB: db,
 		MailSender: &controllers.ProductionMailSender{},
+		Limiter: limiter,
+		TaskConfig: utils.GetTaskConfig(),
+	}
+	if oidcConfig, enabled := utils.GetOIDCConfig(); enabled {
+		handler.OIDC = utils.NewOIDCProvider(oidcConfig)
+	}
+	if auditLogConfig := utils.GetAuditLogConfig(); auditLogConfig.FilePath != "" {
+		handler.AuditLogSink = utils.NewAuditLogSink(auditLogConfig.FilePath)
 	}
 	// ルーティング設定
 	api := r.Group("/api")
+	// クッキーで認証する状態変更リクエストはCSRFトークンを確認する
+	api.Use(middleware.CSRFMiddleware())
 	api.GET("/health_check", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"status": "UP",
 		})
 	})
 
+	// ユーザーグループ内の役割に応じて操作を制限する
+	can := func(permission models.Permission) gin.HandlerFunc {
+		return middleware.PermissionMiddleware(db, permission)
+	}
+
+	// IPアドレスごとのリクエスト数を制限する
+	loginRateLimit := middleware.RateLimitMiddleware(limiter, "login", limiter.Config.LoginPerIP)
+	emailRateLimit := middleware.RateLimitMiddleware(limiter, "email", limiter.Config.EmailPerIP)
+
 	auth := api.Group("/auth")
 	{
-		auth.POST("/signup/request", handler.SendSignUpEmailHandler)
-		auth.POST("/invite/request", handler.SendInviteEmailHandler)
+		auth.GET("/csrf", handler.GetCSRFTokenHandler)
+		auth.POST("/signup/request", emailRateLimit, handler.SendSignUpEmailHandler)
+		auth.POST("/invite/request", emailRateLimit, middleware.AuthMiddleware(db), can(models.PermissionSendInvitations), handler.SendInviteEmailHandler)
 		auth.POST("/signup", handler.SignUpHandler)
 		auth.POST("/invite/signup", handler.InviteSignUpHandler)
-		auth.POST("/login", handler.LoginHandler)
+		auth.POST("/login", loginRateLimit, handler.LoginHandler)
+		auth.POST("/mfa/verify", loginRateLimit, handler.VerifyTwoFactorHandler)
+		auth.POST("/user-group/restore", loginRateLimit, handler.RestoreDeletedUserGroupHandler)
+		auth.GET("/oidc/login", loginRateLimit, handler.OIDCLoginHandler)
+		auth.GET("/oidc/callback", loginRateLimit, handler.OIDCCallbackHandler)
+		auth.POST("/refresh", handler.RefreshSessionHandler)
 		auth.GET("/login/guest", handler.GuestLoginHandler)
 		auth.GET("/logout", handler.LogoutHandler)
 	}
 
 	tasks := api.Group("/tasks")
-	tasks.Use(middleware.AuthMiddleware)
+	tasks.Use(middleware.AuthMiddleware(db))
+	{
+		tasks.GET("/task-board", can(models.PermissionReadTasks), handler.GetTaskBoardTasksHandler)
+		tasks.GET("/look-back", can(models.PermissionReadTasks), handler.GetLookBackTasksHandler)
+		tasks.POST("", can(models.PermissionWriteTasks), handler.CreateTaskHandler)
+		tasks.PUT("/:taskId", can(models.PermissionWriteTasks), handler.UpdateTaskHandler)
+		tasks.PUT("/:taskId/to-completed", can(models.PermissionWriteTasks), handler.UpdateTaskToMoveToCompletedHandler)
+		tasks.PUT("/:taskId/move", can(models.PermissionWriteTasks), handler.MoveTaskHandler)
+		tasks.DELETE("/:taskId", can(models.PermissionWriteTasks), handler.DeleteTaskHandler)
+		tasks.GET("/:taskId/subtasks", can(models.PermissionReadTasks), handler.GetSubtasksHandler)
+		tasks.POST("/:taskId/subtasks", can(models.PermissionWriteTasks), handler.AddSubtaskHandler)
+		tasks.PUT("/:taskId/subtasks/order", can(models.PermissionWriteTasks), handler.ReorderSubtasksHandler)
+		tasks.DELETE("/:taskId/subtasks/:subtaskId", can(models.PermissionWriteTasks), handler.DetachSubtaskHandler)
+		tasks.GET("/:taskId/dependencies", can(models.PermissionReadTasks), handler.GetTaskDependenciesHandler)
+		tasks.POST("/:taskId/blocked-by", can(models.PermissionWriteTasks), handler.AddBlockerHandler)
+		tasks.DELETE("/:taskId/blocked-by/:blockerId", can(models.PermissionWriteTasks), handler.RemoveBlockerHandler)
+		tasks.POST("/:taskId/blocks", can(models.PermissionWriteTasks), handler.AddBlockedTaskHandler)
+		tasks.DELETE("/:taskId/blocks/:blockedId", can(models.PermissionWriteTasks), handler.RemoveBlockedTaskHandler)
+		tasks.GET("/:taskId/history", can(models.PermissionReadTasks), handler.GetTaskHistoryHandler)
+		tasks.GET("/:taskId/time-entries", can(models.PermissionReadTasks), handler.GetTimeEntriesHandler)
+		tasks.POST("/:taskId/time-entries", can(models.PermissionWriteTasks), handler.CreateTimeEntryHandler)
+		tasks.PUT("/:taskId/time-entries/:timeEntryId", can(models.PermissionWriteTasks), handler.UpdateTimeEntryHandler)
+		tasks.DELETE("/:taskId/time-entries/:timeEntryId", can(models.PermissionWriteTasks), handler.DeleteTimeEntryHandler)
+		tasks.PUT("/:taskId/timer/start", can(models.PermissionWriteTasks), handler.StartTimerHandler)
+		tasks.PUT("/:taskId/timer/stop", can(models.PermissionWriteTasks), handler.StopTimerHandler)
+		tasks.GET("/:taskId/comments", can(models.PermissionReadTasks), handler.GetCommentsHandler)
+		tasks.POST("/:taskId/comments", can(models.PermissionWriteTasks), handler.CreateCommentHandler)
+		tasks.PUT("/:taskId/comments/:commentId", can(models.PermissionWriteTasks), handler.UpdateCommentHandler)
+		tasks.DELETE("/:taskId/comments/:commentId", can(models.PermissionWriteTasks), handler.DeleteCommentHandler)
+	}
+
+	recurringTasks := api.Group("/recurring-tasks")
+	recurringTasks.Use(middleware.AuthMiddleware(db))
+	{
+		recurringTasks.GET("", can(models.PermissionReadTasks), handler.GetRecurringTasksHandler)
+		recurringTasks.POST("", can(models.PermissionWriteTasks), handler.CreateRecurringTaskHandler)
+		recurringTasks.PUT("/:recurringTaskId", can(models.PermissionWriteTasks), handler.UpdateRecurringTaskHandler)
+		recurringTasks.PUT("/:recurringTaskId/stop", can(models.PermissionWriteTasks), handler.StopRecurringTaskHandler)
+		recurringTasks.POST("/:recurringTaskId/skips", can(models.PermissionWriteTasks), handler.SkipRecurringTaskOccurrenceHandler)
+		recurringTasks.DELETE("/:recurringTaskId", can(models.PermissionWriteTasks), handler.DeleteRecurringTaskHandler)
+	}
+
+	workflow := api.Group("/workflow")
+	workflow.Use(middleware.AuthMiddleware(db))
+	{
+		workflow.GET("", can(models.PermissionReadTasks), handler.GetWorkflowHandler)
+		workflow.POST("/statuses", can(models.PermissionUpdateUserGroup), handler.CreateWorkflowStatusHandler)
+		workflow.PUT("/statuses/:status", can(models.PermissionUpdateUserGroup), handler.UpdateWorkflowStatusHandler)
+		workflow.DELETE("/statuses/:status", can(models.PermissionUpdateUserGroup), handler.DeleteWorkflowStatusHandler)
+		workflow.PUT("/transitions", can(models.PermissionUpdateUserGroup), handler.UpdateWorkflowTransitionsHandler)
+	}
+
+	labels := api.Group("/labels")
+	labels.Use(middleware.AuthMiddleware(db))
+	{
+		labels.GET("", can(models.PermissionReadCategories), handler.GetLabelsHandler)
+		labels.POST("", can(models.PermissionWriteCategories), handler.CreateLabelHandler)
+		labels.PUT("/:labelId", can(models.PermissionWriteCategories), handler.UpdateLabelHandler)
+		labels.DELETE("/:labelId", can(models.PermissionWriteCategories), handler.DeleteLabelHandler)
+		labels.POST("/:labelId/merge", can(models.PermissionWriteCategories), handler.MergeLabelHandler)
+	}
+
+	trash := api.Group("/trash")
+	trash.Use(middleware.AuthMiddleware(db))
 	{
-		tasks.GET("/task-board", handler.GetTaskBoardTasksHandler)
-		tasks.GET("/look-back", handler.GetLookBackTasksHandler)
-		tasks.POST("", handler.CreateTaskHandler)
-		tasks.PUT("/:taskId", handler.UpdateTaskHandler)
-		tasks.PUT("/:taskId/to-completed", handler.UpdateTaskToMoveToCompletedHandler)
-		tasks.DELETE("/:taskId", handler.DeleteTaskHandler)
+		trash.GET("", can(models.PermissionReadTasks), handler.GetTrashHandler)
+		trash.POST("/:deletionId/restore", handler.RestoreDeletionHandler)
 	}
 
 	category := api.Group("/categories")
-	category.Use(middleware.AuthMiddleware)
+	category.Use(middleware.AuthMiddleware(db))
 	{
-		category.GET("", handler.GetCategoryHandler)
-		category.POST("", handler.CreateCategoryHandler)
-		category.PUT("/:categoryId", handler.UpdateCategoryHandler)
-		category.DELETE("/:categoryId", handler.DeleteCategoryHandler)
+		category.GET("", can(models.PermissionReadCategories), handler.GetCategoryHandler)
+		category.POST("", can(models.PermissionWriteCategories), handler.CreateCategoryHandler)
+		category.PUT("/:categoryId", can(models.PermissionWriteCategories), handler.UpdateCategoryHandler)
+		category.DELETE("/:categoryId", can(models.PermissionWriteCategories), handler.DeleteCategoryHandler)
+		category.GET("/:categoryId/dependency-graph", can(models.PermissionReadTasks), handler.GetCategoryDependencyGraphHandler)
 	}
 
 	users := api.Group("/users")
 	users.PUT("/password", handler.ResetPasswordHandler)
-	users.POST("/password/request", handler.SendEmailResetPasswordHandler)
-	users.Use(middleware.AuthMiddleware)
+	users.POST("/password/request", emailRateLimit, handler.SendEmailResetPasswordHandler)
+	users.PUT("/email/cancel", handler.CancelEmailUpdateHandler)
+	users.Use(middleware.AuthMiddleware(db), middleware.SessionOnlyMiddleware())
 	{
 		users.GET("", handler.GetUsersAllHandler)
 		users.GET("/me", handler.GetCurrentUserHandler)
@@ -70,15 +159,28 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 		users.PUT("/me/password", handler.UpdateCurrentUserPasswordHandler)
 		users.PUT("/me/user-group", handler.UpdateCurrentUserGroupHandler)
 		users.DELETE("/me", handler.DeleteCurrentUserHandler)
+		users.GET("/me/sessions", handler.GetCurrentUserSessionsHandler)
+		users.DELETE("/me/sessions", handler.RevokeAllSessionsHandler)
+		users.DELETE("/me/sessions/:sessionId", handler.RevokeSessionHandler)
+		users.POST("/me/mfa", handler.EnrollTwoFactorHandler)
+		users.PUT("/me/mfa/confirm", handler.ConfirmTwoFactorHandler)
+		users.PUT("/me/mfa/disable", handler.DisableTwoFactorHandler)
+		users.GET("/me/tokens", handler.GetPersonalAccessTokensHandler)
+		users.POST("/me/tokens", handler.CreatePersonalAccessTokenHandler)
+		users.DELETE("/me/tokens/:tokenId", handler.DeletePersonalAccessTokenHandler)
 	}
 
 	userGroup := api.Group("/user-groups")
 	userGroup.GET("", handler.GetUserGroupsHandler)
 	userGroup.POST("", handler.CreateUserGroupHandler)
-	userGroup.Use(middleware.AuthMiddleware)
+	userGroup.Use(middleware.AuthMiddleware(db))
 	{
-		userGroup.PUT("/:user-groupsId", handler.UpdateUserGroupHandler)
-		userGroup.DELETE("/:user-groupsId", handler.DeleteUserGroupHandler)
+		userGroup.PUT("/:user-groupsId", can(models.PermissionUpdateUserGroup), handler.UpdateUserGroupHandler)
+		userGroup.DELETE("/:user-groupsId", can(models.PermissionDeleteUserGroup), handler.DeleteUserGroupHandler)
+		userGroup.PUT("/members/:userId/role", can(models.PermissionManageMembers), handler.UpdateMemberRoleHandler)
+		userGroup.PUT("/owner", can(models.PermissionTransferOwnership), handler.TransferOwnershipHandler)
+		userGroup.GET("/audit-logs", can(models.PermissionReadAuditLogs), handler.GetAuditLogsHandler)
+		userGroup.GET("/audit-logs/verify", can(models.PermissionReadAuditLogs), handler.VerifyAuditLogsHandler)
 	}
 
 	return r
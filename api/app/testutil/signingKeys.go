@@ -0,0 +1,22 @@
+package testutil
+
+import (
+	"os"
+	"strings"
+)
+
+// トークンの種類ごとの署名鍵の環境変数
+var signingKeyEnvs = []string{
+	"SESSION_SECRET_KEY", "EMAIL_SECRET_KEY", "USER_GROUP_ID_SECRET_KEY", "PASSWORD_RESET_SECRET_KEY",
+	"EMAIL_CHANGE_SECRET_KEY", "MFA_CHALLENGE_SECRET_KEY", "OIDC_STATE_SECRET_KEY",
+}
+
+// 署名鍵の環境変数が未設定の場合は、テスト用の鍵を設定する
+// 署名鍵は32バイト以上必要なため、各パッケージのTestMainから呼び出す
+func SetSigningKeys() {
+	for _, env := range signingKeyEnvs {
+		if os.Getenv(env) == "" {
+			os.Setenv(env, "test_"+strings.ToLower(env)+"_0123456789abcdef")
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+type AuditLogConfig struct {
+	FilePath      string        // 空の場合はファイルに出力しない
+	Retention     time.Duration // 0の場合は削除しない
+	PurgeInterval time.Duration
+}
+
+// 環境変数から監査ログの設定を取得する（未設定の場合はデフォルト値）
+func GetAuditLogConfig() AuditLogConfig {
+	retentionDays := uint64(constant.AUDIT_LOG_RETENTION_DAYS)
+	if value, err := strconv.ParseUint(os.Getenv("AUDIT_LOG_RETENTION_DAYS"), 10, 32); err == nil {
+		retentionDays = value
+	}
+
+	return AuditLogConfig{
+		FilePath:      strings.TrimSpace(os.Getenv("AUDIT_LOG_FILE")),
+		Retention:     time.Duration(retentionDays) * time.Hour * 24,
+		PurgeInterval: time.Duration(getEnvUint("AUDIT_LOG_PURGE_INTERVAL_HOURS", constant.AUDIT_LOG_PURGE_INTERVAL_HOURS)) * time.Hour,
+	}
+}
+
+// 監査ログを1行に1件のJSONでファイルに追記する
+// ログローテーションで移動されても新しいファイルに書き込めるよう、書き込むたびにファイルを開く
+// nilの場合は出力しない
+type AuditLogSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewAuditLogSink(path string) *AuditLogSink {
+	return &AuditLogSink{path: path}
+}
+
+func (sink *AuditLogSink) Write(entry interface{}) error {
+	if sink == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	file, err := os.OpenFile(sink.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
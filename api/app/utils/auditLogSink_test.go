@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAuditLogConfig(t *testing.T) {
+	originalFile := os.Getenv("AUDIT_LOG_FILE")
+	originalRetention := os.Getenv("AUDIT_LOG_RETENTION_DAYS")
+	defer os.Setenv("AUDIT_LOG_FILE", originalFile)
+	defer os.Setenv("AUDIT_LOG_RETENTION_DAYS", originalRetention)
+
+	os.Setenv("AUDIT_LOG_FILE", "")
+	os.Setenv("AUDIT_LOG_RETENTION_DAYS", "")
+	auditLogConfig := GetAuditLogConfig()
+	assert.Equal(t, "", auditLogConfig.FilePath)
+	assert.Equal(t, 365*24*time.Hour, auditLogConfig.Retention)
+	assert.Equal(t, 24*time.Hour, auditLogConfig.PurgeInterval)
+
+	// 0の場合は削除しない
+	os.Setenv("AUDIT_LOG_FILE", "/var/log/look-back/audit.log")
+	os.Setenv("AUDIT_LOG_RETENTION_DAYS", "0")
+	auditLogConfig = GetAuditLogConfig()
+	assert.Equal(t, "/var/log/look-back/audit.log", auditLogConfig.FilePath)
+	assert.Equal(t, time.Duration(0), auditLogConfig.Retention)
+}
+
+func TestAuditLogSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink := NewAuditLogSink(path)
+
+	assert.Nil(t, sink.Write(map[string]interface{}{"Action": "login.succeeded", "ActorID": 1}))
+	assert.Nil(t, sink.Write(map[string]interface{}{"Action": "logout", "ActorID": 1}))
+
+	file, err := os.Open(path)
+	assert.Nil(t, err)
+	defer file.Close()
+
+	// 1行に1件のJSONで追記される
+	var actions []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &entry))
+		actions = append(actions, entry["Action"].(string))
+	}
+	assert.Equal(t, []string{"login.succeeded", "logout"}, actions)
+
+	info, _ := os.Stat(path)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// 未設定の場合は何もしない
+	var disabled *AuditLogSink
+	assert.Nil(t, disabled.Write(map[string]interface{}{"Action": "logout"}))
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownSigningKey = errors.New("unknown signing key")
+
+// JWTの署名鍵
+// IDはトークンのkidヘッダーに設定し、検証時にどの鍵で署名したかを判別する
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
+// 複数の署名鍵を管理する鍵束
+// 署名には有効な鍵を1つだけ使い、検証には鍵束のすべての鍵を使う
+// 鍵を追加した直後から新しい鍵で署名し、古い鍵は鍵束から外すまで検証に使い続ける
+type Keyring struct {
+	active SigningKey
+	keys   map[string]SigningKey
+}
+
+// activeKeyIDが空の場合は最後に追加した鍵で署名する
+func NewKeyring(keys []SigningKey, activeKeyID string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring has no keys")
+	}
+
+	keyring := &Keyring{keys: map[string]SigningKey{}}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("signing key has no id")
+		}
+		if len(key.Secret) < minSigningKeyLength {
+			return nil, fmt.Errorf("signing key %s must be at least %d bytes", key.ID, minSigningKeyLength)
+		}
+		if _, exists := keyring.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate signing key id: %s", key.ID)
+		}
+		keyring.keys[key.ID] = key
+	}
+
+	if activeKeyID == "" {
+		activeKeyID = keys[len(keys)-1].ID
+	}
+	active, ok := keyring.keys[activeKeyID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %s is not in keyring", activeKeyID)
+	}
+	keyring.active = active
+
+	return keyring, nil
+}
+
+// "kid1:secret1,kid2:secret2"の形式の鍵束を読み込む
+func ParseKeyring(value string, activeKeyID string) (*Keyring, error) {
+	keys := []SigningKey{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("signing key must be in the form kid:secret")
+		}
+		keys = append(keys, SigningKey{
+			ID:     strings.TrimSpace(parts[0]),
+			Secret: []byte(parts[1]),
+		})
+	}
+
+	return NewKeyring(keys, strings.TrimSpace(activeKeyID))
+}
+
+// 署名に使う鍵を返す
+func (keyring *Keyring) SigningKey() SigningKey {
+	return keyring.active
+}
+
+// kidに対応する検証用の鍵を返す
+func (keyring *Keyring) Key(kid string) ([]byte, error) {
+	key, ok := keyring.keys[kid]
+	if !ok {
+		return nil, ErrUnknownSigningKey
+	}
+	return key.Secret, nil
+}
+
+// 環境変数JWT_SIGNING_KEYSとJWT_ACTIVE_KEY_IDから鍵束を取得する
+// 未設定の場合はnilを返し、トークンの種類ごとの環境変数の鍵で署名する
+func GetKeyring() (*Keyring, error) {
+	value := os.Getenv("JWT_SIGNING_KEYS")
+	activeKeyID := os.Getenv("JWT_ACTIVE_KEY_ID")
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	// 環境変数が変わった場合のみ読み込み直す
+	cachedKeyring.mu.Lock()
+	defer cachedKeyring.mu.Unlock()
+	if cachedKeyring.keyring != nil && cachedKeyring.source == value && cachedKeyring.activeKeyID == activeKeyID {
+		return cachedKeyring.keyring, nil
+	}
+
+	keyring, err := ParseKeyring(value, activeKeyID)
+	if err != nil {
+		return nil, err
+	}
+	cachedKeyring.source = value
+	cachedKeyring.activeKeyID = activeKeyID
+	cachedKeyring.keyring = keyring
+
+	return keyring, nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+const minSigningKeyLength = 32
+
+var cachedKeyring struct {
+	mu          sync.Mutex
+	source      string
+	activeKeyID string
+	keyring     *Keyring
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 32バイト以上のテスト用の署名鍵
+func testSigningSecret(seed string) string {
+	return strings.Repeat(seed, 32)
+}
+
+// テストの間だけ鍵束の環境変数を設定する
+func withKeyring(t *testing.T, keys string, activeKeyID string) {
+	originalKeys := os.Getenv("JWT_SIGNING_KEYS")
+	originalActiveKeyID := os.Getenv("JWT_ACTIVE_KEY_ID")
+	os.Setenv("JWT_SIGNING_KEYS", keys)
+	os.Setenv("JWT_ACTIVE_KEY_ID", activeKeyID)
+	t.Cleanup(func() {
+		os.Setenv("JWT_SIGNING_KEYS", originalKeys)
+		os.Setenv("JWT_ACTIVE_KEY_ID", originalActiveKeyID)
+	})
+}
+
+func TestParseKeyring(t *testing.T) {
+	t.Run("成功_最後の鍵で署名", func(t *testing.T) {
+		keyring, err := ParseKeyring("key-1:"+testSigningSecret("1")+", key-2:"+testSigningSecret("2"), "")
+		assert.Nil(t, err)
+		assert.Equal(t, "key-2", keyring.SigningKey().ID)
+
+		secret, err := keyring.Key("key-1")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte(testSigningSecret("1")), secret)
+
+		_, err = keyring.Key("key-3")
+		assert.ErrorIs(t, err, ErrUnknownSigningKey)
+	})
+
+	t.Run("成功_署名する鍵を指定", func(t *testing.T) {
+		keyring, err := ParseKeyring("key-1:"+testSigningSecret("1")+",key-2:"+testSigningSecret("2"), "key-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "key-1", keyring.SigningKey().ID)
+	})
+
+	t.Run("成功_鍵に区切り文字を含む", func(t *testing.T) {
+		keyring, err := ParseKeyring("key-1:"+testSigningSecret(":"), "")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte(testSigningSecret(":")), keyring.SigningKey().Secret)
+	})
+
+	tests := []struct {
+		name        string
+		value       string
+		activeKeyID string
+	}{
+		{"失敗_鍵がない", "", ""},
+		{"失敗_形式が不正", "key-1", ""},
+		{"失敗_IDがない", ":" + testSigningSecret("1"), ""},
+		{"失敗_鍵が短い", "key-1:short", ""},
+		{"失敗_IDが重複", "key-1:" + testSigningSecret("1") + ",key-1:" + testSigningSecret("2"), ""},
+		{"失敗_署名する鍵がない", "key-1:" + testSigningSecret("1"), "key-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseKeyring(tt.value, tt.activeKeyID)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestGetKeyring(t *testing.T) {
+	withKeyring(t, "", "")
+	keyring, err := GetKeyring()
+	assert.Nil(t, err)
+	assert.Nil(t, keyring, "Keyring should be nil when not configured")
+
+	withKeyring(t, "key-1:"+testSigningSecret("1"), "")
+	keyring, err = GetKeyring()
+	assert.Nil(t, err)
+	assert.Equal(t, "key-1", keyring.SigningKey().ID)
+
+	// 環境変数を変更すると読み込み直す
+	withKeyring(t, "key-1:"+testSigningSecret("1")+",key-2:"+testSigningSecret("2"), "key-1")
+	keyring, _ = GetKeyring()
+	assert.Equal(t, "key-1", keyring.SigningKey().ID)
+
+	withKeyring(t, "key-1:short", "")
+	_, err = GetKeyring()
+	assert.NotNil(t, err)
+}
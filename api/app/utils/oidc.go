@@ -0,0 +1,451 @@
+package utils
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// ユーザーグループを決めるクレーム名と、クレームの値とユーザーグループIDの対応
+	GroupClaim   string
+	GroupMapping map[string]uint
+	// 未登録のユーザーを初回ログイン時に作成するか
+	JITProvisioning bool
+}
+
+// IDトークンから取得したユーザーの情報
+type OIDCClaims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string // GroupClaimの値
+}
+
+// 環境変数からOIDCの設定を取得する
+// OIDC_ISSUERが未設定の場合はOIDCでのログインを無効にする
+func GetOIDCConfig() (OIDCConfig, bool) {
+	config := OIDCConfig{
+		Issuer:          strings.TrimRight(os.Getenv("OIDC_ISSUER"), "/"),
+		ClientID:        os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret:    os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:     os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:          []string{"openid", "email", "profile"},
+		GroupClaim:      os.Getenv("OIDC_GROUP_CLAIM"),
+		GroupMapping:    ParseOIDCGroupMapping(os.Getenv("OIDC_GROUP_MAPPING")),
+		JITProvisioning: os.Getenv("OIDC_JIT_PROVISIONING") == "true",
+	}
+	if config.GroupClaim != "" {
+		config.Scopes = append(config.Scopes, config.GroupClaim)
+	}
+
+	if config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return config, false
+	}
+	return config, true
+}
+
+// "engineering=1,sales=2"の形式でクレームの値とユーザーグループIDの対応を指定する
+func ParseOIDCGroupMapping(value string) map[string]uint {
+	mapping := map[string]uint{}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		userGroupID, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+		if err != nil || userGroupID == 0 {
+			continue
+		}
+		mapping[strings.TrimSpace(parts[0])] = uint(userGroupID)
+	}
+	return mapping
+}
+
+// 認可コードフロー(PKCE)でIdPと通信するクライアント
+// エンドポイントと公開鍵はIdPのディスカバリーから取得してキャッシュする
+type OIDCProvider struct {
+	Config     OIDCConfig
+	HTTPClient *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+}
+
+func NewOIDCProvider(config OIDCConfig) *OIDCProvider {
+	return &OIDCProvider{
+		Config:     config,
+		HTTPClient: &http.Client{Timeout: time.Second * constant.OIDC_HTTP_TIMEOUT_SECONDS},
+		keys:       map[string]*rsa.PublicKey{},
+	}
+}
+
+// IdPの認可エンドポイントのURLを返す
+func (provider *OIDCProvider) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	discovery, err := provider.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.Config.ClientID},
+		"redirect_uri":          {provider.Config.RedirectURL},
+		"scope":                 {strings.Join(provider.Config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {PKCEChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// 認可コードをIDトークンと交換し、検証したクレームを返す
+func (provider *OIDCProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (OIDCClaims, error) {
+	discovery, err := provider.getDiscovery(ctx)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.Config.RedirectURL},
+		"client_id":     {provider.Config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+	if provider.Config.ClientSecret != "" {
+		form.Set("client_secret", provider.Config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokenResponse struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := provider.doJSON(req, &tokenResponse); err != nil {
+		return OIDCClaims{}, fmt.Errorf("failed to exchange authorization code: %v", err)
+	}
+	if tokenResponse.IDToken == "" {
+		return OIDCClaims{}, errors.New("token response has no id_token")
+	}
+
+	return provider.VerifyIDToken(ctx, tokenResponse.IDToken, nonce)
+}
+
+// IDトークンの署名、発行者、対象者、有効期限、nonceを検証する
+func (provider *OIDCProvider) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (OIDCClaims, error) {
+	var oidcClaims OIDCClaims
+
+	token, err := jwt.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return provider.getKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(provider.Config.Issuer),
+		jwt.WithAudience(provider.Config.ClientID),
+		jwt.WithLeeway(time.Second*constant.OIDC_CLOCK_SKEW_SECONDS),
+	)
+	if err != nil {
+		return oidcClaims, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return oidcClaims, errors.New("failed to parse claims")
+	}
+
+	// 有効期限のないトークンは受け付けない
+	if exp, err := claims.GetExpirationTime(); err != nil || exp == nil {
+		return oidcClaims, errors.New("token has no expiration")
+	}
+
+	// 認可リクエストと異なるIDトークンを使い回されないようnonceを確認
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return oidcClaims, errors.New("nonce mismatch")
+	}
+
+	// 複数の対象者を含む場合は、自分宛てに発行されたトークンか確認
+	if audience, _ := claims.GetAudience(); len(audience) > 1 {
+		if azp, _ := claims["azp"].(string); azp != provider.Config.ClientID {
+			return oidcClaims, errors.New("unexpected authorized party")
+		}
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return oidcClaims, errors.New("failed to parse subject")
+	}
+
+	oidcClaims = OIDCClaims{
+		Issuer:        provider.Config.Issuer,
+		Subject:       subject,
+		Email:         stringClaim(claims, "email"),
+		EmailVerified: boolClaim(claims, "email_verified"),
+		Name:          stringClaim(claims, "name"),
+		Groups:        stringListClaim(claims, provider.Config.GroupClaim),
+	}
+	return oidcClaims, nil
+}
+
+// クレームの値に対応するユーザーグループのIDを返す（対応がなければ0）
+func (provider *OIDCProvider) MapUserGroup(claims OIDCClaims) uint {
+	for _, group := range claims.Groups {
+		if userGroupID, ok := provider.Config.GroupMapping[group]; ok {
+			return userGroupID
+		}
+	}
+	return 0
+}
+
+// PKCEのコードベリファイアからS256のコードチャレンジを生成する
+func PKCEChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// PKCEのコードベリファイア（43文字以上の英数字）を生成する
+func GeneratePKCEVerifier() (string, error) {
+	return GenerateRandomString(32)
+}
+
+// 認可リクエストのstate、nonce、コードベリファイアをまとめた短命のトークン
+// コールバックまでの間クッキーに保存する
+type OIDCStateClaims struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+func GenerateOIDCStateToken(stateClaims OIDCStateClaims) (string, error) {
+	return signToken(oidcStateTokenType, jwt.MapClaims{
+		"state": stateClaims.State,
+		"nonce": stateClaims.Nonce,
+		"cv":    stateClaims.CodeVerifier,
+		"exp":   time.Now().Add(time.Minute * constant.OIDC_STATE_LIFETIME_MINUTES).Unix(),
+	})
+}
+
+func ParseOIDCStateToken(tokenString string) (OIDCStateClaims, error) {
+	var stateClaims OIDCStateClaims
+
+	claims, err := parseTokenClaims(oidcStateTokenType, tokenString)
+	if err != nil {
+		return stateClaims, err
+	}
+
+	stateClaims = OIDCStateClaims{
+		State:        stringClaim(claims, "state"),
+		Nonce:        stringClaim(claims, "nonce"),
+		CodeVerifier: stringClaim(claims, "cv"),
+	}
+	if stateClaims.State == "" || stateClaims.Nonce == "" || stateClaims.CodeVerifier == "" {
+		return stateClaims, errors.New("failed to parse state")
+	}
+
+	return stateClaims, nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (provider *OIDCProvider) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if provider.discovery != nil {
+		return provider.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.Config.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var discovery oidcDiscovery
+	if err := provider.doJSON(req, &discovery); err != nil {
+		return nil, fmt.Errorf("failed to fetch openid configuration: %v", err)
+	}
+
+	// なりすましを防ぐため、設定した発行者と一致するか確認
+	if strings.TrimRight(discovery.Issuer, "/") != provider.Config.Issuer {
+		return nil, fmt.Errorf("issuer mismatch: %s", discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("openid configuration is missing endpoints")
+	}
+
+	provider.discovery = &discovery
+	return provider.discovery, nil
+}
+
+// kidに対応する公開鍵を返す
+// 未知のkidの場合は鍵の更新に備えてJWKSを取得し直す
+func (provider *OIDCProvider) getKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	discovery, err := provider.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	provider.mu.Lock()
+	key, ok := provider.keys[kid]
+	provider.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := provider.doJSON(req, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %v", err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		publicKey, err := jwk.rsaPublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = publicKey
+	}
+
+	provider.mu.Lock()
+	provider.keys = keys
+	provider.mu.Unlock()
+
+	key, ok = keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	return key, nil
+}
+
+func (jwk jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid exponent")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
+
+func (provider *OIDCProvider) doJSON(req *http.Request, v interface{}) error {
+	resp, err := provider.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// email_verifiedを文字列で返すIdPもあるため両方を受け付ける
+func boolClaim(claims jwt.MapClaims, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
+
+// 文字列と文字列の配列のどちらのクレームも受け付ける
+func stringListClaim(claims jwt.MapClaims, name string) []string {
+	if name == "" {
+		return []string{}
+	}
+
+	switch value := claims[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := []string{}
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return []string{}
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// テスト用のIdP
+// 認可エンドポイントにアクセスすると、Claimsのユーザーでログインしたものとして認可コードを発行する
+type MockOIDCServer struct {
+	*httptest.Server
+	ClientID string
+	KeyID    string
+	Key      *rsa.PrivateKey
+	Claims   jwt.MapClaims // 発行するIDトークンに含めるクレーム(sub、emailなど)
+
+	mu             sync.Mutex
+	authorizations map[string]mockOIDCAuthorization
+}
+
+type mockOIDCAuthorization struct {
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+}
+
+func NewMockOIDCServer(clientID string) *MockOIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	server := &MockOIDCServer{
+		ClientID:       clientID,
+		KeyID:          "mock-key",
+		Key:            key,
+		Claims:         jwt.MapClaims{},
+		authorizations: map[string]mockOIDCAuthorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", server.handleDiscovery)
+	mux.HandleFunc("/authorize", server.handleAuthorize)
+	mux.HandleFunc("/token", server.handleToken)
+	mux.HandleFunc("/jwks", server.handleJWKS)
+	server.Server = httptest.NewServer(mux)
+
+	return server
+}
+
+func (server *MockOIDCServer) Config(redirectURL string) OIDCConfig {
+	return OIDCConfig{
+		Issuer:       server.URL,
+		ClientID:     server.ClientID,
+		ClientSecret: "mock-secret",
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		GroupMapping: map[string]uint{},
+	}
+}
+
+// Claimsに発行者、対象者、有効期限を加えてIDトークンに署名する
+func (server *MockOIDCServer) SignIDToken(claims jwt.MapClaims) string {
+	idTokenClaims := jwt.MapClaims{
+		"iss": server.URL,
+		"aud": server.ClientID,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Minute * 5).Unix(),
+	}
+	for name, value := range claims {
+		idTokenClaims[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, idTokenClaims)
+	token.Header["kid"] = server.KeyID
+	tokenString, err := token.SignedString(server.Key)
+	if err != nil {
+		panic(err)
+	}
+	return tokenString
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func (server *MockOIDCServer) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeMockJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 server.URL,
+		"authorization_endpoint": server.URL + "/authorize",
+		"token_endpoint":         server.URL + "/token",
+		"jwks_uri":               server.URL + "/jwks",
+	})
+}
+
+func (server *MockOIDCServer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != server.ClientID || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code, _ := GenerateRandomString(16)
+	server.mu.Lock()
+	server.authorizations[code] = mockOIDCAuthorization{
+		redirectURI:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+	}
+	server.mu.Unlock()
+
+	redirectURL, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	redirectQuery := redirectURL.Query()
+	redirectQuery.Set("code", code)
+	redirectQuery.Set("state", query.Get("state"))
+	redirectURL.RawQuery = redirectQuery.Encode()
+
+	http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+}
+
+// 認可コードは一度しか使えない
+func (server *MockOIDCServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	server.mu.Lock()
+	authorization, ok := server.authorizations[r.PostForm.Get("code")]
+	delete(server.authorizations, r.PostForm.Get("code"))
+	server.mu.Unlock()
+
+	if !ok ||
+		r.PostForm.Get("client_id") != server.ClientID ||
+		r.PostForm.Get("redirect_uri") != authorization.redirectURI ||
+		PKCEChallenge(r.PostForm.Get("code_verifier")) != authorization.codeChallenge {
+		writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{"nonce": authorization.nonce}
+	for name, value := range server.Claims {
+		claims[name] = value
+	}
+
+	writeMockJSON(w, http.StatusOK, map[string]string{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"id_token":     server.SignIDToken(claims),
+	})
+}
+
+func (server *MockOIDCServer) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	writeMockJSON(w, http.StatusOK, map[string][]map[string]string{
+		"keys": {{
+			"kid": server.KeyID,
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(server.Key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(server.Key.E)).Bytes()),
+		}},
+	})
+}
+
+func writeMockJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPKCEChallenge(t *testing.T) {
+	// RFC 7636 Appendix B のテストベクター
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", PKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, err := GeneratePKCEVerifier()
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, len(verifier), 43)
+}
+
+func TestParseOIDCGroupMapping(t *testing.T) {
+	mapping := ParseOIDCGroupMapping("engineering=1, sales = 2,invalid,zero=0,bad=x")
+	assert.Equal(t, map[string]uint{"engineering": 1, "sales": 2}, mapping)
+	assert.Empty(t, ParseOIDCGroupMapping(""))
+}
+
+func TestOIDCStateToken(t *testing.T) {
+	stateClaims := OIDCStateClaims{State: "state", Nonce: "nonce", CodeVerifier: "verifier"}
+
+	tokenString, err := GenerateOIDCStateToken(stateClaims)
+	assert.Nil(t, err)
+
+	parsed, err := ParseOIDCStateToken(tokenString)
+	assert.Nil(t, err)
+	assert.Equal(t, stateClaims, parsed)
+
+	// 他の用途のトークンは受け付けない
+	mfaToken, _ := GenerateMFAChallengeToken(1)
+	_, err = ParseOIDCStateToken(mfaToken)
+	assert.NotNil(t, err)
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	server := NewMockOIDCServer("look-back")
+	defer server.Close()
+	server.Claims = jwt.MapClaims{
+		"sub":            "user-1",
+		"email":          "user@example.com",
+		"email_verified": true,
+		"name":           "Test User",
+		"groups":         []string{"sales", "engineering"},
+	}
+
+	config := server.Config("http://localhost/api/auth/oidc/callback")
+	config.GroupClaim = "groups"
+	config.GroupMapping = map[string]uint{"engineering": 3}
+	provider := NewOIDCProvider(config)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier-verifier-verifier-verifier-verifier")
+	assert.Nil(t, err)
+
+	// IdPでのログインを模擬し、コールバックのURLから認可コードを取得する
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	assert.Nil(t, err)
+	callbackURL, _ := url.Parse(resp.Header.Get("Location"))
+	assert.Equal(t, "state", callbackURL.Query().Get("state"))
+	code := callbackURL.Query().Get("code")
+
+	// コードベリファイアが異なる場合は交換できない
+	t.Run("失敗_コードベリファイアが異なる", func(t *testing.T) {
+		resp, _ := client.Get(authURL)
+		callbackURL, _ := url.Parse(resp.Header.Get("Location"))
+
+		_, err := provider.Exchange(context.Background(), callbackURL.Query().Get("code"), "other-verifier", "nonce")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("失敗_nonceが異なる", func(t *testing.T) {
+		resp, _ := client.Get(authURL)
+		callbackURL, _ := url.Parse(resp.Header.Get("Location"))
+
+		_, err := provider.Exchange(context.Background(), callbackURL.Query().Get("code"), "verifier-verifier-verifier-verifier-verifier", "other-nonce")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("成功", func(t *testing.T) {
+		claims, err := provider.Exchange(context.Background(), code, "verifier-verifier-verifier-verifier-verifier", "nonce")
+		assert.Nil(t, err)
+		assert.Equal(t, server.URL, claims.Issuer)
+		assert.Equal(t, "user-1", claims.Subject)
+		assert.Equal(t, "user@example.com", claims.Email)
+		assert.True(t, claims.EmailVerified)
+		assert.Equal(t, uint(3), provider.MapUserGroup(claims))
+
+		// 認可コードは再利用できない
+		_, err = provider.Exchange(context.Background(), code, "verifier-verifier-verifier-verifier-verifier", "nonce")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestOIDCProviderVerifyIDToken(t *testing.T) {
+	server := NewMockOIDCServer("look-back")
+	defer server.Close()
+	provider := NewOIDCProvider(server.Config("http://localhost/api/auth/oidc/callback"))
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		valid  bool
+	}{
+		{"成功", jwt.MapClaims{"sub": "user-1", "nonce": "nonce"}, true},
+		{"失敗_発行者が異なる", jwt.MapClaims{"sub": "user-1", "nonce": "nonce", "iss": "https://evil.example.com"}, false},
+		{"失敗_対象者が異なる", jwt.MapClaims{"sub": "user-1", "nonce": "nonce", "aud": "other-client"}, false},
+		{"失敗_他のクライアントが認可", jwt.MapClaims{"sub": "user-1", "nonce": "nonce", "aud": []string{"look-back", "other-client"}, "azp": "other-client"}, false},
+		{"失敗_有効期限切れ", jwt.MapClaims{"sub": "user-1", "nonce": "nonce", "exp": time.Now().Add(-time.Hour).Unix()}, false},
+		{"失敗_nonceなし", jwt.MapClaims{"sub": "user-1"}, false},
+		{"失敗_subなし", jwt.MapClaims{"nonce": "nonce"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.VerifyIDToken(context.Background(), server.SignIDToken(tt.claims), "nonce")
+			assert.Equal(t, tt.valid, err == nil, "error: %v", err)
+		})
+	}
+
+	t.Run("失敗_署名が異なる", func(t *testing.T) {
+		otherServer := NewMockOIDCServer("look-back")
+		defer otherServer.Close()
+		otherServer.KeyID = server.KeyID
+
+		idToken := otherServer.SignIDToken(jwt.MapClaims{"iss": server.URL, "sub": "user-1", "nonce": "nonce"})
+		_, err := provider.VerifyIDToken(context.Background(), idToken, "nonce")
+		assert.NotNil(t, err)
+	})
+}
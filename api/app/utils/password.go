@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+// argon2idのハッシュパラメータ
+type PasswordHashParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+const (
+	passwordSaltLength = 16
+	passwordKeyLength  = 32
+)
+
+// ハッシュのコストの上限（下限はいずれも1）
+// 保存済みのハッシュのコストが大きすぎる場合も検証に使わない
+const (
+	maxPasswordHashMemory      = 4 * 1024 * 1024 // KiB (4GiB)
+	maxPasswordHashIterations  = 100
+	maxPasswordHashParallelism = 255
+)
+
+// 環境変数からハッシュのコストを取得する（未設定または範囲外の場合はデフォルト値）
+func GetPasswordHashParams() PasswordHashParams {
+	return PasswordHashParams{
+		Memory:      uint32(getEnvUintInRange("PASSWORD_HASH_MEMORY", constant.PASSWORD_HASH_MEMORY, maxPasswordHashMemory)),
+		Iterations:  uint32(getEnvUintInRange("PASSWORD_HASH_ITERATIONS", constant.PASSWORD_HASH_ITERATIONS, maxPasswordHashIterations)),
+		Parallelism: uint8(getEnvUintInRange("PASSWORD_HASH_PARALLELISM", constant.PASSWORD_HASH_PARALLELISM, maxPasswordHashParallelism)),
+	}
+}
+
+// パスワードをソルト付きのargon2idでハッシュ化し、PHC形式の文字列で返す
+func HashPassword(password string) (string, error) {
+	params := GetPasswordHashParams()
+
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, passwordKeyLength)
+
+	encoded := fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+	return encoded, nil
+}
+
+// 保存済みのハッシュと入力されたパスワードが一致するか検証する
+// 旧形式（ソルトなしSHA-256）のハッシュも検証できる
+func ComparePassword(hashedPassword string, password string) bool {
+	if isLegacyPasswordHash(hashedPassword) {
+		legacy := fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
+		return subtle.ConstantTimeCompare([]byte(hashedPassword), []byte(legacy)) == 1
+	}
+
+	params, salt, key, err := decodePasswordHash(hashedPassword)
+	if err != nil {
+		return false
+	}
+
+	inputKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, inputKey) == 1
+}
+
+var (
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
+)
+
+// 存在しないユーザーへのログインでも、パスワードの検証と同じだけ時間をかける
+// 応答時間から登録済みのメールアドレスを推測されないようにするため
+func CompareDummyPassword(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		randomPassword, _ := GenerateRandomString(16)
+		dummyPasswordHash, _ = HashPassword(randomPassword)
+	})
+	ComparePassword(dummyPasswordHash, password)
+}
+
+// 保存済みのハッシュが旧形式、または現在の設定と異なるコストで作られている場合にtrueを返す
+func PasswordNeedsRehash(hashedPassword string) bool {
+	if isLegacyPasswordHash(hashedPassword) {
+		return true
+	}
+
+	params, _, _, err := decodePasswordHash(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	return params != GetPasswordHashParams()
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func isLegacyPasswordHash(hashedPassword string) bool {
+	return len(hashedPassword) == sha256.Size*2 && !strings.HasPrefix(hashedPassword, "$")
+}
+
+func decodePasswordHash(hashedPassword string) (PasswordHashParams, []byte, []byte, error) {
+	var params PasswordHashParams
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("invalid password hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, errors.New("incompatible argon2 version")
+	}
+
+	var memory, iterations, parallelism uint64
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return params, nil, nil, err
+	}
+	if !inRange(memory, maxPasswordHashMemory) || !inRange(iterations, maxPasswordHashIterations) || !inRange(parallelism, maxPasswordHashParallelism) {
+		return params, nil, nil, errors.New("password hash parameters out of range")
+	}
+	params = PasswordHashParams{Memory: uint32(memory), Iterations: uint32(iterations), Parallelism: uint8(parallelism)}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	return params, salt, key, nil
+}
+
+func getEnvUint(name string, defaultValue uint) uint {
+	value, err := strconv.ParseUint(os.Getenv(name), 10, 32)
+	if err != nil || value == 0 {
+		return defaultValue
+	}
+	return uint(value)
+}
+
+// 1以上max以下でない場合はデフォルト値を返す
+func getEnvUintInRange(name string, defaultValue uint, max uint64) uint {
+	value, err := strconv.ParseUint(os.Getenv(name), 10, 32)
+	if err != nil || !inRange(value, max) {
+		return defaultValue
+	}
+	return uint(value)
+}
+
+func inRange(value uint64, max uint64) bool {
+	return value >= 1 && value <= max
+}
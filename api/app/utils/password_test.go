@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashPassword(t *testing.T) {
+	hashedPassword, err := HashPassword("password")
+	assert.Nil(t, err, "Error should be nil")
+	assert.True(t, strings.HasPrefix(hashedPassword, "$argon2id$"), "Hash should be argon2id")
+
+	// 同じパスワードでもソルトが異なるためハッシュは一致しない
+	anotherHashedPassword, err := HashPassword("password")
+	assert.Nil(t, err, "Error should be nil")
+	assert.NotEqual(t, hashedPassword, anotherHashedPassword, "Hashes should be salted")
+}
+
+func TestComparePassword(t *testing.T) {
+	hashedPassword, err := HashPassword("password")
+	assert.Nil(t, err, "Error should be nil")
+
+	assert.True(t, ComparePassword(hashedPassword, "password"), "Correct password should match")
+	assert.False(t, ComparePassword(hashedPassword, "wrong_password"), "Wrong password should not match")
+	assert.False(t, ComparePassword("invalid_hash", "password"), "Invalid hash should not match")
+
+	// 旧形式（ソルトなしSHA-256）
+	legacyHash := fmt.Sprintf("%x", sha256.Sum256([]byte("password")))
+	assert.True(t, ComparePassword(legacyHash, "password"), "Legacy hash should match")
+	assert.False(t, ComparePassword(legacyHash, "wrong_password"), "Legacy hash should not match wrong password")
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	hashedPassword, err := HashPassword("password")
+	assert.Nil(t, err, "Error should be nil")
+	assert.False(t, PasswordNeedsRehash(hashedPassword), "Current hash should not need rehash")
+
+	legacyHash := fmt.Sprintf("%x", sha256.Sum256([]byte("password")))
+	assert.True(t, PasswordNeedsRehash(legacyHash), "Legacy hash should need rehash")
+
+	// コストの設定が変わった場合は再ハッシュが必要
+	originalIterations := os.Getenv("PASSWORD_HASH_ITERATIONS")
+	os.Setenv("PASSWORD_HASH_ITERATIONS", "3")
+	defer os.Setenv("PASSWORD_HASH_ITERATIONS", originalIterations)
+
+	assert.True(t, PasswordNeedsRehash(hashedPassword), "Hash with old cost should need rehash")
+}
+
+func TestPasswordHashParamsRange(t *testing.T) {
+	// 範囲外の設定はデフォルト値を使う（256以上の並列度が0に丸められないようにする）
+	originalParallelism := os.Getenv("PASSWORD_HASH_PARALLELISM")
+	defer os.Setenv("PASSWORD_HASH_PARALLELISM", originalParallelism)
+	for _, value := range []string{"256", "512", "0"} {
+		os.Setenv("PASSWORD_HASH_PARALLELISM", value)
+		assert.Equal(t, uint8(1), GetPasswordHashParams().Parallelism, "Parallelism %s should fall back to default", value)
+	}
+	os.Setenv("PASSWORD_HASH_PARALLELISM", "255")
+	assert.Equal(t, uint8(255), GetPasswordHashParams().Parallelism, "Parallelism 255 should be allowed")
+	os.Setenv("PASSWORD_HASH_PARALLELISM", originalParallelism)
+
+	// 保存済みのハッシュのコストが範囲外の場合は検証せずに不一致とする
+	hashedPassword, err := HashPassword("password")
+	assert.Nil(t, err, "Error should be nil")
+	parts := strings.Split(hashedPassword, "$")
+	for _, cost := range []string{"m=19456,t=2,p=0", "m=19456,t=2,p=256", "m=19456,t=0,p=1", "m=0,t=2,p=1", "m=4294967295,t=2,p=1"} {
+		parts[3] = cost
+		tampered := strings.Join(parts, "$")
+		assert.NotPanics(t, func() { ComparePassword(tampered, "password") }, "Cost %s should not panic", cost)
+		assert.False(t, ComparePassword(tampered, "password"), "Cost %s should not match", cost)
+		assert.True(t, PasswordNeedsRehash(tampered), "Cost %s should need rehash", cost)
+	}
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// 並び順のランクに使う文字（辞書順に並ぶ）
+// ランクは0〜1の36進数の小数の小数点以下を表し、間に必ず別のランクを作れるよう末尾を"0"にしない
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+var ErrInvalidRank = errors.New("invalid rank")
+
+// beforeとafterの間のランクを返す
+// beforeが空の場合は先頭、afterが空の場合は末尾として扱う
+func RankBetween(before string, after string) (string, error) {
+	if !isValidRank(before) || !isValidRank(after) || (after != "" && before >= after) {
+		return "", ErrInvalidRank
+	}
+
+	var rank strings.Builder
+	bounded := after != ""
+	for i := 0; ; i++ {
+		low := 0
+		if i < len(before) {
+			low = strings.IndexByte(rankDigits, before[i])
+		}
+		high := len(rankDigits)
+		if bounded && i < len(after) {
+			high = strings.IndexByte(rankDigits, after[i])
+		}
+
+		if low == high {
+			rank.WriteByte(rankDigits[low])
+			continue
+		}
+
+		mid := (low + high) / 2
+		if mid > low {
+			rank.WriteByte(rankDigits[mid])
+			return rank.String(), nil
+		}
+
+		// 隣り合う文字の場合はbeforeの文字を使い、次の桁で上限なしに間を取る
+		rank.WriteByte(rankDigits[low])
+		bounded = false
+	}
+}
+
+// beforeとafterの間に、昇順のn個のランクを均等に作る
+func RanksBetween(before string, after string, n int) ([]string, error) {
+	if n <= 0 {
+		return []string{}, nil
+	}
+
+	mid, err := RankBetween(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	left, err := RanksBetween(before, mid, n/2)
+	if err != nil {
+		return nil, err
+	}
+	right, err := RanksBetween(mid, after, n-n/2-1)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append(left, mid), right...), nil
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func isValidRank(rank string) bool {
+	if strings.HasSuffix(rank, rankDigits[:1]) {
+		return false
+	}
+	for i := 0; i < len(rank); i++ {
+		if strings.IndexByte(rankDigits, rank[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankBetween(t *testing.T) {
+	rank, err := RankBetween("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "i", rank)
+
+	// 隣り合う文字の間は桁を増やす
+	rank, err = RankBetween("a", "b")
+	assert.Nil(t, err)
+	assert.True(t, "a" < rank && rank < "b")
+
+	rank, err = RankBetween("a", "a0i")
+	assert.Nil(t, err)
+	assert.True(t, "a" < rank && rank < "a0i")
+
+	rank, err = RankBetween("", "1")
+	assert.Nil(t, err)
+	assert.True(t, rank < "1")
+
+	rank, err = RankBetween("zz", "")
+	assert.Nil(t, err)
+	assert.True(t, "zz" < rank)
+
+	// 同じ位置に繰り返し移動しても順序を保つ
+	before, after := "a", "b"
+	for i := 0; i < 100; i++ {
+		rank, err := RankBetween(before, after)
+		assert.Nil(t, err)
+		assert.True(t, before < rank && rank < after)
+		after = rank
+	}
+
+	_, err = RankBetween("b", "a")
+	assert.Equal(t, ErrInvalidRank, err)
+	_, err = RankBetween("a0", "")
+	assert.Equal(t, ErrInvalidRank, err)
+	_, err = RankBetween("A", "")
+	assert.Equal(t, ErrInvalidRank, err)
+}
+
+func TestRanksBetween(t *testing.T) {
+	ranks, err := RanksBetween("", "i", 50)
+	assert.Nil(t, err)
+	assert.Len(t, ranks, 50)
+	for i, rank := range ranks {
+		assert.True(t, rank < "i")
+		if i > 0 {
+			assert.True(t, ranks[i-1] < rank)
+		}
+	}
+}
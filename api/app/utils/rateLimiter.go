@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+// 期間内に許可するリクエストの上限
+type RateLimit struct {
+	Max    int
+	Window time.Duration
+}
+
+// ログイン失敗時のロックアウトの設定
+// ロックアウトが繰り返されるたびにロック時間を倍にする（上限あり）
+type LockoutPolicy struct {
+	MaxFailures  int
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+}
+
+type RateLimitConfig struct {
+	LoginPerIP      RateLimit
+	EmailPerIP      RateLimit
+	EmailPerAccount RateLimit
+	LoginLockout    LockoutPolicy
+}
+
+// 環境変数からレート制限の設定を取得する（未設定の場合はデフォルト値）
+func GetRateLimitConfig() RateLimitConfig {
+	window := time.Duration(getEnvUint("RATE_LIMIT_WINDOW_MINUTES", constant.RATE_LIMIT_WINDOW_MINUTES)) * time.Minute
+
+	return RateLimitConfig{
+		LoginPerIP: RateLimit{
+			Max:    int(getEnvUint("LOGIN_RATE_LIMIT_PER_IP", constant.LOGIN_RATE_LIMIT_PER_IP)),
+			Window: window,
+		},
+		EmailPerIP: RateLimit{
+			Max:    int(getEnvUint("EMAIL_RATE_LIMIT_PER_IP", constant.EMAIL_RATE_LIMIT_PER_IP)),
+			Window: window,
+		},
+		EmailPerAccount: RateLimit{
+			Max:    int(getEnvUint("EMAIL_RATE_LIMIT_PER_ACCOUNT", constant.EMAIL_RATE_LIMIT_PER_ACCOUNT)),
+			Window: window,
+		},
+		LoginLockout: LockoutPolicy{
+			MaxFailures:  int(getEnvUint("LOGIN_MAX_FAILURES", constant.LOGIN_MAX_FAILURES)),
+			BaseDuration: time.Duration(getEnvUint("LOGIN_LOCKOUT_BASE_MINUTES", constant.LOGIN_LOCKOUT_BASE_MINUTES)) * time.Minute,
+			MaxDuration:  time.Duration(getEnvUint("LOGIN_LOCKOUT_MAX_MINUTES", constant.LOGIN_LOCKOUT_MAX_MINUTES)) * time.Minute,
+		},
+	}
+}
+
+// IPアドレスやアカウントごとのリクエスト数とログイン失敗回数をメモリ上で管理する
+// nilの場合は制限しない
+type RateLimiter struct {
+	Config RateLimitConfig
+
+	mu        sync.Mutex
+	now       func() time.Time
+	windows   map[string]*rateWindow
+	failures  map[string]*failureRecord
+	lastSweep time.Time
+}
+
+type rateWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+type failureRecord struct {
+	count       int
+	lockouts    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		Config:   config,
+		now:      time.Now,
+		windows:  map[string]*rateWindow{},
+		failures: map[string]*failureRecord{},
+	}
+}
+
+// 上限に達していなければリクエストを数えてtrueを返す
+// 上限に達している場合は再試行までの時間を返す
+func (limiter *RateLimiter) Allow(key string, limit RateLimit) (bool, time.Duration) {
+	if limiter == nil || limit.Max <= 0 {
+		return true, 0
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	limiter.sweep(now)
+
+	window, ok := limiter.windows[key]
+	if !ok || !now.Before(window.resetAt) {
+		window = &rateWindow{resetAt: now.Add(limit.Window)}
+		limiter.windows[key] = window
+	}
+
+	if window.count >= limit.Max {
+		return false, window.resetAt.Sub(now)
+	}
+	window.count++
+
+	return true, 0
+}
+
+// ロックアウト中であれば解除までの時間を返す
+func (limiter *RateLimiter) LockedFor(key string) time.Duration {
+	if limiter == nil {
+		return 0
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	record, ok := limiter.failures[key]
+	if !ok {
+		return 0
+	}
+
+	now := limiter.now()
+	if now.Before(record.lockedUntil) {
+		return record.lockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// 失敗を記録し、上限に達した場合はロックアウトしてロック時間を返す
+func (limiter *RateLimiter) RecordFailure(key string, policy LockoutPolicy) time.Duration {
+	if limiter == nil || policy.MaxFailures <= 0 {
+		return 0
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	record, ok := limiter.failures[key]
+	if !ok {
+		record = &failureRecord{}
+		limiter.failures[key] = record
+	}
+
+	// しばらく失敗がなければ回数とロック時間を元に戻す
+	if now.Sub(record.lastFailure) > policy.MaxDuration {
+		record.count = 0
+		record.lockouts = 0
+	}
+	record.count++
+	record.lastFailure = now
+
+	if record.count < policy.MaxFailures {
+		return 0
+	}
+
+	lockDuration := policy.BaseDuration << uint(record.lockouts)
+	if lockDuration > policy.MaxDuration || lockDuration <= 0 {
+		lockDuration = policy.MaxDuration
+	}
+	record.count = 0
+	record.lockouts++
+	record.lockedUntil = now.Add(lockDuration)
+	// ロックが解除されるまでは記録を残す
+	record.lastFailure = record.lockedUntil
+
+	return lockDuration
+}
+
+// 成功したら失敗の記録を削除する
+func (limiter *RateLimiter) Reset(key string) {
+	if limiter == nil {
+		return
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	delete(limiter.failures, key)
+}
+
+// 大文字・小文字や前後の空白が違うだけのメールアドレスを同じキーとして扱う
+func RateLimitKey(prefix string, value string) string {
+	return prefix + ":" + strings.ToLower(strings.TrimSpace(value))
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// 期限切れの記録を定期的に削除する
+func (limiter *RateLimiter) sweep(now time.Time) {
+	if now.Sub(limiter.lastSweep) < time.Minute {
+		return
+	}
+	limiter.lastSweep = now
+
+	for key, window := range limiter.windows {
+		if !now.Before(window.resetAt) {
+			delete(limiter.windows, key)
+		}
+	}
+
+	for key, record := range limiter.failures {
+		if now.Sub(record.lastFailure) > limiter.Config.LoginLockout.MaxDuration {
+			delete(limiter.failures, key)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRateLimiter(now *time.Time) *RateLimiter {
+	limiter := NewRateLimiter(RateLimitConfig{
+		LoginLockout: LockoutPolicy{
+			MaxFailures:  3,
+			BaseDuration: time.Minute,
+			MaxDuration:  5 * time.Minute,
+		},
+	})
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Now()
+	limiter := newTestRateLimiter(&now)
+	limit := RateLimit{Max: 2, Window: time.Minute}
+
+	allowed, _ := limiter.Allow("ip:127.0.0.1", limit)
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("ip:127.0.0.1", limit)
+	assert.True(t, allowed)
+
+	// 上限を超えると再試行までの時間を返す
+	allowed, retryAfter := limiter.Allow("ip:127.0.0.1", limit)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	// 別のキーは影響を受けない
+	allowed, _ = limiter.Allow("ip:192.168.0.1", limit)
+	assert.True(t, allowed)
+
+	// 期間が過ぎると再びリクエストできる
+	now = now.Add(time.Minute)
+	allowed, _ = limiter.Allow("ip:127.0.0.1", limit)
+	assert.True(t, allowed)
+}
+
+func TestRateLimiterLockout(t *testing.T) {
+	now := time.Now()
+	limiter := newTestRateLimiter(&now)
+	policy := limiter.Config.LoginLockout
+
+	// 上限に達するまではロックしない
+	assert.Equal(t, time.Duration(0), limiter.RecordFailure("login:test@example.com", policy))
+	assert.Equal(t, time.Duration(0), limiter.RecordFailure("login:test@example.com", policy))
+	assert.Equal(t, time.Minute, limiter.RecordFailure("login:test@example.com", policy))
+	assert.Equal(t, time.Minute, limiter.LockedFor("login:test@example.com"))
+
+	// ロックアウトが繰り返されるとロック時間が倍になる
+	now = now.Add(time.Minute)
+	assert.Equal(t, time.Duration(0), limiter.LockedFor("login:test@example.com"))
+	limiter.RecordFailure("login:test@example.com", policy)
+	limiter.RecordFailure("login:test@example.com", policy)
+	assert.Equal(t, 2*time.Minute, limiter.RecordFailure("login:test@example.com", policy))
+
+	// ロック時間は上限を超えない
+	for i := 0; i < 2; i++ {
+		now = now.Add(5 * time.Minute)
+		limiter.RecordFailure("login:test@example.com", policy)
+		limiter.RecordFailure("login:test@example.com", policy)
+		limiter.RecordFailure("login:test@example.com", policy)
+	}
+	assert.Equal(t, 5*time.Minute, limiter.LockedFor("login:test@example.com"))
+
+	// 成功すると記録が消える
+	limiter.Reset("login:test@example.com")
+	assert.Equal(t, time.Duration(0), limiter.LockedFor("login:test@example.com"))
+}
+
+func TestRateLimiterNil(t *testing.T) {
+	var limiter *RateLimiter
+
+	allowed, _ := limiter.Allow("ip:127.0.0.1", RateLimit{Max: 1, Window: time.Minute})
+	assert.True(t, allowed)
+	assert.Equal(t, time.Duration(0), limiter.RecordFailure("login:test@example.com", LockoutPolicy{MaxFailures: 1}))
+	assert.Equal(t, time.Duration(0), limiter.LockedFor("login:test@example.com"))
+}
+
+func TestRateLimitKey(t *testing.T) {
+	assert.Equal(t, RateLimitKey("login", "test@example.com"), RateLimitKey("login", " Test@Example.com "))
+	assert.NotEqual(t, RateLimitKey("login", "test@example.com"), RateLimitKey("email", "test@example.com"))
+}
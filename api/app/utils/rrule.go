@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RFC 5545の繰り返しルール（RRULE）のうち、次の部分のみに対応する
+// FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY（WEEKLYのみ）, BYMONTHDAY（MONTHLYのみ）, COUNT, UNTIL
+// 日単位で繰り返すため、時刻は扱わない
+type RRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	Count      int        // 0の場合は回数を制限しない
+	Until      *time.Time // 指定した日を含む
+}
+
+const (
+	RRuleDaily   = "DAILY"
+	RRuleWeekly  = "WEEKLY"
+	RRuleMonthly = "MONTHLY"
+)
+
+// 一致する日が見つからない場合に探索を打ち切る期間の数
+const rruleMaxEmptyPeriods = 1000
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// 繰り返しルールの文字列を解析する（先頭の"RRULE:"は省略できる）
+func ParseRRule(value string) (RRule, error) {
+	rule := RRule{Interval: 1}
+
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return rule, errors.New("rrule is empty")
+	}
+
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ";") {
+		keyValue := strings.SplitN(part, "=", 2)
+		if len(keyValue) != 2 || keyValue[1] == "" {
+			return rule, fmt.Errorf("invalid rrule part: %q", part)
+		}
+		key, val := strings.ToUpper(keyValue[0]), strings.ToUpper(keyValue[1])
+		if seen[key] {
+			return rule, fmt.Errorf("duplicate rrule part: %s", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			if val != RRuleDaily && val != RRuleWeekly && val != RRuleMonthly {
+				return rule, fmt.Errorf("unsupported FREQ: %s", val)
+			}
+			rule.Freq = val
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return rule, fmt.Errorf("invalid INTERVAL: %s", val)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday, ok := rruleWeekdays[day]
+				if !ok {
+					return rule, fmt.Errorf("unsupported BYDAY: %s", day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return rule, fmt.Errorf("invalid BYMONTHDAY: %s", day)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, monthDay)
+			}
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return rule, fmt.Errorf("invalid COUNT: %s", val)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseRRuleDate(val)
+			if err != nil {
+				return rule, err
+			}
+			rule.Until = &until
+		default:
+			return rule, fmt.Errorf("unsupported rrule part: %s", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return rule, errors.New("FREQ is required")
+	}
+	if rule.Count != 0 && rule.Until != nil {
+		return rule, errors.New("COUNT and UNTIL cannot be used together")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != RRuleWeekly {
+		return rule, errors.New("BYDAY is supported only with FREQ=WEEKLY")
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != RRuleMonthly {
+		return rule, errors.New("BYMONTHDAY is supported only with FREQ=MONTHLY")
+	}
+
+	return rule, nil
+}
+
+// 開始日以降でルールに一致する日を古い順にfnに渡す
+// COUNTまたはUNTILに達するか、fnがfalseを返すと終了する
+// 開始日がルールに一致しない場合、開始日は繰り返しに含めない
+func (rule RRule) Each(start time.Time, fn func(occurrence time.Time, index int) bool) {
+	start = truncateToDate(start)
+	var until *time.Time
+	if rule.Until != nil {
+		// 開始日のタイムゾーンの日付として比較する
+		untilDate := time.Date(rule.Until.Year(), rule.Until.Month(), rule.Until.Day(), 0, 0, 0, 0, start.Location())
+		until = &untilDate
+	}
+	index := 0
+	emptyPeriods := 0
+
+	for period := 0; emptyPeriods < rruleMaxEmptyPeriods; period++ {
+		candidates := rule.periodDates(start, period)
+		found := false
+
+		for _, candidate := range candidates {
+			if candidate.Before(start) {
+				continue
+			}
+			if until != nil && candidate.After(*until) {
+				return
+			}
+			found = true
+			if !fn(candidate, index) {
+				return
+			}
+			index++
+			if rule.Count != 0 && index >= rule.Count {
+				return
+			}
+		}
+
+		if found {
+			emptyPeriods = 0
+		} else {
+			emptyPeriods++
+		}
+	}
+}
+
+// 開始日以降で指定した日より後の最初の繰り返し日を返す
+func (rule RRule) Next(start time.Time, after time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+
+	rule.Each(start, func(occurrence time.Time, _ int) bool {
+		if occurrence.After(after) {
+			next, found = occurrence, true
+			return false
+		}
+		return true
+	})
+
+	return next, found
+}
+
+// 指定した日がルールの繰り返し日か確認する
+func (rule RRule) Includes(start time.Time, date time.Time) bool {
+	date = truncateToDate(date)
+	included := false
+
+	rule.Each(start, func(occurrence time.Time, _ int) bool {
+		if occurrence.Equal(date) {
+			included = true
+		}
+		return occurrence.Before(date)
+	})
+
+	return included
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// 開始日からperiod番目の期間（日・週・月）に含まれる候補日を古い順に返す
+func (rule RRule) periodDates(start time.Time, period int) []time.Time {
+	switch rule.Freq {
+	case RRuleDaily:
+		return []time.Time{start.AddDate(0, 0, period*rule.Interval)}
+
+	case RRuleWeekly:
+		weekdays := rule.ByDay
+		if len(weekdays) == 0 {
+			weekdays = []time.Weekday{start.Weekday()}
+		}
+		// 週の始まりは月曜日（WKST=MO）
+		weekStart := start.AddDate(0, 0, -mondayOffset(start.Weekday())+period*rule.Interval*7)
+		dates := make([]time.Time, 0, len(weekdays))
+		for _, weekday := range weekdays {
+			dates = append(dates, weekStart.AddDate(0, 0, mondayOffset(weekday)))
+		}
+		return uniqueSortedDates(dates)
+
+	case RRuleMonthly:
+		monthDays := rule.ByMonthDay
+		if len(monthDays) == 0 {
+			monthDays = []int{start.Day()}
+		}
+		monthStart := time.Date(start.Year(), start.Month()+time.Month(period*rule.Interval), 1, 0, 0, 0, 0, start.Location())
+		lastDay := monthStart.AddDate(0, 1, -1).Day()
+		dates := make([]time.Time, 0, len(monthDays))
+		for _, monthDay := range monthDays {
+			if monthDay < 0 {
+				monthDay = lastDay + 1 + monthDay
+			}
+			// 存在しない日（2月30日など）は無視する
+			if monthDay < 1 || monthDay > lastDay {
+				continue
+			}
+			dates = append(dates, monthStart.AddDate(0, 0, monthDay-1))
+		}
+		return uniqueSortedDates(dates)
+	}
+
+	return nil
+}
+
+func parseRRuleDate(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return truncateToDate(date), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL: %s", value)
+}
+
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func mondayOffset(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+// BYDAYやBYMONTHDAYの重複（31と-1など）で同じ日が含まれないようにする
+func uniqueSortedDates(dates []time.Time) []time.Time {
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+
+	unique := make([]time.Time, 0, len(dates))
+	for _, date := range dates {
+		if len(unique) == 0 || !date.Equal(unique[len(unique)-1]) {
+			unique = append(unique, date)
+		}
+	}
+	return unique
+}
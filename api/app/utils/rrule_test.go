@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRRule(t *testing.T) {
+	rule, err := ParseRRule("RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=4")
+	assert.Nil(t, err)
+	assert.Equal(t, RRuleWeekly, rule.Freq)
+	assert.Equal(t, 2, rule.Interval)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Friday}, rule.ByDay)
+	assert.Equal(t, 4, rule.Count)
+
+	rule, err = ParseRRule("FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20231231T000000Z")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, -1}, rule.ByMonthDay)
+	assert.Equal(t, "2023-12-31", rule.Until.Format("2006-01-02"))
+
+	invalidRules := []string{
+		"",
+		"BYDAY=MO",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20231231",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;BYHOUR=9",
+	}
+	for _, invalidRule := range invalidRules {
+		_, err := ParseRRule(invalidRule)
+		assert.NotNil(t, err, invalidRule)
+	}
+}
+
+func TestRRuleEach(t *testing.T) {
+	date := func(value string) time.Time {
+		d, _ := time.Parse("2006-01-02", value)
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		start    string
+		expected []string
+	}{
+		{
+			name:     "毎日",
+			rule:     "FREQ=DAILY;INTERVAL=2;COUNT=3",
+			start:    "2023-01-30",
+			expected: []string{"2023-01-30", "2023-02-01", "2023-02-03"},
+		},
+		{
+			// 2023-01-04は水曜日
+			name:     "毎週_曜日指定",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=4",
+			start:    "2023-01-04",
+			expected: []string{"2023-01-06", "2023-01-09", "2023-01-13", "2023-01-16"},
+		},
+		{
+			name:     "隔週_曜日指定なし",
+			rule:     "FREQ=WEEKLY;INTERVAL=2;UNTIL=20230201",
+			start:    "2023-01-04",
+			expected: []string{"2023-01-04", "2023-01-18", "2023-02-01"},
+		},
+		{
+			// 31日のない月は飛ばす
+			name:     "毎月_日付指定",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=3",
+			start:    "2023-01-15",
+			expected: []string{"2023-01-31", "2023-03-31", "2023-05-31"},
+		},
+		{
+			// 31日と末日が重なる月は1回
+			name:     "毎月_末日",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=31,-1;UNTIL=20230430",
+			start:    "2023-01-01",
+			expected: []string{"2023-01-31", "2023-02-28", "2023-03-31", "2023-04-30"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rule)
+			assert.Nil(t, err)
+
+			occurrences := []string{}
+			rule.Each(date(tt.start), func(occurrence time.Time, _ int) bool {
+				occurrences = append(occurrences, occurrence.Format("2006-01-02"))
+				return len(occurrences) < 10
+			})
+			assert.Equal(t, tt.expected, occurrences)
+		})
+	}
+}
+
+func TestRRuleNextAndIncludes(t *testing.T) {
+	rule, _ := ParseRRule("FREQ=WEEKLY;BYDAY=TU")
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	next, found := rule.Next(start, time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC))
+	assert.True(t, found)
+	assert.Equal(t, "2023-01-10", next.Format("2006-01-02"))
+
+	assert.True(t, rule.Includes(start, time.Date(2023, 1, 17, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, rule.Includes(start, time.Date(2023, 1, 18, 0, 0, 0, 0, time.UTC)))
+
+	// 回数に達した後は見つからない
+	rule, _ = ParseRRule("FREQ=DAILY;COUNT=2")
+	_, found = rule.Next(start, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+	assert.False(t, found)
+}
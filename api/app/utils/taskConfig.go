@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+type TaskConfig struct {
+	// trueの場合、親タスクをLook Backに移動するとサブタスクもあわせて移動する
+	// falseの場合、すべてのサブタスクが完了していなければ親タスクをLook Backに移動できない
+	CascadeSubtaskCompletion bool
+	// 繰り返しタスクからタスクを作成する間隔
+	RecurringTaskInterval time.Duration
+	// 予定日の何日前に繰り返しタスクからタスクを作成するか（0の場合は予定日当日）
+	RecurringTaskLookaheadDays int
+	// ゴミ箱に移してから完全に削除するまでの期間（0の場合は削除しない）
+	TrashRetention time.Duration
+	// ゴミ箱から期限切れのデータを削除する間隔
+	TrashPurgeInterval time.Duration
+	// trueの場合、WIP制限を超えるタスクの作成・変更を拒否する
+	// falseの場合、タスクを作成・変更し、超えたWIP制限を警告として返す
+	RejectOverWipLimit bool
+}
+
+// 環境変数からタスクの設定を取得する（未設定の場合はデフォルト値）
+func GetTaskConfig() TaskConfig {
+	policy := os.Getenv("SUBTASK_COMPLETION_POLICY")
+	if policy == "" {
+		policy = constant.SUBTASK_COMPLETION_POLICY
+	}
+	wipLimitPolicy := os.Getenv("WIP_LIMIT_POLICY")
+	if wipLimitPolicy == "" {
+		wipLimitPolicy = constant.WIP_LIMIT_POLICY
+	}
+	trashRetentionDays := uint64(constant.TRASH_RETENTION_DAYS)
+	if value, err := strconv.ParseUint(os.Getenv("TRASH_RETENTION_DAYS"), 10, 32); err == nil {
+		trashRetentionDays = value
+	}
+
+	return TaskConfig{
+		CascadeSubtaskCompletion:   policy == constant.SUBTASK_COMPLETION_CASCADE,
+		RecurringTaskInterval:      time.Duration(getEnvUint("RECURRING_TASK_INTERVAL_MINUTES", constant.RECURRING_TASK_INTERVAL_MINUTES)) * time.Minute,
+		RecurringTaskLookaheadDays: int(getEnvUint("RECURRING_TASK_LOOKAHEAD_DAYS", constant.RECURRING_TASK_LOOKAHEAD_DAYS)),
+		TrashRetention:             time.Duration(trashRetentionDays) * time.Hour * 24,
+		TrashPurgeInterval:         time.Duration(getEnvUint("TRASH_PURGE_INTERVAL_HOURS", constant.TRASH_PURGE_INTERVAL_HOURS)) * time.Hour,
+		RejectOverWipLimit:         wipLimitPolicy != constant.WIP_LIMIT_WARN,
+	}
+}
+
+// 繰り返しタスクからタスクを作成する期限（この日までの予定日のタスクを作成する）
+func (taskConfig TaskConfig) RecurringTaskHorizon(now time.Time) time.Time {
+	return now.AddDate(0, 0, taskConfig.RecurringTaskLookaheadDays)
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTaskConfig(t *testing.T) {
+	originalPolicy := os.Getenv("SUBTASK_COMPLETION_POLICY")
+	defer os.Setenv("SUBTASK_COMPLETION_POLICY", originalPolicy)
+
+	// 未設定の場合はサブタスクの完了が必要
+	os.Setenv("SUBTASK_COMPLETION_POLICY", "")
+	assert.False(t, GetTaskConfig().CascadeSubtaskCompletion)
+
+	os.Setenv("SUBTASK_COMPLETION_POLICY", "cascade")
+	assert.True(t, GetTaskConfig().CascadeSubtaskCompletion)
+
+	os.Setenv("SUBTASK_COMPLETION_POLICY", "require")
+	assert.False(t, GetTaskConfig().CascadeSubtaskCompletion)
+}
+
+func TestGetRecurringTaskConfig(t *testing.T) {
+	originalInterval := os.Getenv("RECURRING_TASK_INTERVAL_MINUTES")
+	originalLookahead := os.Getenv("RECURRING_TASK_LOOKAHEAD_DAYS")
+	defer os.Setenv("RECURRING_TASK_INTERVAL_MINUTES", originalInterval)
+	defer os.Setenv("RECURRING_TASK_LOOKAHEAD_DAYS", originalLookahead)
+
+	os.Setenv("RECURRING_TASK_INTERVAL_MINUTES", "")
+	os.Setenv("RECURRING_TASK_LOOKAHEAD_DAYS", "")
+	taskConfig := GetTaskConfig()
+	assert.Equal(t, time.Hour, taskConfig.RecurringTaskInterval)
+	assert.Equal(t, 0, taskConfig.RecurringTaskLookaheadDays)
+
+	os.Setenv("RECURRING_TASK_INTERVAL_MINUTES", "15")
+	os.Setenv("RECURRING_TASK_LOOKAHEAD_DAYS", "3")
+	taskConfig = GetTaskConfig()
+	assert.Equal(t, 15*time.Minute, taskConfig.RecurringTaskInterval)
+	now := time.Date(2023, 1, 30, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2023, 2, 2, 9, 0, 0, 0, time.UTC), taskConfig.RecurringTaskHorizon(now))
+}
+
+func TestGetTrashConfig(t *testing.T) {
+	originalRetention := os.Getenv("TRASH_RETENTION_DAYS")
+	originalInterval := os.Getenv("TRASH_PURGE_INTERVAL_HOURS")
+	defer os.Setenv("TRASH_RETENTION_DAYS", originalRetention)
+	defer os.Setenv("TRASH_PURGE_INTERVAL_HOURS", originalInterval)
+
+	os.Setenv("TRASH_RETENTION_DAYS", "")
+	os.Setenv("TRASH_PURGE_INTERVAL_HOURS", "")
+	taskConfig := GetTaskConfig()
+	assert.Equal(t, 30*24*time.Hour, taskConfig.TrashRetention)
+	assert.Equal(t, 24*time.Hour, taskConfig.TrashPurgeInterval)
+
+	// 0の場合は削除しない
+	os.Setenv("TRASH_RETENTION_DAYS", "0")
+	os.Setenv("TRASH_PURGE_INTERVAL_HOURS", "6")
+	taskConfig = GetTaskConfig()
+	assert.Equal(t, time.Duration(0), taskConfig.TrashRetention)
+	assert.Equal(t, 6*time.Hour, taskConfig.TrashPurgeInterval)
+}
+
+func TestGetWipLimitConfig(t *testing.T) {
+	originalPolicy := os.Getenv("WIP_LIMIT_POLICY")
+	defer os.Setenv("WIP_LIMIT_POLICY", originalPolicy)
+
+	// 未設定の場合は拒否する
+	os.Setenv("WIP_LIMIT_POLICY", "")
+	assert.True(t, GetTaskConfig().RejectOverWipLimit)
+
+	os.Setenv("WIP_LIMIT_POLICY", "warn")
+	assert.False(t, GetTaskConfig().RejectOverWipLimit)
+
+	os.Setenv("WIP_LIMIT_POLICY", "reject")
+	assert.True(t, GetTaskConfig().RejectOverWipLimit)
+}
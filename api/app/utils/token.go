@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
-	"strconv"
 	"time"
 	"log"
 
@@ -12,59 +15,365 @@ import (
 	"github.com/alicend/LookBack/app/constant"
 )
 
-func GenerateSessionToken(userId uint) (string, error) {
-	secretKey := os.Getenv("SESSION_SECRET_KEY") // 暗号化、復号化するためのキー
-	tokenLifeTime, err := strconv.Atoi(constant.JWT_TOKEN_LIFETIME)
+// 有効期限の短いアクセストークンを生成する
+// sessionIDはサーバー側のセッション(sessionsテーブル)のトークンID
+func GenerateSessionToken(userId uint, sessionID string) (string, error) {
+	return signToken(sessionTokenType, jwt.MapClaims{
+		"user_id": userId,
+		"sid":     sessionID,
+		"exp":     time.Now().Add(time.Minute * constant.ACCESS_TOKEN_LIFETIME_MINUTES).Unix(),
+	})
+}
+
+func GenerateEmailToken(email string) (string, error) {
+	return signToken(emailTokenType, jwt.MapClaims{
+			"email": email,
+			"exp":  time.Now().Add(time.Hour * 1).Unix(),
+	})
+}
+
+type UserGroupIDClaims struct {
+	UserGroupID uint
+	Email       string
+}
+
+// 招待先のユーザーグループのトークン
+// 招待したメールアドレス以外で使えないよう、メールアドレスもあわせて署名する
+func GenerateUserGroupIDToken(userGroupID uint, email string) (string, error) {
+	return signToken(userGroupIDTokenType, jwt.MapClaims{
+			"user_group_id": userGroupID,
+			"email":         email,
+			"exp":  time.Now().Add(time.Hour * 1).Unix(),
+	})
+}
+
+func ParseSessionToken(tokenString string) (*jwt.Token, error) {
+	return parseToken(sessionTokenType, tokenString)
+}
+
+type PasswordResetClaims struct {
+	UserID              uint
+	TokenID             string
+	PasswordFingerprint string
+}
+
+func GeneratePasswordResetToken(userID uint, tokenID string, passwordFingerprint string) (string, error) {
+	return signToken(passwordResetTokenType, jwt.MapClaims{
+			"user_id": userID,
+			"jti":     tokenID,
+			"pwd":     passwordFingerprint,
+			"exp":     time.Now().Add(time.Minute * constant.PASSWORD_RESET_TOKEN_LIFETIME_MINUTES).Unix(),
+	})
+}
+
+func ParsePasswordResetToken(tokenString string) (PasswordResetClaims, error) {
+	var resetClaims PasswordResetClaims
+
+	claims, err := parseTokenClaims(passwordResetTokenType, tokenString)
 	if err != nil {
+		return resetClaims, err
+	}
+
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return resetClaims, errors.New("failed to parse user ID")
+	}
+	tokenID, ok := claims["jti"].(string)
+	if !ok || tokenID == "" {
+		return resetClaims, errors.New("failed to parse token ID")
+	}
+	passwordFingerprint, ok := claims["pwd"].(string)
+	if !ok || passwordFingerprint == "" {
+		return resetClaims, errors.New("failed to parse password fingerprint")
+	}
+
+	resetClaims = PasswordResetClaims{
+		UserID:              uint(userIDFloat),
+		TokenID:             tokenID,
+		PasswordFingerprint: passwordFingerprint,
+	}
+	return resetClaims, nil
+}
+
+// パスワードハッシュそのものをトークンに含めないよう、ハッシュのダイジェストを返す
+func PasswordFingerprint(hashedPassword string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(hashedPassword)))
+}
+
+// アクセストークンからセッションのトークンIDを取得する
+func GetSessionIDFromToken(token *jwt.Token) (string, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", errors.New("failed to parse claims")
+	}
+
+	sessionID, ok := claims["sid"].(string)
+	if !ok || sessionID == "" {
+		return "", errors.New("failed to parse session ID")
+	}
+
+	return sessionID, nil
+}
+
+// リフレッシュトークンなど、平文で保存しないトークンのダイジェストを返す
+func HashToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
+
+// トークンIDなどに使うランダムな文字列を生成する
+func GenerateRandomString(byteLength int) (string, error) {
+	b := make([]byte, byteLength)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
+	return hex.EncodeToString(b), nil
+}
 
-	claims := jwt.MapClaims{
-		"user_id": userId,
-		"exp":     time.Now().Add(time.Hour * time.Duration(tokenLifeTime)).Unix(),
+type EmailChangeClaims struct {
+	UserID  uint
+	Email   string
+	TokenID string
+}
+
+// メールアドレス変更の確認用トークン（新しいメールアドレス宛て）
+func GenerateEmailChangeToken(userID uint, newEmail string, tokenID string) (string, error) {
+	return signToken(emailChangeTokenType, jwt.MapClaims{
+			"user_id": userID,
+			"email":   newEmail,
+			"jti":     tokenID,
+			"exp":     time.Now().Add(time.Hour * constant.EMAIL_CHANGE_TOKEN_LIFETIME_HOURS).Unix(),
+	})
+}
+
+// メールアドレス変更の取り消し用トークン（変更前のメールアドレス宛て）
+func GenerateEmailChangeCancelToken(userID uint, tokenID string) (string, error) {
+	return signToken(emailChangeCancelTokenType, jwt.MapClaims{
+			"user_id": userID,
+			"jti":     tokenID,
+			"exp":     time.Now().Add(time.Hour * constant.EMAIL_CHANGE_TOKEN_LIFETIME_HOURS).Unix(),
+	})
+}
+
+func ParseEmailChangeToken(tokenString string) (EmailChangeClaims, error) {
+	changeClaims, err := parseEmailChangeToken(emailChangeTokenType, tokenString)
+	if err != nil {
+		return changeClaims, err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secretKey))
+	if changeClaims.Email == "" {
+		return changeClaims, errors.New("failed to parse email")
+	}
+	return changeClaims, nil
+}
+
+func ParseEmailChangeCancelToken(tokenString string) (EmailChangeClaims, error) {
+	return parseEmailChangeToken(emailChangeCancelTokenType, tokenString)
+}
+
+func ParseEmailToken(tokenString string) (string, error) {
+	claims, err := parseTokenClaims(emailTokenType, tokenString)
 	if err != nil {
 		return "", err
 	}
 
-	return tokenString, nil
+	email, ok := claims["email"].(string)
+	if !ok || email == "" {
+		return "", errors.New("failed to parse email")
+	}
+
+	return email, nil
 }
 
-func GenerateEmailToken(email string) (string, error) {
-	secretKey := os.Getenv("EMAIL_SECRET_KEY") // 暗号化、復号化するためのキー
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"email": email,
-			"exp":  time.Now().Add(time.Hour * 1).Unix(),
-	})
+func ParseUserGroupIDToken(tokenString string) (UserGroupIDClaims, error) {
+	var userGroupIDClaims UserGroupIDClaims
 
-	tokenString, err := token.SignedString([]byte(secretKey))
-	return tokenString, err
+	claims, err := parseTokenClaims(userGroupIDTokenType, tokenString)
+	if err != nil {
+		return userGroupIDClaims, err
+	}
+
+	userGroupIDFloat, ok := claims["user_group_id"].(float64)
+	if !ok {
+		return userGroupIDClaims, errors.New("failed to parse user group ID")
+	}
+	email, ok := claims["email"].(string)
+	if !ok || email == "" {
+		return userGroupIDClaims, errors.New("failed to parse email")
+	}
+
+	userGroupIDClaims = UserGroupIDClaims{
+		UserGroupID: uint(userGroupIDFloat),
+		Email:       email,
+	}
+	return userGroupIDClaims, nil
 }
 
-func GenerateUserGroupIDToken(userGroupID uint) (string, error) {
-	secretKey := os.Getenv("USER_GROUP_ID_SECRET_KEY") // 暗号化、復号化するためのキー
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"user_group_id": userGroupID,
-			"exp":  time.Now().Add(time.Hour * 1).Unix(),
+// パスワード認証後、二要素認証が完了するまでの間だけ使う有効期限の短いトークン
+func GenerateMFAChallengeToken(userID uint) (string, error) {
+	return signToken(mfaChallengeTokenType, jwt.MapClaims{
+			"user_id": userID,
+			"exp":     time.Now().Add(time.Minute * constant.MFA_CHALLENGE_TOKEN_LIFETIME_MINUTES).Unix(),
 	})
+}
 
-	tokenString, err := token.SignedString([]byte(secretKey))
-	return tokenString, err
+func ParseMFAChallengeToken(tokenString string) (uint, error) {
+	claims, err := parseTokenClaims(mfaChallengeTokenType, tokenString)
+	if err != nil {
+		return 0, err
+	}
+
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, errors.New("failed to parse user ID")
+	}
+
+	return uint(userIDFloat), nil
 }
 
-func ParseSessionToken(tokenString string) (*jwt.Token, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+// トークンの種類
+// 種類ごとに対象者(aud)を分け、ある種類のトークンを別の種類として受け付けないようにする
+// legacySecretEnvは鍵束(JWT_SIGNING_KEYS)を設定する前から使っている、種類ごとの署名鍵の環境変数
+type tokenType struct {
+	audience        string
+	legacySecretEnv string
+}
+
+var (
+	sessionTokenType           = tokenType{"session", "SESSION_SECRET_KEY"}
+	emailTokenType             = tokenType{"email", "EMAIL_SECRET_KEY"}
+	userGroupIDTokenType       = tokenType{"user-group-id", "USER_GROUP_ID_SECRET_KEY"}
+	passwordResetTokenType     = tokenType{"password-reset", "PASSWORD_RESET_SECRET_KEY"}
+	emailChangeTokenType       = tokenType{"email-change-confirm", "EMAIL_CHANGE_SECRET_KEY"}
+	emailChangeCancelTokenType = tokenType{"email-change-cancel", "EMAIL_CHANGE_SECRET_KEY"}
+	mfaChallengeTokenType      = tokenType{"mfa-challenge", "MFA_CHALLENGE_SECRET_KEY"}
+	oidcStateTokenType         = tokenType{"oidc-state", "OIDC_STATE_SECRET_KEY"}
+)
+
+// 発行者と対象者を設定して署名する
+// 鍵束が設定されている場合は有効な鍵で署名し、kidヘッダーに鍵のIDを設定する
+func signToken(tokenType tokenType, claims jwt.MapClaims) (string, error) {
+	claims["iss"] = jwtIssuer()
+	claims["aud"] = tokenType.audience
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	keyring, err := GetKeyring()
+	if err != nil {
+		log.Printf("Failed to load keyring: %v", err)
+		return "", err
+	}
+	if keyring == nil {
+		secret, err := legacySecret(tokenType)
+		if err != nil {
+			log.Printf("Failed to load signing key: %v", err)
+			return "", err
 		}
-		return []byte(os.Getenv("SESSION_SECRET_KEY")), nil
-	})
+		return token.SignedString(secret)
+	}
+
+	signingKey := keyring.SigningKey()
+	token.Header["kid"] = signingKey.ID
+	return token.SignedString(signingKey.Secret)
+}
+
+// 署名、発行者、対象者、有効期限を検証する
+func parseToken(tokenType tokenType, tokenString string) (*jwt.Token, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return verificationKey(tokenType, token)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(jwtIssuer()),
+		jwt.WithAudience(tokenType.audience),
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println(token)
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("failed to parse claims")
+	}
+
+	// 有効期限のないトークンは受け付けない
+	if exp, err := claims.GetExpirationTime(); err != nil || exp == nil {
+		return nil, errors.New("token has no expiration")
+	}
+
 	return token, nil
-}
\ No newline at end of file
+}
+
+func parseTokenClaims(tokenType tokenType, tokenString string) (jwt.MapClaims, error) {
+	token, err := parseToken(tokenType, tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return token.Claims.(jwt.MapClaims), nil
+}
+
+// kidヘッダーに対応する鍵束の鍵を返す
+// kidのないトークンは鍵束を設定する前に発行したものとして、種類ごとの鍵で検証する
+func verificationKey(tokenType tokenType, token *jwt.Token) (interface{}, error) {
+	keyring, err := GetKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	kid, hasKid := token.Header["kid"].(string)
+	if hasKid {
+		if keyring == nil {
+			return nil, ErrUnknownSigningKey
+		}
+		return keyring.Key(kid)
+	}
+
+	// 鍵束に移行した後は、種類ごとの鍵を削除すればkidのないトークンを受け付けなくなる
+	if keyring != nil && os.Getenv(tokenType.legacySecretEnv) == "" {
+		return nil, ErrUnknownSigningKey
+	}
+	return legacySecret(tokenType)
+}
+
+// 種類ごとの環境変数の鍵を返す
+// 空の鍵や短い鍵では署名を偽造できるため、鍵束と同じ長さに満たない鍵は使わない
+func legacySecret(tokenType tokenType) ([]byte, error) {
+	secret := os.Getenv(tokenType.legacySecretEnv)
+	if len(secret) < minSigningKeyLength {
+		return nil, fmt.Errorf("%s must be at least %d bytes (set a longer key or JWT_SIGNING_KEYS)", tokenType.legacySecretEnv, minSigningKeyLength)
+	}
+	return []byte(secret), nil
+}
+
+func jwtIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return constant.JWT_ISSUER
+}
+
+func parseEmailChangeToken(tokenType tokenType, tokenString string) (EmailChangeClaims, error) {
+	var changeClaims EmailChangeClaims
+
+	claims, err := parseTokenClaims(tokenType, tokenString)
+	if err != nil {
+		return changeClaims, err
+	}
+
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return changeClaims, errors.New("failed to parse user ID")
+	}
+	tokenID, ok := claims["jti"].(string)
+	if !ok || tokenID == "" {
+		return changeClaims, errors.New("failed to parse token ID")
+	}
+	email, _ := claims["email"].(string)
+
+	changeClaims = EmailChangeClaims{
+		UserID:  uint(userIDFloat),
+		Email:   email,
+		TokenID: tokenID,
+	}
+	return changeClaims, nil
+}
@@ -12,12 +12,12 @@ import (
 func TestGenerateSessionToken(t *testing.T) {
 	// テストのための環境変数をモック化
 	originalSecretKey := os.Getenv("SESSION_SECRET_KEY")
-	os.Setenv("SESSION_SECRET_KEY", "test_secret_key")
+	os.Setenv("SESSION_SECRET_KEY", "test_secret_key_0123456789abcdefghij")
 	defer os.Setenv("SESSION_SECRET_KEY", originalSecretKey)
 
 	userId := uint(1)
 
-	token, err := GenerateSessionToken(userId)
+	token, err := GenerateSessionToken(userId, "test_session_id")
 	assert.Nil(t, err, "Error should be nil")
 
 	assert.NotEmpty(t, token, "Token should not be empty")
@@ -26,7 +26,7 @@ func TestGenerateSessionToken(t *testing.T) {
 func TestGenerateEmailToken(t *testing.T) {
 	// テストのための環境変数をモック化
 	originalSecretKey := os.Getenv("EMAIL_SECRET_KEY")
-	os.Setenv("EMAIL_SECRET_KEY", "test_secret_key")
+	os.Setenv("EMAIL_SECRET_KEY", "test_secret_key_0123456789abcdefghij")
 	defer os.Setenv("EMAIL_SECRET_KEY", originalSecretKey)
 
 	email := "test@example.com"
@@ -50,12 +50,14 @@ func TestGenerateEmailToken(t *testing.T) {
 func TestParseSessionToken(t *testing.T) {
 	// テストのための環境変数をモック化
 	originalSecretKey := os.Getenv("SESSION_SECRET_KEY")
-	os.Setenv("SESSION_SECRET_KEY", "test_secret_key")
+	os.Setenv("SESSION_SECRET_KEY", "test_secret_key_0123456789abcdefghij")
 	defer os.Setenv("SESSION_SECRET_KEY", originalSecretKey)
 
 	userId := uint(1)
 	claims := jwt.MapClaims{
 		"user_id": userId,
+		"iss":     "look-back-api",
+		"aud":     "session",
 		"exp":     time.Now().Add(time.Hour * 24).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -72,4 +74,327 @@ func TestParseSessionToken(t *testing.T) {
 	assert.True(t, ok, "Token should have claims")
 
 	assert.Equal(t, userId, uint(parsedClaims["user_id"].(float64)), "User ID should be equal")
+
+	// 対象者のないトークンは受け付けない
+	delete(claims, "aud")
+	tokenString, _ = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(os.Getenv("SESSION_SECRET_KEY")))
+	_, err = ParseSessionToken(tokenString)
+	assert.NotNil(t, err, "Token without audience should be rejected")
+}
+
+func TestTokenTypesAreNotInterchangeable(t *testing.T) {
+	// 鍵束で同じ鍵を使っても、種類の異なるトークンは受け付けない
+	withKeyring(t, "key-1:"+testSigningSecret("1"), "")
+
+	sessionToken, _ := GenerateSessionToken(1, "session_id")
+	mfaToken, _ := GenerateMFAChallengeToken(1)
+	emailToken, _ := GenerateEmailToken("test@example.com")
+	cancelToken, _ := GenerateEmailChangeCancelToken(1, "token_id")
+
+	_, err := ParseMFAChallengeToken(sessionToken)
+	assert.NotNil(t, err, "Session token should not be accepted as MFA challenge token")
+	_, err = ParseSessionToken(mfaToken)
+	assert.NotNil(t, err, "MFA challenge token should not be accepted as session token")
+	_, err = ParseUserGroupIDToken(emailToken)
+	assert.NotNil(t, err, "Email token should not be accepted as user group ID token")
+	_, err = ParsePasswordResetToken(cancelToken)
+	assert.NotNil(t, err, "Email change cancel token should not be accepted as password reset token")
+
+	userID, err := ParseMFAChallengeToken(mfaToken)
+	assert.Nil(t, err, "Error should be nil")
+	assert.Equal(t, uint(1), userID, "User ID should be equal")
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	originalSecretKey := os.Getenv("SESSION_SECRET_KEY")
+	os.Setenv("SESSION_SECRET_KEY", "legacy_secret_key_0123456789abcdefgh")
+	defer os.Setenv("SESSION_SECRET_KEY", originalSecretKey)
+
+	// 鍵束を設定する前に発行したトークン
+	legacyToken, _ := GenerateSessionToken(1, "legacy_session_id")
+
+	withKeyring(t, "key-1:"+testSigningSecret("1"), "")
+	oldToken, _ := GenerateSessionToken(1, "old_session_id")
+	parsed, _ := ParseSessionToken(oldToken)
+	assert.Equal(t, "key-1", parsed.Header["kid"], "Token should have key id")
+
+	// 新しい鍵を追加するとすぐに新しい鍵で署名し、古い鍵のトークンも検証できる
+	withKeyring(t, "key-1:"+testSigningSecret("1")+",key-2:"+testSigningSecret("2"), "")
+	newToken, _ := GenerateSessionToken(1, "new_session_id")
+	parsed, err := ParseSessionToken(newToken)
+	assert.Nil(t, err, "Error should be nil")
+	assert.Equal(t, "key-2", parsed.Header["kid"], "Token should be signed with new key")
+
+	_, err = ParseSessionToken(oldToken)
+	assert.Nil(t, err, "Token signed with old key should be accepted until it is retired")
+	_, err = ParseSessionToken(legacyToken)
+	assert.Nil(t, err, "Legacy token should be accepted while legacy key is set")
+
+	// 古い鍵を外すと、古い鍵のトークンは受け付けない
+	withKeyring(t, "key-2:"+testSigningSecret("2"), "")
+	_, err = ParseSessionToken(oldToken)
+	assert.NotNil(t, err, "Token signed with retired key should be rejected")
+	_, err = ParseSessionToken(newToken)
+	assert.Nil(t, err, "Error should be nil")
+
+	// 種類ごとの鍵を削除すると、kidのないトークンは受け付けない
+	os.Setenv("SESSION_SECRET_KEY", "")
+	_, err = ParseSessionToken(legacyToken)
+	assert.NotNil(t, err, "Legacy token should be rejected after legacy key is removed")
+
+	forgedToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"sid":     "forged_session_id",
+		"iss":     "look-back-api",
+		"aud":     "session",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(""))
+	_, err = ParseSessionToken(forgedToken)
+	assert.NotNil(t, err, "Token signed with empty key should be rejected")
+}
+
+func TestParsePasswordResetToken(t *testing.T) {
+	// テストのための環境変数をモック化
+	originalSecretKey := os.Getenv("PASSWORD_RESET_SECRET_KEY")
+	os.Setenv("PASSWORD_RESET_SECRET_KEY", "test_secret_key_0123456789abcdefghij")
+	defer os.Setenv("PASSWORD_RESET_SECRET_KEY", originalSecretKey)
+
+	fingerprint := PasswordFingerprint("hashed_password")
+
+	t.Run("成功", func(t *testing.T) {
+		tokenString, err := GeneratePasswordResetToken(1, "token_id", fingerprint)
+		assert.Nil(t, err, "Error should be nil")
+
+		claims, err := ParsePasswordResetToken(tokenString)
+		assert.Nil(t, err, "Error should be nil")
+		assert.Equal(t, uint(1), claims.UserID, "User ID should be equal")
+		assert.Equal(t, "token_id", claims.TokenID, "Token ID should be equal")
+		assert.Equal(t, fingerprint, claims.PasswordFingerprint, "Fingerprint should be equal")
+	})
+
+	t.Run("失敗 - 偽造されたトークン", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": 1,
+			"jti":     "token_id",
+			"pwd":     fingerprint,
+			"exp":     time.Now().Add(time.Minute).Unix(),
+		})
+		tokenString, _ := token.SignedString([]byte("forged_secret_key"))
+
+		_, err := ParsePasswordResetToken(tokenString)
+		assert.NotNil(t, err, "Forged token should be rejected")
+	})
+
+	t.Run("失敗 - 有効期限切れ", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": 1,
+			"jti":     "token_id",
+			"pwd":     fingerprint,
+			"exp":     time.Now().Add(-time.Minute).Unix(),
+		})
+		tokenString, _ := token.SignedString([]byte("test_secret_key_0123456789abcdefghij"))
+
+		_, err := ParsePasswordResetToken(tokenString)
+		assert.NotNil(t, err, "Expired token should be rejected")
+	})
+
+	t.Run("失敗 - 有効期限なし", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": 1,
+			"jti":     "token_id",
+			"pwd":     fingerprint,
+		})
+		tokenString, _ := token.SignedString([]byte("test_secret_key_0123456789abcdefghij"))
+
+		_, err := ParsePasswordResetToken(tokenString)
+		assert.NotNil(t, err, "Token without expiration should be rejected")
+	})
+
+	t.Run("失敗 - 署名方式の改ざん", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+			"user_id": 1,
+			"jti":     "token_id",
+			"pwd":     fingerprint,
+			"exp":     time.Now().Add(time.Minute).Unix(),
+		})
+		tokenString, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+		_, err := ParsePasswordResetToken(tokenString)
+		assert.NotNil(t, err, "Unsigned token should be rejected")
+	})
+}
+
+func TestParseEmailChangeToken(t *testing.T) {
+	// テストのための環境変数をモック化
+	originalSecretKey := os.Getenv("EMAIL_CHANGE_SECRET_KEY")
+	os.Setenv("EMAIL_CHANGE_SECRET_KEY", "test_secret_key_0123456789abcdefghij")
+	defer os.Setenv("EMAIL_CHANGE_SECRET_KEY", originalSecretKey)
+
+	confirmToken, err := GenerateEmailChangeToken(1, "new@example.com", "token_id")
+	assert.Nil(t, err, "Error should be nil")
+	cancelToken, err := GenerateEmailChangeCancelToken(1, "token_id")
+	assert.Nil(t, err, "Error should be nil")
+
+	claims, err := ParseEmailChangeToken(confirmToken)
+	assert.Nil(t, err, "Error should be nil")
+	assert.Equal(t, uint(1), claims.UserID, "User ID should be equal")
+	assert.Equal(t, "new@example.com", claims.Email, "Email should be equal")
+	assert.Equal(t, "token_id", claims.TokenID, "Token ID should be equal")
+
+	claims, err = ParseEmailChangeCancelToken(cancelToken)
+	assert.Nil(t, err, "Error should be nil")
+	assert.Equal(t, "token_id", claims.TokenID, "Token ID should be equal")
+
+	// 確認用と取り消し用のトークンは取り違えられない
+	_, err = ParseEmailChangeToken(cancelToken)
+	assert.NotNil(t, err, "Cancel token should not be accepted as confirm token")
+	_, err = ParseEmailChangeCancelToken(confirmToken)
+	assert.NotNil(t, err, "Confirm token should not be accepted as cancel token")
+}
+
+func TestParseEmailToken(t *testing.T) {
+	// テストのための環境変数をモック化
+	originalSecretKey := os.Getenv("EMAIL_SECRET_KEY")
+	os.Setenv("EMAIL_SECRET_KEY", "test_secret_key_0123456789abcdefghij")
+	defer os.Setenv("EMAIL_SECRET_KEY", originalSecretKey)
+
+	t.Run("成功", func(t *testing.T) {
+		tokenString, _ := GenerateEmailToken("test@example.com")
+
+		email, err := ParseEmailToken(tokenString)
+		assert.Nil(t, err, "Error should be nil")
+		assert.Equal(t, "test@example.com", email, "Email should be equal")
+	})
+
+	t.Run("失敗_偽造されたトークン", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"email": "test@example.com",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		})
+		tokenString, _ := token.SignedString([]byte("forged_secret_key"))
+
+		_, err := ParseEmailToken(tokenString)
+		assert.NotNil(t, err, "Forged token should be rejected")
+	})
+
+	t.Run("失敗_有効期限切れ", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"email": "test@example.com",
+			"exp":   time.Now().Add(-time.Hour).Unix(),
+		})
+		tokenString, _ := token.SignedString([]byte(os.Getenv("EMAIL_SECRET_KEY")))
+
+		_, err := ParseEmailToken(tokenString)
+		assert.NotNil(t, err, "Expired token should be rejected")
+	})
+}
+
+func TestParseUserGroupIDToken(t *testing.T) {
+	// テストのための環境変数をモック化
+	originalSecretKey := os.Getenv("USER_GROUP_ID_SECRET_KEY")
+	os.Setenv("USER_GROUP_ID_SECRET_KEY", "test_secret_key_0123456789abcdefghij")
+	defer os.Setenv("USER_GROUP_ID_SECRET_KEY", originalSecretKey)
+
+	t.Run("成功", func(t *testing.T) {
+		tokenString, _ := GenerateUserGroupIDToken(1, "test@example.com")
+
+		claims, err := ParseUserGroupIDToken(tokenString)
+		assert.Nil(t, err, "Error should be nil")
+		assert.Equal(t, uint(1), claims.UserGroupID, "User group ID should be equal")
+		assert.Equal(t, "test@example.com", claims.Email, "Email should be equal")
+	})
+
+	t.Run("失敗_メールアドレスのないトークン", func(t *testing.T) {
+		// 発行者、対象者、鍵は本物のトークンと同じにし、メールアドレスだけを省く
+		tokenString, err := signToken(userGroupIDTokenType, jwt.MapClaims{
+			"user_group_id": 1,
+			"exp":           time.Now().Add(time.Hour).Unix(),
+		})
+		assert.Nil(t, err, "Error should be nil")
+
+		_, err = ParseUserGroupIDToken(tokenString)
+		assert.EqualError(t, err, "failed to parse email", "Token without email should be rejected")
+	})
+
+	t.Run("失敗_偽造されたトークン", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_group_id": 2,
+			"exp":           time.Now().Add(time.Hour).Unix(),
+		})
+		tokenString, _ := token.SignedString([]byte("forged_secret_key"))
+
+		_, err := ParseUserGroupIDToken(tokenString)
+		assert.NotNil(t, err, "Forged token should be rejected")
+	})
+
+	t.Run("失敗_ユーザーグループIDのないトークン", func(t *testing.T) {
+		tokenString, err := signToken(userGroupIDTokenType, jwt.MapClaims{
+			"email": "test@example.com",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		})
+		assert.Nil(t, err, "Error should be nil")
+
+		_, err = ParseUserGroupIDToken(tokenString)
+		assert.EqualError(t, err, "failed to parse user group ID", "Token without user group ID should be rejected")
+	})
+}
+
+func TestParseMFAChallengeToken(t *testing.T) {
+	// テストのための環境変数をモック化
+	originalSecretKey := os.Getenv("MFA_CHALLENGE_SECRET_KEY")
+	os.Setenv("MFA_CHALLENGE_SECRET_KEY", "test_secret_key_0123456789abcdefghij")
+	defer os.Setenv("MFA_CHALLENGE_SECRET_KEY", originalSecretKey)
+
+	t.Run("成功", func(t *testing.T) {
+		tokenString, _ := GenerateMFAChallengeToken(1)
+
+		userID, err := ParseMFAChallengeToken(tokenString)
+		assert.Nil(t, err, "Error should be nil")
+		assert.Equal(t, uint(1), userID, "User ID should be equal")
+	})
+
+	t.Run("失敗_用途の異なるトークン", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": 1,
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		})
+		tokenString, _ := token.SignedString([]byte(os.Getenv("MFA_CHALLENGE_SECRET_KEY")))
+
+		_, err := ParseMFAChallengeToken(tokenString)
+		assert.NotNil(t, err, "Token without purpose should be rejected")
+	})
+
+	t.Run("失敗_有効期限切れ", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": 1,
+			"purpose": "mfa_challenge",
+			"exp":     time.Now().Add(-time.Hour).Unix(),
+		})
+		tokenString, _ := token.SignedString([]byte(os.Getenv("MFA_CHALLENGE_SECRET_KEY")))
+
+		_, err := ParseMFAChallengeToken(tokenString)
+		assert.NotNil(t, err, "Expired token should be rejected")
+	})
+}
+
+func TestLegacySecretKeyLength(t *testing.T) {
+	originalSecretKey := os.Getenv("MFA_CHALLENGE_SECRET_KEY")
+	defer os.Setenv("MFA_CHALLENGE_SECRET_KEY", originalSecretKey)
+
+	// 鍵束も種類ごとの鍵もない場合は署名しない
+	for _, secret := range []string{"", "short_secret_key"} {
+		os.Setenv("MFA_CHALLENGE_SECRET_KEY", secret)
+
+		_, err := GenerateMFAChallengeToken(1)
+		assert.NotNil(t, err, "Token should not be signed with weak key: %q", secret)
+
+		forgedToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": 1,
+			"iss":     "look-back-api",
+			"aud":     "mfa-challenge",
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte(secret))
+		_, err = ParseMFAChallengeToken(forgedToken)
+		assert.NotNil(t, err, "Token signed with weak key should be rejected: %q", secret)
+	}
 }
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alicend/LookBack/app/constant"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTP(RFC 6238)の共有シークレットをBase32で生成する
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// 認証アプリに登録するためのotpauth URIを生成する
+func TOTPURI(accountName string, secret string) string {
+	label := url.PathEscape(constant.TOTP_ISSUER + ":" + accountName)
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", constant.TOTP_ISSUER)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(constant.TOTP_DIGITS))
+	query.Set("period", fmt.Sprint(constant.TOTP_PERIOD_SECONDS))
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// 指定した時刻のワンタイムコードを生成する
+func GenerateTOTPCode(secret string, t time.Time) (string, error) {
+	return totpCode(secret, totpStep(t))
+}
+
+// ワンタイムコードを検証し、一致したタイムステップを返す
+// 時計のずれを考慮して前後のステップも許容する
+func ValidateTOTPCode(secret string, code string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != constant.TOTP_DIGITS {
+		return 0, false
+	}
+
+	current := totpStep(t)
+	for skew := int64(-constant.TOTP_SKEW_STEPS); skew <= constant.TOTP_SKEW_STEPS; skew++ {
+		expected, err := totpCode(secret, current+skew)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + skew, true
+		}
+	}
+
+	return 0, false
+}
+
+// 使い捨てのリカバリーコードを生成する（例: 1a2b3-c4d5e）
+func GenerateRecoveryCode() (string, error) {
+	random, err := GenerateRandomString(5)
+	if err != nil {
+		return "", err
+	}
+	return random[:5] + "-" + random[5:], nil
+}
+
+// 入力の揺れを吸収してリカバリーコードのダイジェストを返す
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return HashToken(normalized)
+}
+
+// ==================================================================
+// 以下はプライベート関数
+// ==================================================================
+func totpStep(t time.Time) int64 {
+	return t.Unix() / constant.TOTP_PERIOD_SECONDS
+}
+
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// RFC 4226 の動的切り捨て
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < constant.TOTP_DIGITS; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", constant.TOTP_DIGITS, value%modulo), nil
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 のテストベクター（シークレットはASCIIの"12345678901234567890"）
+const rfcTestSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTPCode(t *testing.T) {
+	code, err := GenerateTOTPCode(rfcTestSecret, time.Unix(59, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, "287082", code)
+
+	code, err = GenerateTOTPCode(rfcTestSecret, time.Unix(1111111109, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, "081804", code)
+
+	_, err = GenerateTOTPCode("invalid secret!", time.Now())
+	assert.NotNil(t, err)
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.Nil(t, err)
+
+	now := time.Now()
+	code, _ := GenerateTOTPCode(secret, now)
+
+	step, ok := ValidateTOTPCode(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/30, step)
+
+	// 前後1ステップのずれは許容する
+	_, ok = ValidateTOTPCode(secret, code, now.Add(30*time.Second))
+	assert.True(t, ok)
+
+	// それ以上ずれると無効
+	_, ok = ValidateTOTPCode(secret, code, now.Add(90*time.Second))
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTPCode(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("test@example.com", rfcTestSecret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/"))
+
+	parsed, err := url.Parse(uri)
+	assert.Nil(t, err)
+	assert.Equal(t, rfcTestSecret, parsed.Query().Get("secret"))
+	assert.Equal(t, "Look Back Calendar", parsed.Query().Get("issuer"))
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	code, err := GenerateRecoveryCode()
+	assert.Nil(t, err)
+	assert.Len(t, code, 11)
+
+	// ハイフンの有無や大文字・小文字の違いは同じコードとして扱う
+	assert.Equal(t, HashRecoveryCode(code), HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", ""))))
+	assert.NotEqual(t, code, HashRecoveryCode(code))
+}
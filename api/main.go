@@ -2,9 +2,13 @@ package main
 
 import (
 	"log"
+	"time"
+
+	"gorm.io/gorm"
 	"github.com/alicend/LookBack/app/config"
 	"github.com/alicend/LookBack/app/models"
 	"github.com/alicend/LookBack/app/router"
+	"github.com/alicend/LookBack/app/utils"
 )
 
 func main() {
@@ -23,7 +27,57 @@ func main() {
 		log.Fatalf("マイグレーションに失敗しました: %v", err)
 	}
 
+	// 保存期間を過ぎた監査ログを定期的に削除
+	go purgeAuditLogs(db, utils.GetAuditLogConfig())
+
+	// 繰り返しタスクから予定日のタスクを定期的に作成
+	go generateRecurringTasks(db, utils.GetTaskConfig())
+
+	// 保存期間を過ぎたゴミ箱のデータを定期的に削除
+	go purgeDeletions(db, utils.GetTaskConfig())
+
 	// ルーティング
 	r := router.SetupRouter(db)
 	r.Run()
-}
\ No newline at end of file
+}
+
+func purgeAuditLogs(db *gorm.DB, auditLogConfig utils.AuditLogConfig) {
+	if auditLogConfig.Retention == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(auditLogConfig.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := models.PurgeAuditLogs(db, time.Now().Add(-auditLogConfig.Retention)); err != nil {
+			log.Printf("監査ログの削除に失敗しました: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+func generateRecurringTasks(db *gorm.DB, taskConfig utils.TaskConfig) {
+	ticker := time.NewTicker(taskConfig.RecurringTaskInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := models.GenerateRecurringTasks(db, taskConfig.RecurringTaskHorizon(time.Now())); err != nil {
+			log.Printf("繰り返しタスクからのタスクの作成に失敗しました: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+func purgeDeletions(db *gorm.DB, taskConfig utils.TaskConfig) {
+	if taskConfig.TrashRetention == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(taskConfig.TrashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := models.PurgeDeletions(db, time.Now().Add(-taskConfig.TrashRetention)); err != nil {
+			log.Printf("ゴミ箱のデータの削除に失敗しました: %v", err)
+		}
+		<-ticker.C
+	}
+}
@@ -7,6 +7,55 @@ import { store } from "@/store/store";
 
 axios.defaults.withCredentials = true;
 
+// 状態を変更するリクエストにはCSRFトークンをヘッダーに付ける
+// APIは別のオリジンにあり、クッキーを読み取れないため、トークンはAPIから取得して使い回す
+const CSRF_ENDPOINT = `${process.env.NEXT_PUBLIC_RESTAPI_URL}api/auth/csrf`;
+const CSRF_HEADER_NAME = "X-CSRF-Token";
+const CSRF_SAFE_METHODS = ["get", "head", "options"];
+let csrfToken: Promise<string> | null = null;
+
+const fetchCsrfToken = () => {
+  if (!csrfToken) {
+    csrfToken = axios
+      .get(CSRF_ENDPOINT)
+      .then((res) => res.data.csrf_token)
+      .catch((err) => {
+        csrfToken = null;
+        throw err;
+      });
+  }
+  return csrfToken;
+};
+
+axios.interceptors.request.use(async (config) => {
+  if (CSRF_SAFE_METHODS.includes((config.method ?? "get").toLowerCase())) {
+    return config;
+  }
+  try {
+    config.headers[CSRF_HEADER_NAME] = await fetchCsrfToken();
+  } catch (err: any) {
+    // トークンを取得できない場合はそのまま送信し、APIの応答に任せる
+    console.log(err);
+  }
+  return config;
+});
+
+// クッキーの期限切れなどでトークンが一致しない場合は、取得し直して一度だけ再送する
+axios.interceptors.response.use(undefined, async (err: any) => {
+  const config = err.config;
+  if (
+    err.response?.status === 403 &&
+    err.response.data?.error === "invalid csrf token" &&
+    config &&
+    !config.csrfRetried
+  ) {
+    csrfToken = null;
+    config.csrfRetried = true;
+    return axios(config);
+  }
+  return Promise.reject(err);
+});
+
 const theme = createTheme({
   components: {
     MuiTextField: {